@@ -0,0 +1,262 @@
+// Copyright 2016 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	dto "github.com/prometheus/client_model/go"
+	"github.com/prometheus/common/log"
+	"github.com/prometheus/node_exporter/collector"
+)
+
+// agent scrapes the exporter itself and pushes the samples to a remote
+// write receiver. Scrapes that can't be pushed are spooled on disk and
+// sent with their original timestamps once the receiver is reachable
+// again.
+type agent struct {
+	url    string
+	spool  *spool
+	labels map[string]string
+	// downsample reduces the pushed samples, nil pushes all of them.
+	downsample *downsampler
+	client     *http.Client
+	gather     func() (map[string]*dto.MetricFamily, error)
+	now        func() time.Time
+	// pending is signaled when a scrape was spooled.
+	pending chan struct{}
+}
+
+// newAgent returns an agent pushing the metrics served by handler to url,
+// with labels added to every series not having them already.
+func newAgent(url string, spool *spool, labels map[string]string, timeout time.Duration, handler http.Handler) *agent {
+	return &agent{
+		url:     url,
+		spool:   spool,
+		labels:  labels,
+		client:  &http.Client{Timeout: timeout},
+		gather:  func() (map[string]*dto.MetricFamily, error) { return gatherMetrics(handler) },
+		now:     time.Now,
+		pending: make(chan struct{}, 1),
+	}
+}
+
+// parseAgentLabels parses comma-separated name=value pairs. The instance
+// label defaults to the hostname.
+func parseAgentLabels(s string) (map[string]string, error) {
+	labels := map[string]string{}
+	for _, pair := range strings.Split(s, ",") {
+		if pair = strings.TrimSpace(pair); pair == "" {
+			continue
+		}
+		parts := strings.SplitN(pair, "=", 2)
+		if len(parts) != 2 || parts[0] == "" {
+			return nil, fmt.Errorf("invalid label %q, must be name=value", pair)
+		}
+		labels[parts[0]] = parts[1]
+	}
+	if _, ok := labels["instance"]; !ok {
+		hostname, err := os.Hostname()
+		if err != nil {
+			return nil, err
+		}
+		labels["instance"] = hostname
+	}
+	return labels, nil
+}
+
+func (a *agent) run(interval time.Duration) {
+	collector.Go(a.sendLoop)
+	for {
+		if err := a.scrape(); err != nil {
+			log.Errorf("Agent scrape failed: %s", err)
+		}
+		time.Sleep(collector.BackgroundInterval(interval))
+	}
+}
+
+// scrape collects all metrics once and pushes them. They are spooled if
+// the receiver can't be reached or if older scrapes are still spooled, to
+// keep the order.
+func (a *agent) scrape() error {
+	families, err := a.gather()
+	if err != nil {
+		return err
+	}
+	now := a.now()
+	ts := now.UnixNano() / int64(time.Millisecond)
+	series := a.downsample.filter(familiesToTimeSeries(families, a.labels, ts), ts)
+	if len(series) == 0 {
+		return nil
+	}
+	body, err := encodeWriteRequest(&prompbWriteRequest{Timeseries: series})
+	if err != nil {
+		return err
+	}
+	if a.spool.empty() {
+		err := a.send(body)
+		if err == nil {
+			return nil
+		}
+		if rwErr, ok := err.(remoteWriteError); ok && !rwErr.recoverable {
+			return err
+		}
+		log.Debugf("Couldn't push metrics, spooling them: %s", err)
+	}
+	if err := a.spool.write(ts, body, now); err != nil {
+		return err
+	}
+	select {
+	case a.pending <- struct{}{}:
+	default:
+	}
+	return nil
+}
+
+// send pushes a request, counting requests rejected as invalid.
+func (a *agent) send(body []byte) error {
+	err := sendRemoteWrite(a.client, a.url, body)
+	if rwErr, ok := err.(remoteWriteError); ok && !rwErr.recoverable {
+		a.spool.dropped.WithLabelValues("rejected").Inc()
+	}
+	return err
+}
+
+func (a *agent) sendLoop() {
+	failures := 0
+	for {
+		if err := a.flush(); err != nil {
+			failures++
+			delay := retryBackoff(failures, time.Second, time.Minute)
+			log.Warnf("Couldn't push spooled metrics, retrying in %s: %s", delay, err)
+			time.Sleep(delay)
+			continue
+		}
+		failures = 0
+		<-a.pending
+	}
+}
+
+// flush sends the spooled scrapes, oldest first, removing them once they
+// were accepted or rejected as invalid. It stops at the first error worth
+// retrying.
+func (a *agent) flush() error {
+	if err := a.spool.prune(a.now()); err != nil {
+		return err
+	}
+	names, err := a.spool.files()
+	if err != nil {
+		return err
+	}
+	for _, name := range names {
+		body, err := ioutil.ReadFile(name)
+		if err != nil {
+			return err
+		}
+		err = a.send(body)
+		if rwErr, ok := err.(remoteWriteError); ok && !rwErr.recoverable {
+			log.Errorf("Dropping spooled scrape %s rejected by %s: %s", filepath.Base(name), a.url, err)
+		} else if err != nil {
+			return err
+		}
+		if err := os.Remove(name); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// familiesToTimeSeries converts the families into remote write series with
+// a single sample at timestamp ts, unless the metric has its own timestamp.
+// Summaries and histograms are split into their series like on a scrape.
+func familiesToTimeSeries(families map[string]*dto.MetricFamily, external map[string]string, ts int64) []*prompbTimeSeries {
+	names := make([]string, 0, len(families))
+	for n := range families {
+		names = append(names, n)
+	}
+	sort.Strings(names)
+
+	var series []*prompbTimeSeries
+	for _, name := range names {
+		for _, m := range families[name].Metric {
+			t := ts
+			if m.TimestampMs != nil {
+				t = m.GetTimestampMs()
+			}
+			add := func(name string, value float64, extra ...string) {
+				series = append(series, &prompbTimeSeries{
+					Labels:  remoteWriteLabels(name, m.Label, external, extra...),
+					Samples: []*prompbSample{{Value: value, Timestamp: t}},
+				})
+			}
+			switch {
+			case m.Counter != nil:
+				add(name, m.Counter.GetValue())
+			case m.Gauge != nil:
+				add(name, m.Gauge.GetValue())
+			case m.Untyped != nil:
+				add(name, m.Untyped.GetValue())
+			case m.Summary != nil:
+				for _, q := range m.Summary.Quantile {
+					add(name, q.GetValue(), "quantile", formatFloat(q.GetQuantile()))
+				}
+				add(name+"_sum", m.Summary.GetSampleSum())
+				add(name+"_count", float64(m.Summary.GetSampleCount()))
+			case m.Histogram != nil:
+				for _, b := range m.Histogram.Bucket {
+					add(name+"_bucket", float64(b.GetCumulativeCount()), "le", formatFloat(b.GetUpperBound()))
+				}
+				add(name+"_bucket", float64(m.Histogram.GetSampleCount()), "le", "+Inf")
+				add(name+"_sum", m.Histogram.GetSampleSum())
+				add(name+"_count", float64(m.Histogram.GetSampleCount()))
+			}
+		}
+	}
+	return series
+}
+
+// remoteWriteLabels returns the labels of a series sorted by name, as
+// required by remote write, including the metric name, the extra name and
+// value pairs and the external labels the metric doesn't have.
+func remoteWriteLabels(name string, pairs []*dto.LabelPair, external map[string]string, extra ...string) []*prompbLabel {
+	labels := map[string]string{"__name__": name}
+	for _, lp := range pairs {
+		labels[lp.GetName()] = lp.GetValue()
+	}
+	for i := 0; i+1 < len(extra); i += 2 {
+		labels[extra[i]] = extra[i+1]
+	}
+	for n, v := range external {
+		if _, ok := labels[n]; !ok {
+			labels[n] = v
+		}
+	}
+	names := make([]string, 0, len(labels))
+	for n := range labels {
+		names = append(names, n)
+	}
+	sort.Strings(names)
+	result := make([]*prompbLabel, 0, len(names))
+	for _, n := range names {
+		result = append(result, &prompbLabel{Name: n, Value: labels[n]})
+	}
+	return result
+}
@@ -0,0 +1,213 @@
+// Copyright 2016 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang/protobuf/proto"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/prometheus/common/expfmt"
+)
+
+const agentTestMetrics = `# TYPE node_load1 gauge
+node_load1 0.5
+# TYPE node_exporter_scrape_duration_seconds summary
+node_exporter_scrape_duration_seconds{collector="cpu",quantile="0.5"} 0.1
+node_exporter_scrape_duration_seconds_sum{collector="cpu"} 0.3
+node_exporter_scrape_duration_seconds_count{collector="cpu"} 3
+`
+
+func newTestAgent(t *testing.T, url string) (*agent, func()) {
+	dir, err := ioutil.TempDir("", "node_exporter_agent")
+	if err != nil {
+		t.Fatal(err)
+	}
+	spool, err := newSpool(dir, 0, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	a := newAgent(url, spool, map[string]string{"job": "node", "instance": "host"}, time.Second, nil)
+	a.gather = func() (map[string]*dto.MetricFamily, error) {
+		var parser expfmt.TextParser
+		return parser.TextToMetricFamilies(strings.NewReader(agentTestMetrics))
+	}
+	a.now = func() time.Time { return time.Unix(1500000000, 0) }
+	return a, func() { os.RemoveAll(dir) }
+}
+
+func TestAgent(t *testing.T) {
+	status := http.StatusOK
+	var received []*prompbWriteRequest
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if status != http.StatusOK {
+			w.WriteHeader(status)
+			return
+		}
+		body, _ := ioutil.ReadAll(r.Body)
+		data, err := snappyDecode(body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		req := &prompbWriteRequest{}
+		if err := proto.Unmarshal(data, req); err != nil {
+			t.Fatal(err)
+		}
+		received = append(received, req)
+	}))
+	defer srv.Close()
+
+	a, cleanup := newTestAgent(t, srv.URL)
+	defer cleanup()
+	spooled := func() []string {
+		names, err := a.spool.files()
+		if err != nil {
+			t.Fatal(err)
+		}
+		return names
+	}
+
+	// Scrapes are pushed directly while the receiver is reachable.
+	if err := a.scrape(); err != nil {
+		t.Fatal(err)
+	}
+	if want, got := 1, len(received); want != got {
+		t.Fatalf("want %d requests, got %d", want, got)
+	}
+	if names := spooled(); len(names) != 0 {
+		t.Fatalf("want nothing spooled, got %v", names)
+	}
+
+	status = http.StatusServiceUnavailable
+	received = nil
+	for _, sec := range []int64{1500000015, 1500000030} {
+		a.now = func() time.Time { return time.Unix(sec, 0) }
+		if err := a.scrape(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := a.flush(); err == nil {
+		t.Fatal("want error while the receiver is unavailable")
+	}
+	if names := spooled(); len(names) != 2 {
+		t.Fatalf("want 2 spooled scrapes, got %v", names)
+	}
+
+	status = http.StatusOK
+	if err := a.flush(); err != nil {
+		t.Fatal(err)
+	}
+	if want, got := 2, len(received); want != got {
+		t.Fatalf("want %d requests, got %d", want, got)
+	}
+	if want, got := int64(1500000015000), received[0].Timeseries[0].Samples[0].Timestamp; want != got {
+		t.Errorf("want oldest scrape first with its original timestamp %d, got %d", want, got)
+	}
+	if names := spooled(); len(names) != 0 {
+		t.Errorf("want empty spool after flush, got %v", names)
+	}
+
+	// Rejected requests are dropped rather than spooled.
+	status = http.StatusBadRequest
+	if err := a.scrape(); err == nil {
+		t.Error("want error for rejected scrape")
+	}
+	if names := spooled(); len(names) != 0 {
+		t.Errorf("want rejected scrape dropped, got %v", names)
+	}
+}
+
+func TestSpoolPrune(t *testing.T) {
+	dir, err := ioutil.TempDir("", "node_exporter_spool")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	s, err := newSpool(dir, 25, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	now := time.Unix(1500007200, 0)
+	for _, sec := range []int64{1500000000, 1500007000, 1500007100, 1500007200} {
+		if err := s.write(sec*1000, []byte("0123456789"), now); err != nil {
+			t.Fatal(err)
+		}
+	}
+	names, err := s.files()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, name := range names {
+		got = append(got, filepath.Base(name))
+	}
+	// The first scrape is too old, the second doesn't fit.
+	if want := []string{"00000001500007100000.spool", "00000001500007200000.spool"}; !reflect.DeepEqual(want, got) {
+		t.Errorf("want spooled %v, got %v", want, got)
+	}
+}
+
+func TestFamiliesToTimeSeries(t *testing.T) {
+	var parser expfmt.TextParser
+	families, err := parser.TextToMetricFamilies(strings.NewReader(agentTestMetrics))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, s := range familiesToTimeSeries(families, map[string]string{"job": "node"}, 1000) {
+		pairs := []string{}
+		for _, l := range s.Labels {
+			pairs = append(pairs, l.Name+"="+l.Value)
+		}
+		got = append(got, strings.Join(pairs, ","))
+	}
+	want := []string{
+		"__name__=node_exporter_scrape_duration_seconds,collector=cpu,job=node,quantile=0.5",
+		"__name__=node_exporter_scrape_duration_seconds_sum,collector=cpu,job=node",
+		"__name__=node_exporter_scrape_duration_seconds_count,collector=cpu,job=node",
+		"__name__=node_load1,job=node",
+	}
+	if !reflect.DeepEqual(want, got) {
+		t.Errorf("want series %v, got %v", want, got)
+	}
+}
+
+func TestParseAgentLabels(t *testing.T) {
+	labels, err := parseAgentLabels("job=node, instance=laptop")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := map[string]string{"job": "node", "instance": "laptop"}; !reflect.DeepEqual(want, labels) {
+		t.Errorf("want %v, got %v", want, labels)
+	}
+	labels, err = parseAgentLabels("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if hostname, _ := os.Hostname(); labels["instance"] != hostname {
+		t.Errorf("want instance to default to %q, got %q", hostname, labels["instance"])
+	}
+	if _, err := parseAgentLabels("job"); err == nil {
+		t.Error("want error for label without value")
+	}
+}
@@ -0,0 +1,266 @@
+// Copyright 2016 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"os/exec"
+	"sort"
+	"strings"
+	"time"
+
+	dto "github.com/prometheus/client_model/go"
+	"github.com/prometheus/common/log"
+	"github.com/prometheus/node_exporter/collector"
+)
+
+// alertRule fires an action when a metric crosses a threshold, for hosts
+// without a reachable Prometheus and Alertmanager.
+type alertRule struct {
+	Name string `json:"name"`
+	// Metric is the name of the metric compared to Threshold, restricted
+	// to the series matching all Labels.
+	Metric string            `json:"metric"`
+	Labels map[string]string `json:"labels"`
+	// DivideBy optionally names a metric the value is divided by, taking
+	// the series with the same labels, e.g. to compare the available
+	// space of a filesystem relative to its size.
+	DivideBy  string  `json:"divide_by"`
+	Op        string  `json:"op"`
+	Threshold float64 `json:"threshold"`
+	// Webhook is a URL notifications are posted to as JSON.
+	Webhook string `json:"webhook"`
+	// Exec is a command run for notifications, getting the alert in the
+	// ALERT_NAME, ALERT_STATE, ALERT_LABELS and ALERT_VALUE environment
+	// variables.
+	Exec []string `json:"exec"`
+}
+
+func (r alertRule) validate() error {
+	if r.Name == "" {
+		return fmt.Errorf("alert without name")
+	}
+	if r.Metric == "" {
+		return fmt.Errorf("alert %s: no metric", r.Name)
+	}
+	if _, ok := alertOps[r.Op]; !ok {
+		return fmt.Errorf("alert %s: unknown op %q", r.Name, r.Op)
+	}
+	if r.Webhook == "" && len(r.Exec) == 0 {
+		return fmt.Errorf("alert %s: neither webhook nor exec given", r.Name)
+	}
+	return nil
+}
+
+var alertOps = map[string]func(a, b float64) bool{
+	"<":  func(a, b float64) bool { return a < b },
+	"<=": func(a, b float64) bool { return a <= b },
+	">":  func(a, b float64) bool { return a > b },
+	">=": func(a, b float64) bool { return a >= b },
+}
+
+// alertNotification is sent when a series starts or stops matching a rule.
+type alertNotification struct {
+	Alert  string            `json:"alert"`
+	State  string            `json:"state"`
+	Labels map[string]string `json:"labels"`
+	Value  float64           `json:"value"`
+	Time   time.Time         `json:"time"`
+}
+
+// alerter evaluates alert rules against the exporter's own metrics.
+type alerter struct {
+	rules  []alertRule
+	gather func() (map[string]*dto.MetricFamily, error)
+	notify func(alertRule, alertNotification) error
+	// firing holds the last notification of every firing series by rule
+	// name and labels.
+	firing map[string]alertNotification
+}
+
+// newAlerter returns an alerter evaluating rules against the metrics served
+// by handler.
+func newAlerter(rules []alertRule, handler http.Handler) *alerter {
+	client := &http.Client{Timeout: 10 * time.Second}
+	return &alerter{
+		rules:  rules,
+		gather: func() (map[string]*dto.MetricFamily, error) { return gatherMetrics(handler) },
+		notify: func(r alertRule, n alertNotification) error { return sendAlertNotification(client, r, n) },
+		firing: map[string]alertNotification{},
+	}
+}
+
+func (a *alerter) run(interval time.Duration) {
+	for {
+		a.evaluate(time.Now())
+		time.Sleep(collector.BackgroundInterval(interval))
+	}
+}
+
+// evaluate checks all rules once and notifies about series that started
+// or stopped matching, including firing series that disappeared.
+func (a *alerter) evaluate(now time.Time) {
+	families, err := a.gather()
+	if err != nil {
+		log.Errorf("Couldn't gather metrics for alerting: %s", err)
+		return
+	}
+	matching := map[string]bool{}
+	for _, r := range a.rules {
+		for _, s := range alertSeries(families, r) {
+			key := r.Name + s.key
+			if !alertOps[r.Op](s.value, r.Threshold) {
+				continue
+			}
+			matching[key] = true
+			if _, ok := a.firing[key]; ok {
+				continue
+			}
+			n := alertNotification{Alert: r.Name, State: "firing", Labels: s.labels, Value: s.value, Time: now}
+			a.firing[key] = n
+			a.send(r, n)
+		}
+	}
+	for _, r := range a.rules {
+		for key, n := range a.firing {
+			if n.Alert != r.Name || matching[key] {
+				continue
+			}
+			delete(a.firing, key)
+			n.State, n.Time = "resolved", now
+			a.send(r, n)
+		}
+	}
+}
+
+func (a *alerter) send(r alertRule, n alertNotification) {
+	log.Infof("Alert %s is %s for %v with value %g", n.Alert, n.State, n.Labels, n.Value)
+	if err := a.notify(r, n); err != nil {
+		log.Errorf("Couldn't send notification for alert %s: %s", n.Alert, err)
+	}
+}
+
+type alertSample struct {
+	key    string
+	labels map[string]string
+	value  float64
+}
+
+// alertSeries returns the values of the series of the rule's metric
+// matching its labels, divided by the series of DivideBy with the same
+// labels if set. Series without a non-zero divisor are skipped.
+func alertSeries(families map[string]*dto.MetricFamily, r alertRule) []alertSample {
+	mf, ok := families[r.Metric]
+	if !ok {
+		return nil
+	}
+	divisors := map[string]float64{}
+	if r.DivideBy != "" {
+		if dmf, ok := families[r.DivideBy]; ok {
+			for _, m := range dmf.Metric {
+				if v, ok := sampleValue(m); ok {
+					divisors[labelsKey(m.Label)] = v
+				}
+			}
+		}
+	}
+
+	var samples []alertSample
+metrics:
+	for _, m := range mf.Metric {
+		labels := make(map[string]string, len(m.Label))
+		for _, lp := range m.Label {
+			labels[lp.GetName()] = lp.GetValue()
+		}
+		for name, value := range r.Labels {
+			if labels[name] != value {
+				continue metrics
+			}
+		}
+		value, ok := sampleValue(m)
+		if !ok {
+			continue
+		}
+		key := labelsKey(m.Label)
+		if r.DivideBy != "" {
+			d := divisors[key]
+			if d == 0 {
+				continue
+			}
+			value /= d
+		}
+		samples = append(samples, alertSample{key: key, labels: labels, value: value})
+	}
+	return samples
+}
+
+func sampleValue(m *dto.Metric) (float64, bool) {
+	switch {
+	case m.Gauge != nil:
+		return m.Gauge.GetValue(), true
+	case m.Counter != nil:
+		return m.Counter.GetValue(), true
+	case m.Untyped != nil:
+		return m.Untyped.GetValue(), true
+	}
+	return 0, false
+}
+
+func labelsKey(labels []*dto.LabelPair) string {
+	pairs := make([]string, 0, len(labels))
+	for _, lp := range labels {
+		pairs = append(pairs, fmt.Sprintf("%s=%q", lp.GetName(), lp.GetValue()))
+	}
+	sort.Strings(pairs)
+	return "{" + strings.Join(pairs, ",") + "}"
+}
+
+// sendAlertNotification posts n to the rule's webhook and runs its command.
+func sendAlertNotification(client *http.Client, r alertRule, n alertNotification) error {
+	if r.Webhook != "" {
+		body, err := json.Marshal(n)
+		if err != nil {
+			return err
+		}
+		resp, err := client.Post(r.Webhook, "application/json", bytes.NewReader(body))
+		if err != nil {
+			return err
+		}
+		resp.Body.Close()
+		if resp.StatusCode/100 != 2 {
+			return fmt.Errorf("webhook %s returned status %s", r.Webhook, resp.Status)
+		}
+	}
+	if len(r.Exec) > 0 {
+		labels, err := json.Marshal(n.Labels)
+		if err != nil {
+			return err
+		}
+		cmd := exec.Command(r.Exec[0], r.Exec[1:]...)
+		cmd.Env = append(os.Environ(),
+			"ALERT_NAME="+n.Alert,
+			"ALERT_STATE="+n.State,
+			"ALERT_LABELS="+string(labels),
+			fmt.Sprintf("ALERT_VALUE=%g", n.Value),
+		)
+		if out, err := cmd.CombinedOutput(); err != nil {
+			return fmt.Errorf("%s failed: %s: %s", r.Exec[0], err, bytes.TrimSpace(out))
+		}
+	}
+	return nil
+}
@@ -0,0 +1,122 @@
+// Copyright 2016 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	dto "github.com/prometheus/client_model/go"
+	"github.com/prometheus/common/expfmt"
+)
+
+func TestAlerter(t *testing.T) {
+	text := `node_power_supply_capacity{power_supply="BAT0"} 8
+node_filesystem_avail{mountpoint="/"} 4
+node_filesystem_size{mountpoint="/"} 100
+node_filesystem_avail{mountpoint="/home"} 50
+node_filesystem_size{mountpoint="/home"} 100
+`
+	var notifications []alertNotification
+	a := &alerter{
+		rules: []alertRule{
+			{Name: "BatteryLow", Metric: "node_power_supply_capacity", Op: "<", Threshold: 10},
+			{Name: "DiskFull", Metric: "node_filesystem_avail", DivideBy: "node_filesystem_size", Op: "<", Threshold: 0.05},
+		},
+		gather: func() (map[string]*dto.MetricFamily, error) {
+			var parser expfmt.TextParser
+			return parser.TextToMetricFamilies(strings.NewReader(text))
+		},
+		notify: func(r alertRule, n alertNotification) error {
+			notifications = append(notifications, n)
+			return nil
+		},
+		firing: map[string]alertNotification{},
+	}
+
+	at := time.Unix(1500000000, 0)
+	a.evaluate(at)
+	a.evaluate(at)
+	if want, got := 2, len(notifications); want != got {
+		t.Fatalf("want %d notifications, got %d: %v", want, got, notifications)
+	}
+	if notifications[0].Alert == "DiskFull" {
+		notifications[0], notifications[1] = notifications[1], notifications[0]
+	}
+	want := []alertNotification{
+		{Alert: "BatteryLow", State: "firing", Labels: map[string]string{"power_supply": "BAT0"}, Value: 8, Time: at},
+		{Alert: "DiskFull", State: "firing", Labels: map[string]string{"mountpoint": "/"}, Value: 0.04, Time: at},
+	}
+	if !reflect.DeepEqual(want, notifications) {
+		t.Errorf("want %v, got %v", want, notifications)
+	}
+
+	text = `node_power_supply_capacity{power_supply="BAT0"} 12
+node_filesystem_avail{mountpoint="/"} 4
+node_filesystem_size{mountpoint="/"} 100
+`
+	notifications = nil
+	a.evaluate(at)
+	wantResolved := []alertNotification{
+		{Alert: "BatteryLow", State: "resolved", Labels: map[string]string{"power_supply": "BAT0"}, Value: 8, Time: at},
+	}
+	if !reflect.DeepEqual(wantResolved, notifications) {
+		t.Errorf("want %v, got %v", wantResolved, notifications)
+	}
+}
+
+func TestSendAlertNotification(t *testing.T) {
+	var got alertNotification
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := json.NewDecoder(r.Body).Decode(&got); err != nil {
+			t.Error(err)
+		}
+	}))
+	defer srv.Close()
+
+	n := alertNotification{Alert: "BatteryLow", State: "firing", Labels: map[string]string{"power_supply": "BAT0"}, Value: 8, Time: time.Unix(1500000000, 0).UTC()}
+	r := alertRule{Webhook: srv.URL, Exec: []string{"sh", "-c", `test "$ALERT_NAME $ALERT_STATE $ALERT_VALUE" = "BatteryLow firing 8"`}}
+	if err := sendAlertNotification(http.DefaultClient, r, n); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(n, got) {
+		t.Errorf("want webhook payload %v, got %v", n, got)
+	}
+
+	r = alertRule{Exec: []string{"false"}}
+	if err := sendAlertNotification(http.DefaultClient, r, n); err == nil {
+		t.Error("want error for failing command")
+	}
+}
+
+func TestAlertRuleValidate(t *testing.T) {
+	for _, r := range []alertRule{
+		{Metric: "m", Op: "<", Webhook: "http://localhost/"},
+		{Name: "a", Op: "<", Webhook: "http://localhost/"},
+		{Name: "a", Metric: "m", Op: "==", Webhook: "http://localhost/"},
+		{Name: "a", Metric: "m", Op: "<"},
+	} {
+		if err := r.validate(); err == nil {
+			t.Errorf("want error for %+v", r)
+		}
+	}
+	if err := (alertRule{Name: "a", Metric: "m", Op: "<", Exec: []string{"true"}}).validate(); err != nil {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,289 @@
+// Copyright 2016 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/prometheus/node_exporter/collector"
+)
+
+// assetRule is a recommended alerting rule. Expressions and annotations
+// refer to metrics as {{ns}}_name so they follow -web.telemetry-prefix.
+type assetRule struct {
+	collectors  []string
+	alert       string
+	expr        string
+	forDuration string
+	severity    string
+	summary     string
+}
+
+// assetPanel is a graph of the generated Grafana dashboard.
+type assetPanel struct {
+	collectors []string
+	title      string
+	expr       string
+	legend     string
+	unit       string
+}
+
+var assetRules = []assetRule{
+	{
+		collectors:  []string{"filesystem"},
+		alert:       "NodeFilesystemFillingUp",
+		expr:        `predict_linear({{ns}}_filesystem_avail{fstype!~"tmpfs|ramfs"}[6h], 24 * 3600) < 0 and {{ns}}_filesystem_readonly == 0`,
+		forDuration: "1h",
+		severity:    "warning",
+		summary:     "Filesystem {{ $labels.mountpoint }} on {{ $labels.instance }} is predicted to run out of space within 24 hours.",
+	},
+	{
+		collectors:  []string{"filesystem"},
+		alert:       "NodeFilesystemAlmostFull",
+		expr:        `{{ns}}_filesystem_avail{fstype!~"tmpfs|ramfs"} / {{ns}}_filesystem_size < 0.05 and {{ns}}_filesystem_readonly == 0`,
+		forDuration: "30m",
+		severity:    "critical",
+		summary:     "Filesystem {{ $labels.mountpoint }} on {{ $labels.instance }} has less than 5% space left.",
+	},
+	{
+		collectors:  []string{"timesync"},
+		alert:       "NodeClockSkew",
+		expr:        `abs({{ns}}_timesync_offset_seconds) > 0.05`,
+		forDuration: "10m",
+		severity:    "warning",
+		summary:     "Clock of {{ $labels.instance }} is more than 50ms off its time source.",
+	},
+	{
+		collectors:  []string{"timesync"},
+		alert:       "NodeClockNotSynchronising",
+		expr:        `{{ns}}_timesync_leap_status == 3`,
+		forDuration: "10m",
+		severity:    "warning",
+		summary:     "Clock of {{ $labels.instance }} is not synchronised.",
+	},
+	{
+		collectors:  []string{"ntp"},
+		alert:       "NodeNTPDrift",
+		expr:        `abs({{ns}}_ntp_drift_seconds) > 0.05`,
+		forDuration: "10m",
+		severity:    "warning",
+		summary:     "Clock of {{ $labels.instance }} is more than 50ms off the NTP server.",
+	},
+	{
+		collectors:  []string{"power_supply"},
+		alert:       "NodeBatteryLow",
+		expr:        `{{ns}}_power_supply_capacity < 10 and on (instance, power_supply) {{ns}}_power_supply_info{type="Battery", status="Discharging"}`,
+		forDuration: "5m",
+		severity:    "warning",
+		summary:     "Battery {{ $labels.power_supply }} of {{ $labels.instance }} is discharging and below 10%.",
+	},
+}
+
+var assetPanels = []assetPanel{
+	{
+		collectors: []string{"stat", "cpu"},
+		title:      "CPU usage",
+		expr:       `sum by (instance) (rate({{ns}}_cpu{mode!="idle"}[5m])) / count by (instance) ({{ns}}_cpu{mode="idle"})`,
+		legend:     "{{instance}}",
+		unit:       "percentunit",
+	},
+	{
+		collectors: []string{"loadavg"},
+		title:      "Load average",
+		expr:       `{{ns}}_load1`,
+		legend:     "{{instance}}",
+		unit:       "short",
+	},
+	{
+		collectors: []string{"meminfo"},
+		title:      "Available memory",
+		expr:       `{{ns}}_memory_MemAvailable`,
+		legend:     "{{instance}}",
+		unit:       "bytes",
+	},
+	{
+		collectors: []string{"filesystem"},
+		title:      "Filesystem space available",
+		expr:       `{{ns}}_filesystem_avail{fstype!~"tmpfs|ramfs"}`,
+		legend:     "{{instance}} {{mountpoint}}",
+		unit:       "bytes",
+	},
+	{
+		collectors: []string{"diskstats"},
+		title:      "Disk I/O time",
+		expr:       `rate({{ns}}_disk_io_time_ms[5m]) / 1000`,
+		legend:     "{{instance}} {{device}}",
+		unit:       "percentunit",
+	},
+	{
+		collectors: []string{"netdev"},
+		title:      "Network receive",
+		expr:       `rate({{ns}}_network_receive_bytes{device!="lo"}[5m])`,
+		legend:     "{{instance}} {{device}}",
+		unit:       "Bps",
+	},
+	{
+		collectors: []string{"netdev"},
+		title:      "Network transmit",
+		expr:       `rate({{ns}}_network_transmit_bytes{device!="lo"}[5m])`,
+		legend:     "{{instance}} {{device}}",
+		unit:       "Bps",
+	},
+	{
+		collectors: []string{"timesync"},
+		title:      "Clock offset",
+		expr:       `{{ns}}_timesync_offset_seconds`,
+		legend:     "{{instance}}",
+		unit:       "s",
+	},
+	{
+		collectors: []string{"power_supply"},
+		title:      "Battery charge",
+		expr:       `{{ns}}_power_supply_capacity`,
+		legend:     "{{instance}} {{power_supply}}",
+		unit:       "percent",
+	},
+}
+
+// available reports whether any of the collectors is built into this
+// binary.
+func available(collectors []string) bool {
+	for _, c := range collectors {
+		if _, ok := collector.Factories[c]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+func withNamespace(s string) string {
+	return strings.Replace(s, "{{ns}}", collector.Namespace, -1)
+}
+
+// generateRules returns the recommended alerting rules for the collectors
+// of this build in the YAML rule file format.
+func generateRules() []byte {
+	var b bytes.Buffer
+	b.WriteString("groups:\n- name: node_exporter\n  rules:\n")
+	for _, r := range assetRules {
+		if !available(r.collectors) {
+			continue
+		}
+		fmt.Fprintf(&b, "  - alert: %s\n", r.alert)
+		fmt.Fprintf(&b, "    expr: %s\n", yamlQuote(withNamespace(r.expr)))
+		fmt.Fprintf(&b, "    for: %s\n", r.forDuration)
+		fmt.Fprintf(&b, "    labels:\n      severity: %s\n", r.severity)
+		fmt.Fprintf(&b, "    annotations:\n      summary: %s\n", yamlQuote(r.summary))
+	}
+	return b.Bytes()
+}
+
+// yamlQuote returns s as a single-quoted YAML scalar.
+func yamlQuote(s string) string {
+	return "'" + strings.Replace(s, "'", "''", -1) + "'"
+}
+
+// generateDashboard returns a Grafana dashboard graphing the metrics of the
+// collectors of this build.
+func generateDashboard() ([]byte, error) {
+	type target struct {
+		Expr         string `json:"expr"`
+		LegendFormat string `json:"legendFormat"`
+		RefID        string `json:"refId"`
+	}
+	type gridPos struct {
+		H int `json:"h"`
+		W int `json:"w"`
+		X int `json:"x"`
+		Y int `json:"y"`
+	}
+	type panel struct {
+		ID          int                    `json:"id"`
+		Type        string                 `json:"type"`
+		Title       string                 `json:"title"`
+		Datasource  string                 `json:"datasource"`
+		GridPos     gridPos                `json:"gridPos"`
+		Targets     []target               `json:"targets"`
+		FieldConfig map[string]interface{} `json:"fieldConfig"`
+	}
+
+	panels := []panel{}
+	for _, p := range assetPanels {
+		if !available(p.collectors) {
+			continue
+		}
+		n := len(panels)
+		panels = append(panels, panel{
+			ID:         n + 1,
+			Type:       "timeseries",
+			Title:      p.title,
+			Datasource: "${datasource}",
+			GridPos:    gridPos{H: 8, W: 12, X: (n % 2) * 12, Y: (n / 2) * 8},
+			Targets: []target{{
+				Expr:         withNamespace(p.expr),
+				LegendFormat: p.legend,
+				RefID:        "A",
+			}},
+			FieldConfig: map[string]interface{}{
+				"defaults": map[string]interface{}{"unit": p.unit},
+			},
+		})
+	}
+
+	dashboard := map[string]interface{}{
+		"title":         "Node Exporter",
+		"uid":           "node-exporter",
+		"schemaVersion": 27,
+		"tags":          []string{"node_exporter"},
+		"time":          map[string]string{"from": "now-6h", "to": "now"},
+		"templating": map[string]interface{}{
+			"list": []interface{}{
+				map[string]interface{}{
+					"name":  "datasource",
+					"type":  "datasource",
+					"query": "prometheus",
+				},
+			},
+		},
+		"panels": panels,
+	}
+	return json.MarshalIndent(dashboard, "", "  ")
+}
+
+// generateAssets writes the alerting rules and the Grafana dashboard for
+// this build to dir.
+func generateAssets(dir string) error {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	rules := filepath.Join(dir, "node_exporter_alerts.yml")
+	if err := ioutil.WriteFile(rules, generateRules(), 0644); err != nil {
+		return fmt.Errorf("couldn't write %s: %s", rules, err)
+	}
+	dashboard, err := generateDashboard()
+	if err != nil {
+		return err
+	}
+	path := filepath.Join(dir, "node_exporter_dashboard.json")
+	if err := ioutil.WriteFile(path, append(dashboard, '\n'), 0644); err != nil {
+		return fmt.Errorf("couldn't write %s: %s", path, err)
+	}
+	return nil
+}
@@ -0,0 +1,71 @@
+// Copyright 2016 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/prometheus/node_exporter/collector"
+)
+
+func TestGenerateRules(t *testing.T) {
+	rules := string(generateRules())
+	for _, want := range []string{
+		"  - alert: NodeFilesystemFillingUp\n",
+		"predict_linear(node_filesystem_avail{",
+		"  - alert: NodeClockSkew\n",
+		"abs(node_timesync_offset_seconds) > 0.05",
+		"  - alert: NodeBatteryLow\n",
+		"      summary: 'Clock of {{ $labels.instance }} is more than 50ms off its time source.'\n",
+	} {
+		if !strings.Contains(rules, want) {
+			t.Errorf("want %q in rules:\n%s", want, rules)
+		}
+	}
+	if strings.Contains(rules, "{{ns}}") {
+		t.Errorf("unexpanded namespace in rules:\n%s", rules)
+	}
+}
+
+func TestGenerateDashboard(t *testing.T) {
+	defer collector.SetNamespace("node")
+	if err := collector.SetNamespace("host"); err != nil {
+		t.Fatal(err)
+	}
+	data, err := generateDashboard()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var dashboard struct {
+		Panels []struct {
+			Title   string
+			Targets []struct{ Expr string }
+		}
+	}
+	if err := json.Unmarshal(data, &dashboard); err != nil {
+		t.Fatal(err)
+	}
+	if len(dashboard.Panels) == 0 {
+		t.Fatal("no panels in dashboard")
+	}
+	for _, p := range dashboard.Panels {
+		for _, target := range p.Targets {
+			if !strings.Contains(target.Expr, "host_") || strings.Contains(target.Expr, "node_") {
+				t.Errorf("panel %q: expression %q doesn't use the namespace", p.Title, target.Expr)
+			}
+		}
+	}
+}
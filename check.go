@@ -0,0 +1,116 @@
+// Copyright 2016 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/node_exporter/collector"
+)
+
+const (
+	checkOK               = "ok"
+	checkNoData           = "no data"
+	checkFailed           = "failed"
+	checkPermissionDenied = "permission denied"
+	checkUnavailable      = "unavailable"
+	checkSkipped          = "skipped"
+)
+
+// checkResult is the outcome of probing a single collector.
+type checkResult struct {
+	name    string
+	enabled bool
+	status  string
+	series  int
+	err     error
+}
+
+// checkCollector creates the named collector and runs it once, counting the
+// series it exposes.
+func checkCollector(name string, factory func() (collector.Collector, error)) checkResult {
+	r := checkResult{name: name}
+	c, err := factory()
+	if err != nil {
+		r.status, r.err = checkUnavailable, err
+		return r
+	}
+	metrics := make(chan prometheus.Metric)
+	done := make(chan struct{})
+	collector.Go(func() {
+		for range metrics {
+			r.series++
+		}
+		close(done)
+	})
+	err = c.Update(metrics)
+	close(metrics)
+	<-done
+
+	switch {
+	case err != nil && strings.Contains(err.Error(), "permission denied"):
+		r.status, r.err = checkPermissionDenied, err
+	case err != nil:
+		r.status, r.err = checkFailed, err
+	case r.series == 0:
+		r.status = checkNoData
+	default:
+		r.status = checkOK
+	}
+	return r
+}
+
+// checkCollectors probes every collector built into this binary and writes
+// a report to w. It returns false if any of the enabled collectors doesn't
+// work on this host. Experimental collectors are only probed if allowed.
+func checkCollectors(w io.Writer, enabled []string, experimental bool) bool {
+	isEnabled := map[string]bool{}
+	for _, n := range enabled {
+		isEnabled[n] = true
+	}
+	names := make([]string, 0, len(collector.Factories))
+	for n := range collector.Factories {
+		names = append(names, n)
+	}
+	sort.Strings(names)
+
+	collector.BeginScrape()
+	ok := true
+	tw := tabwriter.NewWriter(w, 0, 8, 2, ' ', 0)
+	fmt.Fprintln(tw, "COLLECTOR\tENABLED\tSTATUS\tSERIES\tERROR")
+	for _, n := range names {
+		var r checkResult
+		if !experimental && collector.CollectorMaturity(n) == collector.MaturityExperimental {
+			r = checkResult{name: n, status: checkSkipped, err: fmt.Errorf("experimental, requires -collector.enable-experimental")}
+		} else {
+			r = checkCollector(n, collector.Factories[n])
+		}
+		r.enabled = isEnabled[n]
+		if r.enabled && r.status != checkOK && r.status != checkNoData {
+			ok = false
+		}
+		var errText string
+		if r.err != nil {
+			errText = r.err.Error()
+		}
+		fmt.Fprintf(tw, "%s\t%t\t%s\t%d\t%s\n", r.name, r.enabled, r.status, r.series, errText)
+	}
+	tw.Flush()
+	return ok
+}
@@ -0,0 +1,89 @@
+// Copyright 2016 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"errors"
+	"strconv"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/node_exporter/collector"
+)
+
+type checkTestCollector struct {
+	series int
+	err    error
+}
+
+func (c checkTestCollector) Update(ch chan<- prometheus.Metric) error {
+	desc := prometheus.NewDesc("node_check_test", "Test metric.", []string{"n"}, nil)
+	for i := 0; i < c.series; i++ {
+		ch <- prometheus.MustNewConstMetric(desc, prometheus.GaugeValue, 1, strconv.Itoa(i))
+	}
+	return c.err
+}
+
+func TestCheckCollector(t *testing.T) {
+	for _, tc := range []struct {
+		factory func() (collector.Collector, error)
+		status  string
+		series  int
+	}{
+		{
+			factory: func() (collector.Collector, error) { return checkTestCollector{series: 2}, nil },
+			status:  checkOK,
+			series:  2,
+		},
+		{
+			factory: func() (collector.Collector, error) { return checkTestCollector{}, nil },
+			status:  checkNoData,
+		},
+		{
+			factory: func() (collector.Collector, error) {
+				return checkTestCollector{series: 1, err: errors.New("open /sys/x: permission denied")}, nil
+			},
+			status: checkPermissionDenied,
+			series: 1,
+		},
+		{
+			factory: func() (collector.Collector, error) { return checkTestCollector{err: errors.New("boom")}, nil },
+			status:  checkFailed,
+		},
+		{
+			factory: func() (collector.Collector, error) { return nil, errors.New("not configured") },
+			status:  checkUnavailable,
+		},
+	} {
+		r := checkCollector("test", tc.factory)
+		if r.status != tc.status || r.series != tc.series {
+			t.Errorf("want status %q with %d series, got %q with %d (%v)", tc.status, tc.series, r.status, r.series, r.err)
+		}
+	}
+}
+
+func TestCheckMaturity(t *testing.T) {
+	collector.Maturities["test_experimental"] = collector.MaturityExperimental
+	defer delete(collector.Maturities, "test_experimental")
+
+	if err := checkMaturity("cpu,test_experimental", false); err == nil {
+		t.Error("want error for experimental collector")
+	}
+	if err := checkMaturity("cpu,test_experimental", true); err != nil {
+		t.Errorf("want no error with experimental collectors allowed, got %s", err)
+	}
+	if err := checkMaturity("cpu,hwmon", false); err != nil {
+		t.Errorf("want no error for stable and beta collectors, got %s", err)
+	}
+}
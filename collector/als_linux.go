@@ -0,0 +1,131 @@
+// Copyright 2016 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// +build !noals
+
+package collector
+
+import (
+	"os"
+	"path"
+	"strings"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const alsSubsystem = "als"
+
+type alsCollector struct {
+	fs fileSystem
+
+	illuminance *prometheus.Desc
+	proximity   *prometheus.Desc
+}
+
+// alsSensor is an IIO device measuring ambient light or proximity. name is
+// the driver's name for the device. Readings the sensor doesn't have are -1.
+type alsSensor struct {
+	device, name           string
+	illuminance, proximity float64
+}
+
+func init() {
+	Factories["als"] = NewALSCollector
+	Maturities["als"] = MaturityBeta
+	SysfsFactories["als"] = func(root string) (Collector, error) {
+		return newALSCollector(rootFS(root)), nil
+	}
+}
+
+// NewALSCollector returns a new Collector exposing the readings of the
+// ambient light and proximity sensors in /sys/bus/iio/devices, which decide
+// on the brightness of screens and keyboard backlights.
+func NewALSCollector() (Collector, error) {
+	return newALSCollector(sysFS), nil
+}
+
+func newALSCollector(fsys fileSystem) *alsCollector {
+	labels := []string{"device", "name"}
+	return &alsCollector{
+		fs: fsys,
+		illuminance: prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, alsSubsystem, "illuminance_lux"),
+			"Ambient light measured by the sensor.",
+			labels, nil,
+		),
+		proximity: prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, alsSubsystem, "proximity"),
+			"Proximity measured by the sensor, higher when an object is closer, in units depending on the sensor.",
+			labels, nil,
+		),
+	}
+}
+
+func (c *alsCollector) Update(ch chan<- prometheus.Metric) (err error) {
+	sensors, err := readALSSensors(c.fs)
+	if err != nil {
+		return err
+	}
+	for _, s := range sensors {
+		if s.illuminance >= 0 {
+			ch <- prometheus.MustNewConstMetric(c.illuminance, prometheus.GaugeValue, s.illuminance, s.device, s.name)
+		}
+		if s.proximity >= 0 {
+			ch <- prometheus.MustNewConstMetric(c.proximity, prometheus.GaugeValue, s.proximity, s.device, s.name)
+		}
+	}
+	return nil
+}
+
+// readALSSensors returns the IIO devices in bus/iio/devices with an
+// illuminance or proximity channel.
+func readALSSensors(fsys fileSystem) ([]alsSensor, error) {
+	files, err := fsys.Glob("bus/iio/devices/*/name")
+	if err != nil {
+		return nil, err
+	}
+	var sensors []alsSensor
+	for _, file := range files {
+		name, err := readFSFile(fsys, file)
+		if err != nil {
+			return nil, err
+		}
+		dir := path.Dir(file)
+		s := alsSensor{device: path.Base(dir), name: strings.TrimSpace(string(name))}
+		if s.illuminance, err = readIIOChannel(fsys, dir, "in_illuminance"); err != nil {
+			return nil, err
+		}
+		if s.proximity, err = readIIOChannel(fsys, dir, "in_proximity"); err != nil {
+			return nil, err
+		}
+		if s.illuminance < 0 && s.proximity < 0 {
+			continue
+		}
+		sensors = append(sensors, s)
+	}
+	return sensors, nil
+}
+
+// readIIOChannel returns the value of the first channel of a type, which
+// drivers with a single channel may or may not number, e.g. in_illuminance
+// or in_illuminance0, or -1 if the device has none.
+func readIIOChannel(fsys fileSystem, dir, channel string) (float64, error) {
+	for _, c := range []string{channel, channel + "0"} {
+		v, err := readIIOValue(fsys, dir, c)
+		if os.IsNotExist(err) {
+			continue
+		}
+		return v, err
+	}
+	return -1, nil
+}
@@ -0,0 +1,46 @@
+// Copyright 2016 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collector
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestReadALSSensors(t *testing.T) {
+	sensors, err := readALSSensors(dirFS{root: func() string { return "fixtures/sys" }})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []alsSensor{
+		{device: "iio:device3", name: "cros-ec-light", illuminance: 156, proximity: -1},
+		{device: "iio:device5", name: "stk3310", illuminance: 40, proximity: 12},
+	}
+	if !reflect.DeepEqual(want, sensors) {
+		t.Errorf("want %+v, got %+v", want, sensors)
+	}
+}
+
+func TestReadALSSensorsNumberedChannel(t *testing.T) {
+	sensors, err := readALSSensors(mapFS{
+		"bus/iio/devices/iio:device0/name":                  "acpi-als\n",
+		"bus/iio/devices/iio:device0/in_illuminance0_input": "87\n",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []alsSensor{{device: "iio:device0", name: "acpi-als", illuminance: 87, proximity: -1}}; !reflect.DeepEqual(want, sensors) {
+		t.Errorf("want %+v, got %+v", want, sensors)
+	}
+}
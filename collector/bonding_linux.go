@@ -17,7 +17,6 @@ package collector
 
 import (
 	"fmt"
-	"io/ioutil"
 	"os"
 	"path"
 	"strings"
@@ -58,7 +57,7 @@ func NewBondingCollector() (Collector, error) {
 
 // Update reads and exposes bonding states, implements Collector interface. Caution: This works only on linux.
 func (c *bondingCollector) Update(ch chan<- prometheus.Metric) (err error) {
-	bondingStats, err := readBondingStats(sysFilePath("class/net"))
+	bondingStats, err := readBondingStats(sysFS, "class/net")
 	if err != nil {
 		return err
 	}
@@ -71,23 +70,23 @@ func (c *bondingCollector) Update(ch chan<- prometheus.Metric) (err error) {
 	return nil
 }
 
-func readBondingStats(root string) (status map[string][2]int, err error) {
+func readBondingStats(fsys fileSystem, root string) (status map[string][2]int, err error) {
 	status = map[string][2]int{}
-	masters, err := ioutil.ReadFile(path.Join(root, "bonding_masters"))
+	masters, err := readFSFile(fsys, path.Join(root, "bonding_masters"))
 	if err != nil {
 		return nil, err
 	}
 	for _, master := range strings.Fields(string(masters)) {
-		slaves, err := ioutil.ReadFile(path.Join(root, master, "bonding", "slaves"))
+		slaves, err := readFSFile(fsys, path.Join(root, master, "bonding", "slaves"))
 		if err != nil {
 			return nil, err
 		}
 		sstat := [2]int{0, 0}
 		for _, slave := range strings.Fields(string(slaves)) {
-			state, err := ioutil.ReadFile(path.Join(root, master, fmt.Sprintf("lower_%s", slave), "operstate"))
+			state, err := readFSFile(fsys, path.Join(root, master, fmt.Sprintf("lower_%s", slave), "operstate"))
 			if os.IsNotExist(err) {
 				// some older? kernels use slave_ prefix
-				state, err = ioutil.ReadFile(path.Join(root, master, fmt.Sprintf("slave_%s", slave), "operstate"))
+				state, err = readFSFile(fsys, path.Join(root, master, fmt.Sprintf("slave_%s", slave), "operstate"))
 			}
 			if err != nil {
 				return nil, err
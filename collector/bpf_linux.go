@@ -0,0 +1,275 @@
+// Copyright 2016 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// +build !nobpf
+
+package collector
+
+import (
+	"fmt"
+	"runtime"
+	"strconv"
+	"syscall"
+	"unsafe"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	bpfSubsystem = "bpf"
+
+	// Commands from linux/bpf.h.
+	bpfProgGetNextID   = 11
+	bpfMapGetNextID    = 12
+	bpfProgGetFDByID   = 13
+	bpfMapGetFDByID    = 14
+	bpfObjGetInfoByFD  = 15
+	bpfObjInfoTypeSize = 4
+
+	// Link attributes from linux/if_link.h.
+	iflaXDP         = 43
+	iflaXDPAttached = 2
+	iflaXDPProgID   = 4
+)
+
+var (
+	bpfProgTypes = []string{
+		"unspec", "socket_filter", "kprobe", "sched_cls", "sched_act",
+		"tracepoint", "xdp", "perf_event", "cgroup_skb", "cgroup_sock",
+		"lwt_in", "lwt_out", "lwt_xmit", "sock_ops", "sk_skb",
+		"cgroup_device", "sk_msg", "raw_tracepoint", "cgroup_sock_addr",
+		"lwt_seg6local", "lirc_mode2", "sk_reuseport", "flow_dissector",
+		"cgroup_sysctl", "raw_tracepoint_writable", "cgroup_sockopt",
+		"tracing", "struct_ops", "ext", "lsm", "sk_lookup", "syscall",
+		"netfilter",
+	}
+	bpfMapTypes = []string{
+		"unspec", "hash", "array", "prog_array", "perf_event_array",
+		"percpu_hash", "percpu_array", "stack_trace", "cgroup_array",
+		"lru_hash", "lru_percpu_hash", "lpm_trie", "array_of_maps",
+		"hash_of_maps", "devmap", "sockmap", "cpumap", "xskmap", "sockhash",
+		"cgroup_storage", "reuseport_sockarray", "percpu_cgroup_storage",
+		"queue", "stack", "sk_storage", "devmap_hash", "struct_ops",
+		"ringbuf", "inode_storage", "task_storage", "bloom_filter",
+		"user_ringbuf", "cgrp_storage", "arena",
+	}
+	xdpAttachModes = []string{"none", "driver", "generic", "offload", "multi"}
+)
+
+type xdpAttachment struct {
+	device string
+	mode   string
+	progID uint32
+}
+
+type bpfCollector struct {
+	programs *prometheus.Desc
+	maps     *prometheus.Desc
+	xdp      *prometheus.Desc
+}
+
+func init() {
+	Factories["bpf"] = NewBPFCollector
+	Maturities["bpf"] = MaturityBeta
+}
+
+// NewBPFCollector returns a new Collector exposing an inventory of loaded
+// eBPF programs and maps as well as XDP attachments per interface.
+func NewBPFCollector() (Collector, error) {
+	return &bpfCollector{
+		programs: prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, bpfSubsystem, "programs"),
+			"Number of loaded eBPF programs by type.",
+			[]string{"type"}, nil,
+		),
+		maps: prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, bpfSubsystem, "maps"),
+			"Number of loaded eBPF maps by type.",
+			[]string{"type"}, nil,
+		),
+		xdp: prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, bpfSubsystem, "xdp_attached"),
+			"Whether an XDP program is attached to the interface, labeled with the attach mode and program id.",
+			[]string{"device", "mode", "prog_id"}, nil,
+		),
+	}, nil
+}
+
+func (c *bpfCollector) Update(ch chan<- prometheus.Metric) (err error) {
+	msgs, err := netlinkDump(syscall.RTM_GETLINK)
+	if err != nil {
+		return fmt.Errorf("couldn't dump links: %s", err)
+	}
+	attachments, err := parseXDPLinkMessages(msgs)
+	if err != nil {
+		return err
+	}
+	for _, a := range attachments {
+		value := 1.0
+		if a.mode == "none" {
+			value = 0
+		}
+		ch <- prometheus.MustNewConstMetric(
+			c.xdp, prometheus.GaugeValue, value, a.device, a.mode, strconv.FormatUint(uint64(a.progID), 10))
+	}
+
+	if sysBPF == 0 {
+		return nil
+	}
+	programs, err := bpfCountObjects(bpfProgGetNextID, bpfProgGetFDByID, bpfProgTypes)
+	if err != nil {
+		return fmt.Errorf("couldn't list eBPF programs: %s", err)
+	}
+	for typ, n := range programs {
+		ch <- prometheus.MustNewConstMetric(c.programs, prometheus.GaugeValue, float64(n), typ)
+	}
+	maps, err := bpfCountObjects(bpfMapGetNextID, bpfMapGetFDByID, bpfMapTypes)
+	if err != nil {
+		return fmt.Errorf("couldn't list eBPF maps: %s", err)
+	}
+	for typ, n := range maps {
+		ch <- prometheus.MustNewConstMetric(c.maps, prometheus.GaugeValue, float64(n), typ)
+	}
+	return nil
+}
+
+func parseXDPLinkMessages(msgs []syscall.NetlinkMessage) ([]xdpAttachment, error) {
+	var attachments []xdpAttachment
+	for _, m := range msgs {
+		if m.Header.Type == syscall.NLMSG_DONE {
+			break
+		}
+		if m.Header.Type != syscall.RTM_NEWLINK {
+			continue
+		}
+		if len(m.Data) < syscall.SizeofIfInfomsg {
+			return nil, fmt.Errorf("short link message: %d bytes", len(m.Data))
+		}
+		attrs, err := parseNetlinkAttrs(m.Data[syscall.SizeofIfInfomsg:])
+		if err != nil {
+			return nil, fmt.Errorf("couldn't parse link attributes: %s", err)
+		}
+		a := xdpAttachment{mode: "none"}
+		for _, attr := range attrs {
+			switch attr.typ {
+			case syscall.IFLA_IFNAME:
+				a.device = cString(attr.value)
+			case iflaXDP:
+				nested, err := parseNetlinkAttrs(attr.value)
+				if err != nil {
+					return nil, fmt.Errorf("couldn't parse XDP attributes: %s", err)
+				}
+				for _, n := range nested {
+					switch {
+					case n.typ == iflaXDPAttached && len(n.value) >= 1:
+						a.mode = xdpAttachModeName(n.value[0])
+					case n.typ == iflaXDPProgID && len(n.value) >= 4:
+						a.progID = nativeEndian().Uint32(n.value)
+					}
+				}
+			}
+		}
+		attachments = append(attachments, a)
+	}
+	return attachments, nil
+}
+
+func xdpAttachModeName(mode uint8) string {
+	if int(mode) < len(xdpAttachModes) {
+		return xdpAttachModes[mode]
+	}
+	return strconv.Itoa(int(mode))
+}
+
+// bpfCountObjects walks all object ids using the given GET_NEXT_ID command
+// and counts the objects by the type reported by BPF_OBJ_GET_INFO_BY_FD.
+func bpfCountObjects(nextCmd, fdCmd uintptr, typeNames []string) (map[string]int, error) {
+	counts := map[string]int{}
+	var id uint32
+	for {
+		next, err := bpfGetNextID(nextCmd, id)
+		if err == syscall.ENOENT {
+			return counts, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		id = next
+
+		typ, err := bpfObjectType(fdCmd, id)
+		if err == syscall.ENOENT {
+			// Object was unloaded in the meantime.
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		name := strconv.FormatUint(uint64(typ), 10)
+		if int(typ) < len(typeNames) {
+			name = typeNames[typ]
+		}
+		counts[name]++
+	}
+}
+
+func bpfGetNextID(cmd uintptr, id uint32) (uint32, error) {
+	// struct { __u32 start_id; __u32 next_id; __u32 open_flags; }
+	attr := [3]uint32{id}
+	if err := bpfCall(cmd, unsafe.Pointer(&attr), unsafe.Sizeof(attr)); err != nil {
+		return 0, err
+	}
+	return attr[1], nil
+}
+
+func bpfObjectType(cmd uintptr, id uint32) (uint32, error) {
+	fdAttr := [3]uint32{id}
+	fd, err := bpfCallFD(cmd, unsafe.Pointer(&fdAttr), unsafe.Sizeof(fdAttr))
+	if err != nil {
+		return 0, err
+	}
+	defer syscall.Close(fd)
+
+	// Both struct bpf_prog_info and struct bpf_map_info start with the
+	// type, so only the first field is requested. The kernel writes to info
+	// through a pointer the garbage collector doesn't see, so it is
+	// allocated on the heap and kept alive until the call returned.
+	info := new([bpfObjInfoTypeSize]byte)
+	infoAttr := struct {
+		fd      uint32
+		infoLen uint32
+		info    uint64
+	}{
+		fd:      uint32(fd),
+		infoLen: uint32(len(info)),
+		info:    uint64(uintptr(unsafe.Pointer(&info[0]))),
+	}
+	err = bpfCall(bpfObjGetInfoByFD, unsafe.Pointer(&infoAttr), unsafe.Sizeof(infoAttr))
+	runtime.KeepAlive(info)
+	if err != nil {
+		return 0, err
+	}
+	return nativeEndian().Uint32(info[:]), nil
+}
+
+func bpfCall(cmd uintptr, attr unsafe.Pointer, size uintptr) error {
+	_, err := bpfCallFD(cmd, attr, size)
+	return err
+}
+
+func bpfCallFD(cmd uintptr, attr unsafe.Pointer, size uintptr) (int, error) {
+	r, _, errno := syscall.Syscall(sysBPF, cmd, uintptr(attr), size)
+	if errno != 0 {
+		return 0, errno
+	}
+	return int(r), nil
+}
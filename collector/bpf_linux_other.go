@@ -0,0 +1,20 @@
+// Copyright 2016 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// +build !nobpf,linux,!386,!amd64,!arm,!arm64,!ppc64,!ppc64le
+
+package collector
+
+// The bpf(2) syscall number is unknown on this architecture, only XDP
+// attachments are reported.
+const sysBPF = 0
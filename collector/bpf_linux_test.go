@@ -0,0 +1,49 @@
+// Copyright 2016 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collector
+
+import (
+	"syscall"
+	"testing"
+)
+
+func TestXDPLinkMessages(t *testing.T) {
+	progID := make([]byte, 4)
+	nativeEndian().PutUint32(progID, 42)
+	xdp := append(netlinkAttrBytes(iflaXDPAttached, []byte{2}), netlinkAttrBytes(iflaXDPProgID, progID)...)
+
+	eth0 := make([]byte, syscall.SizeofIfInfomsg)
+	eth0 = append(eth0, netlinkAttrBytes(syscall.IFLA_IFNAME, []byte("eth0\x00"))...)
+	eth0 = append(eth0, netlinkAttrBytes(iflaXDP|syscall.NLA_F_NESTED, xdp)...)
+
+	lo := make([]byte, syscall.SizeofIfInfomsg)
+	lo = append(lo, netlinkAttrBytes(syscall.IFLA_IFNAME, []byte("lo\x00"))...)
+
+	attachments, err := parseXDPLinkMessages([]syscall.NetlinkMessage{
+		{Header: syscall.NlMsghdr{Type: syscall.RTM_NEWLINK}, Data: eth0},
+		{Header: syscall.NlMsghdr{Type: syscall.RTM_NEWLINK}, Data: lo},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want, got := 2, len(attachments); want != got {
+		t.Fatalf("want %d links, got %d", want, got)
+	}
+	if want, got := (xdpAttachment{device: "eth0", mode: "generic", progID: 42}), attachments[0]; want != got {
+		t.Errorf("want %v, got %v", want, got)
+	}
+	if want, got := (xdpAttachment{device: "lo", mode: "none"}), attachments[1]; want != got {
+		t.Errorf("want %v, got %v", want, got)
+	}
+}
@@ -0,0 +1,446 @@
+// Copyright 2016 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// This collector is only built with -tags bpftrace, as it needs bpftrace,
+// root privileges and a kernel with BTF to attach its probes. It doesn't
+// load eBPF programs itself but runs bpftrace, which compiles its script
+// against the kernel's BTF, and reads the histograms it prints.
+
+// +build bpftrace
+
+package collector
+
+import (
+	"bufio"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"math"
+	"net"
+	"os/exec"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/common/log"
+)
+
+const (
+	bpftraceSubsystem = "bpftrace"
+
+	// BTF was first exposed in /sys/kernel/btf/vmlinux with 5.2, which is
+	// needed to compile the probes without kernel headers.
+	bpftraceMinKernel = "5.2"
+
+	bpftraceIntervalMarker = "interval"
+)
+
+var (
+	bpftraceCommand    = flag.String("collector.bpftrace.command", "bpftrace", "Command to run bpftrace.")
+	bpftraceInterval   = flag.Duration("collector.bpftrace.interval", 15*time.Second, "How often bpftrace reports its histograms.")
+	bpftraceIPv4Prefix = flag.Int("collector.bpftrace.retransmit-ipv4-prefix", 24, "Prefix length of the IPv4 subnets TCP retransmits are counted by.")
+	bpftraceIPv6Prefix = flag.Int("collector.bpftrace.retransmit-ipv6-prefix", 64, "Prefix length of the IPv6 subnets TCP retransmits are counted by.")
+)
+
+// bpftraceScript traces the time between entering and leaving each syscall, and
+// between issuing and completing each block request, and counts TCP
+// retransmits by remote subnet. The retransmit maps are keyed by the masked
+// bytes of the address covered by the prefix, so their size is bounded by
+// the number of subnets, and cleared every interval. Each interval ends with
+// a marker line, and the start times are cleared on exit so that bpftrace
+// doesn't print them.
+const bpftraceScript = `
+tracepoint:raw_syscalls:sys_enter { @syscall_start[tid] = nsecs; }
+tracepoint:raw_syscalls:sys_exit /@syscall_start[tid]/ {
+	$ns = nsecs - @syscall_start[tid];
+	@syscall_ns = hist($ns);
+	@syscall_ns_sum = sum($ns);
+	delete(@syscall_start[tid]);
+}
+tracepoint:block:block_rq_issue { @block_start[args->dev, args->sector] = nsecs; }
+tracepoint:block:block_rq_complete /@block_start[args->dev, args->sector]/ {
+	$ns = nsecs - @block_start[args->dev, args->sector];
+	@block_ns[args->dev] = hist($ns);
+	@block_ns_sum[args->dev] = sum($ns);
+	delete(@block_start[args->dev, args->sector]);
+}
+tracepoint:tcp:tcp_retransmit_skb /args->family == 2/ { @tcp_retransmits4[%s] = count(); }
+tracepoint:tcp:tcp_retransmit_skb /args->family == 10/ { @tcp_retransmits6[%s] = count(); }
+interval:s:%d {
+	print(@syscall_ns); print(@syscall_ns_sum);
+	print(@block_ns); print(@block_ns_sum);
+	print(@tcp_retransmits4); print(@tcp_retransmits6);
+	clear(@tcp_retransmits4); clear(@tcp_retransmits6);
+	printf("%s\n");
+}
+END { clear(@syscall_start); clear(@block_start); }
+`
+
+// bpftraceBucket is a power of two bucket of a bpftrace histogram, holding the
+// values up to and including max nanoseconds. The last bucket has no upper
+// bound and max is +Inf.
+type bpftraceBucket struct {
+	max   float64
+	count uint64
+}
+
+type bpftraceHistogram struct {
+	buckets []bpftraceBucket
+	sum     float64
+}
+
+// bpftraceStats holds the latest histograms and counts printed by bpftrace.
+// Block histograms are keyed by device number as major:minor, retransmits
+// of the last interval by remote subnet.
+type bpftraceStats struct {
+	syscall     bpftraceHistogram
+	block       map[string]bpftraceHistogram
+	retransmits map[string]float64
+}
+
+type bpftraceCollector struct {
+	mtx   sync.Mutex
+	stats bpftraceStats
+	err   error
+	// retransmitTotals sums the retransmits of all intervals by subnet,
+	// kept in -collector.state-dir.
+	retransmitTotals map[string]float64
+
+	syscall     *prometheus.Desc
+	block       *prometheus.Desc
+	retransmits *prometheus.Desc
+}
+
+func init() {
+	Factories["bpftrace"] = NewBpftraceCollector
+	Maturities["bpftrace"] = MaturityExperimental
+}
+
+// NewBpftraceCollector returns a new Collector exposing syscall and block I/O
+// latency histograms, traced by a bpftrace process running in the
+// background.
+func NewBpftraceCollector() (Collector, error) {
+	data, err := readFSFile(procFS, "sys/kernel/osrelease")
+	if err != nil {
+		return nil, err
+	}
+	release := strings.TrimSpace(string(data))
+	if compareKernelReleases(release, bpftraceMinKernel) < 0 {
+		return nil, fmt.Errorf("kernel %s is too old for the bpftrace collector, need at least %s", release, bpftraceMinKernel)
+	}
+	btf, err := sysFS.Open("kernel/btf/vmlinux")
+	if err != nil {
+		return nil, fmt.Errorf("kernel BTF is unavailable: %s", err)
+	}
+	btf.Close()
+	if *bpftraceIPv4Prefix < 0 || *bpftraceIPv4Prefix > 8*net.IPv4len {
+		return nil, fmt.Errorf("invalid IPv4 prefix length %d", *bpftraceIPv4Prefix)
+	}
+	if *bpftraceIPv6Prefix < 0 || *bpftraceIPv6Prefix > 8*net.IPv6len {
+		return nil, fmt.Errorf("invalid IPv6 prefix length %d", *bpftraceIPv6Prefix)
+	}
+	if _, err := exec.LookPath(*bpftraceCommand); err != nil {
+		return nil, err
+	}
+
+	c := newBpftraceCollector()
+	if err := loadState(bpftraceSubsystem, &c.retransmitTotals); err != nil {
+		return nil, fmt.Errorf("couldn't restore bpftrace state: %s", err)
+	}
+	Go(func() { c.run(*bpftraceCommand, *bpftraceInterval) })
+	return c, nil
+}
+
+func newBpftraceCollector() *bpftraceCollector {
+	return &bpftraceCollector{
+		err:              fmt.Errorf("bpftrace hasn't reported yet"),
+		retransmitTotals: map[string]float64{},
+		syscall: prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, bpftraceSubsystem, "syscall_latency_seconds"),
+			"Latency of system calls since bpftrace was started.",
+			nil, nil,
+		),
+		block: prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, bpftraceSubsystem, "block_io_latency_seconds"),
+			"Latency of block I/O requests from issue to completion since bpftrace was started.",
+			[]string{"device"}, nil,
+		),
+		retransmits: prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, bpftraceSubsystem, "tcp_retransmits_total"),
+			"TCP segments retransmitted to remote addresses in the subnet, since the exporter started unless kept in -collector.state-dir.",
+			[]string{"subnet"}, nil,
+		),
+	}
+}
+
+func (c *bpftraceCollector) Update(ch chan<- prometheus.Metric) (err error) {
+	c.mtx.Lock()
+	stats, err := c.stats, c.err
+	retransmits := make(map[string]float64, len(c.retransmitTotals))
+	for subnet, v := range c.retransmitTotals {
+		retransmits[subnet] = v
+	}
+	c.mtx.Unlock()
+	if err != nil {
+		return err
+	}
+
+	ch <- bpftraceConstHistogram(c.syscall, stats.syscall)
+	for device, h := range stats.block {
+		ch <- bpftraceConstHistogram(c.block, h, device)
+	}
+	for subnet, v := range retransmits {
+		ch <- prometheus.MustNewConstMetric(c.retransmits, prometheus.CounterValue, v, subnet)
+	}
+	return nil
+}
+
+// run keeps bpftrace running, restarting it when it exits. Histograms start
+// from zero again after a restart, which Prometheus handles as a counter
+// reset.
+func (c *bpftraceCollector) run(command string, interval time.Duration) {
+	seconds := int(interval.Seconds())
+	if seconds < 1 {
+		seconds = 1
+	}
+	script := fmt.Sprintf(bpftraceScript,
+		bpftraceSubnetKey("args->daddr", *bpftraceIPv4Prefix),
+		bpftraceSubnetKey("args->daddr_v6", *bpftraceIPv6Prefix),
+		seconds, bpftraceIntervalMarker)
+	for {
+		err := c.trace(command, script)
+		log.Errorf("bpftrace exited, restarting: %s", err)
+		c.mtx.Lock()
+		c.err = fmt.Errorf("bpftrace exited: %s", err)
+		c.mtx.Unlock()
+		time.Sleep(BackgroundInterval(interval))
+	}
+}
+
+func (c *bpftraceCollector) trace(command, script string) error {
+	cmd := localeCommand(command, "-f", "json", "-e", script)
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return err
+	}
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+	err = parseBpftraceOutput(stdout, *bpftraceIPv4Prefix, *bpftraceIPv6Prefix, c.report)
+	if err != nil {
+		cmd.Process.Kill()
+		cmd.Wait()
+		return err
+	}
+	if err := cmd.Wait(); err != nil {
+		return err
+	}
+	return fmt.Errorf("bpftrace stopped")
+}
+
+// report takes the stats of an interval and adds its retransmits to the
+// totals, which are saved if they changed.
+func (c *bpftraceCollector) report(stats bpftraceStats) {
+	c.mtx.Lock()
+	c.stats, c.err = stats, nil
+	for subnet, v := range stats.retransmits {
+		c.retransmitTotals[subnet] += v
+	}
+	saved := make(map[string]float64, len(c.retransmitTotals))
+	for subnet, v := range c.retransmitTotals {
+		saved[subnet] = v
+	}
+	c.mtx.Unlock()
+	if len(stats.retransmits) == 0 {
+		return
+	}
+	if err := saveState(bpftraceSubsystem, saved); err != nil {
+		log.Errorf("Couldn't save bpftrace state: %s", err)
+	}
+}
+
+// parseBpftraceOutput reads the JSON lines printed by bpftrace and calls report
+// after each interval marker. The retransmit maps were keyed with the given
+// prefix lengths.
+func parseBpftraceOutput(r io.Reader, ipv4Prefix, ipv6Prefix int, report func(bpftraceStats)) error {
+	var stats bpftraceStats
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		var line struct {
+			Type string          `json:"type"`
+			Data json.RawMessage `json:"data"`
+		}
+		if err := json.Unmarshal(scanner.Bytes(), &line); err != nil {
+			return fmt.Errorf("invalid bpftrace output %q: %s", scanner.Text(), err)
+		}
+		switch line.Type {
+		case "printf":
+			var text string
+			if err := json.Unmarshal(line.Data, &text); err == nil && strings.TrimSpace(text) == bpftraceIntervalMarker {
+				report(stats)
+				stats.retransmits = nil
+			}
+		case "hist", "map":
+			var maps map[string]json.RawMessage
+			if err := json.Unmarshal(line.Data, &maps); err != nil {
+				return fmt.Errorf("invalid bpftrace output %q: %s", scanner.Text(), err)
+			}
+			for name, data := range maps {
+				if err := stats.parseMap(name, data, ipv4Prefix, ipv6Prefix); err != nil {
+					return fmt.Errorf("invalid bpftrace map %s: %s", name, err)
+				}
+			}
+		}
+	}
+	return scanner.Err()
+}
+
+func (s *bpftraceStats) parseMap(name string, data json.RawMessage, ipv4Prefix, ipv6Prefix int) error {
+	switch name {
+	case "@syscall_ns":
+		buckets, err := parseBpftraceHist(data)
+		if err != nil {
+			return err
+		}
+		s.syscall.buckets = buckets
+	case "@syscall_ns_sum":
+		return json.Unmarshal(data, &s.syscall.sum)
+	case "@block_ns", "@block_ns_sum":
+		// Block maps are empty until the first request completes, which
+		// bpftrace prints as an empty object.
+		var byDev map[string]json.RawMessage
+		if err := json.Unmarshal(data, &byDev); err != nil {
+			return err
+		}
+		block := map[string]bpftraceHistogram{}
+		for k, v := range s.block {
+			block[k] = v
+		}
+		for dev, v := range byDev {
+			device, err := bpftraceDevice(dev)
+			if err != nil {
+				return err
+			}
+			h := block[device]
+			if name == "@block_ns" {
+				if h.buckets, err = parseBpftraceHist(v); err != nil {
+					return err
+				}
+			} else if err := json.Unmarshal(v, &h.sum); err != nil {
+				return err
+			}
+			block[device] = h
+		}
+		s.block = block
+	case "@tcp_retransmits4", "@tcp_retransmits6":
+		var byKey map[string]float64
+		if err := json.Unmarshal(data, &byKey); err != nil {
+			return err
+		}
+		size, prefix := net.IPv4len, ipv4Prefix
+		if name == "@tcp_retransmits6" {
+			size, prefix = net.IPv6len, ipv6Prefix
+		}
+		if s.retransmits == nil {
+			s.retransmits = map[string]float64{}
+		}
+		for key, v := range byKey {
+			subnet, err := bpftraceSubnet(key, size, prefix)
+			if err != nil {
+				return err
+			}
+			s.retransmits[subnet] += v
+		}
+	}
+	return nil
+}
+
+func parseBpftraceHist(data json.RawMessage) ([]bpftraceBucket, error) {
+	var raw []struct {
+		Min   *float64 `json:"min"`
+		Max   *float64 `json:"max"`
+		Count uint64   `json:"count"`
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, err
+	}
+	buckets := make([]bpftraceBucket, 0, len(raw))
+	for _, b := range raw {
+		max := math.Inf(1)
+		if b.Max != nil {
+			max = *b.Max
+		}
+		buckets = append(buckets, bpftraceBucket{max: max, count: b.Count})
+	}
+	return buckets, nil
+}
+
+// bpftraceDevice converts a kernel internal dev_t, which uses 20 bits for the
+// minor number, to major:minor.
+func bpftraceDevice(s string) (string, error) {
+	dev, err := strconv.ParseUint(strings.TrimSpace(s), 10, 32)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%d:%d", dev>>20, dev&(1<<20-1)), nil
+}
+
+// bpftraceSubnetKey returns the bpftrace map key of the subnet of the address
+// in field: the bytes covered by the prefix, with the last one masked.
+func bpftraceSubnetKey(field string, prefix int) string {
+	n := (prefix + 7) / 8
+	if n == 0 {
+		n = 1
+	}
+	keys := make([]string, n)
+	for i := range keys {
+		keys[i] = fmt.Sprintf("%s[%d]", field, i)
+		if bits := prefix - 8*i; bits < 8 {
+			keys[i] += fmt.Sprintf(" & %d", 0xff<<uint(8-bits)&0xff)
+		}
+	}
+	return strings.Join(keys, ", ")
+}
+
+// bpftraceSubnet returns the subnet of the given prefix length in CIDR notation
+// for a key of the map keyed by bpftraceSubnetKey, which bpftrace prints as
+// comma-separated bytes.
+func bpftraceSubnet(key string, size, prefix int) (string, error) {
+	ip := make(net.IP, size)
+	for i, b := range strings.Split(key, ",") {
+		v, err := strconv.ParseUint(strings.TrimSpace(b), 10, 8)
+		if err != nil || i >= size {
+			return "", fmt.Errorf("invalid address key %q", key)
+		}
+		ip[i] = byte(v)
+	}
+	mask := net.CIDRMask(prefix, 8*size)
+	subnet := net.IPNet{IP: ip.Mask(mask), Mask: mask}
+	return subnet.String(), nil
+}
+
+func bpftraceConstHistogram(desc *prometheus.Desc, h bpftraceHistogram, labels ...string) prometheus.Metric {
+	var count uint64
+	buckets := map[float64]uint64{}
+	for _, b := range h.buckets {
+		count += b.count
+		if !math.IsInf(b.max, 1) {
+			buckets[b.max/1e9] = count
+		}
+	}
+	return prometheus.MustNewConstHistogram(desc, count, h.sum/1e9, buckets, labels...)
+}
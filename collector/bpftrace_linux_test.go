@@ -0,0 +1,170 @@
+// Copyright 2016 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// +build bpftrace
+
+package collector
+
+import (
+	"io/ioutil"
+	"math"
+	"os"
+	"reflect"
+	"testing"
+
+	dto "github.com/prometheus/client_model/go"
+)
+
+func TestParseBpftraceOutput(t *testing.T) {
+	file, err := os.Open("fixtures/bpftrace-latency.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+
+	var reports []bpftraceStats
+	if err := parseBpftraceOutput(file, 24, 64, func(s bpftraceStats) { reports = append(reports, s) }); err != nil {
+		t.Fatal(err)
+	}
+	if want, got := 2, len(reports); want != got {
+		t.Fatalf("want %d reports, got %d", want, got)
+	}
+	if want, got := 0, len(reports[0].block); want != got {
+		t.Errorf("want %d block devices in first report, got %d", want, got)
+	}
+
+	stats := reports[1]
+	if want, got := 4, len(stats.syscall.buckets); want != got {
+		t.Fatalf("want %d syscall buckets, got %d", want, got)
+	}
+	if !math.IsInf(stats.syscall.buckets[3].max, 1) {
+		t.Errorf("want last syscall bucket to be unbounded, got %f", stats.syscall.buckets[3].max)
+	}
+	if want, got := 2147600000.0, stats.syscall.sum; want != got {
+		t.Errorf("want syscall sum %f, got %f", want, got)
+	}
+
+	sda, ok := stats.block["8:0"]
+	if !ok {
+		t.Fatalf("want block device 8:0, got %v", stats.block)
+	}
+	if want, got := 2, len(sda.buckets); want != got {
+		t.Errorf("want %d buckets for 8:0, got %d", want, got)
+	}
+	if want, got := 400000.0, sda.sum; want != got {
+		t.Errorf("want sum %f for 8:0, got %f", want, got)
+	}
+	if _, ok := stats.block["259:0"]; !ok {
+		t.Errorf("want block device 259:0, got %v", stats.block)
+	}
+	want := map[string]float64{
+		"10.0.1.0/24":   5,
+		"10.0.2.0/24":   1,
+		"2001:db8::/64": 5,
+	}
+	if !reflect.DeepEqual(want, stats.retransmits) {
+		t.Errorf("want retransmits %v, got %v", want, stats.retransmits)
+	}
+}
+
+func TestBpftraceSubnetKey(t *testing.T) {
+	for _, tt := range []struct {
+		prefix int
+		want   string
+	}{
+		{24, "a[0], a[1], a[2]"},
+		{20, "a[0], a[1], a[2] & 240"},
+		{1, "a[0] & 128"},
+		{0, "a[0] & 0"},
+	} {
+		if got := bpftraceSubnetKey("a", tt.prefix); tt.want != got {
+			t.Errorf("/%d: want key %q, got %q", tt.prefix, tt.want, got)
+		}
+	}
+}
+
+func TestBpftraceSubnet(t *testing.T) {
+	for _, tt := range []struct {
+		key          string
+		size, prefix int
+		want         string
+	}{
+		{"10, 0, 16", 4, 20, "10.0.16.0/20"},
+		{"10,0,1", 4, 24, "10.0.1.0/24"},
+		{"0", 4, 0, "0.0.0.0/0"},
+		{"32, 1, 13, 184, 0, 0, 0, 0", 16, 64, "2001:db8::/64"},
+	} {
+		got, err := bpftraceSubnet(tt.key, tt.size, tt.prefix)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if tt.want != got {
+			t.Errorf("%q: want subnet %q, got %q", tt.key, tt.want, got)
+		}
+	}
+	for _, key := range []string{"10, 0, 300", "1, 2, 3, 4, 5", "bogus"} {
+		if _, err := bpftraceSubnet(key, 4, 24); err == nil {
+			t.Errorf("want error for key %q", key)
+		}
+	}
+}
+
+func TestBpftraceConstHistogram(t *testing.T) {
+	c := newBpftraceCollector()
+	h := bpftraceHistogram{
+		buckets: []bpftraceBucket{{max: 511, count: 20}, {max: 1023, count: 60}, {max: math.Inf(1), count: 2}},
+		sum:     100000,
+	}
+	var m dto.Metric
+	if err := bpftraceConstHistogram(c.syscall, h).Write(&m); err != nil {
+		t.Fatal(err)
+	}
+	hist := m.GetHistogram()
+	if want, got := uint64(82), hist.GetSampleCount(); want != got {
+		t.Errorf("want sample count %d, got %d", want, got)
+	}
+	if want, got := 0.0001, hist.GetSampleSum(); want != got {
+		t.Errorf("want sample sum %f, got %f", want, got)
+	}
+	if want, got := 2, len(hist.GetBucket()); want != got {
+		t.Fatalf("want %d buckets, got %d", want, got)
+	}
+	for _, b := range hist.GetBucket() {
+		if b.GetUpperBound() == 1023e-9 && b.GetCumulativeCount() != 80 {
+			t.Errorf("want 80 samples up to 1023ns, got %d", b.GetCumulativeCount())
+		}
+	}
+}
+
+func TestBpftraceRetransmitState(t *testing.T) {
+	dir, err := ioutil.TempDir("", "state")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	defer func(dir string) { *stateDir = dir }(*stateDir)
+	*stateDir = dir
+
+	c := newBpftraceCollector()
+	c.report(bpftraceStats{retransmits: map[string]float64{"10.0.1.0/24": 3}})
+	c.report(bpftraceStats{retransmits: map[string]float64{"10.0.1.0/24": 2, "10.0.2.0/24": 1}})
+
+	// A restarted collector continues from the saved totals.
+	restarted := newBpftraceCollector()
+	if err := loadState(bpftraceSubsystem, &restarted.retransmitTotals); err != nil {
+		t.Fatal(err)
+	}
+	if want, got := map[string]float64{"10.0.1.0/24": 5, "10.0.2.0/24": 1}, restarted.retransmitTotals; !reflect.DeepEqual(want, got) {
+		t.Errorf("want restored retransmits %v, got %v", want, got)
+	}
+}
@@ -0,0 +1,163 @@
+// Copyright 2016 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// +build !nocertificate
+
+package collector
+
+import (
+	"crypto/x509"
+	"encoding/pem"
+	"flag"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/common/log"
+)
+
+const certificateSubsystem = "certificate"
+
+var (
+	certificateGlobs = flag.String("collector.certificate.globs", "", "Comma-separated list of file globs matching PEM encoded certificates.")
+)
+
+type certificateCollector struct {
+	globs []string
+
+	notAfter    *prometheus.Desc
+	notBefore   *prometheus.Desc
+	chainLength *prometheus.Desc
+	parseError  *prometheus.Desc
+}
+
+func init() {
+	Factories["certificate"] = NewCertificateCollector
+}
+
+// NewCertificateCollector returns a new Collector exposing validity periods
+// of the PEM certificates found in the configured file globs.
+func NewCertificateCollector() (Collector, error) {
+	var globs []string
+	for _, g := range strings.Split(*certificateGlobs, ",") {
+		if g = strings.TrimSpace(g); g != "" {
+			if _, err := filepath.Match(g, ""); err != nil {
+				return nil, fmt.Errorf("invalid certificate glob %q: %s", g, err)
+			}
+			globs = append(globs, g)
+		}
+	}
+	if len(globs) == 0 {
+		return nil, fmt.Errorf("no globs specified, see -collector.certificate.globs")
+	}
+
+	labels := []string{"path", "index", "subject"}
+	return &certificateCollector{
+		globs: globs,
+		notAfter: prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, certificateSubsystem, "not_after_timestamp_seconds"),
+			"Unixtime after which the certificate is no longer valid.",
+			labels, nil,
+		),
+		notBefore: prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, certificateSubsystem, "not_before_timestamp_seconds"),
+			"Unixtime before which the certificate is not yet valid.",
+			labels, nil,
+		),
+		chainLength: prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, certificateSubsystem, "chain_length"),
+			"Number of certificates contained in the file.",
+			[]string{"path"}, nil,
+		),
+		parseError: prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, certificateSubsystem, "parse_error"),
+			"1 if the file could not be read or contains invalid certificates, 0 otherwise.",
+			[]string{"path"}, nil,
+		),
+	}, nil
+}
+
+func (c *certificateCollector) Update(ch chan<- prometheus.Metric) (err error) {
+	var paths []string
+	for _, g := range c.globs {
+		root, pattern := splitLocalPath(g)
+		matches, err := rootFS(root).Glob(pattern)
+		if err != nil {
+			return err
+		}
+		for _, m := range matches {
+			paths = append(paths, filepath.Join(root, filepath.FromSlash(m)))
+		}
+	}
+	sort.Strings(paths)
+
+	seen := map[string]bool{}
+	for _, path := range paths {
+		if seen[path] {
+			continue
+		}
+		seen[path] = true
+
+		certs, err := readCertificates(path)
+		parseError := 0.0
+		if err != nil {
+			log.Errorf("Error reading certificates from %s: %s", path, err)
+			parseError = 1
+		}
+		ch <- prometheus.MustNewConstMetric(c.parseError, prometheus.GaugeValue, parseError, path)
+		if err != nil {
+			continue
+		}
+
+		ch <- prometheus.MustNewConstMetric(c.chainLength, prometheus.GaugeValue, float64(len(certs)), path)
+		for i, cert := range certs {
+			labels := []string{path, strconv.Itoa(i), cert.Subject.CommonName}
+			ch <- prometheus.MustNewConstMetric(c.notAfter, prometheus.GaugeValue, float64(cert.NotAfter.Unix()), labels...)
+			ch <- prometheus.MustNewConstMetric(c.notBefore, prometheus.GaugeValue, float64(cert.NotBefore.Unix()), labels...)
+		}
+	}
+	return nil
+}
+
+// readCertificates returns all certificates of the PEM file at path in the
+// order they appear in.
+func readCertificates(path string) ([]*x509.Certificate, error) {
+	data, err := readFSFile(localFS(path))
+	if err != nil {
+		return nil, err
+	}
+
+	var certs []*x509.Certificate
+	for {
+		var block *pem.Block
+		block, data = pem.Decode(data)
+		if block == nil {
+			break
+		}
+		if block.Type != "CERTIFICATE" {
+			continue
+		}
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return nil, err
+		}
+		certs = append(certs, cert)
+	}
+	if len(certs) == 0 {
+		return nil, fmt.Errorf("no certificates found")
+	}
+	return certs, nil
+}
@@ -0,0 +1,41 @@
+// Copyright 2016 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collector
+
+import (
+	"testing"
+)
+
+func TestReadCertificates(t *testing.T) {
+	certs, err := readCertificates("fixtures/certificate/chain.pem")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want, got := 2, len(certs); want != got {
+		t.Fatalf("want chain length %d, got %d", want, got)
+	}
+	if want, got := "leaf.example.com", certs[0].Subject.CommonName; want != got {
+		t.Errorf("want subject %q, got %q", want, got)
+	}
+	if want, got := int64(2107435007), certs[0].NotAfter.Unix(); want != got {
+		t.Errorf("want not_after %d, got %d", want, got)
+	}
+	if want, got := int64(2422795007), certs[1].NotAfter.Unix(); want != got {
+		t.Errorf("want not_after %d, got %d", want, got)
+	}
+
+	if _, err := readCertificates("fixtures/certificate/invalid.pem"); err == nil {
+		t.Error("expected error for file without certificates")
+	}
+}
@@ -0,0 +1,168 @@
+// Copyright 2016 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collector
+
+import (
+	"bufio"
+	"fmt"
+	"math"
+	"os"
+	"path"
+	"runtime"
+	"strconv"
+	"strings"
+	"syscall"
+
+	"github.com/prometheus/common/log"
+)
+
+// The period of the CPU quota set by ApplySelfLimits, in microseconds.
+const cgroupCPUPeriod = 100000
+
+// selfCgroup returns the sysfs relative directory of the exporter's cgroup
+// in the unified cgroup v2 hierarchy mounted at /sys/fs/cgroup.
+func selfCgroup(procfs, sysfs fileSystem) (string, error) {
+	if _, err := readFSFile(sysfs, "fs/cgroup/cgroup.controllers"); err != nil {
+		return "", fmt.Errorf("no cgroup v2 hierarchy mounted at /sys/fs/cgroup: %s", err)
+	}
+	data, err := readFSFile(procfs, "self/cgroup")
+	if err != nil {
+		return "", err
+	}
+	for _, line := range strings.Split(string(data), "\n") {
+		if strings.HasPrefix(line, "0::") {
+			return path.Join("fs/cgroup", strings.TrimPrefix(line, "0::")), nil
+		}
+	}
+	return "", fmt.Errorf("exporter isn't in a cgroup v2 hierarchy")
+}
+
+// readCgroupKeyValues parses cgroup files like cpu.stat and memory.events
+// with a key and an integer value per line.
+func readCgroupKeyValues(fsys fileSystem, name string) (map[string]uint64, error) {
+	file, err := fsys.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	values := map[string]uint64{}
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) != 2 {
+			continue
+		}
+		v, err := strconv.ParseUint(fields[1], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid line in %s: %q", name, scanner.Text())
+		}
+		values[fields[0]] = v
+	}
+	return values, scanner.Err()
+}
+
+// ApplySelfLimits limits the exporter to cpus CPUs and memory bytes, so a
+// misbehaving collector can't starve the host. Zero leaves a resource
+// unlimited. The limits are set on the exporter's own cgroup if no other
+// process shares it, so they don't apply to unrelated processes. Otherwise
+// memory is limited with RLIMIT_DATA and CPU usage only by the number of
+// threads running Go code. It returns how the limits were applied.
+func ApplySelfLimits(cpus float64, memory int64) (string, error) {
+	dir, err := selfCgroup(procFS, sysFS)
+	if err == nil {
+		err = checkCgroupExclusive(sysFS, dir, os.Getpid())
+	}
+	if err == nil {
+		err = writeCgroupLimits(sysFS, dir, cpus, memory)
+	}
+	if err == nil {
+		return "cgroup " + strings.TrimPrefix(dir, "fs/cgroup"), nil
+	}
+	if _, ok := err.(cgroupRestoreError); ok {
+		// The CPU quota stays applied, falling back would report the
+		// limits wrongly.
+		return "", err
+	}
+	log.Debugf("Couldn't limit own cgroup: %s", err)
+
+	if memory > 0 {
+		limit := &syscall.Rlimit{Cur: uint64(memory), Max: uint64(memory)}
+		if err := syscall.Setrlimit(syscall.RLIMIT_DATA, limit); err != nil {
+			return "", fmt.Errorf("couldn't set RLIMIT_DATA: %s", err)
+		}
+	}
+	if cpus > 0 {
+		runtime.GOMAXPROCS(int(math.Max(1, math.Ceil(cpus))))
+	}
+	return "rlimit and GOMAXPROCS", nil
+}
+
+// checkCgroupExclusive returns an error if processes other than pid are
+// members of the cgroup dir.
+func checkCgroupExclusive(sysfs fileSystem, dir string, pid int) error {
+	data, err := readFSFile(sysfs, path.Join(dir, "cgroup.procs"))
+	if err != nil {
+		return err
+	}
+	var others []string
+	for _, p := range strings.Fields(string(data)) {
+		if p != strconv.Itoa(pid) {
+			others = append(others, p)
+		}
+	}
+	if len(others) > 0 {
+		return fmt.Errorf("cgroup %s is shared with processes %s", strings.TrimPrefix(dir, "fs/cgroup"), strings.Join(others, ", "))
+	}
+	return nil
+}
+
+// cgroupRestoreError is returned by writeCgroupLimits if the memory limit
+// couldn't be set and the CPU quota, which was already set, couldn't be
+// restored either.
+type cgroupRestoreError struct {
+	err, restoreErr error
+}
+
+func (e cgroupRestoreError) Error() string {
+	return fmt.Sprintf("%s, and couldn't restore cpu.max: %s", e.err, e.restoreErr)
+}
+
+// writeCgroupLimits sets the limits of the cgroup dir of sysfs. If the memory
+// limit can't be set, the previous CPU quota is restored, so that either both
+// limits or none are applied.
+func writeCgroupLimits(sysfs fileSystem, dir string, cpus float64, memory int64) error {
+	var oldQuota []byte
+	if cpus > 0 {
+		var err error
+		if oldQuota, err = readFSFile(sysfs, path.Join(dir, "cpu.max")); err != nil {
+			return err
+		}
+		quota := fmt.Sprintf("%d %d\n", int64(cpus*cgroupCPUPeriod), cgroupCPUPeriod)
+		if err := writeFSFile(sysfs, path.Join(dir, "cpu.max"), []byte(quota)); err != nil {
+			return err
+		}
+	}
+	if memory > 0 {
+		if err := writeFSFile(sysfs, path.Join(dir, "memory.max"), []byte(strconv.FormatInt(memory, 10)+"\n")); err != nil {
+			if oldQuota != nil {
+				if rerr := writeFSFile(sysfs, path.Join(dir, "cpu.max"), oldQuota); rerr != nil {
+					return cgroupRestoreError{err: err, restoreErr: rerr}
+				}
+			}
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,114 @@
+// Copyright 2016 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collector
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestSelfCgroup(t *testing.T) {
+	procfs := mapFS{"self/cgroup": "0::/system.slice/node_exporter.service\n"}
+	sysfs := mapFS{
+		"fs/cgroup/cgroup.controllers":                               "cpu memory pids\n",
+		"fs/cgroup/system.slice/node_exporter.service/cpu.stat":      "usage_usec 8000\nnr_periods 120\nnr_throttled 7\nthrottled_usec 35000\n",
+		"fs/cgroup/system.slice/node_exporter.service/memory.events": "low 0\nhigh 0\nmax 3\noom 0\noom_kill 0\n",
+	}
+	dir, err := selfCgroup(procfs, sysfs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want, got := "fs/cgroup/system.slice/node_exporter.service", dir; want != got {
+		t.Errorf("want cgroup %q, got %q", want, got)
+	}
+
+	stat, err := readCgroupKeyValues(sysfs, dir+"/cpu.stat")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want, got := uint64(35000), stat["throttled_usec"]; want != got {
+		t.Errorf("want throttled_usec %d, got %d", want, got)
+	}
+
+	// Hybrid hierarchies mount cgroup v1 at /sys/fs/cgroup.
+	if _, err := selfCgroup(mapFS{"self/cgroup": "4:memory:/foo\n0::/\n"}, mapFS{}); err == nil {
+		t.Error("want error without cgroup v2 hierarchy")
+	}
+}
+
+func TestCheckCgroupExclusive(t *testing.T) {
+	dir := "fs/cgroup/system.slice/node_exporter.service"
+	if err := checkCgroupExclusive(mapFS{dir + "/cgroup.procs": "42\n"}, dir, 42); err != nil {
+		t.Errorf("want no error for exclusive cgroup, got %s", err)
+	}
+	// Containers without a private cgroup namespace often run everything
+	// in one cgroup.
+	if err := checkCgroupExclusive(mapFS{dir + "/cgroup.procs": "1\n42\n97\n"}, dir, 42); err == nil {
+		t.Error("want error for shared cgroup")
+	}
+}
+
+func TestWriteCgroupLimits(t *testing.T) {
+	dir, err := ioutil.TempDir("", "node_exporter_cgroup")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	for name, data := range map[string]string{"cpu.max": "max 100000\n", "memory.max": "max\n"} {
+		if err := ioutil.WriteFile(filepath.Join(dir, name), []byte(data), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	sysfs := rootFS(dir)
+	if err := writeCgroupLimits(sysfs, ".", 0.5, 64<<20); err != nil {
+		t.Fatal(err)
+	}
+	got := map[string]string{}
+	for _, name := range []string{"cpu.max", "memory.max"} {
+		data, err := ioutil.ReadFile(filepath.Join(dir, name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		got[name] = string(data)
+	}
+	want := map[string]string{"cpu.max": "50000 100000\n", "memory.max": "67108864\n"}
+	if !reflect.DeepEqual(want, got) {
+		t.Errorf("want %v, got %v", want, got)
+	}
+
+	// A failing memory limit restores the CPU quota.
+	if err := ioutil.WriteFile(filepath.Join(dir, "cpu.max"), []byte("max 100000\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Remove(filepath.Join(dir, "memory.max")); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Mkdir(filepath.Join(dir, "memory.max"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := writeCgroupLimits(sysfs, ".", 0.5, 64<<20); err == nil {
+		t.Fatal("want error for failing memory limit")
+	}
+	data, err := ioutil.ReadFile(filepath.Join(dir, "cpu.max"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want, got := "max 100000\n", string(data); want != got {
+		t.Errorf("want cpu.max restored to %q, got %q", want, got)
+	}
+}
@@ -0,0 +1,385 @@
+// Copyright 2016 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// +build !nocloud
+
+package collector
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"path"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/common/log"
+)
+
+const cloudSubsystem = "cloud"
+
+var (
+	cloudProviderName = flag.String("collector.cloud.provider", "", "Cloud provider whose metadata service to query (ec2, gce or azure).")
+	cloudMetadataURL  = flag.String("collector.cloud.metadata-url", "", "Base URL of the metadata service, defaults to the provider's well-known address.")
+	cloudTimeout      = flag.Duration("collector.cloud.timeout", 2*time.Second, "Timeout for requests to the metadata service.")
+	cloudPollInterval = flag.Duration("collector.cloud.termination-poll-interval", 5*time.Second, "Interval at which the termination notice is polled in the background.")
+
+	cloudDefaultURLs = map[string]string{
+		"ec2":   "http://169.254.169.254",
+		"gce":   "http://metadata.google.internal",
+		"azure": "http://169.254.169.254",
+	}
+)
+
+// cloudInstance describes the instance the exporter runs on.
+type cloudInstance struct {
+	instanceType string
+	zone         string
+	lifecycle    string
+}
+
+// cloudTermination is a pending termination or preemption of the instance.
+// A zero time means the provider did not announce when it will happen.
+type cloudTermination struct {
+	pending bool
+	time    time.Time
+}
+
+// cloudProvider queries the metadata service of a cloud provider.
+type cloudProvider interface {
+	instance() (cloudInstance, error)
+	termination() (cloudTermination, error)
+}
+
+// cloudMetadataClient performs requests against a metadata service.
+type cloudMetadataClient struct {
+	client  *http.Client
+	baseURL string
+	header  http.Header
+}
+
+type cloudCollector struct {
+	name     string
+	provider cloudProvider
+
+	mtx              sync.Mutex
+	termination      cloudTermination
+	terminationError error
+
+	info           *prometheus.Desc
+	imminent       *prometheus.Desc
+	untilTerminate *prometheus.Desc
+}
+
+func init() {
+	Factories["cloud"] = NewCloudCollector
+}
+
+// NewCloudCollector returns a new Collector exposing instance metadata and
+// pending spot or preemption terminations from the cloud metadata service.
+func NewCloudCollector() (Collector, error) {
+	name := *cloudProviderName
+	provider, err := newCloudProvider(name, *cloudMetadataURL, *cloudTimeout)
+	if err != nil {
+		return nil, err
+	}
+	c := newCloudCollector(name, provider)
+	Go(func() { c.pollTermination(*cloudPollInterval) })
+	return c, nil
+}
+
+func newCloudCollector(name string, provider cloudProvider) *cloudCollector {
+	return &cloudCollector{
+		name:     name,
+		provider: provider,
+		info: prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, cloudSubsystem, "instance_info"),
+			"Instance metadata as reported by the cloud metadata service.",
+			[]string{"provider", "instance_type", "zone", "lifecycle"}, nil,
+		),
+		imminent: prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, "preemption", "imminent"),
+			"1 if the cloud provider announced a spot termination or preemption of the instance, 0 otherwise.",
+			[]string{"provider"}, nil,
+		),
+		untilTerminate: prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, "preemption", "seconds_until_termination"),
+			"Seconds until the announced termination, only present if the provider announced a time.",
+			[]string{"provider"}, nil,
+		),
+	}
+}
+
+func newCloudProvider(name, baseURL string, timeout time.Duration) (cloudProvider, error) {
+	if baseURL == "" {
+		baseURL = cloudDefaultURLs[name]
+	}
+	c := &cloudMetadataClient{
+		client:  &http.Client{Timeout: timeout},
+		baseURL: strings.TrimRight(baseURL, "/"),
+		header:  http.Header{},
+	}
+	switch name {
+	case "ec2":
+		return &ec2Provider{c}, nil
+	case "gce":
+		c.header.Set("Metadata-Flavor", "Google")
+		return &gceProvider{c}, nil
+	case "azure":
+		c.header.Set("Metadata", "true")
+		return &azureProvider{c}, nil
+	case "":
+		return nil, fmt.Errorf("no cloud provider specified, see -collector.cloud.provider")
+	}
+	return nil, fmt.Errorf("unsupported cloud provider %q", name)
+}
+
+func (c *cloudCollector) Update(ch chan<- prometheus.Metric) (err error) {
+	instance, err := c.provider.instance()
+	if err != nil {
+		return fmt.Errorf("couldn't get instance metadata: %s", err)
+	}
+	ch <- prometheus.MustNewConstMetric(c.info, prometheus.GaugeValue, 1,
+		c.name, instance.instanceType, instance.zone, instance.lifecycle)
+
+	// The termination notice is polled in the background, so that it is
+	// noticed quickly even with long scrape intervals.
+	c.mtx.Lock()
+	termination, err := c.termination, c.terminationError
+	c.mtx.Unlock()
+	if err != nil {
+		return fmt.Errorf("couldn't get termination notice: %s", err)
+	}
+	imminent := 0.0
+	if termination.pending {
+		imminent = 1
+	}
+	ch <- prometheus.MustNewConstMetric(c.imminent, prometheus.GaugeValue, imminent, c.name)
+	if termination.pending && !termination.time.IsZero() {
+		until := termination.time.Sub(time.Now()).Seconds()
+		if until < 0 {
+			until = 0
+		}
+		ch <- prometheus.MustNewConstMetric(c.untilTerminate, prometheus.GaugeValue, until, c.name)
+	}
+	return nil
+}
+
+func (c *cloudCollector) pollTermination(interval time.Duration) {
+	for {
+		c.updateTermination()
+		time.Sleep(BackgroundInterval(interval))
+	}
+}
+
+func (c *cloudCollector) updateTermination() {
+	termination, err := c.provider.termination()
+	if err != nil {
+		log.Debugf("Error polling termination notice: %s", err)
+	} else if termination.pending {
+		c.mtx.Lock()
+		wasPending := c.termination.pending
+		c.mtx.Unlock()
+		if !wasPending {
+			log.Warnf("Cloud provider announced termination of this instance at %s", termination.time)
+		}
+	}
+
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	c.termination, c.terminationError = termination, err
+}
+
+// get returns the body of the metadata document at path. A missing document
+// is reported as found=false rather than an error.
+func (c *cloudMetadataClient) get(path string, header http.Header) (body string, found bool, err error) {
+	req, err := http.NewRequest("GET", c.baseURL+path, nil)
+	if err != nil {
+		return "", false, err
+	}
+	for k, v := range c.header {
+		req.Header[k] = v
+	}
+	for k, v := range header {
+		req.Header[k] = v
+	}
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return "", false, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusNotFound {
+		return "", false, nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		return "", false, fmt.Errorf("%s returned %s", path, resp.Status)
+	}
+	data, err := ioutil.ReadAll(resp.Body)
+	return strings.TrimSpace(string(data)), true, err
+}
+
+type ec2Provider struct {
+	*cloudMetadataClient
+}
+
+// tokenHeader returns the IMDSv2 session token header. If the token can't be
+// obtained, IMDSv1 requests without token are attempted.
+func (p *ec2Provider) tokenHeader() http.Header {
+	req, err := http.NewRequest("PUT", p.baseURL+"/latest/api/token", nil)
+	if err != nil {
+		return nil
+	}
+	req.Header.Set("X-aws-ec2-metadata-token-ttl-seconds", "60")
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return nil
+	}
+	defer resp.Body.Close()
+	token, err := ioutil.ReadAll(resp.Body)
+	if err != nil || resp.StatusCode != http.StatusOK {
+		return nil
+	}
+	return http.Header{"X-Aws-Ec2-Metadata-Token": []string{string(token)}}
+}
+
+func (p *ec2Provider) instance() (cloudInstance, error) {
+	var (
+		i      cloudInstance
+		err    error
+		header = p.tokenHeader()
+	)
+	if i.instanceType, _, err = p.get("/latest/meta-data/instance-type", header); err != nil {
+		return i, err
+	}
+	if i.zone, _, err = p.get("/latest/meta-data/placement/availability-zone", header); err != nil {
+		return i, err
+	}
+	if i.lifecycle, _, err = p.get("/latest/meta-data/instance-life-cycle", header); err != nil {
+		return i, err
+	}
+	return i, nil
+}
+
+func (p *ec2Provider) termination() (cloudTermination, error) {
+	body, found, err := p.get("/latest/meta-data/spot/instance-action", p.tokenHeader())
+	if err != nil || !found {
+		return cloudTermination{}, err
+	}
+	var action struct {
+		Action string `json:"action"`
+		Time   string `json:"time"`
+	}
+	if err := json.Unmarshal([]byte(body), &action); err != nil {
+		return cloudTermination{}, err
+	}
+	if action.Action != "terminate" && action.Action != "stop" && action.Action != "hibernate" {
+		return cloudTermination{}, nil
+	}
+	t, _ := time.Parse(time.RFC3339, action.Time)
+	return cloudTermination{pending: true, time: t}, nil
+}
+
+type gceProvider struct {
+	*cloudMetadataClient
+}
+
+func (p *gceProvider) instance() (cloudInstance, error) {
+	var i cloudInstance
+	machineType, _, err := p.get("/computeMetadata/v1/instance/machine-type", nil)
+	if err != nil {
+		return i, err
+	}
+	zone, _, err := p.get("/computeMetadata/v1/instance/zone", nil)
+	if err != nil {
+		return i, err
+	}
+	preemptible, _, err := p.get("/computeMetadata/v1/instance/scheduling/preemptible", nil)
+	if err != nil {
+		return i, err
+	}
+	// Machine type and zone are given as full resource paths.
+	i.instanceType = path.Base(machineType)
+	i.zone = path.Base(zone)
+	i.lifecycle = "normal"
+	if preemptible == "TRUE" {
+		i.lifecycle = "preemptible"
+	}
+	return i, nil
+}
+
+func (p *gceProvider) termination() (cloudTermination, error) {
+	preempted, _, err := p.get("/computeMetadata/v1/instance/preempted", nil)
+	if err != nil {
+		return cloudTermination{}, err
+	}
+	return cloudTermination{pending: preempted == "TRUE"}, nil
+}
+
+type azureProvider struct {
+	*cloudMetadataClient
+}
+
+func (p *azureProvider) instance() (cloudInstance, error) {
+	body, _, err := p.get("/metadata/instance/compute?api-version=2019-06-01", nil)
+	if err != nil {
+		return cloudInstance{}, err
+	}
+	var compute struct {
+		VMSize   string `json:"vmSize"`
+		Location string `json:"location"`
+		Zone     string `json:"zone"`
+		Priority string `json:"priority"`
+	}
+	if err := json.Unmarshal([]byte(body), &compute); err != nil {
+		return cloudInstance{}, err
+	}
+	i := cloudInstance{
+		instanceType: compute.VMSize,
+		zone:         compute.Location,
+		lifecycle:    strings.ToLower(compute.Priority),
+	}
+	if compute.Zone != "" {
+		i.zone = compute.Location + "-" + compute.Zone
+	}
+	if i.lifecycle == "" {
+		i.lifecycle = "regular"
+	}
+	return i, nil
+}
+
+func (p *azureProvider) termination() (cloudTermination, error) {
+	body, _, err := p.get("/metadata/scheduledevents?api-version=2019-08-01", nil)
+	if err != nil {
+		return cloudTermination{}, err
+	}
+	var events struct {
+		Events []struct {
+			EventType string
+			NotBefore string
+		}
+	}
+	if err := json.Unmarshal([]byte(body), &events); err != nil {
+		return cloudTermination{}, err
+	}
+	for _, e := range events.Events {
+		if e.EventType == "Preempt" || e.EventType == "Terminate" {
+			t, _ := time.Parse(time.RFC1123, e.NotBefore)
+			return cloudTermination{pending: true, time: t}, nil
+		}
+	}
+	return cloudTermination{}, nil
+}
@@ -0,0 +1,154 @@
+// Copyright 2016 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collector
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+func TestEC2Provider(t *testing.T) {
+	docs := map[string]string{
+		"/latest/meta-data/instance-type":               "m4.large",
+		"/latest/meta-data/placement/availability-zone": "eu-west-1b",
+		"/latest/meta-data/instance-life-cycle":         "spot",
+		"/latest/meta-data/spot/instance-action":        `{"action": "terminate", "time": "2016-09-18T08:22:00Z"}`,
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == "PUT" {
+			w.Write([]byte("token"))
+			return
+		}
+		if r.Header.Get("X-aws-ec2-metadata-token") != "token" {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		doc, ok := docs[r.URL.Path]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write([]byte(doc))
+	}))
+	defer server.Close()
+
+	p, err := newCloudProvider("ec2", server.URL, time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	instance, err := p.instance()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want, got := (cloudInstance{"m4.large", "eu-west-1b", "spot"}), instance; want != got {
+		t.Errorf("want %v, got %v", want, got)
+	}
+
+	termination, err := p.termination()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !termination.pending {
+		t.Error("expected pending termination")
+	}
+	if want, got := time.Date(2016, 9, 18, 8, 22, 0, 0, time.UTC), termination.time; !want.Equal(got) {
+		t.Errorf("want termination at %s, got %s", want, got)
+	}
+
+	delete(docs, "/latest/meta-data/spot/instance-action")
+	termination, err = p.termination()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if termination.pending {
+		t.Error("expected no pending termination")
+	}
+}
+
+func TestGCEProvider(t *testing.T) {
+	docs := map[string]string{
+		"/computeMetadata/v1/instance/machine-type":           "projects/1234/machineTypes/n1-standard-2",
+		"/computeMetadata/v1/instance/zone":                   "projects/1234/zones/us-central1-f",
+		"/computeMetadata/v1/instance/scheduling/preemptible": "TRUE",
+		"/computeMetadata/v1/instance/preempted":              "FALSE",
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Metadata-Flavor") != "Google" {
+			http.Error(w, "missing header", http.StatusForbidden)
+			return
+		}
+		w.Write([]byte(docs[r.URL.Path]))
+	}))
+	defer server.Close()
+
+	p, err := newCloudProvider("gce", server.URL, time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	instance, err := p.instance()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want, got := (cloudInstance{"n1-standard-2", "us-central1-f", "preemptible"}), instance; want != got {
+		t.Errorf("want %v, got %v", want, got)
+	}
+	termination, err := p.termination()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if termination.pending {
+		t.Error("expected no pending preemption")
+	}
+}
+
+type testCloudProvider struct {
+	pending cloudTermination
+}
+
+func (p *testCloudProvider) instance() (cloudInstance, error) {
+	return cloudInstance{"m4.large", "eu-west-1b", "spot"}, nil
+}
+
+func (p *testCloudProvider) termination() (cloudTermination, error) {
+	return p.pending, nil
+}
+
+func TestCloudCollectorTermination(t *testing.T) {
+	provider := &testCloudProvider{}
+	c := newCloudCollector("ec2", provider)
+
+	c.updateTermination()
+	if got := collectCloudMetrics(t, c); got != 2 {
+		t.Errorf("want 2 metrics without pending termination, got %d", got)
+	}
+
+	provider.pending = cloudTermination{pending: true, time: time.Now().Add(2 * time.Minute)}
+	c.updateTermination()
+	if got := collectCloudMetrics(t, c); got != 3 {
+		t.Errorf("want 3 metrics with pending termination, got %d", got)
+	}
+}
+
+func collectCloudMetrics(t *testing.T, c *cloudCollector) int {
+	ch := make(chan prometheus.Metric, 8)
+	if err := c.Update(ch); err != nil {
+		t.Fatal(err)
+	}
+	close(ch)
+	return len(ch)
+}
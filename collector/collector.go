@@ -15,16 +15,43 @@
 package collector
 
 import (
+	"fmt"
+	"regexp"
+
 	"github.com/prometheus/client_golang/prometheus"
 )
 
-const Namespace = "node"
+// Namespace is the prefix of all exported metric names. It must only be
+// changed through SetNamespace before any collector is created.
+var Namespace = "node"
+
+var namespaceRE = regexp.MustCompile("^[a-zA-Z_][a-zA-Z0-9_]*$")
+
+// SetNamespace changes the prefix of all exported metric names. Colons are
+// rejected as they are reserved for recording rules.
+func SetNamespace(ns string) error {
+	if !namespaceRE.MatchString(ns) {
+		return fmt.Errorf("invalid metric namespace %q, must match %s", ns, namespaceRE)
+	}
+	Namespace = ns
+	return nil
+}
 
 var Factories = make(map[string]func() (Collector, error))
 
+// SysfsFactories holds the collectors that can read sysfs from a root other
+// than -collector.sysfs, to expose further device trees like those of test
+// rigs mounted over the network.
+var SysfsFactories = make(map[string]func(root string) (Collector, error))
+
 // Interface a collector has to implement.
 type Collector interface {
 	// Get new metrics and expose them via prometheus registry.
+	//
+	// Update may be called concurrently, as scrapes overlap when they
+	// take longer than the scrape interval or several servers scrape the
+	// exporter. State kept across calls, like caches of descriptors or
+	// previous samples, must be guarded, e.g. by using descCache.
 	Update(ch chan<- prometheus.Metric) (err error)
 }
 
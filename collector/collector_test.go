@@ -0,0 +1,32 @@
+// Copyright 2016 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collector
+
+import "testing"
+
+func TestSetNamespace(t *testing.T) {
+	defer func(ns string) { Namespace = ns }(Namespace)
+
+	for _, ns := range []string{"", "1node", "node:sub", "node-exporter"} {
+		if err := SetNamespace(ns); err == nil {
+			t.Errorf("expected error for namespace %q", ns)
+		}
+	}
+	if err := SetNamespace("acme_node"); err != nil {
+		t.Fatal(err)
+	}
+	if want, got := "acme_node", Namespace; want != got {
+		t.Errorf("want namespace %q, got %q", want, got)
+	}
+}
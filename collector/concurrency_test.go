@@ -0,0 +1,95 @@
+// Copyright 2016 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collector
+
+import (
+	"flag"
+	"sync"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+)
+
+// raceTestCollectors are the collectors exposing fixtures, as run by the end
+// to end test.
+var raceTestCollectors = []string{
+	"als", "bonding", "certificate", "conntrack", "cros_ec", "devfreq",
+	"diskstats", "efi_vars", "entropy", "filefd", "filesystem", "hwmon",
+	"kdump", "kernel_info", "ksmd", "lid", "loadavg", "mdadm", "megacli",
+	"meminfo", "meminfo_numa", "netdev", "netstat", "pcie",
+	"platform_security", "power_supply", "reboot", "rtc", "runtime_pm",
+	"sockstat", "stat", "tcp_settings", "textfile", "thermal_zone", "typec",
+	"virtualization", "vm_settings",
+}
+
+// TestConcurrentUpdate scrapes all collectors at once, each from several
+// goroutines as overlapping scrapes do, for the race detector to find
+// unguarded shared state. Run it with go test -race.
+func TestConcurrentUpdate(t *testing.T) {
+	for name, value := range map[string]string{
+		"collector.procfs":              "fixtures/proc",
+		"collector.sysfs":               "fixtures/sys",
+		"collector.textfile.directory":  "fixtures/textfile/two_metric_files/",
+		"collector.megacli.command":     "fixtures/megacli",
+		"collector.certificate.globs":   "fixtures/certificate/*.pem",
+		"collector.reboot.flag-file":    "fixtures/reboot-required",
+		"collector.reboot.modules-path": "fixtures/lib/modules",
+	} {
+		f := flag.Lookup(name)
+		if f == nil {
+			continue
+		}
+		defer flag.Set(name, f.Value.String())
+		flag.Set(name, value)
+	}
+
+	collectors := map[string]Collector{}
+	for _, name := range raceTestCollectors {
+		factory, ok := Factories[name]
+		if !ok {
+			continue
+		}
+		c, err := factory()
+		if err != nil {
+			t.Errorf("couldn't create %s collector: %s", name, err)
+			continue
+		}
+		collectors[name] = c
+	}
+
+	var wg sync.WaitGroup
+	for _, c := range collectors {
+		for i := 0; i < 4; i++ {
+			wg.Add(1)
+			go func(c Collector) {
+				defer wg.Done()
+				for j := 0; j < 2; j++ {
+					ch := make(chan prometheus.Metric)
+					done := make(chan struct{})
+					go func() {
+						for m := range ch {
+							m.Write(&dto.Metric{})
+						}
+						close(done)
+					}()
+					c.Update(ch)
+					close(ch)
+					<-done
+				}
+			}(c)
+		}
+	}
+	wg.Wait()
+}
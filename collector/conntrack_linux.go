@@ -46,7 +46,7 @@ func NewConntrackCollector() (Collector, error) {
 }
 
 func (c *conntrackCollector) Update(ch chan<- prometheus.Metric) (err error) {
-	value, err := readUintFromFile(procFilePath("sys/net/netfilter/nf_conntrack_count"))
+	value, err := readFSUint(procFS, "sys/net/netfilter/nf_conntrack_count")
 	if err != nil {
 		// Conntrack probably not loaded into the kernel.
 		return nil
@@ -54,7 +54,7 @@ func (c *conntrackCollector) Update(ch chan<- prometheus.Metric) (err error) {
 	ch <- prometheus.MustNewConstMetric(
 		c.current, prometheus.GaugeValue, float64(value))
 
-	value, err = readUintFromFile(procFilePath("sys/net/netfilter/nf_conntrack_max"))
+	value, err = readFSUint(procFS, "sys/net/netfilter/nf_conntrack_max")
 	if err != nil {
 		return nil
 	}
@@ -0,0 +1,142 @@
+// Copyright 2016 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// +build !nocontainers
+
+package collector
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const containersSubsystem = "containers"
+
+var (
+	containersSocket  = flag.String("collector.containers.socket", "/var/run/docker.sock", "Path to the Docker API compatible socket of the container runtime.")
+	containersTimeout = flag.Duration("collector.containers.timeout", 10*time.Second, "Timeout for requests to the container runtime.")
+
+	// Container states as reported by the Docker API.
+	containerStates = []string{"created", "restarting", "running", "removing", "paused", "exited", "dead"}
+)
+
+type containersCollector struct {
+	client  *http.Client
+	baseURL string
+
+	containers *prometheus.Desc
+	images     *prometheus.Desc
+	imageBytes *prometheus.Desc
+	layerBytes *prometheus.Desc
+}
+
+func init() {
+	Factories["containers"] = NewContainersCollector
+}
+
+// NewContainersCollector returns a new Collector exposing container counts by
+// state and image disk usage, read from the local container runtime socket.
+func NewContainersCollector() (Collector, error) {
+	socket := *containersSocket
+	client := &http.Client{
+		Timeout: *containersTimeout,
+		Transport: &http.Transport{
+			Dial: func(_, _ string) (net.Conn, error) {
+				return net.Dial("unix", socket)
+			},
+		},
+	}
+	return newContainersCollector(client, "http://localhost"), nil
+}
+
+func newContainersCollector(client *http.Client, baseURL string) *containersCollector {
+	return &containersCollector{
+		client:  client,
+		baseURL: baseURL,
+		containers: prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, containersSubsystem, "state"),
+			"Number of containers by state.",
+			[]string{"state"}, nil,
+		),
+		images: prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, containersSubsystem, "images"),
+			"Number of images known to the container runtime.",
+			nil, nil,
+		),
+		imageBytes: prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, containersSubsystem, "images_size_bytes"),
+			"Sum of the sizes of all images.",
+			nil, nil,
+		),
+		layerBytes: prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, containersSubsystem, "layers_size_bytes"),
+			"Disk space used by all image layers, counting shared layers once.",
+			nil, nil,
+		),
+	}
+}
+
+func (c *containersCollector) Update(ch chan<- prometheus.Metric) (err error) {
+	var containers []struct {
+		State string
+	}
+	if err := c.get("/containers/json?all=1", &containers); err != nil {
+		return err
+	}
+	states := map[string]float64{}
+	for _, s := range containerStates {
+		states[s] = 0
+	}
+	for _, container := range containers {
+		states[container.State]++
+	}
+	for state, n := range states {
+		ch <- prometheus.MustNewConstMetric(c.containers, prometheus.GaugeValue, n, state)
+	}
+
+	var df struct {
+		LayersSize int64
+		Images     []struct {
+			Size int64
+		}
+	}
+	if err := c.get("/system/df", &df); err != nil {
+		return err
+	}
+	var imageBytes int64
+	for _, image := range df.Images {
+		imageBytes += image.Size
+	}
+	ch <- prometheus.MustNewConstMetric(c.images, prometheus.GaugeValue, float64(len(df.Images)))
+	ch <- prometheus.MustNewConstMetric(c.imageBytes, prometheus.GaugeValue, float64(imageBytes))
+	ch <- prometheus.MustNewConstMetric(c.layerBytes, prometheus.GaugeValue, float64(df.LayersSize))
+	return nil
+}
+
+func (c *containersCollector) get(path string, v interface{}) error {
+	resp, err := c.client.Get(c.baseURL + path)
+	if err != nil {
+		return fmt.Errorf("couldn't query container runtime: %s", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("container runtime returned %s for %s", resp.Status, path)
+	}
+	return json.NewDecoder(resp.Body).Decode(v)
+}
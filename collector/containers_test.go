@@ -0,0 +1,66 @@
+// Copyright 2016 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collector
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+)
+
+func TestContainersCollector(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/containers/json", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`[{"Id":"a","State":"running"},{"Id":"b","State":"running"},{"Id":"c","State":"exited"}]`))
+	})
+	mux.HandleFunc("/system/df", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"LayersSize":1092588,"Images":[{"Id":"i1","Size":1092588},{"Id":"i2","Size":2000}]}`))
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	c := newContainersCollector(http.DefaultClient, server.URL)
+	ch := make(chan prometheus.Metric, 32)
+	if err := c.Update(ch); err != nil {
+		t.Fatal(err)
+	}
+	close(ch)
+
+	values := map[string]float64{}
+	for m := range ch {
+		pb := &dto.Metric{}
+		m.Write(pb)
+		key := m.Desc().String()
+		for _, l := range pb.Label {
+			key += l.GetValue()
+		}
+		values[key] = pb.Gauge.GetValue()
+	}
+
+	if want, got := 2.0, values[c.containers.String()+"running"]; want != got {
+		t.Errorf("want %f running containers, got %f", want, got)
+	}
+	if want, got := 0.0, values[c.containers.String()+"paused"]; want != got {
+		t.Errorf("want %f paused containers, got %f", want, got)
+	}
+	if want, got := 2.0, values[c.images.String()]; want != got {
+		t.Errorf("want %f images, got %f", want, got)
+	}
+	if want, got := 1094588.0, values[c.imageBytes.String()]; want != got {
+		t.Errorf("want %f image bytes, got %f", want, got)
+	}
+}
@@ -0,0 +1,57 @@
+// Copyright 2016 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// +build !nocpu
+
+package collector
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+type statCollector struct {
+	cpu *prometheus.Desc
+}
+
+func init() {
+	Factories["cpu"] = NewStatCollector
+}
+
+// NewStatCollector returns a new Collector exposing CPU stats from the
+// cpu:N:sys kstats.
+func NewStatCollector() (Collector, error) {
+	return &statCollector{
+		cpu: prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, "", "cpu"),
+			"Seconds the cpus spent in each mode.",
+			[]string{"cpu", "mode"}, nil,
+		),
+	}, nil
+}
+
+func (c *statCollector) Update(ch chan<- prometheus.Metric) (err error) {
+	stats, err := readKstat("cpu::sys:")
+	if err != nil {
+		return err
+	}
+	times, err := kstatCPUTimes(stats)
+	if err != nil {
+		return err
+	}
+	for cpu, modes := range times {
+		for mode, v := range modes {
+			ch <- prometheus.MustNewConstMetric(c.cpu, prometheus.CounterValue, v, cpu, mode)
+		}
+	}
+	return nil
+}
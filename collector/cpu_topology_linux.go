@@ -0,0 +1,96 @@
+// Copyright 2016 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collector
+
+import (
+	"os"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// cpuTopology describes where a logical CPU sits in the package, die, core
+// and NUMA node hierarchy. Values the kernel doesn't report are empty.
+type cpuTopology struct {
+	cpu       int
+	packageID string
+	dieID     string
+	coreID    string
+	numaNode  string
+}
+
+// sharedCPUTopology returns the topology of all online CPUs ordered by CPU
+// number. It is read once per scrape and shared between collectors.
+func sharedCPUTopology() ([]cpuTopology, error) {
+	v, err := sharedValue("cpu_topology", func() (interface{}, error) {
+		return readCPUTopology()
+	})
+	if err != nil {
+		return nil, err
+	}
+	return v.([]cpuTopology), nil
+}
+
+func readCPUTopology() ([]cpuTopology, error) {
+	// Offline CPUs have no topology directory.
+	topoDirs, err := sysFS.Glob("devices/system/cpu/cpu[0-9]*/topology")
+	if err != nil {
+		return nil, err
+	}
+
+	var topology []cpuTopology
+	for _, topoDir := range topoDirs {
+		dir := path.Dir(topoDir)
+		cpu, err := strconv.Atoi(strings.TrimPrefix(path.Base(dir), "cpu"))
+		if err != nil {
+			continue
+		}
+		t := cpuTopology{cpu: cpu}
+		for _, f := range []struct {
+			name string
+			dst  *string
+		}{
+			{"physical_package_id", &t.packageID},
+			{"die_id", &t.dieID},
+			{"core_id", &t.coreID},
+		} {
+			data, err := readFSFile(sysFS, path.Join(topoDir, f.name))
+			// die_id only exists since Linux 5.2.
+			if os.IsNotExist(err) {
+				continue
+			}
+			if err != nil {
+				return nil, err
+			}
+			*f.dst = strings.TrimSpace(string(data))
+		}
+		nodes, err := sysFS.Glob(path.Join(dir, "node[0-9]*"))
+		if err != nil {
+			return nil, err
+		}
+		if len(nodes) > 0 {
+			t.numaNode = strings.TrimPrefix(path.Base(nodes[0]), "node")
+		}
+		topology = append(topology, t)
+	}
+	sort.Sort(byCPU(topology))
+	return topology, nil
+}
+
+type byCPU []cpuTopology
+
+func (t byCPU) Len() int           { return len(t) }
+func (t byCPU) Swap(i, j int)      { t[i], t[j] = t[j], t[i] }
+func (t byCPU) Less(i, j int) bool { return t[i].cpu < t[j].cpu }
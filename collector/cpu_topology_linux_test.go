@@ -0,0 +1,61 @@
+// Copyright 2016 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collector
+
+import (
+	"flag"
+	"reflect"
+	"testing"
+)
+
+func TestReadCPUTopology(t *testing.T) {
+	if err := flag.Set("collector.sysfs", "fixtures/sys"); err != nil {
+		t.Fatal(err)
+	}
+	topology, err := readCPUTopology()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []cpuTopology{
+		{cpu: 0, packageID: "0", dieID: "0", coreID: "0", numaNode: "0"},
+		{cpu: 1, packageID: "0", dieID: "0", coreID: "1", numaNode: "0"},
+		{cpu: 10, packageID: "1", coreID: "0", numaNode: "1"},
+	}
+	if !reflect.DeepEqual(want, topology) {
+		t.Errorf("want %+v, got %+v", want, topology)
+	}
+}
+
+func TestReadCPUTopologyMapFS(t *testing.T) {
+	defer func(fsys fileSystem) { sysFS = fsys }(sysFS)
+	sysFS = mapFS{
+		"devices/system/cpu/cpu0/topology/physical_package_id": "0\n",
+		"devices/system/cpu/cpu0/topology/core_id":             "0\n",
+		"devices/system/cpu/cpu0/node0/cpulist":                "0-1\n",
+		"devices/system/cpu/cpu1/topology/physical_package_id": "0\n",
+		"devices/system/cpu/cpu1/topology/core_id":             "4\n",
+		"devices/system/cpu/cpu2/online":                       "0\n",
+	}
+	topology, err := readCPUTopology()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []cpuTopology{
+		{cpu: 0, packageID: "0", coreID: "0", numaNode: "0"},
+		{cpu: 1, packageID: "0", coreID: "4"},
+	}
+	if !reflect.DeepEqual(want, topology) {
+		t.Errorf("want %+v, got %+v", want, topology)
+	}
+}
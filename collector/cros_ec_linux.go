@@ -0,0 +1,154 @@
+// Copyright 2016 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// +build !nocros_ec
+
+package collector
+
+import (
+	"os"
+	"path"
+	"strings"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	crosECSubsystem = "cros_ec"
+
+	// crosECLidAngleUnreliable is the angle the EC reports when it can't
+	// tell, e.g. while the lid is folded back in tablet mode.
+	crosECLidAngleUnreliable = 500
+)
+
+type crosECCollector struct {
+	fs fileSystem
+
+	sensor      *prometheus.Desc
+	lidAngle    *prometheus.Desc
+	illuminance *prometheus.Desc
+}
+
+// crosECSensor is a sensor behind the embedded controller of a Chromebook,
+// read through its IIO device. typ is the driver's name without the
+// "cros-ec-" prefix, e.g. "accel", "light" or "lid-angle". Readings the
+// sensor doesn't have are -1.
+type crosECSensor struct {
+	device, typ, location string
+	lidAngle              float64
+	illuminance           float64
+}
+
+func init() {
+	Factories["cros_ec"] = NewCrosECCollector
+	Maturities["cros_ec"] = MaturityBeta
+	SysfsFactories["cros_ec"] = func(root string) (Collector, error) {
+		return newCrosECCollector(rootFS(root)), nil
+	}
+}
+
+// NewCrosECCollector returns a new Collector exposing the sensors of the
+// ChromeOS embedded controller: the lid angle, ambient light and the
+// accelerometers, gyroscopes and other motion sensors it reads. Its battery
+// and charger are exposed by the power_supply collector and its fans and
+// temperatures by hwmon.
+func NewCrosECCollector() (Collector, error) {
+	return newCrosECCollector(sysFS), nil
+}
+
+func newCrosECCollector(fsys fileSystem) *crosECCollector {
+	return &crosECCollector{
+		fs: fsys,
+		sensor: prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, crosECSubsystem, "sensor_info"),
+			"Sensors behind the embedded controller, with the part of the device they are located in.",
+			[]string{"device", "type", "location"}, nil,
+		),
+		lidAngle: prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, crosECSubsystem, "lid_angle_degrees"),
+			"Angle between the lid and the base, 0 when closed.",
+			[]string{"device"}, nil,
+		),
+		illuminance: prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, crosECSubsystem, "illuminance_lux"),
+			"Ambient light measured by the sensor.",
+			[]string{"device"}, nil,
+		),
+	}
+}
+
+func (c *crosECCollector) Update(ch chan<- prometheus.Metric) (err error) {
+	sensors, err := readCrosECSensors(c.fs)
+	if err != nil {
+		return err
+	}
+	for _, s := range sensors {
+		ch <- prometheus.MustNewConstMetric(c.sensor, prometheus.GaugeValue, 1, s.device, s.typ, s.location)
+		if s.lidAngle >= 0 {
+			ch <- prometheus.MustNewConstMetric(c.lidAngle, prometheus.GaugeValue, s.lidAngle, s.device)
+		}
+		if s.illuminance >= 0 {
+			ch <- prometheus.MustNewConstMetric(c.illuminance, prometheus.GaugeValue, s.illuminance, s.device)
+		}
+	}
+	return nil
+}
+
+// readCrosECSensors returns the IIO devices in bus/iio/devices whose driver
+// is one of the cros-ec sensor drivers.
+func readCrosECSensors(fsys fileSystem) ([]crosECSensor, error) {
+	files, err := fsys.Glob("bus/iio/devices/*/name")
+	if err != nil {
+		return nil, err
+	}
+	var sensors []crosECSensor
+	for _, file := range files {
+		name, err := readFSFile(fsys, file)
+		if err != nil {
+			return nil, err
+		}
+		typ := strings.TrimSpace(string(name))
+		if !strings.HasPrefix(typ, "cros-ec-") {
+			continue
+		}
+		dir := path.Dir(file)
+		s := crosECSensor{
+			device:      path.Base(dir),
+			typ:         strings.TrimPrefix(typ, "cros-ec-"),
+			lidAngle:    -1,
+			illuminance: -1,
+		}
+		// Only sensors with a known place in the device have a location.
+		if location, err := readFSFile(fsys, path.Join(dir, "location")); err == nil {
+			s.location = strings.TrimSpace(string(location))
+		} else if !os.IsNotExist(err) {
+			return nil, err
+		}
+		switch s.typ {
+		case "lid-angle":
+			angle, err := readFSUint(fsys, path.Join(dir, "in_angl_raw"))
+			if err != nil {
+				return nil, err
+			}
+			if angle != crosECLidAngleUnreliable {
+				s.lidAngle = float64(angle)
+			}
+		case "light":
+			if s.illuminance, err = readIIOValue(fsys, dir, "in_illuminance"); err != nil {
+				return nil, err
+			}
+		}
+		sensors = append(sensors, s)
+	}
+	return sensors, nil
+}
@@ -0,0 +1,35 @@
+// Copyright 2016 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collector
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestReadCrosECSensors(t *testing.T) {
+	sensors, err := readCrosECSensors(dirFS{root: func() string { return "fixtures/sys" }})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []crosECSensor{
+		{device: "iio:device0", typ: "accel", location: "base", lidAngle: -1, illuminance: -1},
+		{device: "iio:device1", typ: "accel", location: "lid", lidAngle: -1, illuminance: -1},
+		{device: "iio:device2", typ: "lid-angle", lidAngle: 118, illuminance: -1},
+		{device: "iio:device3", typ: "light", location: "lid", lidAngle: -1, illuminance: 156},
+	}
+	if !reflect.DeepEqual(want, sensors) {
+		t.Errorf("want %+v, got %+v", want, sensors)
+	}
+}
@@ -0,0 +1,141 @@
+// Copyright 2016 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collector
+
+import (
+	"reflect"
+	"sync"
+	"unsafe"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Describer is implemented by collectors that can list the descriptors of
+// the metrics they expose themselves.
+type Describer interface {
+	Describe(ch chan<- *prometheus.Desc)
+}
+
+var (
+	descType      = reflect.TypeOf((*prometheus.Desc)(nil))
+	descCacheType = reflect.TypeOf((*descCache)(nil))
+	mutexType     = reflect.TypeOf(sync.Mutex{})
+	rwMutexType   = reflect.TypeOf(sync.RWMutex{})
+)
+
+// Describe sends the descriptors of the metrics c exposes to ch without
+// running it. Collectors that aren't Describers are searched for the
+// descriptors they hold, which misses those only created while collecting.
+func Describe(c Collector, ch chan<- *prometheus.Desc) {
+	if d, ok := c.(Describer); ok {
+		d.Describe(ch)
+		return
+	}
+	describeValue(reflect.ValueOf(c), ch, map[uintptr]bool{}, map[reflect.Type]bool{})
+}
+
+// describeValue sends the descriptors reachable from v to ch. The fields of
+// collectors are mostly unexported, so descriptors are taken through their
+// pointers rather than Interface. Mutexes of a struct are held while its
+// fields are read, as collectors may be updating them concurrently.
+func describeValue(v reflect.Value, ch chan<- *prometheus.Desc, seen map[uintptr]bool, holds map[reflect.Type]bool) {
+	if !mayHoldDesc(v.Type(), holds) {
+		return
+	}
+	switch v.Kind() {
+	case reflect.Ptr:
+		if v.IsNil() || seen[v.Pointer()] {
+			return
+		}
+		seen[v.Pointer()] = true
+		switch v.Type() {
+		case descType:
+			ch <- (*prometheus.Desc)(unsafe.Pointer(v.Pointer()))
+		case descCacheType:
+			for _, d := range (*descCache)(unsafe.Pointer(v.Pointer())).all() {
+				ch <- d
+			}
+		default:
+			describeValue(v.Elem(), ch, seen, holds)
+		}
+	case reflect.Interface:
+		if !v.IsNil() {
+			describeValue(v.Elem(), ch, seen, holds)
+		}
+	case reflect.Struct:
+		if v.CanAddr() {
+			defer lockFields(v)()
+		}
+		for i := 0; i < v.NumField(); i++ {
+			describeValue(v.Field(i), ch, seen, holds)
+		}
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			describeValue(v.Index(i), ch, seen, holds)
+		}
+	case reflect.Map:
+		for _, k := range v.MapKeys() {
+			describeValue(v.MapIndex(k), ch, seen, holds)
+		}
+	}
+}
+
+// lockFields locks the mutexes among the fields of the addressable struct v
+// and returns a function unlocking them.
+func lockFields(v reflect.Value) func() {
+	var unlock []func()
+	for i := 0; i < v.NumField(); i++ {
+		p := unsafe.Pointer(v.Field(i).UnsafeAddr())
+		switch v.Field(i).Type() {
+		case mutexType:
+			m := (*sync.Mutex)(p)
+			m.Lock()
+			unlock = append(unlock, m.Unlock)
+		case rwMutexType:
+			m := (*sync.RWMutex)(p)
+			m.RLock()
+			unlock = append(unlock, m.RUnlock)
+		}
+	}
+	return func() {
+		for _, u := range unlock {
+			u()
+		}
+	}
+}
+
+// mayHoldDesc returns whether values of type t can lead to a descriptor, so
+// that maps and slices of plain data aren't walked.
+func mayHoldDesc(t reflect.Type, holds map[reflect.Type]bool) bool {
+	if h, ok := holds[t]; ok {
+		return h
+	}
+	// Assumed while t is being looked at, which ends recursive types.
+	holds[t] = false
+	h := false
+	switch t.Kind() {
+	case reflect.Interface:
+		h = true
+	case reflect.Ptr:
+		h = t == descType || t == descCacheType || mayHoldDesc(t.Elem(), holds)
+	case reflect.Slice, reflect.Array, reflect.Map:
+		h = mayHoldDesc(t.Elem(), holds)
+	case reflect.Struct:
+		for i := 0; i < t.NumField() && !h; i++ {
+			h = mayHoldDesc(t.Field(i).Type, holds)
+		}
+	}
+	holds[t] = h
+	return h
+}
@@ -0,0 +1,68 @@
+// Copyright 2016 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collector
+
+import (
+	"regexp"
+	"sort"
+	"sync"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var describeNameRE = regexp.MustCompile(`^Desc\{fqName: "([^"]*)"`)
+
+type describeTestCollector struct {
+	mtx     sync.Mutex
+	samples map[string]float64
+	info    *prometheus.Desc
+	byType  map[string]*prometheus.Desc
+	dynamic *descCache
+}
+
+func (c *describeTestCollector) Update(ch chan<- prometheus.Metric) error { return nil }
+
+func TestDescribe(t *testing.T) {
+	c := &describeTestCollector{
+		samples: map[string]float64{"eth0": 1},
+		info:    prometheus.NewDesc("node_test_info", "Info.", nil, nil),
+		byType:  map[string]*prometheus.Desc{"temp": prometheus.NewDesc("node_test_temp_celsius", "Temperature.", nil, nil)},
+		dynamic: newDescCache(),
+	}
+	c.dynamic.get("rx", func() *prometheus.Desc {
+		return prometheus.NewDesc("node_test_rx_bytes_total", "Received bytes.", nil, nil)
+	})
+
+	ch := make(chan *prometheus.Desc, 10)
+	Describe(c, ch)
+	close(ch)
+	var names []string
+	for d := range ch {
+		names = append(names, describeNameRE.FindStringSubmatch(d.String())[1])
+	}
+	sort.Strings(names)
+	want := []string{"node_test_info", "node_test_rx_bytes_total", "node_test_temp_celsius"}
+	if len(names) != len(want) {
+		t.Fatalf("want descriptors %v, got %v", want, names)
+	}
+	for i := range want {
+		if want[i] != names[i] {
+			t.Errorf("want descriptors %v, got %v", want, names)
+		}
+	}
+	// The collector's mutex was released.
+	c.mtx.Lock()
+	c.mtx.Unlock()
+}
@@ -0,0 +1,178 @@
+// Copyright 2016 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// +build !nodevfreq
+
+package collector
+
+import (
+	"bufio"
+	"bytes"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const devfreqSubsystem = "devfreq"
+
+type devfreqCollector struct {
+	fs fileSystem
+
+	frequency       *prometheus.Desc
+	targetFrequency *prometheus.Desc
+	minFrequency    *prometheus.Desc
+	maxFrequency    *prometheus.Desc
+	governor        *prometheus.Desc
+	transitions     *prometheus.Desc
+}
+
+// devfreqDevice is a device with dynamic frequency scaling, like a GPU or
+// memory controller, with frequencies in hertz. transitions is -1 if the
+// kernel doesn't keep statistics.
+type devfreqDevice struct {
+	device                string
+	cur, target, min, max uint64
+	governor              string
+	transitions           int64
+}
+
+func init() {
+	Factories["devfreq"] = NewDevfreqCollector
+	Maturities["devfreq"] = MaturityBeta
+	SysfsFactories["devfreq"] = func(root string) (Collector, error) {
+		return newDevfreqCollector(rootFS(root)), nil
+	}
+}
+
+// NewDevfreqCollector returns a new Collector exposing the frequencies and
+// governors of the devices in /sys/class/devfreq, the cpufreq counterpart
+// for GPUs, memory controllers and buses, including those scaled through
+// SCMI performance domains on ARM.
+func NewDevfreqCollector() (Collector, error) {
+	return newDevfreqCollector(sysFS), nil
+}
+
+func newDevfreqCollector(fsys fileSystem) *devfreqCollector {
+	labels := []string{"device"}
+	return &devfreqCollector{
+		fs: fsys,
+		frequency: prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, devfreqSubsystem, "frequency_hertz"),
+			"Current frequency of the device.",
+			labels, nil,
+		),
+		targetFrequency: prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, devfreqSubsystem, "target_frequency_hertz"),
+			"Frequency the governor last requested for the device.",
+			labels, nil,
+		),
+		minFrequency: prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, devfreqSubsystem, "min_frequency_hertz"),
+			"Minimum frequency the governor may choose for the device.",
+			labels, nil,
+		),
+		maxFrequency: prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, devfreqSubsystem, "max_frequency_hertz"),
+			"Maximum frequency the governor may choose for the device.",
+			labels, nil,
+		),
+		governor: prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, devfreqSubsystem, "governor_info"),
+			"Frequency scaling governor of the device.",
+			[]string{"device", "governor"}, nil,
+		),
+		transitions: prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, devfreqSubsystem, "transitions_total"),
+			"Number of frequency changes of the device.",
+			labels, nil,
+		),
+	}
+}
+
+func (c *devfreqCollector) Update(ch chan<- prometheus.Metric) (err error) {
+	devices, err := readDevfreqDevices(c.fs)
+	if err != nil {
+		return err
+	}
+	for _, d := range devices {
+		ch <- prometheus.MustNewConstMetric(c.frequency, prometheus.GaugeValue, float64(d.cur), d.device)
+		ch <- prometheus.MustNewConstMetric(c.targetFrequency, prometheus.GaugeValue, float64(d.target), d.device)
+		ch <- prometheus.MustNewConstMetric(c.minFrequency, prometheus.GaugeValue, float64(d.min), d.device)
+		ch <- prometheus.MustNewConstMetric(c.maxFrequency, prometheus.GaugeValue, float64(d.max), d.device)
+		ch <- prometheus.MustNewConstMetric(c.governor, prometheus.GaugeValue, 1, d.device, d.governor)
+		if d.transitions >= 0 {
+			ch <- prometheus.MustNewConstMetric(c.transitions, prometheus.CounterValue, float64(d.transitions), d.device)
+		}
+	}
+	return nil
+}
+
+// readDevfreqDevices returns the devices in class/devfreq.
+func readDevfreqDevices(fsys fileSystem) ([]devfreqDevice, error) {
+	files, err := fsys.Glob("class/devfreq/*/cur_freq")
+	if err != nil {
+		return nil, err
+	}
+	var devices []devfreqDevice
+	for _, file := range files {
+		dir := path.Dir(file)
+		d := devfreqDevice{device: path.Base(dir), transitions: -1}
+		for _, f := range []struct {
+			name string
+			dst  *uint64
+		}{
+			{"cur_freq", &d.cur},
+			{"target_freq", &d.target},
+			{"min_freq", &d.min},
+			{"max_freq", &d.max},
+		} {
+			if *f.dst, err = readFSUint(fsys, path.Join(dir, f.name)); err != nil {
+				return nil, err
+			}
+		}
+		governor, err := readFSFile(fsys, path.Join(dir, "governor"))
+		if err != nil {
+			return nil, err
+		}
+		d.governor = strings.TrimSpace(string(governor))
+
+		// trans_stat is missing without statistics support and may be
+		// unreadable to unprivileged users.
+		if stat, err := readFSFile(fsys, path.Join(dir, "trans_stat")); err == nil {
+			d.transitions = parseDevfreqTransitions(stat)
+		} else if !os.IsNotExist(err) && !os.IsPermission(err) {
+			return nil, err
+		}
+		devices = append(devices, d)
+	}
+	return devices, nil
+}
+
+// parseDevfreqTransitions returns the total from the last line of
+// trans_stat, e.g. "Total transition : 4213", or -1 if it is missing.
+func parseDevfreqTransitions(stat []byte) int64 {
+	scanner := bufio.NewScanner(bytes.NewReader(stat))
+	for scanner.Scan() {
+		parts := strings.SplitN(scanner.Text(), ":", 2)
+		if len(parts) != 2 || strings.TrimSpace(parts[0]) != "Total transition" {
+			continue
+		}
+		if n, err := strconv.ParseInt(strings.TrimSpace(parts[1]), 10, 64); err == nil {
+			return n
+		}
+	}
+	return -1
+}
@@ -0,0 +1,33 @@
+// Copyright 2016 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collector
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestReadDevfreqDevices(t *testing.T) {
+	devices, err := readDevfreqDevices(dirFS{root: func() string { return "fixtures/sys" }})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []devfreqDevice{
+		{device: "dmc", cur: 856000000, target: 856000000, min: 328000000, max: 856000000, governor: "dmc_ondemand", transitions: -1},
+		{device: "ff9a0000.gpu", cur: 600000000, target: 600000000, min: 200000000, max: 800000000, governor: "simple_ondemand", transitions: 73},
+	}
+	if !reflect.DeepEqual(want, devices) {
+		t.Errorf("want %+v, got %+v", want, devices)
+	}
+}
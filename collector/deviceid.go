@@ -0,0 +1,176 @@
+// Copyright 2016 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collector
+
+import (
+	"fmt"
+	"path"
+	"strings"
+)
+
+// Schemes of stable device names, named after the udev link directories.
+const (
+	DeviceIdentityByID   = "by-id"
+	DeviceIdentityByPath = "by-path"
+)
+
+// DeviceIdentifier maps kernel device names, which can change between boots
+// and hotplugs (sda becoming sdb), to stable names. Block devices are named
+// after their udev links in /dev/disk, physical network interfaces after
+// the bus address (by-path) or the MAC address (by-id) of their device.
+// Devices without a stable name keep their kernel name.
+//
+// Links are read once per DeviceIdentifier, so a new one should be used for
+// every scrape. It is not safe for concurrent use.
+type DeviceIdentifier struct {
+	scheme string
+	sys    fileSystem
+	disk   fileSystem
+
+	links map[string]string
+	// macs counts the physical network interfaces by MAC address.
+	macs  map[string]int
+	cache map[string]string
+}
+
+// NewDeviceIdentifier returns an identifier for the given scheme.
+func NewDeviceIdentifier(scheme string) (*DeviceIdentifier, error) {
+	return newDeviceIdentifier(scheme, sysFS, rootFS(devFilePath("disk")))
+}
+
+func newDeviceIdentifier(scheme string, sys, disk fileSystem) (*DeviceIdentifier, error) {
+	if scheme != DeviceIdentityByID && scheme != DeviceIdentityByPath {
+		return nil, fmt.Errorf("unknown device identity %q, must be %q or %q", scheme, DeviceIdentityByID, DeviceIdentityByPath)
+	}
+	return &DeviceIdentifier{
+		scheme: scheme,
+		sys:    sys,
+		disk:   disk,
+		cache:  map[string]string{},
+	}, nil
+}
+
+// Identify returns the stable name of device, which is either a kernel name
+// like sda or a device path like /dev/sda1. Paths are kept as paths, e.g.
+// /dev/disk/by-id/ata-WDC_WD40EFRX-part1.
+func (d *DeviceIdentifier) Identify(device string) string {
+	id, ok := d.cache[device]
+	if !ok {
+		id = d.identify(device)
+		d.cache[device] = id
+	}
+	return id
+}
+
+func (d *DeviceIdentifier) identify(device string) string {
+	name := strings.TrimPrefix(device, "/dev/")
+	if strings.Contains(name, "/") {
+		// Names like mapper/vg-root are already stable.
+		return device
+	}
+	if id, ok := d.netIdentity(name); ok {
+		return id
+	}
+	if d.links == nil {
+		d.links = d.readDiskLinks()
+	}
+	id, ok := d.links[name]
+	switch {
+	case !ok:
+		return device
+	case name != device:
+		return path.Join("/dev/disk", d.scheme, id)
+	}
+	return id
+}
+
+// netIdentity returns the stable name of a network interface backed by a
+// device. Virtual interfaces are named by whoever creates them and have no
+// better name.
+func (d *DeviceIdentifier) netIdentity(name string) (string, bool) {
+	dir := path.Join("class/net", name)
+	link, err := readFSLink(d.sys, path.Join(dir, "device"))
+	if err != nil {
+		return "", false
+	}
+	if d.scheme == DeviceIdentityByID {
+		address, ok := netMACAddress(d.sys, dir)
+		if !ok {
+			return "", false
+		}
+		if d.macs == nil {
+			d.macs = d.countMACAddresses()
+		}
+		// Interfaces sharing an address without a permanent one keep
+		// their kernel name.
+		if d.macs[address] > 1 {
+			return "", false
+		}
+		return "mac-" + address, true
+	}
+	id := path.Base(link)
+	if subsystem, err := readFSLink(d.sys, path.Join(dir, "device/subsystem")); err == nil {
+		id = path.Base(subsystem) + "-" + id
+	}
+	return id, true
+}
+
+// netMACAddress returns the permanent MAC address of the network interface
+// in dir. Bond slaves take the address of their bond, so the one they were
+// enslaved with is preferred.
+func netMACAddress(sys fileSystem, dir string) (string, bool) {
+	for _, name := range []string{"bonding_slave/perm_hwaddr", "address"} {
+		if address, err := readFSFile(sys, path.Join(dir, name)); err == nil {
+			return strings.TrimSpace(string(address)), true
+		}
+	}
+	return "", false
+}
+
+func (d *DeviceIdentifier) countMACAddresses() map[string]int {
+	macs := map[string]int{}
+	dirs, err := d.sys.Glob("class/net/*")
+	if err != nil {
+		return macs
+	}
+	for _, dir := range dirs {
+		if _, err := readFSLink(d.sys, path.Join(dir, "device")); err != nil {
+			continue
+		}
+		if address, ok := netMACAddress(d.sys, dir); ok {
+			macs[address]++
+		}
+	}
+	return macs
+}
+
+// readDiskLinks returns the first link in the scheme's directory of
+// /dev/disk by kernel name of its target.
+func (d *DeviceIdentifier) readDiskLinks() map[string]string {
+	links := map[string]string{}
+	names, err := d.disk.Glob(d.scheme + "/*")
+	if err != nil {
+		return links
+	}
+	for _, name := range names {
+		target, err := readFSLink(d.disk, name)
+		if err != nil {
+			continue
+		}
+		if _, ok := links[path.Base(target)]; !ok {
+			links[path.Base(target)] = path.Base(name)
+		}
+	}
+	return links
+}
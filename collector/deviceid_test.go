@@ -0,0 +1,102 @@
+// Copyright 2016 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collector
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestDeviceIdentifier(t *testing.T) {
+	sys := dirFS{root: func() string { return "fixtures/sys" }}
+	disk := dirFS{root: func() string { return "fixtures/dev/disk" }}
+
+	if _, err := newDeviceIdentifier("by-uuid", sys, disk); err == nil {
+		t.Error("want error for unknown scheme")
+	}
+
+	for scheme, want := range map[string]map[string]string{
+		DeviceIdentityByID: {
+			"sda":                 "ata-WDC_WD40EFRX-68N32N0_WD-WCC7K1234567",
+			"/dev/sda1":           "/dev/disk/by-id/ata-WDC_WD40EFRX-68N32N0_WD-WCC7K1234567-part1",
+			"nvme0n1":             "nvme-Samsung_SSD_970_EVO_1TB_S467NX0M123456",
+			"eth0":                "mac-8c:16:45:2a:7b:01",
+			"bond0":               "bond0",
+			"sdz":                 "sdz",
+			"/dev/sdz1":           "/dev/sdz1",
+			"/dev/mapper/vg-root": "/dev/mapper/vg-root",
+		},
+		DeviceIdentityByPath: {
+			"sda":       "pci-0000:00:17.0-ata-1",
+			"/dev/sda1": "/dev/disk/by-path/pci-0000:00:17.0-ata-1-part1",
+			"nvme0n1":   "pci-0000:3c:00.0-nvme-1",
+			"eth0":      "pci-0000:00:1f.6",
+			"bond0":     "bond0",
+		},
+	} {
+		d, err := newDeviceIdentifier(scheme, sys, disk)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for device, id := range want {
+			if got := d.Identify(device); id != got {
+				t.Errorf("%s: want %s to be identified as %q, got %q", scheme, device, id, got)
+			}
+		}
+	}
+}
+
+func TestDeviceIdentifierSharedMAC(t *testing.T) {
+	dir, err := ioutil.TempDir("", "node_exporter_deviceid_test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	for iface, files := range map[string]map[string]string{
+		// Bond slaves carry the bond's address.
+		"eth1": {"address": "02:00:00:00:00:01\n", "bonding_slave/perm_hwaddr": "8c:16:45:2a:7b:11\n"},
+		"eth2": {"address": "02:00:00:00:00:01\n", "bonding_slave/perm_hwaddr": "8c:16:45:2a:7b:12\n"},
+		"eth3": {"address": "02:00:00:00:00:03\n"},
+		"eth4": {"address": "02:00:00:00:00:03\n"},
+	} {
+		ifaceDir := filepath.Join(dir, "class", "net", iface)
+		for name, content := range files {
+			os.MkdirAll(filepath.Dir(filepath.Join(ifaceDir, name)), 0755)
+			if err := ioutil.WriteFile(filepath.Join(ifaceDir, name), []byte(content), 0644); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if err := os.Symlink("../../../devices/"+iface, filepath.Join(ifaceDir, "device")); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	d, err := newDeviceIdentifier(DeviceIdentityByID, dirFS{root: func() string { return dir }}, mapFS{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for device, want := range map[string]string{
+		"eth1": "mac-8c:16:45:2a:7b:11",
+		"eth2": "mac-8c:16:45:2a:7b:12",
+		"eth3": "eth3",
+		"eth4": "eth4",
+	} {
+		if got := d.Identify(device); want != got {
+			t.Errorf("want %s to be identified as %q, got %q", device, want, got)
+		}
+	}
+}
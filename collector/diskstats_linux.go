@@ -20,7 +20,6 @@ import (
 	"flag"
 	"fmt"
 	"io"
-	"os"
 	"regexp"
 	"strconv"
 	"strings"
@@ -216,7 +215,7 @@ func (c *diskstatsCollector) Update(ch chan<- prometheus.Metric) (err error) {
 }
 
 func getDiskStats() (map[string]map[int]string, error) {
-	file, err := os.Open(procFilePath("diskstats"))
+	file, err := procFS.Open("diskstats")
 	if err != nil {
 		return nil, err
 	}
@@ -245,8 +244,8 @@ func parseDiskStats(r io.Reader) (map[string]map[int]string, error) {
 		if len(parts) < 4 { // we strip major, minor and dev
 			return nil, fmt.Errorf("invalid line in %s: %s", procFilePath("diskstats"), scanner.Text())
 		}
-		dev := parts[2]
-		diskStats[dev] = map[int]string{}
+		dev := labelValues.intern(parts[2])
+		diskStats[dev] = make(map[int]string, len(parts)-1)
 		for i, v := range parts[3:] {
 			diskStats[dev][i] = v
 		}
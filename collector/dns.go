@@ -0,0 +1,146 @@
+// Copyright 2016 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// +build !nodns
+
+package collector
+
+import (
+	"flag"
+	"fmt"
+	"net"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/common/log"
+)
+
+const dnsSubsystem = "dns_probe"
+
+var (
+	dnsHostnames = flag.String("collector.dns.hostnames", "", "Comma-separated list of hostnames to resolve using the system resolver.")
+	dnsTimeout   = flag.Duration("collector.dns.timeout", 5*time.Second, "Timeout for resolving a single hostname.")
+)
+
+type dnsProbeResult struct {
+	hostname  string
+	success   bool
+	duration  time.Duration
+	addresses int
+}
+
+type dnsCollector struct {
+	hostnames []string
+	timeout   time.Duration
+
+	success   *prometheus.Desc
+	duration  *prometheus.Desc
+	addresses *prometheus.Desc
+}
+
+func init() {
+	Factories["dns"] = NewDNSCollector
+}
+
+// NewDNSCollector returns a new Collector resolving the configured hostnames
+// with the system resolver and exposing success and latency of each lookup.
+func NewDNSCollector() (Collector, error) {
+	var hostnames []string
+	for _, h := range strings.Split(*dnsHostnames, ",") {
+		if h = strings.TrimSpace(h); h != "" {
+			hostnames = append(hostnames, h)
+		}
+	}
+	if len(hostnames) == 0 {
+		return nil, fmt.Errorf("no hostnames specified, see -collector.dns.hostnames")
+	}
+
+	return &dnsCollector{
+		hostnames: hostnames,
+		timeout:   *dnsTimeout,
+		success: prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, dnsSubsystem, "success"),
+			"Whether the hostname could be resolved.",
+			[]string{"hostname"}, nil,
+		),
+		duration: prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, dnsSubsystem, "duration_seconds"),
+			"Time taken to resolve the hostname.",
+			[]string{"hostname"}, nil,
+		),
+		addresses: prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, dnsSubsystem, "addresses"),
+			"Number of addresses the hostname resolved to.",
+			[]string{"hostname"}, nil,
+		),
+	}, nil
+}
+
+func (c *dnsCollector) Update(ch chan<- prometheus.Metric) (err error) {
+	results := make([]dnsProbeResult, len(c.hostnames))
+
+	wg := sync.WaitGroup{}
+	wg.Add(len(c.hostnames))
+	for i, h := range c.hostnames {
+		i, h := i, h
+		Go(func() {
+			results[i] = resolveWithTimeout(h, c.timeout)
+			wg.Done()
+		})
+	}
+	wg.Wait()
+
+	for _, r := range results {
+		success := 0.0
+		if r.success {
+			success = 1
+		}
+		ch <- prometheus.MustNewConstMetric(c.success, prometheus.GaugeValue, success, r.hostname)
+		ch <- prometheus.MustNewConstMetric(c.duration, prometheus.GaugeValue, r.duration.Seconds(), r.hostname)
+		ch <- prometheus.MustNewConstMetric(c.addresses, prometheus.GaugeValue, float64(r.addresses), r.hostname)
+	}
+	return nil
+}
+
+func resolveWithTimeout(hostname string, timeout time.Duration) dnsProbeResult {
+	type lookup struct {
+		addrs []string
+		err   error
+	}
+	var (
+		begin = time.Now()
+		done  = make(chan lookup, 1)
+	)
+	Go(func() {
+		addrs, err := net.LookupHost(hostname)
+		done <- lookup{addrs, err}
+	})
+
+	r := dnsProbeResult{hostname: hostname}
+	select {
+	case l := <-done:
+		r.duration = time.Since(begin)
+		if l.err != nil {
+			log.Debugf("Resolving %s failed: %s", hostname, l.err)
+			break
+		}
+		r.success = true
+		r.addresses = len(l.addrs)
+	case <-time.After(timeout):
+		r.duration = timeout
+		log.Debugf("Resolving %s timed out after %s", hostname, timeout)
+	}
+	return r
+}
@@ -0,0 +1,37 @@
+// Copyright 2016 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collector
+
+import (
+	"fmt"
+	"path"
+)
+
+// efiGlobalVariable is the vendor GUID of the variables defined by the UEFI
+// specification, like SecureBoot.
+const efiGlobalVariable = "8be4df61-93ca-11d2-aa0d-00e098032b8c"
+
+// readEFIVariable returns the data of a variable in efivarfs, without the
+// four bytes of attributes preceding it.
+func readEFIVariable(fsys fileSystem, name, guid string) ([]byte, error) {
+	file := path.Join("firmware/efi/efivars", name+"-"+guid)
+	data, err := readFSFile(fsys, file)
+	if err != nil {
+		return nil, err
+	}
+	if len(data) < 4 {
+		return nil, fmt.Errorf("invalid EFI variable %s: %d bytes", file, len(data))
+	}
+	return data[4:], nil
+}
@@ -0,0 +1,167 @@
+// Copyright 2016 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// +build !noefi_vars
+
+package collector
+
+import (
+	"encoding/binary"
+	"fmt"
+	"os"
+	"syscall"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	efiSubsystem = "efi"
+
+	// efiImageSecurityDatabase is the vendor GUID of the Secure Boot
+	// signature databases db and dbx.
+	efiImageSecurityDatabase = "d719b2cb-3d3a-4596-a3bc-dad00e67656f"
+
+	efivarfsMagic = 0xde5e81e4
+)
+
+type efiVarsCollector struct {
+	fs fileSystem
+	// storage returns the size of the variable storage and the free space
+	// in it, 0 if unknown.
+	storage func() (size, free float64, err error)
+
+	storageSize   *prometheus.Desc
+	storageFree   *prometheus.Desc
+	variables     *prometheus.Desc
+	dbxSize       *prometheus.Desc
+	dbxSignatures *prometheus.Desc
+}
+
+func init() {
+	Factories["efi_vars"] = NewEFIVarsCollector
+	Maturities["efi_vars"] = MaturityExperimental
+}
+
+// NewEFIVarsCollector returns a new Collector exposing how full the
+// firmware's variable storage is and the size of the Secure Boot revocation
+// list dbx, whose updates fail or even brick machines when it runs out.
+func NewEFIVarsCollector() (Collector, error) {
+	return newEFIVarsCollector(sysFS, func() (float64, float64, error) {
+		return efivarfsStorage(sysFilePath("firmware/efi/efivars"))
+	}), nil
+}
+
+func newEFIVarsCollector(fsys fileSystem, storage func() (float64, float64, error)) *efiVarsCollector {
+	return &efiVarsCollector{
+		fs:      fsys,
+		storage: storage,
+		storageSize: prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, efiSubsystem, "variable_storage_size_bytes"),
+			"Size of the firmware's storage for non-volatile variables.",
+			nil, nil,
+		),
+		storageFree: prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, efiSubsystem, "variable_storage_free_bytes"),
+			"Free space in the firmware's storage for non-volatile variables.",
+			nil, nil,
+		),
+		variables: prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, efiSubsystem, "variables"),
+			"Number of EFI variables.",
+			nil, nil,
+		),
+		dbxSize: prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, efiSubsystem, "dbx_size_bytes"),
+			"Size of the Secure Boot revocation list dbx.",
+			nil, nil,
+		),
+		dbxSignatures: prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, efiSubsystem, "dbx_signatures"),
+			"Number of hashes and certificates revoked by the Secure Boot revocation list dbx.",
+			nil, nil,
+		),
+	}
+}
+
+func (c *efiVarsCollector) Update(ch chan<- prometheus.Metric) (err error) {
+	vars, err := c.fs.Glob("firmware/efi/efivars/*")
+	if err != nil {
+		return err
+	}
+	// Systems booted with a legacy BIOS have no EFI variables.
+	if len(vars) == 0 {
+		return nil
+	}
+	ch <- prometheus.MustNewConstMetric(c.variables, prometheus.GaugeValue, float64(len(vars)))
+
+	size, free, err := c.storage()
+	if err != nil {
+		return err
+	}
+	if size > 0 {
+		ch <- prometheus.MustNewConstMetric(c.storageSize, prometheus.GaugeValue, size)
+		ch <- prometheus.MustNewConstMetric(c.storageFree, prometheus.GaugeValue, free)
+	}
+
+	dbx, err := readEFIVariable(c.fs, "dbx", efiImageSecurityDatabase)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	signatures, err := countEFISignatures(dbx)
+	if err != nil {
+		return fmt.Errorf("invalid dbx: %s", err)
+	}
+	ch <- prometheus.MustNewConstMetric(c.dbxSize, prometheus.GaugeValue, float64(len(dbx)))
+	ch <- prometheus.MustNewConstMetric(c.dbxSignatures, prometheus.GaugeValue, float64(signatures))
+	return nil
+}
+
+// efivarfsStorage returns the size of the firmware's variable storage and
+// the free space in it from the file system statistics of efivarfs mounted
+// at path, as reported by recent kernels. Older ones report no blocks at
+// all, and the size is 0.
+func efivarfsStorage(path string) (size, free float64, err error) {
+	buf := new(syscall.Statfs_t)
+	if err := syscall.Statfs(path, buf); err != nil {
+		return 0, 0, err
+	}
+	if uint32(buf.Type) != efivarfsMagic {
+		return 0, 0, nil
+	}
+	return float64(buf.Blocks) * float64(buf.Bsize), float64(buf.Bfree) * float64(buf.Bsize), nil
+}
+
+// countEFISignatures returns the number of signatures in a signature
+// database, a sequence of EFI_SIGNATURE_LISTs. Each list has a 28 byte
+// header of the signature type GUID, the size of the list, of an optional
+// header and of each of the signatures following it.
+func countEFISignatures(data []byte) (int, error) {
+	n := 0
+	for len(data) > 0 {
+		if len(data) < 28 {
+			return 0, fmt.Errorf("truncated signature list of %d bytes", len(data))
+		}
+		listSize := binary.LittleEndian.Uint32(data[16:20])
+		headerSize := binary.LittleEndian.Uint32(data[20:24])
+		signatureSize := binary.LittleEndian.Uint32(data[24:28])
+		if uint64(listSize) > uint64(len(data)) || uint64(listSize) < 28+uint64(headerSize) || signatureSize == 0 {
+			return 0, fmt.Errorf("invalid signature list of %d bytes with %d byte signatures", listSize, signatureSize)
+		}
+		n += int((listSize - 28 - headerSize) / signatureSize)
+		data = data[listSize:]
+	}
+	return n, nil
+}
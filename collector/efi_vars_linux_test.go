@@ -0,0 +1,85 @@
+// Copyright 2016 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collector
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+)
+
+func TestEFIVarsCollector(t *testing.T) {
+	for _, tt := range []struct {
+		name       string
+		size, free float64
+		want       map[string]float64
+	}{
+		{
+			name: "efivarfs",
+			size: 65536,
+			free: 12288,
+			want: map[string]float64{
+				"variables": 3, "variable_storage_size_bytes": 65536, "variable_storage_free_bytes": 12288,
+				"dbx_size_bytes": 316, "dbx_signatures": 4,
+			},
+		},
+		{
+			name: "without storage statistics",
+			want: map[string]float64{"variables": 3, "dbx_size_bytes": 316, "dbx_signatures": 4},
+		},
+	} {
+		c := newEFIVarsCollector(dirFS{root: func() string { return "fixtures/sys" }}, func() (float64, float64, error) {
+			return tt.size, tt.free, nil
+		})
+		ch := make(chan prometheus.Metric, 8)
+		if err := c.Update(ch); err != nil {
+			t.Fatal(err)
+		}
+		close(ch)
+
+		got := map[string]float64{}
+		for m := range ch {
+			pb := &dto.Metric{}
+			m.Write(pb)
+			for name, desc := range map[string]*prometheus.Desc{
+				"variables":                   c.variables,
+				"variable_storage_size_bytes": c.storageSize,
+				"variable_storage_free_bytes": c.storageFree,
+				"dbx_size_bytes":              c.dbxSize,
+				"dbx_signatures":              c.dbxSignatures,
+			} {
+				if m.Desc() == desc {
+					got[name] = pb.Gauge.GetValue()
+				}
+			}
+		}
+		if !reflect.DeepEqual(tt.want, got) {
+			t.Errorf("%s: want %v, got %v", tt.name, tt.want, got)
+		}
+	}
+}
+
+func TestCountEFISignatures(t *testing.T) {
+	if _, err := countEFISignatures(make([]byte, 20)); err == nil {
+		t.Error("want error for truncated signature list")
+	}
+	list := make([]byte, 28)
+	list[16] = 200
+	list[24] = 48
+	if _, err := countEFISignatures(list); err == nil {
+		t.Error("want error for signature list exceeding the data")
+	}
+}
@@ -42,7 +42,7 @@ func NewEntropyCollector() (Collector, error) {
 }
 
 func (c *entropyCollector) Update(ch chan<- prometheus.Metric) (err error) {
-	value, err := readUintFromFile(procFilePath("sys/kernel/random/entropy_avail"))
+	value, err := readFSUint(procFS, "sys/kernel/random/entropy_avail")
 	if err != nil {
 		return fmt.Errorf("couldn't get entropy_avail: %s", err)
 	}
@@ -0,0 +1,79 @@
+// Copyright 2016 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collector
+
+import (
+	"flag"
+	"sync"
+	"time"
+)
+
+var eventsSize = flag.Int("collector.events.size", 256, "Number of events kept in memory for the /events endpoint.")
+
+// Event is a state change noticed by a collector between scrapes.
+type Event struct {
+	Time      time.Time `json:"time"`
+	Collector string    `json:"collector"`
+	Source    string    `json:"source"`
+	Kind      string    `json:"kind"`
+	From      string    `json:"from,omitempty"`
+	To        string    `json:"to,omitempty"`
+}
+
+// EventLog is a ring buffer keeping the most recent events.
+type EventLog struct {
+	mtx    sync.Mutex
+	events []Event
+	next   int
+	full   bool
+}
+
+// Events holds the events of all collectors. Its size is fixed on first
+// use, after flags have been parsed.
+var Events = &EventLog{}
+
+// NewEventLog returns an EventLog keeping the last size events.
+func NewEventLog(size int) *EventLog {
+	return &EventLog{events: make([]Event, size)}
+}
+
+// Record adds an event, replacing the oldest one if the log is full.
+func (l *EventLog) Record(e Event) {
+	l.mtx.Lock()
+	defer l.mtx.Unlock()
+	if l.events == nil {
+		l.events = make([]Event, *eventsSize)
+	}
+	if len(l.events) == 0 {
+		return
+	}
+	if e.Time.IsZero() {
+		e.Time = time.Now()
+	}
+	l.events[l.next] = e
+	l.next = (l.next + 1) % len(l.events)
+	if l.next == 0 {
+		l.full = true
+	}
+}
+
+// List returns the recorded events, oldest first.
+func (l *EventLog) List() []Event {
+	l.mtx.Lock()
+	defer l.mtx.Unlock()
+	if !l.full {
+		return append([]Event{}, l.events[:l.next]...)
+	}
+	return append(append([]Event{}, l.events[l.next:]...), l.events[:l.next]...)
+}
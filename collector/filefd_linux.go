@@ -20,7 +20,6 @@ import (
 	"fmt"
 	"github.com/prometheus/client_golang/prometheus"
 	"io"
-	"os"
 	"strconv"
 	"strings"
 )
@@ -30,7 +29,7 @@ const (
 )
 
 type fileFDStatCollector struct {
-	metrics map[string]prometheus.Gauge
+	descs *descCache
 }
 
 func init() {
@@ -40,45 +39,39 @@ func init() {
 // NewFileFDStatCollector returns a new Collector exposing file-nr stats.
 func NewFileFDStatCollector() (Collector, error) {
 	return &fileFDStatCollector{
-		metrics: map[string]prometheus.Gauge{},
+		descs: newDescCache(),
 	}, nil
 }
 
 func (c *fileFDStatCollector) Update(ch chan<- prometheus.Metric) (err error) {
-	fileFDStat, err := getFileFDStats(procFilePath("sys/fs/file-nr"))
+	fileFDStat, err := getFileFDStats(procFS, "sys/fs/file-nr")
 	if err != nil {
 		return fmt.Errorf("couldn't get file-nr: %s", err)
 	}
 	for name, value := range fileFDStat {
-		if _, ok := c.metrics[name]; !ok {
-			c.metrics[name] = prometheus.NewGauge(
-				prometheus.GaugeOpts{
-					Namespace: Namespace,
-					Subsystem: fileFDStatSubsystem,
-					Name:      name,
-					Help:      fmt.Sprintf("File descriptor statistics: %s.", name),
-				},
+		desc := c.descs.get(name, func() *prometheus.Desc {
+			return prometheus.NewDesc(
+				prometheus.BuildFQName(Namespace, fileFDStatSubsystem, name),
+				fmt.Sprintf("File descriptor statistics: %s.", name),
+				nil, nil,
 			)
-		}
+		})
 		v, err := strconv.ParseFloat(value, 64)
 		if err != nil {
 			return fmt.Errorf("invalid value %s in file-nr: %s", value, err)
 		}
-		c.metrics[name].Set(v)
-	}
-	for _, m := range c.metrics {
-		m.Collect(ch)
+		ch <- prometheus.MustNewConstMetric(desc, prometheus.GaugeValue, v)
 	}
-	return err
+	return nil
 }
 
-func getFileFDStats(fileName string) (map[string]string, error) {
-	file, err := os.Open(fileName)
+func getFileFDStats(fsys fileSystem, name string) (map[string]string, error) {
+	file, err := fsys.Open(name)
 	if err != nil {
 		return nil, err
 	}
 	defer file.Close()
-	return parseFileFDStats(file, fileName)
+	return parseFileFDStats(file, name)
 }
 
 func parseFileFDStats(r io.Reader, fileName string) (map[string]string, error) {
@@ -86,6 +79,9 @@ func parseFileFDStats(r io.Reader, fileName string) (map[string]string, error) {
 	scanner.Scan()
 	// The file-nr proc file is separated by tabs, not spaces.
 	line := strings.Split(string(scanner.Text()), "\u0009")
+	if len(line) < 3 {
+		return nil, fmt.Errorf("invalid line in %s: %s", fileName, scanner.Text())
+	}
 	var fileFDStat = map[string]string{}
 	// The file-nr proc is only 1 line with 3 values.
 	fileFDStat["allocated"] = line[0]
@@ -0,0 +1,184 @@
+// Copyright 2016 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// +build !nofilehash
+
+package collector
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"flag"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/common/log"
+)
+
+const filehashSubsystem = "filehash"
+
+var (
+	filehashFiles    = flag.String("collector.filehash.files", "", "Comma-separated list of files to watch for content changes.")
+	filehashInterval = flag.Duration("collector.filehash.interval", 5*time.Minute, "Minimum interval between two hashes of the same file.")
+)
+
+// fileHashState is what is known about a single watched file.
+type fileHashState struct {
+	hash       []byte
+	checked    time.Time
+	changes    float64
+	lastChange time.Time
+	failed     bool
+}
+
+// fileHashSaved is the part of a fileHashState kept across restarts. With
+// the hash saved, changes while the exporter wasn't running are detected.
+type fileHashSaved struct {
+	Hash       []byte    `json:"hash"`
+	Changes    float64   `json:"changes"`
+	LastChange time.Time `json:"last_change"`
+}
+
+type filehashCollector struct {
+	interval time.Duration
+
+	mtx   sync.Mutex
+	files map[string]*fileHashState
+
+	changes    *prometheus.Desc
+	lastChange *prometheus.Desc
+	readError  *prometheus.Desc
+}
+
+func init() {
+	Factories["filehash"] = NewFilehashCollector
+}
+
+// NewFilehashCollector returns a new Collector exposing how often the
+// content of the watched files changed since the exporter started, or since
+// they were first watched with -collector.state-dir.
+func NewFilehashCollector() (Collector, error) {
+	files := map[string]*fileHashState{}
+	for _, f := range strings.Split(*filehashFiles, ",") {
+		if f = strings.TrimSpace(f); f != "" {
+			files[f] = &fileHashState{}
+		}
+	}
+	if len(files) == 0 {
+		return nil, fmt.Errorf("no files specified, see -collector.filehash.files")
+	}
+	saved := map[string]fileHashSaved{}
+	if err := loadState(filehashSubsystem, &saved); err != nil {
+		return nil, fmt.Errorf("couldn't restore filehash state: %s", err)
+	}
+	for path, s := range saved {
+		if state, ok := files[path]; ok {
+			state.hash, state.changes, state.lastChange = s.Hash, s.Changes, s.LastChange
+		}
+	}
+
+	return &filehashCollector{
+		interval: *filehashInterval,
+		files:    files,
+		changes: prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, filehashSubsystem, "changes_total"),
+			"Number of content changes detected, since the exporter started unless kept in -collector.state-dir.",
+			[]string{"path"}, nil,
+		),
+		lastChange: prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, filehashSubsystem, "last_change_timestamp_seconds"),
+			"Unixtime the last content change was detected, 0 if none was detected yet.",
+			[]string{"path"}, nil,
+		),
+		readError: prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, filehashSubsystem, "read_error"),
+			"1 if the file could not be read on the last check, 0 otherwise.",
+			[]string{"path"}, nil,
+		),
+	}, nil
+}
+
+func (c *filehashCollector) Update(ch chan<- prometheus.Metric) (err error) {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	now := time.Now()
+	checked := false
+	for path, state := range c.files {
+		if now.Sub(state.checked) >= BackgroundInterval(c.interval) {
+			state.check(path, now)
+			checked = true
+		}
+
+		lastChange, failed := 0.0, 0.0
+		if !state.lastChange.IsZero() {
+			lastChange = float64(state.lastChange.Unix())
+		}
+		if state.failed {
+			failed = 1
+		}
+		ch <- prometheus.MustNewConstMetric(c.changes, prometheus.CounterValue, state.changes, path)
+		ch <- prometheus.MustNewConstMetric(c.lastChange, prometheus.GaugeValue, lastChange, path)
+		ch <- prometheus.MustNewConstMetric(c.readError, prometheus.GaugeValue, failed, path)
+	}
+	if !checked {
+		return nil
+	}
+	saved := make(map[string]fileHashSaved, len(c.files))
+	for path, state := range c.files {
+		saved[path] = fileHashSaved{Hash: state.hash, Changes: state.changes, LastChange: state.lastChange}
+	}
+	if err := saveState(filehashSubsystem, saved); err != nil {
+		return fmt.Errorf("couldn't save filehash state: %s", err)
+	}
+	return nil
+}
+
+// check hashes the file and records a change if the hash differs from the
+// previous one. The first successful hash only sets the baseline.
+func (s *fileHashState) check(path string, now time.Time) {
+	s.checked = now
+
+	hash, err := hashFile(path)
+	if err != nil {
+		log.Errorf("Error hashing %s: %s", path, err)
+		s.failed = true
+		return
+	}
+	s.failed = false
+
+	if s.hash != nil && !bytes.Equal(s.hash, hash) {
+		s.changes++
+		s.lastChange = now
+	}
+	s.hash = hash
+}
+
+func hashFile(path string) ([]byte, error) {
+	fsys, name := localFS(path)
+	f, err := fsys.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return nil, err
+	}
+	return h.Sum(nil), nil
+}
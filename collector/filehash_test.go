@@ -0,0 +1,101 @@
+// Copyright 2016 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collector
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+func TestFileHashState(t *testing.T) {
+	dir, err := ioutil.TempDir("", "filehash")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "sshd_config")
+
+	state := &fileHashState{}
+	state.check(path, time.Now())
+	if !state.failed {
+		t.Error("expected missing file to fail")
+	}
+
+	if err := ioutil.WriteFile(path, []byte("PermitRootLogin no\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	state.check(path, time.Now())
+	if state.failed || state.changes != 0 {
+		t.Errorf("want baseline without changes, got failed=%v changes=%f", state.failed, state.changes)
+	}
+	state.check(path, time.Now())
+	if state.changes != 0 {
+		t.Errorf("want no change for identical content, got %f", state.changes)
+	}
+
+	if err := ioutil.WriteFile(path, []byte("PermitRootLogin yes\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	changed := time.Now()
+	state.check(path, changed)
+	if want, got := 1.0, state.changes; want != got {
+		t.Errorf("want %f changes, got %f", want, got)
+	}
+	if !state.lastChange.Equal(changed) {
+		t.Errorf("want last change %s, got %s", changed, state.lastChange)
+	}
+}
+
+func TestFilehashState(t *testing.T) {
+	dir, err := ioutil.TempDir("", "filehash")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	defer func(dir, files string) { *stateDir, *filehashFiles = dir, files }(*stateDir, *filehashFiles)
+	path := filepath.Join(dir, "sshd_config")
+	*stateDir = dir
+	*filehashFiles = path
+
+	if err := ioutil.WriteFile(path, []byte("PermitRootLogin no\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	c, err := NewFilehashCollector()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Update(make(chan prometheus.Metric, 8)); err != nil {
+		t.Fatal(err)
+	}
+
+	// A change while the exporter isn't running is detected on restart.
+	if err := ioutil.WriteFile(path, []byte("PermitRootLogin yes\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	c, err = NewFilehashCollector()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Update(make(chan prometheus.Metric, 8)); err != nil {
+		t.Fatal(err)
+	}
+	if want, got := 1.0, c.(*filehashCollector).files[path].changes; want != got {
+		t.Errorf("want %f changes, got %f", want, got)
+	}
+}
@@ -0,0 +1,70 @@
+// Copyright 2016 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// +build !nofilesystem
+
+package collector
+
+import (
+	"bytes"
+	"syscall"
+
+	"github.com/prometheus/common/log"
+)
+
+const (
+	defIgnoredMountPoints = "^/(proc|aha)($|/)"
+	defIgnoredFSTypes     = "^(procfs|ahafs|nfs|nfs3|nfs4)$"
+)
+
+// Expose filesystem fullness.
+func (c *filesystemCollector) GetStats() (stats []filesystemStats, err error) {
+	out, err := localeCommand("mount").Output()
+	if err != nil {
+		return nil, err
+	}
+	mounts, err := parseAIXMounts(bytes.NewReader(out))
+	if err != nil {
+		return nil, err
+	}
+	for _, m := range mounts {
+		if c.ignoredMountPointsPattern.MatchString(m.mountPoint) {
+			log.Debugf("Ignoring mount point: %s", m.mountPoint)
+			continue
+		}
+		if c.ignoredFSTypesPattern.MatchString(m.fsType) {
+			log.Debugf("Ignoring fs type: %s", m.fsType)
+			continue
+		}
+		buf := new(syscall.Statfs_t)
+		if err := syscall.Statfs(m.mountPoint, buf); err != nil {
+			log.Debugf("Statfs on %s returned %s", m.mountPoint, err)
+			continue
+		}
+
+		var ro float64
+		if m.ro {
+			ro = 1
+		}
+		stats = append(stats, filesystemStats{
+			labelValues: []string{m.device, m.mountPoint, m.fsType},
+			size:        float64(buf.Blocks) * float64(buf.Bsize),
+			free:        float64(buf.Bfree) * float64(buf.Bsize),
+			avail:       float64(buf.Bavail) * float64(buf.Bsize),
+			files:       float64(buf.Files),
+			filesFree:   float64(buf.Ffree),
+			ro:          ro,
+		})
+	}
+	return stats, nil
+}
@@ -13,6 +13,7 @@
 
 // +build freebsd openbsd darwin,amd64
 // +build !nofilesystem
+// +build cgo
 
 package collector
 
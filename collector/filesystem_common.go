@@ -12,13 +12,16 @@
 // limitations under the License.
 
 // +build !nofilesystem
-// +build linux freebsd openbsd darwin,amd64
+// +build linux freebsd,cgo openbsd,cgo darwin,amd64,cgo aix
 
 package collector
 
 import (
 	"flag"
 	"regexp"
+	"strings"
+	"sync"
+	"time"
 
 	"github.com/prometheus/client_golang/prometheus"
 )
@@ -40,6 +43,10 @@ var (
 		defIgnoredFSTypes,
 		"Regexp of filesystem types to ignore for filesystem collector.")
 
+	filesystemGrowthWindow = flag.Duration(
+		"collector.filesystem.growth-window", 30*time.Minute,
+		"Window over which the growth of used space is computed from previous scrapes, sampled at most once a minute, 0 to disable.")
+
 	filesystemLabelNames = []string{"device", "mountpoint", "fstype"}
 )
 
@@ -48,6 +55,21 @@ type filesystemCollector struct {
 	ignoredFSTypesPattern     *regexp.Regexp
 	sizeDesc, freeDesc, availDesc,
 	filesDesc, filesFreeDesc, roDesc *prometheus.Desc
+	growthDesc, untilFullDesc *prometheus.Desc
+
+	mtx     sync.Mutex
+	history map[string][]filesystemSample
+}
+
+// filesystemMinSampleInterval is the minimum time between the samples the
+// growth is computed from, which bounds the history of frequently scraped
+// filesystems.
+const filesystemMinSampleInterval = time.Minute
+
+// filesystemSample is the used space of a filesystem at a previous scrape.
+type filesystemSample struct {
+	time time.Time
+	used float64
 }
 
 type filesystemStats struct {
@@ -102,6 +124,18 @@ func NewFilesystemCollector() (Collector, error) {
 		filesystemLabelNames, nil,
 	)
 
+	growthDesc := prometheus.NewDesc(
+		prometheus.BuildFQName(Namespace, subsystem, "growth_bytes_per_second"),
+		"Growth of used space in bytes per second over the scrapes in -collector.filesystem.growth-window.",
+		filesystemLabelNames, nil,
+	)
+
+	untilFullDesc := prometheus.NewDesc(
+		prometheus.BuildFQName(Namespace, subsystem, "seconds_until_full"),
+		"Seconds until the space available to non-root users is used up at the current growth rate, only present while growing.",
+		filesystemLabelNames, nil,
+	)
+
 	return &filesystemCollector{
 		ignoredMountPointsPattern: mountPointPattern,
 		ignoredFSTypesPattern:     filesystemsTypesPattern,
@@ -111,6 +145,9 @@ func NewFilesystemCollector() (Collector, error) {
 		filesDesc:                 filesDesc,
 		filesFreeDesc:             filesFreeDesc,
 		roDesc:                    roDesc,
+		growthDesc:                growthDesc,
+		untilFullDesc:             untilFullDesc,
+		history:                   map[string][]filesystemSample{},
 	}, nil
 }
 
@@ -145,5 +182,54 @@ func (c *filesystemCollector) Update(ch chan<- prometheus.Metric) (err error) {
 			s.ro, s.labelValues...,
 		)
 	}
+
+	if *filesystemGrowthWindow <= 0 {
+		return nil
+	}
+	for i, rate := range c.updateGrowth(stats, time.Now(), *filesystemGrowthWindow) {
+		s := stats[i]
+		ch <- prometheus.MustNewConstMetric(
+			c.growthDesc, prometheus.GaugeValue,
+			rate, s.labelValues...,
+		)
+		if rate > 0 {
+			ch <- prometheus.MustNewConstMetric(
+				c.untilFullDesc, prometheus.GaugeValue,
+				s.avail/rate, s.labelValues...,
+			)
+		}
+	}
 	return nil
 }
+
+// updateGrowth records the used space of each filesystem and returns its
+// growth rate in bytes per second since the oldest sample within window,
+// keyed by index in stats. Samples are taken at most every
+// filesystemMinSampleInterval, and filesystems are left out until their
+// oldest sample is that old.
+func (c *filesystemCollector) updateGrowth(stats []filesystemStats, now time.Time, window time.Duration) map[int]float64 {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	rates := map[int]float64{}
+	history := make(map[string][]filesystemSample, len(stats))
+	for i, s := range stats {
+		key := strings.Join(s.labelValues, "\x00")
+		samples := c.history[key]
+		for len(samples) > 0 && now.Sub(samples[0].time) > window {
+			samples = samples[1:]
+		}
+		cur := filesystemSample{time: now, used: s.size - s.free}
+		if len(samples) > 0 {
+			if elapsed := now.Sub(samples[0].time); elapsed >= filesystemMinSampleInterval {
+				rates[i] = (cur.used - samples[0].used) / elapsed.Seconds()
+			}
+		}
+		if len(samples) == 0 || now.Sub(samples[len(samples)-1].time) >= filesystemMinSampleInterval {
+			samples = append(samples, cur)
+		}
+		history[key] = samples
+	}
+	c.history = history
+	return rates
+}
@@ -0,0 +1,75 @@
+// Copyright 2016 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// +build !nofilesystem
+// +build linux freebsd,cgo openbsd,cgo darwin,amd64,cgo aix
+
+package collector
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestFilesystemGrowth(t *testing.T) {
+	c, err := NewFilesystemCollector()
+	if err != nil {
+		t.Fatal(err)
+	}
+	collector := c.(*filesystemCollector)
+	root := filesystemStats{labelValues: []string{"/dev/sda1", "/", "ext4"}, size: 1000, free: 600}
+	data := filesystemStats{labelValues: []string{"/dev/sdb1", "/data", "xfs"}, size: 1000, free: 100}
+
+	now := time.Now()
+	window := 10 * time.Minute
+	if want, got := 0, len(collector.updateGrowth([]filesystemStats{root}, now, window)); want != got {
+		t.Errorf("want %d rates on first scrape, got %d", want, got)
+	}
+
+	// 100 bytes more used on / in 5 minutes, /data appears.
+	root.free = 500
+	rates := collector.updateGrowth([]filesystemStats{root, data}, now.Add(5*time.Minute), window)
+	if want, got := 1, len(rates); want != got {
+		t.Fatalf("want %d rates, got %v", want, got)
+	}
+	if want, got := 100.0/300, rates[0]; want != got {
+		t.Errorf("want growth %f, got %f", want, got)
+	}
+
+	// Scrapes right after another aren't sampled.
+	rates = collector.updateGrowth([]filesystemStats{root, data}, now.Add(5*time.Minute+10*time.Second), window)
+	if want, got := 2, len(collector.history[strings.Join(root.labelValues, "\x00")]); want != got {
+		t.Errorf("want %d samples of /, got %d", want, got)
+	}
+	if want, got := 100.0/310, rates[0]; want != got {
+		t.Errorf("want growth %f, got %f", want, got)
+	}
+
+	// Another 100 bytes 10 minutes later, the first sample fell out of the
+	// window.
+	root.free = 400
+	rates = collector.updateGrowth([]filesystemStats{root, data}, now.Add(15*time.Minute), window)
+	if want, got := 100.0/600, rates[0]; want != got {
+		t.Errorf("want growth %f, got %f", want, got)
+	}
+	if want, got := 0.0, rates[1]; want != got {
+		t.Errorf("want growth %f of /data, got %f", want, got)
+	}
+
+	// Unmounted filesystems are forgotten.
+	collector.updateGrowth([]filesystemStats{root}, now.Add(16*time.Minute), window)
+	if want, got := 1, len(collector.history); want != got {
+		t.Errorf("want history of %d filesystems, got %d", want, got)
+	}
+}
@@ -17,7 +17,6 @@ package collector
 
 import (
 	"bufio"
-	"os"
 	"strings"
 	"syscall"
 
@@ -53,7 +52,7 @@ func (c *filesystemCollector) GetStats() (stats []filesystemStats, err error) {
 			continue
 		}
 		buf := new(syscall.Statfs_t)
-		err := syscall.Statfs(mpd.mountPoint, buf)
+		err := syscall.Statfs(rootfsFilePath(mpd.mountPoint), buf)
 		if err != nil {
 			log.Debugf("Statfs on %s returned %s",
 				mpd.mountPoint, err)
@@ -80,7 +79,7 @@ func (c *filesystemCollector) GetStats() (stats []filesystemStats, err error) {
 }
 
 func mountPointDetails() ([]filesystemDetails, error) {
-	file, err := os.Open(procFilePath("mounts"))
+	file, err := procFS.Open("mounts")
 	if err != nil {
 		return nil, err
 	}
@@ -91,7 +90,13 @@ func mountPointDetails() ([]filesystemDetails, error) {
 	scanner := bufio.NewScanner(file)
 	for scanner.Scan() {
 		parts := strings.Fields(scanner.Text())
-		filesystems = append(filesystems, filesystemDetails{parts[0], parts[1], parts[2]})
+		// Only report the host's filesystems, under the path they have
+		// on the host, when it is mounted into a container.
+		mountPoint, ok := rootfsStripPrefix(parts[1])
+		if !ok {
+			continue
+		}
+		filesystems = append(filesystems, filesystemDetails{parts[0], mountPoint, parts[2]})
 	}
 	return filesystems, nil
 }
@@ -0,0 +1,255 @@
+// Copyright 2016 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// +build !nofirewall
+
+package collector
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const firewallSubsystem = "firewall"
+
+var (
+	iptablesSaveCommand = flag.String("collector.firewall.iptables-save-command", "iptables-save", "Command to run iptables-save.")
+	iptablesChains      = flag.String("collector.firewall.iptables-chains", "", "Comma-separated list of table:chain pairs to export iptables counters for.")
+	nftCommand          = flag.String("collector.firewall.nft-command", "nft", "Command to run nft.")
+	nftCounters         = flag.String("collector.firewall.nft-counters", "", "Comma-separated list of family:table:name named nftables counters to export.")
+)
+
+// firewallCounter holds packet and byte counters.
+type firewallCounter struct {
+	packets, bytes uint64
+}
+
+// iptablesChain holds the policy counter and the sum of all rule counters
+// of a single chain.
+type iptablesChain struct {
+	policy firewallCounter
+	rules  firewallCounter
+}
+
+type firewallCollector struct {
+	chains   map[string]bool
+	counters map[string]bool
+
+	chainPackets   *prometheus.Desc
+	chainBytes     *prometheus.Desc
+	counterPackets *prometheus.Desc
+	counterBytes   *prometheus.Desc
+}
+
+func init() {
+	Factories["firewall"] = NewFirewallCollector
+}
+
+// NewFirewallCollector returns a new Collector exposing packet and byte
+// counters of allowlisted iptables chains and named nftables counters.
+func NewFirewallCollector() (Collector, error) {
+	c := &firewallCollector{
+		chains:   firewallAllowlist(*iptablesChains),
+		counters: firewallAllowlist(*nftCounters),
+		chainPackets: prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, firewallSubsystem, "iptables_chain_packets_total"),
+			"Number of packets counted by the chain policy or the sum of its rules.",
+			[]string{"table", "chain", "counter"}, nil,
+		),
+		chainBytes: prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, firewallSubsystem, "iptables_chain_bytes_total"),
+			"Number of bytes counted by the chain policy or the sum of its rules.",
+			[]string{"table", "chain", "counter"}, nil,
+		),
+		counterPackets: prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, firewallSubsystem, "nft_counter_packets_total"),
+			"Number of packets counted by a named nftables counter.",
+			[]string{"family", "table", "name"}, nil,
+		),
+		counterBytes: prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, firewallSubsystem, "nft_counter_bytes_total"),
+			"Number of bytes counted by a named nftables counter.",
+			[]string{"family", "table", "name"}, nil,
+		),
+	}
+	if len(c.chains) == 0 && len(c.counters) == 0 {
+		return nil, fmt.Errorf("no chains or counters specified, see -collector.firewall.iptables-chains and -collector.firewall.nft-counters")
+	}
+	return c, nil
+}
+
+func (c *firewallCollector) Update(ch chan<- prometheus.Metric) (err error) {
+	if len(c.chains) > 0 {
+		if err := c.updateIptables(ch); err != nil {
+			return fmt.Errorf("couldn't get iptables counters: %s", err)
+		}
+	}
+	if len(c.counters) > 0 {
+		if err := c.updateNft(ch); err != nil {
+			return fmt.Errorf("couldn't get nftables counters: %s", err)
+		}
+	}
+	return nil
+}
+
+func (c *firewallCollector) updateIptables(ch chan<- prometheus.Metric) error {
+	out, err := localeCommand(*iptablesSaveCommand, "-c").Output()
+	if err != nil {
+		return err
+	}
+	chains, err := parseIptablesSave(bytes.NewReader(out))
+	if err != nil {
+		return err
+	}
+	for key, chain := range chains {
+		if !c.chains[key] {
+			continue
+		}
+		parts := strings.SplitN(key, ":", 2)
+		for counter, v := range map[string]firewallCounter{"policy": chain.policy, "rules": chain.rules} {
+			ch <- prometheus.MustNewConstMetric(c.chainPackets, prometheus.CounterValue, float64(v.packets), parts[0], parts[1], counter)
+			ch <- prometheus.MustNewConstMetric(c.chainBytes, prometheus.CounterValue, float64(v.bytes), parts[0], parts[1], counter)
+		}
+	}
+	return nil
+}
+
+func (c *firewallCollector) updateNft(ch chan<- prometheus.Metric) error {
+	out, err := localeCommand(*nftCommand, "-j", "list", "counters").Output()
+	if err != nil {
+		return err
+	}
+	counters, err := parseNftCounters(bytes.NewReader(out))
+	if err != nil {
+		return err
+	}
+	for key, v := range counters {
+		if !c.counters[key] {
+			continue
+		}
+		parts := strings.SplitN(key, ":", 3)
+		ch <- prometheus.MustNewConstMetric(c.counterPackets, prometheus.CounterValue, float64(v.packets), parts...)
+		ch <- prometheus.MustNewConstMetric(c.counterBytes, prometheus.CounterValue, float64(v.bytes), parts...)
+	}
+	return nil
+}
+
+func firewallAllowlist(list string) map[string]bool {
+	allowed := map[string]bool{}
+	for _, item := range strings.Split(list, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			allowed[item] = true
+		}
+	}
+	return allowed
+}
+
+// parseIptablesSave parses the output of iptables-save -c and returns the
+// counters per table:chain.
+func parseIptablesSave(r io.Reader) (map[string]*iptablesChain, error) {
+	var (
+		chains  = map[string]*iptablesChain{}
+		scanner = bufio.NewScanner(r)
+		table   string
+	)
+
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		switch {
+		case strings.HasPrefix(line, "*"):
+			table = line[1:]
+		case strings.HasPrefix(line, ":"):
+			// :INPUT ACCEPT [1234:567890]
+			parts := strings.Fields(line[1:])
+			if len(parts) != 3 {
+				return nil, fmt.Errorf("invalid chain line: %q", line)
+			}
+			counter, err := parseIptablesCounter(parts[2])
+			if err != nil {
+				return nil, err
+			}
+			chains[table+":"+parts[0]] = &iptablesChain{policy: counter}
+		case strings.HasPrefix(line, "["):
+			// [10:600] -A INPUT -i lo -j ACCEPT
+			parts := strings.Fields(line)
+			if len(parts) < 3 || parts[1] != "-A" {
+				continue
+			}
+			counter, err := parseIptablesCounter(parts[0])
+			if err != nil {
+				return nil, err
+			}
+			chain, ok := chains[table+":"+parts[2]]
+			if !ok {
+				return nil, fmt.Errorf("rule for undeclared chain %s:%s", table, parts[2])
+			}
+			chain.rules.packets += counter.packets
+			chain.rules.bytes += counter.bytes
+		}
+	}
+
+	return chains, scanner.Err()
+}
+
+func parseIptablesCounter(s string) (firewallCounter, error) {
+	parts := strings.Split(strings.Trim(s, "[]"), ":")
+	if len(parts) != 2 {
+		return firewallCounter{}, fmt.Errorf("invalid counter: %q", s)
+	}
+	packets, err := strconv.ParseUint(parts[0], 10, 64)
+	if err != nil {
+		return firewallCounter{}, err
+	}
+	bytes, err := strconv.ParseUint(parts[1], 10, 64)
+	if err != nil {
+		return firewallCounter{}, err
+	}
+	return firewallCounter{packets: packets, bytes: bytes}, nil
+}
+
+// parseNftCounters parses the output of nft -j list counters and returns
+// the counters per family:table:name.
+func parseNftCounters(r io.Reader) (map[string]firewallCounter, error) {
+	var doc struct {
+		Nftables []struct {
+			Counter *struct {
+				Family  string `json:"family"`
+				Table   string `json:"table"`
+				Name    string `json:"name"`
+				Packets uint64 `json:"packets"`
+				Bytes   uint64 `json:"bytes"`
+			} `json:"counter"`
+		} `json:"nftables"`
+	}
+	if err := json.NewDecoder(r).Decode(&doc); err != nil {
+		return nil, err
+	}
+
+	counters := map[string]firewallCounter{}
+	for _, o := range doc.Nftables {
+		if o.Counter == nil {
+			continue
+		}
+		key := strings.Join([]string{o.Counter.Family, o.Counter.Table, o.Counter.Name}, ":")
+		counters[key] = firewallCounter{packets: o.Counter.Packets, bytes: o.Counter.Bytes}
+	}
+	return counters, nil
+}
@@ -0,0 +1,68 @@
+// Copyright 2016 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collector
+
+import (
+	"os"
+	"testing"
+)
+
+func TestIptablesSave(t *testing.T) {
+	file, err := os.Open("fixtures/iptables-save.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+
+	chains, err := parseIptablesSave(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	input := chains["filter:INPUT"]
+	if input == nil {
+		t.Fatal("filter:INPUT chain missing")
+	}
+	if want, got := uint64(1523), input.policy.packets; want != got {
+		t.Errorf("want %d policy packets, got %d", want, got)
+	}
+	if want, got := uint64(148623+2150318+311), input.rules.packets; want != got {
+		t.Errorf("want %d rule packets, got %d", want, got)
+	}
+	if want, got := uint64(18420+240), chains["filter:ssh"].rules.bytes; want != got {
+		t.Errorf("want %d rule bytes in ssh chain, got %d", want, got)
+	}
+	if want, got := uint64(251340), chains["nat:PREROUTING"].policy.bytes; want != got {
+		t.Errorf("want %d policy bytes in nat:PREROUTING, got %d", want, got)
+	}
+}
+
+func TestNftCounters(t *testing.T) {
+	file, err := os.Open("fixtures/nft-counters.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+
+	counters, err := parseNftCounters(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want, got := 2, len(counters); want != got {
+		t.Fatalf("want %d counters, got %d", want, got)
+	}
+	if want, got := (firewallCounter{packets: 1204, bytes: 98321}), counters["inet:filter:http"]; want != got {
+		t.Errorf("want %v, got %v", want, got)
+	}
+}
@@ -0,0 +1,227 @@
+// Copyright 2016 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collector
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// fileSystem gives collectors access to procfs, sysfs and other host files by
+// slash-separated names relative to a root, so that tests can substitute an
+// in-memory tree.
+type fileSystem interface {
+	fs.GlobFS
+}
+
+var (
+	procFS fileSystem = dirFS{root: procRoot}
+	sysFS  fileSystem = dirFS{root: sysRoot}
+	// rootfsFS is the host's root filesystem, see -path.rootfs.
+	rootfsFS fileSystem = dirFS{root: func() string { return rootfsFilePath("/") }}
+	// devFS is the host's /dev.
+	devFS fileSystem = dirFS{root: func() string { return devFilePath("") }}
+)
+
+// dirFS is a fileSystem rooted at a directory. The root is resolved on every
+// access so that it follows changes to the -collector.procfs,
+// -collector.sysfs and -path.rootfs flags.
+type dirFS struct {
+	root func() string
+}
+
+func (d dirFS) path(op, name string) (string, error) {
+	if !fs.ValidPath(name) {
+		return "", &fs.PathError{Op: op, Path: name, Err: fs.ErrInvalid}
+	}
+	return filepath.Join(d.root(), filepath.FromSlash(name)), nil
+}
+
+func (d dirFS) Open(name string) (fs.File, error) {
+	return d.OpenFile(name, os.O_RDONLY)
+}
+
+// OpenFile opens the named file with the given flags, e.g. for device files
+// that need a descriptor for ioctls.
+func (d dirFS) OpenFile(name string, flag int) (*os.File, error) {
+	p, err := d.path("open", name)
+	if err != nil {
+		return nil, err
+	}
+	recordPath(p)
+	return os.OpenFile(p, flag, 0)
+}
+
+func (d dirFS) Glob(pattern string) ([]string, error) {
+	p, err := d.path("glob", pattern)
+	if err != nil {
+		return nil, err
+	}
+	matches, err := filepath.Glob(p)
+	if err != nil {
+		return nil, err
+	}
+	for i, m := range matches {
+		recordPath(m)
+		rel, err := filepath.Rel(d.root(), m)
+		if err != nil {
+			return nil, err
+		}
+		matches[i] = filepath.ToSlash(rel)
+	}
+	return matches, nil
+}
+
+// Readlink returns the destination of the named symbolic link.
+func (d dirFS) Readlink(name string) (string, error) {
+	p, err := d.path("readlink", name)
+	if err != nil {
+		return "", err
+	}
+	recordPath(p)
+	return os.Readlink(p)
+}
+
+// WriteFile writes data to the named file, which must exist.
+func (d dirFS) WriteFile(name string, data []byte) error {
+	f, err := d.OpenFile(name, os.O_WRONLY|os.O_TRUNC)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// openFSFile opens the named file with the given flags, if fsys is backed by
+// real files.
+func openFSFile(fsys fileSystem, name string, flag int) (*os.File, error) {
+	o, ok := fsys.(interface {
+		OpenFile(name string, flag int) (*os.File, error)
+	})
+	if !ok {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrInvalid}
+	}
+	return o.OpenFile(name, flag)
+}
+
+// writeFSFile writes data to the named file, if fsys supports writing.
+func writeFSFile(fsys fileSystem, name string, data []byte) error {
+	w, ok := fsys.(interface {
+		WriteFile(name string, data []byte) error
+	})
+	if !ok {
+		return &fs.PathError{Op: "write", Path: name, Err: fs.ErrInvalid}
+	}
+	return w.WriteFile(name, data)
+}
+
+// readFSLink returns the destination of the named symbolic link, if fsys
+// supports links.
+func readFSLink(fsys fileSystem, name string) (string, error) {
+	l, ok := fsys.(interface {
+		Readlink(name string) (string, error)
+	})
+	if !ok {
+		return "", &fs.PathError{Op: "readlink", Path: name, Err: fs.ErrInvalid}
+	}
+	return l.Readlink(name)
+}
+
+// readFSFile returns the content of the named file.
+func readFSFile(fsys fileSystem, name string) ([]byte, error) {
+	return fs.ReadFile(fsys, name)
+}
+
+// readFSUint returns the integer stored in the named file.
+func readFSUint(fsys fileSystem, name string) (uint64, error) {
+	file, err := fsys.Open(name)
+	if err != nil {
+		return 0, err
+	}
+	defer file.Close()
+	return readUint(file, name)
+}
+
+// readFSFloat returns the decimal number stored in the named file.
+func readFSFloat(fsys fileSystem, name string) (float64, error) {
+	data, err := readFSFile(fsys, name)
+	if err != nil {
+		return 0, err
+	}
+	value, err := strconv.ParseFloat(strings.TrimSpace(string(data)), 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid value in %s: %s", name, err)
+	}
+	return value, nil
+}
+
+// parseSysfsChoice parses an attribute listing the possible values with the
+// current one in brackets, e.g. "auto [inhibit-charge] force-discharge". The
+// current value is empty if none is bracketed.
+func parseSysfsChoice(s string) (choices []string, current string) {
+	for _, c := range strings.Fields(s) {
+		if strings.HasPrefix(c, "[") && strings.HasSuffix(c, "]") {
+			c = c[1 : len(c)-1]
+			current = c
+		}
+		choices = append(choices, c)
+	}
+	return choices, current
+}
+
+// rootFS returns a fileSystem rooted at a fixed directory.
+func rootFS(root string) fileSystem {
+	return dirFS{root: func() string { return root }}
+}
+
+// localFS returns a fileSystem for the exporter's own file path p, like the
+// -collector.textfile.directory, and the name of p within it. Unlike host
+// files, these paths are not resolved relative to -path.rootfs.
+func localFS(p string) (fileSystem, string) {
+	root, name := splitLocalPath(p)
+	return rootFS(root), name
+}
+
+// splitLocalPath splits p into the longest directory without glob wildcards
+// and the slash-separated name or pattern below it.
+func splitLocalPath(p string) (root, name string) {
+	p = filepath.Clean(p)
+	meta := `*?[`
+	if filepath.Separator != '\\' {
+		meta += `\`
+	}
+	end := len(p)
+	if i := strings.IndexAny(p, meta); i >= 0 {
+		end = i
+	}
+	i := strings.LastIndexByte(p[:end], filepath.Separator)
+	root, name = p[:i+1], p[i+1:]
+	switch {
+	case name == "..":
+		root, name = p, "."
+	case name == "":
+		name = "."
+	}
+	if root == "" {
+		root = "."
+	}
+	return root, filepath.ToSlash(name)
+}
@@ -0,0 +1,129 @@
+// Copyright 2016 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collector
+
+import (
+	"io/fs"
+	"os"
+	"path"
+	"reflect"
+	"sort"
+	"testing"
+	"testing/fstest"
+)
+
+// mapFS is an in-memory fileSystem mapping slash-separated names to file
+// contents. Directories are implied by the names of the files they contain.
+// Files with the content mapFSDenied fail to open with a permission error.
+type mapFS map[string]string
+
+const mapFSDenied = "\x00denied"
+
+func (m mapFS) Open(name string) (fs.File, error) {
+	data, ok := m[name]
+	if !ok {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrNotExist}
+	}
+	if data == mapFSDenied {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrPermission}
+	}
+	return fstest.MapFS{name: {Data: []byte(data)}}.Open(name)
+}
+
+func (m mapFS) Glob(pattern string) ([]string, error) {
+	seen := map[string]bool{}
+	var matches []string
+	for name := range m {
+		for ; name != "."; name = path.Dir(name) {
+			if seen[name] {
+				continue
+			}
+			ok, err := path.Match(pattern, name)
+			if err != nil {
+				return nil, err
+			}
+			if ok {
+				seen[name] = true
+				matches = append(matches, name)
+			}
+		}
+	}
+	sort.Strings(matches)
+	return matches, nil
+}
+
+func TestDirFS(t *testing.T) {
+	fsys := dirFS{root: func() string { return "fixtures/sys" }}
+
+	matches, err := fsys.Glob("devices/system/node/node[0-9]*")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want, got := []string{"devices/system/node/node0", "devices/system/node/node1"}, matches; !reflect.DeepEqual(want, got) {
+		t.Errorf("want matches %v, got %v", want, got)
+	}
+
+	value, err := readFSUint(fsys, "devices/system/cpu/cpu10/topology/physical_package_id")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want, got := uint64(1), value; want != got {
+		t.Errorf("want value %d, got %d", want, got)
+	}
+
+	if _, err := readFSFile(fsys, "does/not/exist"); !os.IsNotExist(err) {
+		t.Errorf("want not exist error, got %v", err)
+	}
+	// Names can't escape the root.
+	if _, err := readFSFile(fsys, "../proc/stat"); err == nil {
+		t.Error("want error for name outside of the root")
+	}
+}
+
+func TestSplitLocalPath(t *testing.T) {
+	for _, tt := range []struct {
+		path, root, name string
+	}{
+		{"/etc/machine-id", "/etc/", "machine-id"},
+		{"/etc/ssl/certs/*.pem", "/etc/ssl/certs/", "*.pem"},
+		{"/etc/ssl/*/cert.pem", "/etc/ssl/", "*/cert.pem"},
+		{"fixtures/textfile/", "fixtures/", "textfile"},
+		{"token", ".", "token"},
+		{"../..", "../..", "."},
+		{"/", "/", "."},
+	} {
+		if root, name := splitLocalPath(tt.path); tt.root != root || tt.name != name {
+			t.Errorf("%s: want %q and %q, got %q and %q", tt.path, tt.root, tt.name, root, name)
+		}
+	}
+}
+
+func TestMapFS(t *testing.T) {
+	fsys := mapFS{
+		"devices/system/cpu/cpu0/topology/core_id": "0\n",
+		"devices/system/cpu/cpu1/online":           "0\n",
+	}
+
+	matches, err := fsys.Glob("devices/system/cpu/cpu[0-9]*")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want, got := []string{"devices/system/cpu/cpu0", "devices/system/cpu/cpu1"}, matches; !reflect.DeepEqual(want, got) {
+		t.Errorf("want matches %v, got %v", want, got)
+	}
+
+	if _, err := readFSUint(fsys, "devices/system/cpu/cpu1/topology/core_id"); !os.IsNotExist(err) {
+		t.Errorf("want not exist error, got %v", err)
+	}
+}
@@ -0,0 +1,129 @@
+// Copyright 2016 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// +build gofuzz
+
+// Fuzz targets for go-fuzz (https://github.com/dvyukov/go-fuzz). Build and run
+// one of them with, for example:
+//
+//   go-fuzz-build -func FuzzMemInfo github.com/prometheus/node_exporter/collector
+//   go-fuzz -bin collector-fuzz.zip -workdir fuzz/meminfo
+//
+// Seeding the corpus with the matching file below fixtures/ gets the fuzzer
+// going quickly.
+
+package collector
+
+import (
+	"bytes"
+	"regexp"
+	"syscall"
+)
+
+// fuzzResult tells go-fuzz whether the input was parsed successfully, so
+// that inputs reaching deeper into the parser are preferred.
+func fuzzResult(err error) int {
+	if err != nil {
+		return 0
+	}
+	return 1
+}
+
+func FuzzDiskStats(data []byte) int {
+	_, err := parseDiskStats(bytes.NewReader(data))
+	return fuzzResult(err)
+}
+
+func FuzzFileFDStats(data []byte) int {
+	_, err := parseFileFDStats(bytes.NewReader(data), "file-nr")
+	return fuzzResult(err)
+}
+
+func FuzzInterrupts(data []byte) int {
+	_, err := parseInterrupts(bytes.NewReader(data))
+	return fuzzResult(err)
+}
+
+func FuzzLoad(data []byte) int {
+	_, err := parseLoad(string(data))
+	return fuzzResult(err)
+}
+
+func FuzzMemInfo(data []byte) int {
+	_, err := parseMemInfo(bytes.NewReader(data))
+	return fuzzResult(err)
+}
+
+func FuzzMemInfoNuma(data []byte) int {
+	_, err := parseMemInfoNuma(bytes.NewReader(data))
+	return fuzzResult(err)
+}
+
+func FuzzNetDevStats(data []byte) int {
+	_, err := parseNetDevStats(bytes.NewReader(data), regexp.MustCompile("^$"))
+	return fuzzResult(err)
+}
+
+func FuzzNetStats(data []byte) int {
+	_, err := parseNetStats(bytes.NewReader(data), "netstat")
+	return fuzzResult(err)
+}
+
+func FuzzSockStats(data []byte) int {
+	_, err := parseSockStats(bytes.NewReader(data), "sockstat")
+	return fuzzResult(err)
+}
+
+func FuzzTCPStats(data []byte) int {
+	_, err := parseTCPStats(bytes.NewReader(data))
+	return fuzzResult(err)
+}
+
+func FuzzNetlinkAttrs(data []byte) int {
+	_, err := parseNetlinkAttrs(data)
+	return fuzzResult(err)
+}
+
+func FuzzQdiscMessages(data []byte) int {
+	_, err := parseQdiscMessages([]syscall.NetlinkMessage{
+		{Header: syscall.NlMsghdr{Type: syscall.RTM_NEWQDISC}, Data: data},
+	})
+	return fuzzResult(err)
+}
+
+func FuzzXDPLinkMessages(data []byte) int {
+	_, err := parseXDPLinkMessages([]syscall.NetlinkMessage{
+		{Header: syscall.NlMsghdr{Type: syscall.RTM_NEWLINK}, Data: data},
+	})
+	return fuzzResult(err)
+}
+
+func FuzzIptablesSave(data []byte) int {
+	_, err := parseIptablesSave(bytes.NewReader(data))
+	return fuzzResult(err)
+}
+
+func FuzzNftCounters(data []byte) int {
+	_, err := parseNftCounters(bytes.NewReader(data))
+	return fuzzResult(err)
+}
+
+func FuzzChronyTracking(data []byte) int {
+	_, err := parseChronyTracking(data, 0)
+	return fuzzResult(err)
+}
+
+func FuzzNtpqVariables(data []byte) int {
+	_, err := parseNtpqVariables(string(data))
+	return fuzzResult(err)
+}
@@ -0,0 +1,165 @@
+// Copyright 2016 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// +build !nofwupd
+
+package collector
+
+import (
+	"fmt"
+
+	"github.com/godbus/dbus"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/common/log"
+)
+
+const (
+	fwupdSubsystem = "fwupd"
+	fwupdObject    = "org.freedesktop.fwupd"
+
+	// fwupdFlagUpdatable is FWUPD_DEVICE_FLAG_UPDATABLE, set for devices
+	// whose firmware fwupd can update.
+	fwupdFlagUpdatable = 1 << 1
+)
+
+type fwupdCollector struct {
+	device          *prometheus.Desc
+	updateAvailable *prometheus.Desc
+	update          *prometheus.Desc
+}
+
+// fwupdDevice is a device fwupd knows the firmware of. updates are the
+// versions it can be upgraded to, newest first.
+type fwupdDevice struct {
+	id, name, vendor, version string
+	updatable                 bool
+	updates                   []string
+}
+
+type fwupdDbus struct {
+	object dbus.BusObject
+}
+
+type fwupdInterface interface {
+	getDevices() ([]fwupdDevice, error)
+	getUpgrades(id string) ([]string, error)
+}
+
+func init() {
+	Factories["fwupd"] = NewFwupdCollector
+	Maturities["fwupd"] = MaturityExperimental
+}
+
+// NewFwupdCollector returns a new Collector exposing the firmware versions
+// of the devices known to fwupd and the updates available for them, as far
+// as its metadata was refreshed, e.g. by fwupd-refresh.timer.
+func NewFwupdCollector() (Collector, error) {
+	return &fwupdCollector{
+		device: prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, fwupdSubsystem, "device_info"),
+			"Devices known to fwupd with their current firmware version.",
+			[]string{"device_id", "name", "vendor", "version"}, nil,
+		),
+		updateAvailable: prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, fwupdSubsystem, "device_update_available"),
+			"Whether a firmware update is available for the updatable device.",
+			[]string{"device_id"}, nil,
+		),
+		update: prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, fwupdSubsystem, "device_update_info"),
+			"Newest firmware version available for the device.",
+			[]string{"device_id", "version"}, nil,
+		),
+	}, nil
+}
+
+func (fc *fwupdCollector) Update(ch chan<- prometheus.Metric) error {
+	conn, err := newSystemBusConn()
+	if err != nil {
+		return fmt.Errorf("unable to connect to dbus: %s", err)
+	}
+	defer conn.Close()
+
+	return fc.collectMetrics(ch, &fwupdDbus{object: conn.Object(fwupdObject, "/")})
+}
+
+func (fc *fwupdCollector) collectMetrics(ch chan<- prometheus.Metric, c fwupdInterface) error {
+	devices, err := c.getDevices()
+	if err != nil {
+		return err
+	}
+	for _, d := range devices {
+		ch <- prometheus.MustNewConstMetric(fc.device, prometheus.GaugeValue, 1, d.id, d.name, d.vendor, d.version)
+		if !d.updatable {
+			continue
+		}
+		updates, err := c.getUpgrades(d.id)
+		if err != nil {
+			return err
+		}
+		var available float64
+		if len(updates) > 0 {
+			available = 1
+			ch <- prometheus.MustNewConstMetric(fc.update, prometheus.GaugeValue, 1, d.id, updates[0])
+		}
+		ch <- prometheus.MustNewConstMetric(fc.updateAvailable, prometheus.GaugeValue, available, d.id)
+	}
+	return nil
+}
+
+func (c *fwupdDbus) getDevices() ([]fwupdDevice, error) {
+	var result []map[string]dbus.Variant
+	if err := c.object.Call(fwupdObject+".GetDevices", 0).Store(&result); err != nil {
+		return nil, fmt.Errorf("unable to get fwupd devices: %s", err)
+	}
+	devices := make([]fwupdDevice, 0, len(result))
+	for _, props := range result {
+		flags, _ := props["Flags"].Value().(uint64)
+		devices = append(devices, fwupdDevice{
+			id:        fwupdString(props, "DeviceId"),
+			name:      fwupdString(props, "Name"),
+			vendor:    fwupdString(props, "Vendor"),
+			version:   fwupdString(props, "Version"),
+			updatable: flags&fwupdFlagUpdatable != 0,
+		})
+	}
+	return devices, nil
+}
+
+// getUpgrades returns the newer versions of the firmware of a device.
+func (c *fwupdDbus) getUpgrades(id string) ([]string, error) {
+	var result []map[string]dbus.Variant
+	err := c.object.Call(fwupdObject+".GetUpgrades", 0, id).Store(&result)
+	if err, ok := err.(dbus.Error); ok {
+		switch err.Name {
+		// fwupd reports devices being up to date or without
+		// metadata for their firmware as errors.
+		case fwupdObject + ".NothingToDo", fwupdObject + ".NotFound", fwupdObject + ".NotSupported":
+			log.Debugf("No fwupd upgrades for device %s: %s", id, err)
+			return nil, nil
+		}
+	}
+	if err != nil {
+		return nil, fmt.Errorf("unable to get fwupd upgrades of device %s: %s", id, err)
+	}
+	versions := make([]string, 0, len(result))
+	for _, props := range result {
+		versions = append(versions, fwupdString(props, "Version"))
+	}
+	return versions, nil
+}
+
+func fwupdString(props map[string]dbus.Variant, key string) string {
+	s, _ := props[key].Value().(string)
+	return s
+}
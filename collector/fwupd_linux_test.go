@@ -0,0 +1,77 @@
+// Copyright 2016 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collector
+
+import (
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+)
+
+type testFwupdInterface struct{}
+
+func (c *testFwupdInterface) getDevices() ([]fwupdDevice, error) {
+	return []fwupdDevice{
+		{id: "a45df35ac0e948ee180fe216a5f703f32dda163f", name: "System Firmware", vendor: "Dell Inc.", version: "1.14.0", updatable: true},
+		{id: "362301da643102b9f38477387e2193e57abaa590", name: "Samsung SSD 970 EVO Plus", vendor: "Samsung", version: "2B2QEXM7", updatable: true},
+		{id: "d8bc5ff8d7a5eb0d5b28a4f5f0f1e3bbcd58e2c1", name: "TPM", vendor: "Infineon", version: "7.85.4555.0"},
+	}, nil
+}
+
+func (c *testFwupdInterface) getUpgrades(id string) ([]string, error) {
+	if id == "a45df35ac0e948ee180fe216a5f703f32dda163f" {
+		return []string{"1.16.0", "1.15.1"}, nil
+	}
+	return nil, nil
+}
+
+func TestFwupdCollector(t *testing.T) {
+	fc, err := NewFwupdCollector()
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := fc.(*fwupdCollector)
+	ch := make(chan prometheus.Metric, 16)
+	if err := c.collectMetrics(ch, &testFwupdInterface{}); err != nil {
+		t.Fatal(err)
+	}
+	close(ch)
+
+	values := map[string]float64{}
+	for m := range ch {
+		pb := &dto.Metric{}
+		m.Write(pb)
+		key := m.Desc().String()
+		for _, l := range pb.Label {
+			key += l.GetValue()
+		}
+		values[key] = pb.Gauge.GetValue()
+	}
+	if want, got := 6, len(values); want != got {
+		t.Errorf("want %d metrics, got %d", want, got)
+	}
+	for _, tt := range []struct {
+		key  string
+		want float64
+	}{
+		{c.updateAvailable.String() + "a45df35ac0e948ee180fe216a5f703f32dda163f", 1},
+		{c.update.String() + "a45df35ac0e948ee180fe216a5f703f32dda163f1.16.0", 1},
+		{c.updateAvailable.String() + "362301da643102b9f38477387e2193e57abaa590", 0},
+	} {
+		if got, ok := values[tt.key]; !ok || tt.want != got {
+			t.Errorf("want %s %f, got %f", tt.key, tt.want, got)
+		}
+	}
+}
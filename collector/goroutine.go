@@ -0,0 +1,35 @@
+// Copyright 2016 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collector
+
+// OnPanic is called with the value of a panic in a goroutine started with
+// Go. The exporter sets it to write a crash report and resume panicking, it
+// is nil without -crash.dir.
+var OnPanic func(v interface{})
+
+// Go runs f in a new goroutine whose panics are passed to OnPanic. A panic
+// in a goroutine can't be recovered by its starter, so every goroutine of
+// the exporter, including the ones of collectors, is started with it.
+func Go(f func()) {
+	go func() {
+		if h := OnPanic; h != nil {
+			defer func() {
+				if v := recover(); v != nil {
+					h(v)
+				}
+			}()
+		}
+		f()
+	}()
+}
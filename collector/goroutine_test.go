@@ -0,0 +1,29 @@
+// Copyright 2016 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collector
+
+import (
+	"testing"
+)
+
+func TestGo(t *testing.T) {
+	defer func() { OnPanic = nil }()
+	panics := make(chan interface{})
+	OnPanic = func(v interface{}) { panics <- v }
+
+	Go(func() { panic("boom") })
+	if want, got := "boom", <-panics; want != got {
+		t.Errorf("want panic %q passed to OnPanic, got %v", want, got)
+	}
+}
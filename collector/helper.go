@@ -14,10 +14,16 @@
 package collector
 
 import (
+	"bytes"
+	"errors"
 	"fmt"
-	"io/ioutil"
+	"io"
+	"math"
+	"os"
+	"os/exec"
 	"strconv"
 	"strings"
+	"sync"
 )
 
 func splitToInts(str string, sep string) (ints []int, err error) {
@@ -31,14 +37,136 @@ func splitToInts(str string, sep string) (ints []int, err error) {
 	return ints, nil
 }
 
-func readUintFromFile(path string) (uint64, error) {
-	data, err := ioutil.ReadFile(path)
-	if err != nil {
+// valueBufPool holds buffers for reading single value files from procfs and
+// sysfs, whose content never exceeds a page.
+var valueBufPool = sync.Pool{
+	New: func() interface{} { return new([4096]byte) },
+}
+
+// readUint parses the integer making up the content of r, name is used in
+// errors only.
+func readUint(r io.Reader, name string) (uint64, error) {
+	buf := valueBufPool.Get().(*[4096]byte)
+	defer valueBufPool.Put(buf)
+	// procfs and sysfs return the whole value in a single read.
+	n, err := r.Read(buf[:])
+	if err != nil && err != io.EOF {
 		return 0, err
 	}
-	value, err := strconv.ParseUint(strings.TrimSpace(string(data)), 10, 64)
+	value, err := parseUintBytes(buf[:n])
 	if err != nil {
-		return 0, err
+		return 0, fmt.Errorf("invalid value in %s: %s", name, err)
+	}
+	return value, nil
+}
+
+// parseUintBytes parses a decimal integer surrounded by optional whitespace.
+// Unlike strconv.ParseUint on a trimmed string it doesn't allocate.
+func parseUintBytes(b []byte) (uint64, error) {
+	b = bytes.TrimSpace(b)
+	if len(b) == 0 {
+		return 0, errors.New("empty value")
+	}
+	var value uint64
+	for _, c := range b {
+		if c < '0' || c > '9' {
+			return 0, fmt.Errorf("invalid integer %q", b)
+		}
+		d := uint64(c - '0')
+		if value > (math.MaxUint64-d)/10 {
+			return 0, fmt.Errorf("integer %q out of range", b)
+		}
+		value = value*10 + d
 	}
 	return value, nil
 }
+
+// parseLocaleFloat parses a number printed by an external tool, which may
+// follow the user's locale, as some vendor tools ignore the C locale set by
+// localeCommand. Besides the plain format of strconv.ParseFloat
+// it accepts decimal commas, like 36,6, and thousands separators, like
+// 1,234,567.8, 1.234.567,8, 1 234 567,8 or 1'234'567.8. The last dot or
+// comma is the decimal separator unless it occurs more than once, so a
+// single comma, as in 1,234, is read as a decimal comma.
+func parseLocaleFloat(s string) (float64, error) {
+	s = strings.TrimSpace(s)
+	if v, err := strconv.ParseFloat(s, 64); err == nil {
+		return v, nil
+	}
+	invalid := fmt.Errorf("invalid number %q", s)
+
+	var sign, exp string
+	if strings.HasPrefix(s, "-") || strings.HasPrefix(s, "+") {
+		sign, s = s[:1], s[1:]
+	}
+	if i := strings.IndexAny(s, "eE"); i >= 0 {
+		s, exp = s[:i], s[i:]
+	}
+	integer, frac := s, ""
+	dec := strings.LastIndex(s, ".")
+	if comma := strings.LastIndex(s, ","); comma > dec {
+		dec = comma
+	}
+	if dec >= 0 && strings.Count(s, s[dec:dec+1]) == 1 {
+		integer, frac = s[:dec], s[dec+1:]
+		if !isDigits(frac) {
+			return 0, invalid
+		}
+	}
+
+	// The integer part may be split into groups of three digits by a
+	// single kind of separator, the first group holding one to three.
+	var (
+		digits strings.Builder
+		sep    rune
+		group  int
+	)
+	for _, r := range integer {
+		switch {
+		case r >= '0' && r <= '9':
+			digits.WriteRune(r)
+			group++
+		case isGroupSeparator(r) && (sep == 0 || r == sep) && group > 0 && group <= 3 && (sep == 0 || group == 3):
+			sep = r
+			group = 0
+		default:
+			return 0, invalid
+		}
+	}
+	if group == 0 || sep != 0 && group != 3 {
+		return 0, invalid
+	}
+
+	number := sign + digits.String()
+	if frac != "" {
+		number += "." + frac
+	}
+	return strconv.ParseFloat(number+exp, 64)
+}
+
+func isGroupSeparator(r rune) bool {
+	switch r {
+	case ',', '.', ' ', '\'', '\u00a0', '\u202f', '\u2019':
+		return true
+	}
+	return false
+}
+
+func isDigits(s string) bool {
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return s != ""
+}
+
+// localeCommand returns the command running an external tool whose output
+// is parsed. The C locale makes it print numbers with a decimal point and
+// without thousands separators. Collectors run tools through it instead of
+// exec.Command.
+func localeCommand(name string, args ...string) *exec.Cmd {
+	cmd := exec.Command(name, args...)
+	cmd.Env = append(os.Environ(), "LC_ALL=C")
+	return cmd
+}
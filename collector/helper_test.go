@@ -0,0 +1,134 @@
+// Copyright 2016 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collector
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestParseUintBytes(t *testing.T) {
+	for _, tc := range []struct {
+		in   string
+		want uint64
+		err  bool
+	}{
+		{in: "0\n", want: 0},
+		{in: " 4096\n", want: 4096},
+		{in: "18446744073709551615", want: 18446744073709551615},
+		{in: "18446744073709551616", err: true},
+		{in: "", err: true},
+		{in: "-1", err: true},
+		{in: "12 kB", err: true},
+	} {
+		got, err := parseUintBytes([]byte(tc.in))
+		if tc.err {
+			if err == nil {
+				t.Errorf("parseUintBytes(%q): expected error", tc.in)
+			}
+			continue
+		}
+		if err != nil || got != tc.want {
+			t.Errorf("parseUintBytes(%q) = %d, %v, want %d", tc.in, got, err, tc.want)
+		}
+	}
+
+	b := []byte("123456789\n")
+	if allocs := testing.AllocsPerRun(100, func() { parseUintBytes(b) }); allocs != 0 {
+		t.Errorf("want no allocations, got %v", allocs)
+	}
+}
+
+func TestParseLocaleFloat(t *testing.T) {
+	for _, tc := range []struct {
+		in   string
+		want float64
+		err  bool
+	}{
+		{in: "36.6", want: 36.6},
+		{in: " 1e-3\n", want: 0.001},
+		{in: "36,6", want: 36.6},
+		{in: "-0,50", want: -0.5},
+		{in: "1,5e3", want: 1500},
+		{in: "1,234,567", want: 1234567},
+		{in: "1,234,567.89", want: 1234567.89},
+		{in: "1.234.567", want: 1234567},
+		{in: "1.234.567,89", want: 1234567.89},
+		{in: "1 234 567,89", want: 1234567.89},
+		{in: "1 234,5", want: 1234.5},
+		{in: "1'234'567.8", want: 1234567.8},
+		{in: "+12.345,6", want: 12345.6},
+		{in: "1,234", want: 1.234},
+		{in: "", err: true},
+		{in: "n/a", err: true},
+		{in: "1,", err: true},
+		{in: "1,23,456", err: true},
+		{in: "1234,567.8", err: true},
+		{in: "1,234.567,8", err: true},
+		{in: "1.234,567.8", err: true},
+		{in: "1 234.567.8", err: true},
+		{in: ",5", err: true},
+		{in: "12 C", err: true},
+	} {
+		got, err := parseLocaleFloat(tc.in)
+		if tc.err {
+			if err == nil {
+				t.Errorf("parseLocaleFloat(%q): expected error, got %v", tc.in, got)
+			}
+			continue
+		}
+		if err != nil || got != tc.want {
+			t.Errorf("parseLocaleFloat(%q) = %v, %v, want %v", tc.in, got, err, tc.want)
+		}
+	}
+}
+
+// TestLocaleCommand checks that collectors of all platforms run external
+// tools through localeCommand, so that their numbers are parsed in the C
+// locale.
+func TestLocaleCommand(t *testing.T) {
+	files, err := filepath.Glob("*.go")
+	if err != nil {
+		t.Fatal(err)
+	}
+	fset := token.NewFileSet()
+	for _, file := range files {
+		if strings.HasSuffix(file, "_test.go") {
+			continue
+		}
+		f, err := parser.ParseFile(fset, file, nil, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, decl := range f.Decls {
+			if fn, ok := decl.(*ast.FuncDecl); ok && fn.Name.Name == "localeCommand" {
+				continue
+			}
+			ast.Inspect(decl, func(n ast.Node) bool {
+				sel, ok := n.(*ast.SelectorExpr)
+				if !ok {
+					return true
+				}
+				if pkg, ok := sel.X.(*ast.Ident); ok && pkg.Name == "exec" && sel.Sel.Name == "Command" {
+					t.Errorf("%s: want localeCommand, got exec.Command", fset.Position(sel.Pos()))
+				}
+				return true
+			})
+		}
+	}
+}
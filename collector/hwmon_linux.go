@@ -0,0 +1,237 @@
+// Copyright 2016 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// +build !nohwmon
+
+package collector
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const hwmonSubsystem = "hwmon"
+
+// hwmonAttrRE matches the sensor attributes exposed, e.g. temp1_input.
+var hwmonAttrRE = regexp.MustCompile(`^(temp|fan|in|power|curr|pwm)(\d+)_(input|max|crit|target|enable)$`)
+
+// hwmonPwmModes names the fan control modes of pwm*_enable. Values above 2
+// select driver specific automatic modes.
+var hwmonPwmModes = []string{"full-speed", "manual", "auto"}
+
+// hwmonMetrics maps sensor types and attributes to metric names, help
+// texts and the divisor converting the sysfs value to base units.
+var hwmonMetrics = map[string]struct {
+	name    string
+	help    string
+	divisor float64
+}{
+	"temp_input":  {"temp_celsius", "Temperature of the sensor.", 1000},
+	"temp_max":    {"temp_max_celsius", "High temperature threshold of the sensor.", 1000},
+	"temp_crit":   {"temp_crit_celsius", "Critical temperature threshold of the sensor.", 1000},
+	"fan_input":   {"fan_rpm", "Speed of the fan.", 1},
+	"fan_max":     {"fan_max_rpm", "Maximum speed of the fan.", 1},
+	"fan_target":  {"fan_target_rpm", "Speed the fan is controlled to.", 1},
+	"in_input":    {"in_volts", "Voltage of the sensor.", 1000},
+	"in_max":      {"in_max_volts", "Maximum voltage of the sensor.", 1000},
+	"in_crit":     {"in_crit_volts", "Critical voltage of the sensor.", 1000},
+	"power_input": {"power_watts", "Power consumption of the sensor.", 1e6},
+	"power_max":   {"power_max_watts", "Maximum power consumption of the sensor.", 1e6},
+	"power_crit":  {"power_crit_watts", "Critical power consumption of the sensor.", 1e6},
+	"curr_input":  {"curr_amps", "Current of the sensor.", 1000},
+	"curr_max":    {"curr_max_amps", "Maximum current of the sensor.", 1000},
+	"curr_crit":   {"curr_crit_amps", "Critical current of the sensor.", 1000},
+}
+
+type hwmonCollector struct {
+	fs fileSystem
+
+	descs   map[string]*prometheus.Desc
+	pwmMode *prometheus.Desc
+}
+
+// hwmonValue is a single attribute of a sensor. The sensor is identified by
+// its attribute prefix like temp1, label is its name given by the driver.
+type hwmonValue struct {
+	chip, device, sensor, label string
+	// metric is the key into hwmonMetrics.
+	metric string
+	value  float64
+}
+
+func init() {
+	Factories["hwmon"] = NewHwmonCollector
+	Maturities["hwmon"] = MaturityBeta
+	SysfsFactories["hwmon"] = func(root string) (Collector, error) {
+		return newHwmonCollector(rootFS(root)), nil
+	}
+}
+
+// NewHwmonCollector returns a new Collector exposing hardware sensors from
+// /sys/class/hwmon, labeled with their chip and sensor names like lm-sensors.
+func NewHwmonCollector() (Collector, error) {
+	return newHwmonCollector(sysFS), nil
+}
+
+func newHwmonCollector(fsys fileSystem) *hwmonCollector {
+	c := &hwmonCollector{
+		fs:    fsys,
+		descs: map[string]*prometheus.Desc{},
+		pwmMode: prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, hwmonSubsystem, "pwm_mode"),
+			"Fan control mode of the PWM output, 1 for the current mode.",
+			[]string{"chip", "device", "sensor", "label", "mode"}, nil,
+		),
+	}
+	for key, m := range hwmonMetrics {
+		c.descs[key] = prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, hwmonSubsystem, m.name),
+			m.help,
+			[]string{"chip", "device", "sensor", "label"}, nil,
+		)
+	}
+	return c
+}
+
+func (c *hwmonCollector) Update(ch chan<- prometheus.Metric) (err error) {
+	values, err := readHwmon(c.fs)
+	if err != nil {
+		return err
+	}
+	for _, v := range values {
+		if v.metric == "pwm_enable" {
+			mode := hwmonPwmMode(v.value)
+			for _, m := range hwmonPwmModes {
+				var value float64
+				if m == mode {
+					value = 1
+				}
+				ch <- prometheus.MustNewConstMetric(c.pwmMode, prometheus.GaugeValue, value, v.chip, v.device, v.sensor, v.label, m)
+			}
+			continue
+		}
+		ch <- prometheus.MustNewConstMetric(c.descs[v.metric], prometheus.GaugeValue,
+			v.value/hwmonMetrics[v.metric].divisor, v.chip, v.device, v.sensor, v.label)
+	}
+	return nil
+}
+
+// readHwmon returns the sensor values of all hwmon chips.
+func readHwmon(fsys fileSystem) ([]hwmonValue, error) {
+	dirs, err := fsys.Glob("class/hwmon/hwmon*")
+	if err != nil {
+		return nil, err
+	}
+	var values []hwmonValue
+	for _, dir := range dirs {
+		v, err := readHwmonChip(fsys, dir)
+		if err != nil {
+			return nil, err
+		}
+		values = append(values, v...)
+	}
+	return values, nil
+}
+
+func readHwmonChip(fsys fileSystem, dir string) ([]hwmonValue, error) {
+	// Older drivers keep the attributes in the device directory.
+	attrDir := dir
+	name, err := readFSFile(fsys, path.Join(dir, "name"))
+	if err != nil {
+		attrDir = path.Join(dir, "device")
+		if name, err = readFSFile(fsys, path.Join(attrDir, "name")); err != nil {
+			return nil, fmt.Errorf("couldn't get name of %s: %s", dir, err)
+		}
+	}
+	chip, device := hwmonChip(fsys, dir, strings.TrimSpace(string(name)))
+
+	files, err := fsys.Glob(path.Join(attrDir, "*_*"))
+	if err != nil {
+		return nil, err
+	}
+	var values []hwmonValue
+	for _, file := range files {
+		match := hwmonAttrRE.FindStringSubmatch(path.Base(file))
+		if match == nil {
+			continue
+		}
+		metric := match[1] + "_" + match[3]
+		if _, ok := hwmonMetrics[metric]; !ok && metric != "pwm_enable" {
+			continue
+		}
+		raw, err := readFSFile(fsys, file)
+		if err != nil {
+			// Some attributes can't be read while the device is
+			// suspended.
+			continue
+		}
+		value, err := strconv.ParseFloat(strings.TrimSpace(string(raw)), 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid value %q in %s: %s", raw, file, err)
+		}
+		sensor := match[1] + match[2]
+		values = append(values, hwmonValue{
+			chip:   chip,
+			device: device,
+			sensor: sensor,
+			label:  hwmonSensorLabel(fsys, attrDir, sensor),
+			metric: metric,
+			value:  value,
+		})
+	}
+	return values, nil
+}
+
+// hwmonChip returns the chip name, made of the bus and the driver name like
+// platform_coretemp, and the name of the device on the bus. Virtual chips
+// without a device are only named by the driver, and their device is the
+// hwmon directory to tell several of them apart.
+func hwmonChip(fsys fileSystem, dir, name string) (chip, device string) {
+	dev, err := readFSLink(fsys, path.Join(dir, "device"))
+	if err != nil {
+		return name, path.Base(dir)
+	}
+	bus, err := readFSLink(fsys, path.Join(dir, "device/subsystem"))
+	if err != nil {
+		return name, path.Base(dev)
+	}
+	return path.Base(bus) + "_" + name, path.Base(dev)
+}
+
+// hwmonSensorLabel returns the label of a sensor like temp1, or an empty
+// string if the driver provides none. Labels aren't unique, not even within
+// a chip.
+func hwmonSensorLabel(fsys fileSystem, dir, sensor string) string {
+	label, err := readFSFile(fsys, path.Join(dir, sensor+"_label"))
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(label))
+}
+
+// hwmonPwmMode returns the fan control mode of a pwm*_enable value.
+func hwmonPwmMode(enable float64) string {
+	switch {
+	case enable == 0:
+		return "full-speed"
+	case enable == 1:
+		return "manual"
+	default:
+		return "auto"
+	}
+}
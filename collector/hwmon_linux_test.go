@@ -0,0 +1,56 @@
+// Copyright 2016 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collector
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestReadHwmon(t *testing.T) {
+	values, err := readHwmon(dirFS{root: func() string { return "fixtures/sys" }})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []hwmonValue{
+		{"platform_coretemp", "coretemp.0", "temp1", "Package id 0", "temp_crit", 100000},
+		{"platform_coretemp", "coretemp.0", "temp1", "Package id 0", "temp_input", 55000},
+		{"platform_coretemp", "coretemp.0", "temp1", "Package id 0", "temp_max", 80000},
+		{"platform_coretemp", "coretemp.0", "temp2", "Core 0", "temp_crit", 100000},
+		{"platform_coretemp", "coretemp.0", "temp2", "Core 0", "temp_input", 52000},
+		{"platform_coretemp", "coretemp.0", "temp2", "Core 0", "temp_max", 80000},
+		{"pci_nvme", "0000:3c:00.0", "temp1", "Composite", "temp_crit", 84850},
+		{"pci_nvme", "0000:3c:00.0", "temp1", "Composite", "temp_input", 38850},
+		{"platform_thinkpad", "thinkpad_hwmon", "fan1", "", "fan_input", 2493},
+		{"platform_thinkpad", "thinkpad_hwmon", "pwm1", "", "pwm_enable", 2},
+		{"acpitz", "hwmon3", "in0", "", "in_input", 12000},
+		{"acpitz", "hwmon3", "temp1", "", "temp_input", 27800},
+		{"pci_amdgpu", "0000:03:00.0", "fan1", "", "fan_input", 1210},
+		{"pci_amdgpu", "0000:03:00.0", "fan1", "", "fan_max", 3300},
+		{"pci_amdgpu", "0000:03:00.0", "fan1", "", "fan_target", 1500},
+		{"pci_amdgpu", "0000:03:00.0", "pwm1", "", "pwm_enable", 1},
+		{"pci_amdgpu", "0000:03:00.0", "temp1", "edge", "temp_input", 45000},
+	}
+	if !reflect.DeepEqual(want, values) {
+		t.Errorf("want %v, got %v", want, values)
+	}
+}
+
+func TestHwmonPwmMode(t *testing.T) {
+	for enable, want := range map[float64]string{0: "full-speed", 1: "manual", 2: "auto", 5: "auto"} {
+		if got := hwmonPwmMode(enable); want != got {
+			t.Errorf("%v: want %s, got %s", enable, want, got)
+		}
+	}
+}
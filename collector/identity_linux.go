@@ -0,0 +1,90 @@
+// Copyright 2016 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// +build !noidentity
+
+package collector
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var (
+	identityMachineIDPath = flag.String("collector.identity.machine-id-path", "/etc/machine-id", "File containing the machine ID.")
+	identityHashMachineID = flag.Bool("collector.identity.hash-machine-id", false, "Export the SHA-256 hash of the machine ID instead of the machine ID itself.")
+)
+
+type identityCollector struct {
+	info *prometheus.Desc
+}
+
+func init() {
+	Factories["identity"] = NewIdentityCollector
+}
+
+// NewIdentityCollector returns a new Collector exposing identifiers of the
+// host that survive relabeling of the instance label.
+func NewIdentityCollector() (Collector, error) {
+	return &identityCollector{
+		info: prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, "identity", "info"),
+			"Machine ID, hostname and boot ID of the host.",
+			[]string{"machine_id", "hostname", "boot_id"}, nil,
+		),
+	}, nil
+}
+
+func (c *identityCollector) Update(ch chan<- prometheus.Metric) (err error) {
+	fsys, name := rootfsFile(*identityMachineIDPath)
+	machineID, err := readMachineID(fsys, name, *identityHashMachineID)
+	if err != nil {
+		return err
+	}
+	hostname, err := readFSFile(procFS, "sys/kernel/hostname")
+	if err != nil {
+		return fmt.Errorf("couldn't get hostname: %s", err)
+	}
+	bootID, err := readFSFile(procFS, "sys/kernel/random/boot_id")
+	if err != nil {
+		return fmt.Errorf("couldn't get boot ID: %s", err)
+	}
+	ch <- prometheus.MustNewConstMetric(c.info, prometheus.GaugeValue, 1,
+		machineID, strings.TrimSpace(string(hostname)), strings.TrimSpace(string(bootID)))
+	return nil
+}
+
+// readMachineID returns the machine ID stored in the named file of fsys, or
+// the hex encoded SHA-256 hash of it if hash is set. A missing file results
+// in an empty ID, as not every distribution provides one.
+func readMachineID(fsys fileSystem, name string, hash bool) (string, error) {
+	data, err := readFSFile(fsys, name)
+	if os.IsNotExist(err) {
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("couldn't get machine ID: %s", err)
+	}
+	id := strings.TrimSpace(string(data))
+	if hash && id != "" {
+		sum := sha256.Sum256([]byte(id))
+		return hex.EncodeToString(sum[:]), nil
+	}
+	return id, nil
+}
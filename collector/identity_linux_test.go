@@ -0,0 +1,44 @@
+// Copyright 2016 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collector
+
+import (
+	"testing"
+)
+
+func TestReadMachineID(t *testing.T) {
+	id, err := readMachineID(dirFS{root: func() string { return "fixtures" }}, "etc/machine-id", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want, got := "5b9c1e5ddbd84c6c8f3a3a1a0f2d4e7b", id; want != got {
+		t.Errorf("want machine ID %q, got %q", want, got)
+	}
+
+	id, err = readMachineID(dirFS{root: func() string { return "fixtures" }}, "etc/machine-id", true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want, got := "c52018d5216d97089516daa691c2f44b0d56b9a033291a4507f9f439120ef91a", id; want != got {
+		t.Errorf("want hashed machine ID %q, got %q", want, got)
+	}
+
+	id, err = readMachineID(dirFS{root: func() string { return "fixtures" }}, "etc/does-not-exist", true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want, got := "", id; want != got {
+		t.Errorf("want empty machine ID, got %q", got)
+	}
+}
@@ -0,0 +1,37 @@
+// Copyright 2016 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collector
+
+import (
+	"os"
+	"path"
+)
+
+// readIIOValue returns the processed value of an IIO channel, e.g.
+// in_illuminance_input, or its raw value multiplied by the channel's scale
+// if the driver doesn't process it.
+func readIIOValue(fsys fileSystem, dir, channel string) (float64, error) {
+	if v, err := readFSFloat(fsys, path.Join(dir, channel+"_input")); !os.IsNotExist(err) {
+		return v, err
+	}
+	raw, err := readFSFloat(fsys, path.Join(dir, channel+"_raw"))
+	if err != nil {
+		return 0, err
+	}
+	scale, err := readFSFloat(fsys, path.Join(dir, channel+"_scale"))
+	if os.IsNotExist(err) {
+		return raw, nil
+	}
+	return raw * scale, err
+}
@@ -0,0 +1,104 @@
+// Copyright 2016 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collector
+
+import (
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// maxInternedStrings bounds the interner on hosts where names keep changing,
+// e.g. veth devices of short-lived containers.
+const maxInternedStrings = 4096
+
+// labelValues interns label values that repeat on every scrape, such as
+// device names.
+var labelValues = newStringInterner(maxInternedStrings)
+
+// stringInterner returns a canonical copy of strings seen before, so that
+// values parsed from procfs on each scrape don't keep the whole line they
+// were cut from alive and lookups from byte slices don't allocate.
+type stringInterner struct {
+	mtx     sync.Mutex
+	max     int
+	strings map[string]string
+}
+
+func newStringInterner(max int) *stringInterner {
+	return &stringInterner{max: max, strings: map[string]string{}}
+}
+
+func (i *stringInterner) intern(s string) string {
+	i.mtx.Lock()
+	defer i.mtx.Unlock()
+	if is, ok := i.strings[s]; ok {
+		return is
+	}
+	return i.add(string([]byte(s)))
+}
+
+// internBytes is like intern but only allocates if b wasn't seen before.
+func (i *stringInterner) internBytes(b []byte) string {
+	i.mtx.Lock()
+	defer i.mtx.Unlock()
+	if s, ok := i.strings[string(b)]; ok {
+		return s
+	}
+	return i.add(string(b))
+}
+
+func (i *stringInterner) add(s string) string {
+	if len(i.strings) >= i.max {
+		i.strings = map[string]string{}
+	}
+	i.strings[s] = s
+	return s
+}
+
+// descCache holds descriptors of metrics whose names are only known after
+// parsing, so that they are created once instead of on every scrape. It is
+// safe for concurrent scrapes.
+type descCache struct {
+	mtx   sync.Mutex
+	descs map[string]*prometheus.Desc
+}
+
+func newDescCache() *descCache {
+	return &descCache{descs: map[string]*prometheus.Desc{}}
+}
+
+// get returns the descriptor stored under key, calling newDesc to create it
+// on first use.
+func (c *descCache) get(key string, newDesc func() *prometheus.Desc) *prometheus.Desc {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	d, ok := c.descs[key]
+	if !ok {
+		d = newDesc()
+		c.descs[key] = d
+	}
+	return d
+}
+
+// all returns the descriptors created so far.
+func (c *descCache) all() []*prometheus.Desc {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	descs := make([]*prometheus.Desc, 0, len(c.descs))
+	for _, d := range c.descs {
+		descs = append(descs, d)
+	}
+	return descs
+}
@@ -0,0 +1,59 @@
+// Copyright 2016 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collector
+
+import (
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+func TestStringInterner(t *testing.T) {
+	i := newStringInterner(2)
+	line := []byte("sda 1 2 3")
+
+	first := i.internBytes(line[:3])
+	if want, got := "sda", first; want != got {
+		t.Fatalf("want %q, got %q", want, got)
+	}
+	if allocs := testing.AllocsPerRun(100, func() { i.internBytes(line[:3]) }); allocs != 0 {
+		t.Errorf("want no allocations for interned value, got %v", allocs)
+	}
+	if want, got := first, i.intern("sda"); want != got {
+		t.Errorf("want %q, got %q", want, got)
+	}
+
+	i.intern("sdb")
+	i.intern("sdc")
+	if want, got := 1, len(i.strings); want != got {
+		t.Errorf("want interner reset to %d entries, got %d", want, got)
+	}
+}
+
+func TestDescCache(t *testing.T) {
+	var (
+		c     = newDescCache()
+		calls int
+	)
+	newDesc := func() *prometheus.Desc {
+		calls++
+		return prometheus.NewDesc("node_test", "Test metric.", nil, nil)
+	}
+	if c.get("test", newDesc) != c.get("test", newDesc) {
+		t.Error("want the same descriptor for the same key")
+	}
+	if want, got := 1, calls; want != got {
+		t.Errorf("want %d descriptor created, got %d", want, got)
+	}
+}
@@ -20,7 +20,6 @@ import (
 	"errors"
 	"fmt"
 	"io"
-	"os"
 	"strconv"
 	"strings"
 
@@ -62,7 +61,7 @@ type interrupt struct {
 }
 
 func getInterrupts() (map[string]interrupt, error) {
-	file, err := os.Open(procFilePath("interrupts"))
+	file, err := procFS.Open("interrupts")
 	if err != nil {
 		return nil, err
 	}
@@ -81,6 +80,9 @@ func parseInterrupts(r io.Reader) (map[string]interrupt, error) {
 		return nil, errors.New("interrupts empty")
 	}
 	cpuNum := len(strings.Fields(string(scanner.Text()))) // one header per cpu
+	if cpuNum == 0 {
+		return nil, errors.New("no cpus in interrupts header")
+	}
 
 	for scanner.Scan() {
 		line := scanner.Text()
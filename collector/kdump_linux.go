@@ -0,0 +1,87 @@
+// Copyright 2016 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// +build !nokdump
+
+package collector
+
+import (
+	"os"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const kdumpSubsystem = "kdump"
+
+type kdumpCollector struct {
+	fs fileSystem
+
+	loaded          *prometheus.Desc
+	crashKernelSize *prometheus.Desc
+}
+
+func init() {
+	Factories["kdump"] = NewKdumpCollector
+	Maturities["kdump"] = MaturityExperimental
+}
+
+// NewKdumpCollector returns a new Collector exposing whether a crash kernel
+// is loaded to capture a dump on a kernel panic, and the memory reserved for
+// it with the crashkernel boot parameter.
+func NewKdumpCollector() (Collector, error) {
+	return newKdumpCollector(sysFS), nil
+}
+
+func newKdumpCollector(fsys fileSystem) *kdumpCollector {
+	return &kdumpCollector{
+		fs: fsys,
+		loaded: prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, kdumpSubsystem, "loaded"),
+			"Whether a crash kernel is loaded to capture a dump on a kernel panic.",
+			nil, nil,
+		),
+		crashKernelSize: prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, kdumpSubsystem, "crashkernel_size_bytes"),
+			"Memory reserved for the crash kernel, 0 if crashkernel isn't set or the reservation failed.",
+			nil, nil,
+		),
+	}
+}
+
+func (c *kdumpCollector) Update(ch chan<- prometheus.Metric) (err error) {
+	loaded, size, err := readKdump(c.fs)
+	if err != nil {
+		return err
+	}
+	ch <- prometheus.MustNewConstMetric(c.loaded, prometheus.GaugeValue, float64(loaded))
+	ch <- prometheus.MustNewConstMetric(c.crashKernelSize, prometheus.GaugeValue, float64(size))
+	return nil
+}
+
+// readKdump returns whether a crash kernel is loaded and the size of the
+// memory reserved for it. Kernels built without kexec have neither, and
+// can't capture dumps.
+func readKdump(fsys fileSystem) (loaded, size uint64, err error) {
+	loaded, err = readFSUint(fsys, "kernel/kexec_crash_loaded")
+	if os.IsNotExist(err) {
+		return 0, 0, nil
+	}
+	if err != nil {
+		return 0, 0, err
+	}
+	size, err = readFSUint(fsys, "kernel/kexec_crash_size")
+	if err != nil {
+		return 0, 0, err
+	}
+	return loaded, size, nil
+}
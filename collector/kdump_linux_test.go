@@ -0,0 +1,35 @@
+// Copyright 2016 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collector
+
+import "testing"
+
+func TestReadKdump(t *testing.T) {
+	for _, tt := range []struct {
+		name         string
+		fs           fileSystem
+		loaded, size uint64
+	}{
+		{"fixture", dirFS{root: func() string { return "fixtures/sys" }}, 1, 268435456},
+		{"without kexec", mapFS{}, 0, 0},
+	} {
+		loaded, size, err := readKdump(tt.fs)
+		if err != nil {
+			t.Fatalf("%s: %s", tt.name, err)
+		}
+		if tt.loaded != loaded || tt.size != size {
+			t.Errorf("%s: want %d, %d, got %d, %d", tt.name, tt.loaded, tt.size, loaded, size)
+		}
+	}
+}
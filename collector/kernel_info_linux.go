@@ -0,0 +1,181 @@
+// Copyright 2016 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// +build !nokernel_info
+
+package collector
+
+import (
+	"bufio"
+	"compress/gzip"
+	"flag"
+	"io"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/common/log"
+)
+
+const kernelSubsystem = "kernel"
+
+var kernelConfigOptions = flag.String("collector.kernel_info.config-options",
+	"CONFIG_ACPI_BATTERY,CONFIG_SENSORS_CORETEMP,CONFIG_HWMON,CONFIG_THERMAL,CONFIG_POWER_SUPPLY,CONFIG_TYPEC,CONFIG_PCIEASPM,CONFIG_PM,CONFIG_NUMA,CONFIG_KSM,CONFIG_BONDING,CONFIG_IP_VS,CONFIG_BPF_SYSCALL,CONFIG_DEBUG_INFO_BTF",
+	"Comma-separated kernel config options exposed by the kernel_info collector.")
+
+type kernelInfoCollector struct {
+	proc, sys, rootfs fileSystem
+	options           []string
+
+	// configBootID is the boot ID kernelConfig was read in.
+	configMtx    sync.Mutex
+	configBootID string
+	kernelConfig map[string]string
+
+	module *prometheus.Desc
+	config *prometheus.Desc
+}
+
+func init() {
+	Factories["kernel_info"] = NewKernelInfoCollector
+	Maturities["kernel_info"] = MaturityExperimental
+}
+
+// NewKernelInfoCollector returns a new Collector exposing the loaded kernel
+// modules from /proc/modules and selected options of the kernel config, to
+// diagnose collectors missing their drivers.
+func NewKernelInfoCollector() (Collector, error) {
+	var options []string
+	for _, o := range strings.Split(*kernelConfigOptions, ",") {
+		if o = strings.TrimSpace(o); o != "" {
+			options = append(options, o)
+		}
+	}
+	return &kernelInfoCollector{
+		proc:    procFS,
+		sys:     sysFS,
+		rootfs:  rootfsFS,
+		options: options,
+		module: prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, kernelSubsystem, "module_info"),
+			"Loaded kernel module and its version, if it declares one.",
+			[]string{"name", "version"}, nil,
+		),
+		config: prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, kernelSubsystem, "config_info"),
+			"Value of a kernel config option, y for built in, m for module and n for not set.",
+			[]string{"option", "value"}, nil,
+		),
+	}, nil
+}
+
+func (c *kernelInfoCollector) Update(ch chan<- prometheus.Metric) (err error) {
+	modules, err := readKernelModules(c.proc, c.sys)
+	if err != nil {
+		return err
+	}
+	for _, m := range modules {
+		ch <- prometheus.MustNewConstMetric(c.module, prometheus.GaugeValue, 1, m.name, m.version)
+	}
+
+	config, err := c.readKernelConfig()
+	if err != nil {
+		return err
+	}
+	if config == nil {
+		log.Debugf("kernel_info: no kernel config found in /proc/config.gz or /boot")
+		return nil
+	}
+	for _, o := range c.options {
+		v, ok := config[o]
+		if !ok {
+			v = "n"
+		}
+		ch <- prometheus.MustNewConstMetric(c.config, prometheus.GaugeValue, 1, o, v)
+	}
+	return nil
+}
+
+// readKernelConfig returns the kernel config, which only changes with a
+// reboot, so it is read again only when the boot ID changes. Without a boot
+// ID it is read every time.
+func (c *kernelInfoCollector) readKernelConfig() (map[string]string, error) {
+	bootID, err := readFSFile(c.proc, "sys/kernel/random/boot_id")
+	if err != nil {
+		return readKernelConfig(c.proc, c.rootfs)
+	}
+	c.configMtx.Lock()
+	defer c.configMtx.Unlock()
+	if c.configBootID == string(bootID) {
+		return c.kernelConfig, nil
+	}
+	config, err := readKernelConfig(c.proc, c.rootfs)
+	if err != nil {
+		return nil, err
+	}
+	c.configBootID, c.kernelConfig = string(bootID), config
+	return config, nil
+}
+
+// readKernelConfig returns the options of the running kernel's config from
+// config.gz of proc, or else from /boot/config-<release> of rootfs. It
+// returns nil if neither exists.
+func readKernelConfig(proc, rootfs fileSystem) (map[string]string, error) {
+	f, err := proc.Open("config.gz")
+	if err == nil {
+		defer f.Close()
+		gz, err := gzip.NewReader(f)
+		if err != nil {
+			return nil, err
+		}
+		return parseKernelConfig(gz)
+	}
+	if !os.IsNotExist(err) {
+		return nil, err
+	}
+
+	release, err := readFSFile(proc, "sys/kernel/osrelease")
+	if err != nil {
+		return nil, err
+	}
+	boot, err := rootfs.Open("boot/config-" + strings.TrimSpace(string(release)))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer boot.Close()
+	return parseKernelConfig(boot)
+}
+
+// parseKernelConfig parses a kernel config. Options that are not set map to
+// n, quotes of string values are removed.
+func parseKernelConfig(r io.Reader) (map[string]string, error) {
+	config := map[string]string{}
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if strings.HasPrefix(line, "# CONFIG_") && strings.HasSuffix(line, " is not set") {
+			config[strings.TrimSuffix(line[2:], " is not set")] = "n"
+			continue
+		}
+		parts := strings.SplitN(line, "=", 2)
+		if len(parts) != 2 || !strings.HasPrefix(parts[0], "CONFIG_") {
+			continue
+		}
+		config[parts[0]] = strings.Trim(parts[1], `"`)
+	}
+	return config, scanner.Err()
+}
@@ -0,0 +1,113 @@
+// Copyright 2016 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collector
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestReadKernelModules(t *testing.T) {
+	modules, err := readKernelModules(
+		dirFS{root: func() string { return "fixtures/proc" }},
+		dirFS{root: func() string { return "fixtures/sys" }},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []kernelModule{
+		{name: "e1000e", version: "3.2.6-k"},
+		{name: "coretemp"},
+		{name: "battery"},
+		{name: "bonding", version: "3.7.1"},
+		{name: "ptp"},
+	}
+	if !reflect.DeepEqual(want, modules) {
+		t.Errorf("want %+v, got %+v", want, modules)
+	}
+}
+
+func TestReadKernelConfig(t *testing.T) {
+	config, err := readKernelConfig(dirFS{root: func() string { return "fixtures/proc" }}, mapFS{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for option, want := range map[string]string{
+		"CONFIG_ACPI_BATTERY":     "m",
+		"CONFIG_HWMON":            "y",
+		"CONFIG_TYPEC":            "n",
+		"CONFIG_NR_CPUS":          "512",
+		"CONFIG_DEFAULT_HOSTNAME": "(none)",
+		"CONFIG_LOCALVERSION":     "",
+	} {
+		if got, ok := config[option]; !ok || want != got {
+			t.Errorf("%s: want %q, got %q", option, want, got)
+		}
+	}
+
+	// Without config.gz, the config of the release is looked up in /boot.
+	dir, err := ioutil.TempDir("", "node_exporter_kernel_info_test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	os.MkdirAll(filepath.Join(dir, "sys", "kernel"), 0755)
+	ioutil.WriteFile(filepath.Join(dir, "sys", "kernel", "osrelease"), []byte("0.0.0-nonexistent\n"), 0644)
+	config, err = readKernelConfig(dirFS{root: func() string { return dir }}, mapFS{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if config != nil {
+		t.Errorf("want no config without config.gz and /boot/config-0.0.0-nonexistent, got %d options", len(config))
+	}
+}
+
+func TestKernelConfigCachedPerBoot(t *testing.T) {
+	gzipped := func(s string) string {
+		var buf bytes.Buffer
+		w := gzip.NewWriter(&buf)
+		w.Write([]byte(s))
+		w.Close()
+		return buf.String()
+	}
+	proc := mapFS{
+		"config.gz":                 gzipped("CONFIG_HWMON=y\n"),
+		"sys/kernel/random/boot_id": "6f1b3c2e-0000-4000-8000-000000000001\n",
+	}
+	c := &kernelInfoCollector{proc: proc}
+	read := func() string {
+		config, err := c.readKernelConfig()
+		if err != nil {
+			t.Fatal(err)
+		}
+		return config["CONFIG_HWMON"]
+	}
+	if want, got := "y", read(); want != got {
+		t.Errorf("want CONFIG_HWMON %q, got %q", want, got)
+	}
+
+	proc["config.gz"] = gzipped("CONFIG_HWMON=m\n")
+	if want, got := "y", read(); want != got {
+		t.Errorf("want cached CONFIG_HWMON %q within the same boot, got %q", want, got)
+	}
+	proc["sys/kernel/random/boot_id"] = "6f1b3c2e-0000-4000-8000-000000000002\n"
+	if want, got := "m", read(); want != got {
+		t.Errorf("want CONFIG_HWMON %q after reboot, got %q", want, got)
+	}
+}
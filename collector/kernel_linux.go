@@ -0,0 +1,82 @@
+// Copyright 2016 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collector
+
+import (
+	"bufio"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// compareKernelReleases compares two kernel releases by their numeric and
+// non-numeric components, e.g. 4.4.0-9 < 4.4.0-21.
+func compareKernelReleases(a, b string) int {
+	pa, pb := splitRelease(a), splitRelease(b)
+	for i := 0; i < len(pa) && i < len(pb); i++ {
+		na, errA := strconv.Atoi(pa[i])
+		nb, errB := strconv.Atoi(pb[i])
+		switch {
+		case errA == nil && errB == nil:
+			if na != nb {
+				if na < nb {
+					return -1
+				}
+				return 1
+			}
+		case pa[i] != pb[i]:
+			if pa[i] < pb[i] {
+				return -1
+			}
+			return 1
+		}
+	}
+	return len(pa) - len(pb)
+}
+
+func splitRelease(s string) []string {
+	return strings.FieldsFunc(s, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+// kernelModule is a loaded kernel module. The version is only known for
+// modules declaring one.
+type kernelModule struct {
+	name, version string
+}
+
+// readKernelModules parses the modules file of proc and looks up the
+// versions of the modules in sys.
+func readKernelModules(proc, sys fileSystem) ([]kernelModule, error) {
+	f, err := proc.Open("modules")
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	var modules []kernelModule
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 {
+			continue
+		}
+		m := kernelModule{name: fields[0]}
+		if v, err := readFSFile(sys, "module/"+m.name+"/version"); err == nil {
+			m.version = strings.TrimSpace(string(v))
+		}
+		modules = append(modules, m)
+	}
+	return modules, scanner.Err()
+}
@@ -63,7 +63,7 @@ func NewKsmdCollector() (Collector, error) {
 // Expose kernel and system statistics.
 func (c *ksmdCollector) Update(ch chan<- prometheus.Metric) (err error) {
 	for _, n := range ksmdFiles {
-		val, err := readUintFromFile(sysFilePath(path.Join("kernel/mm/ksm", n)))
+		val, err := readFSUint(sysFS, path.Join("kernel/mm/ksm", n))
 		if err != nil {
 			return err
 		}
@@ -0,0 +1,151 @@
+// Copyright 2016 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collector
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// kstat is a single statistic as printed by the illumos and Solaris
+// `kstat -p` command, keyed module:instance:name:statistic.
+type kstat struct {
+	module    string
+	instance  int
+	name      string
+	statistic string
+	value     string
+}
+
+// parseKstat parses the parseable output of `kstat -p`, one tab separated
+// key and value per line.
+func parseKstat(r io.Reader) ([]kstat, error) {
+	var stats []kstat
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if line == "" {
+			continue
+		}
+		parts := strings.SplitN(line, "\t", 2)
+		if len(parts) != 2 {
+			return nil, fmt.Errorf("invalid kstat line: %q", line)
+		}
+		key := strings.SplitN(parts[0], ":", 4)
+		if len(key) != 4 {
+			return nil, fmt.Errorf("invalid kstat key: %q", parts[0])
+		}
+		instance, err := strconv.Atoi(key[1])
+		if err != nil {
+			return nil, fmt.Errorf("invalid kstat instance in %q: %s", parts[0], err)
+		}
+		stats = append(stats, kstat{
+			module:    key[0],
+			instance:  instance,
+			name:      key[2],
+			statistic: key[3],
+			value:     strings.TrimSpace(parts[1]),
+		})
+	}
+	return stats, scanner.Err()
+}
+
+// kstatCPUModes maps the nanosecond counters of the cpu:N:sys kstats to
+// the modes of node_cpu.
+var kstatCPUModes = map[string]string{
+	"cpu_nsec_user":   "user",
+	"cpu_nsec_kernel": "system",
+	"cpu_nsec_idle":   "idle",
+	"cpu_nsec_intr":   "irq",
+}
+
+// kstatCPUTimes returns the seconds spent per cpu and mode.
+func kstatCPUTimes(stats []kstat) (map[string]map[string]float64, error) {
+	times := map[string]map[string]float64{}
+	for _, s := range stats {
+		mode, ok := kstatCPUModes[s.statistic]
+		if s.module != "cpu" || s.name != "sys" || !ok {
+			continue
+		}
+		v, err := strconv.ParseUint(s.value, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid value for %s of cpu %d: %s", s.statistic, s.instance, err)
+		}
+		cpu := "cpu" + strconv.Itoa(s.instance)
+		if times[cpu] == nil {
+			times[cpu] = map[string]float64{}
+		}
+		times[cpu][mode] = float64(v) / 1e9
+	}
+	return times, nil
+}
+
+// kstatLinkStats maps the statistics of the link kstats to the keys the
+// Linux netdev collector reports.
+var kstatLinkStats = map[string]string{
+	"rbytes64":   "receive_bytes",
+	"ipackets64": "receive_packets",
+	"ierrors":    "receive_errs",
+	"norcvbuf":   "receive_drop",
+	"multircv":   "receive_multicast",
+	"obytes64":   "transmit_bytes",
+	"opackets64": "transmit_packets",
+	"oerrors":    "transmit_errs",
+	"noxmtbuf":   "transmit_drop",
+	"multixmt":   "transmit_multicast",
+}
+
+// kstatNetDevStats returns the statistics of each link, in the format of
+// getNetDevStats.
+func kstatNetDevStats(stats []kstat) map[string]map[string]string {
+	netDev := map[string]map[string]string{}
+	for _, s := range stats {
+		key, ok := kstatLinkStats[s.statistic]
+		if s.module != "link" || !ok {
+			continue
+		}
+		if netDev[s.name] == nil {
+			netDev[s.name] = map[string]string{}
+		}
+		netDev[s.name][key] = s.value
+	}
+	return netDev
+}
+
+// kstatMemInfo returns the memory sizes in bytes from the
+// unix:0:system_pages kstat.
+func kstatMemInfo(stats []kstat, pageSize int) (map[string]float64, error) {
+	keys := map[string]string{
+		"pagestotal":  "total_bytes",
+		"freemem":     "free_bytes",
+		"availrmem":   "available_bytes",
+		"pageslocked": "locked_bytes",
+	}
+	memInfo := map[string]float64{}
+	for _, s := range stats {
+		key, ok := keys[s.statistic]
+		if s.module != "unix" || s.name != "system_pages" || !ok {
+			continue
+		}
+		pages, err := strconv.ParseUint(s.value, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid value for %s: %s", s.statistic, err)
+		}
+		memInfo[key] = float64(pages) * float64(pageSize)
+	}
+	return memInfo, nil
+}
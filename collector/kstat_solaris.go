@@ -0,0 +1,34 @@
+// Copyright 2016 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// +build solaris
+
+package collector
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+)
+
+var kstatCommand = flag.String("collector.kstat.command", "kstat", "Command to read kernel statistics with on illumos and Solaris.")
+
+// readKstat returns the kernel statistics matching the selectors, each in
+// the module:instance:name:statistic syntax of kstat(1M).
+func readKstat(selectors ...string) ([]kstat, error) {
+	out, err := localeCommand(*kstatCommand, append([]string{"-p"}, selectors...)...).Output()
+	if err != nil {
+		return nil, fmt.Errorf("couldn't run %s: %s", *kstatCommand, err)
+	}
+	return parseKstat(bytes.NewReader(out))
+}
@@ -0,0 +1,96 @@
+// Copyright 2016 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collector
+
+import (
+	"os"
+	"strings"
+	"testing"
+)
+
+func readKstatFixture(t *testing.T) []kstat {
+	file, err := os.Open("fixtures/kstat")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+
+	stats, err := parseKstat(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return stats
+}
+
+func TestParseKstat(t *testing.T) {
+	stats := readKstatFixture(t)
+	if want, got := 25, len(stats); want != got {
+		t.Fatalf("want %d kstats, got %d", want, got)
+	}
+	want := kstat{module: "link", instance: 0, name: "net0", statistic: "ierrors", value: "0"}
+	if got := stats[9]; want != got {
+		t.Errorf("want %+v, got %+v", want, got)
+	}
+
+	for _, in := range []string{"cpu:0:sys:cpu_nsec_idle", "cpu:0:sys\t1", "cpu:x:sys:idle\t1"} {
+		if _, err := parseKstat(strings.NewReader(in)); err == nil {
+			t.Errorf("parseKstat(%q): expected error", in)
+		}
+	}
+}
+
+func TestKstatCPUTimes(t *testing.T) {
+	times, err := kstatCPUTimes(readKstatFixture(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want, got := 2, len(times); want != got {
+		t.Fatalf("want %d cpus, got %d", want, got)
+	}
+	if want, got := 73.25, times["cpu1"]["user"]; want != got {
+		t.Errorf("want cpu1 user %f, got %f", want, got)
+	}
+	if want, got := 92.5, times["cpu1"]["system"]; want != got {
+		t.Errorf("want cpu1 system %f, got %f", want, got)
+	}
+	if _, ok := times["cpu0"]["dtrace"]; ok {
+		t.Error("unexpected dtrace mode")
+	}
+}
+
+func TestKstatMemInfo(t *testing.T) {
+	memInfo, err := kstatMemInfo(readKstatFixture(t), 4096)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want, got := 2086599.0*4096, memInfo["total_bytes"]; want != got {
+		t.Errorf("want total %f, got %f", want, got)
+	}
+	if want, got := 1223019.0*4096, memInfo["free_bytes"]; want != got {
+		t.Errorf("want free %f, got %f", want, got)
+	}
+}
+
+func TestKstatNetDevStats(t *testing.T) {
+	netDev := kstatNetDevStats(readKstatFixture(t))
+	if want, got := "2406012937", netDev["net0"]["receive_bytes"]; want != got {
+		t.Errorf("want net0 receive_bytes %s, got %s", want, got)
+	}
+	if want, got := "603125", netDev["net0"]["transmit_packets"]; want != got {
+		t.Errorf("want net0 transmit_packets %s, got %s", want, got)
+	}
+	if _, ok := netDev["net0"]["link_state"]; ok {
+		t.Error("unexpected link_state")
+	}
+}
@@ -0,0 +1,201 @@
+// Copyright 2016 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// +build !nokubelet
+
+package collector
+
+import (
+	"crypto/tls"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const kubeletSubsystem = "kubelet"
+
+var (
+	kubeletURL             = flag.String("collector.kubelet.url", "http://localhost:10255/stats/summary", "URL of the kubelet stats summary endpoint.")
+	kubeletBearerTokenFile = flag.String("collector.kubelet.bearer-token-file", "", "File containing the bearer token to authenticate against the kubelet.")
+	kubeletInsecure        = flag.Bool("collector.kubelet.insecure-skip-verify", false, "Do not verify the kubelet's TLS certificate.")
+	kubeletTimeout         = flag.Duration("collector.kubelet.timeout", 10*time.Second, "Timeout for requests to the kubelet.")
+)
+
+// kubeletFsStats is the subset of the kubelet's FsStats used here.
+type kubeletFsStats struct {
+	AvailableBytes *uint64 `json:"availableBytes"`
+	CapacityBytes  *uint64 `json:"capacityBytes"`
+	UsedBytes      *uint64 `json:"usedBytes"`
+	InodesFree     *uint64 `json:"inodesFree"`
+	Inodes         *uint64 `json:"inodes"`
+}
+
+// kubeletSummary is the subset of the kubelet's stats summary used here.
+type kubeletSummary struct {
+	Node struct {
+		Fs      *kubeletFsStats `json:"fs"`
+		Runtime *struct {
+			ImageFs *kubeletFsStats `json:"imageFs"`
+		} `json:"runtime"`
+	} `json:"node"`
+	Pods []struct {
+		EphemeralStorage *kubeletFsStats `json:"ephemeral-storage"`
+	} `json:"pods"`
+}
+
+type kubeletCollector struct {
+	client *http.Client
+	url    string
+	token  string
+
+	pods             *prometheus.Desc
+	podsEphemeral    *prometheus.Desc
+	fsAvailable      *prometheus.Desc
+	fsCapacity       *prometheus.Desc
+	fsInodesFree     *prometheus.Desc
+	fsInodes         *prometheus.Desc
+	fsAvailableRatio *prometheus.Desc
+}
+
+func init() {
+	Factories["kubelet"] = NewKubeletCollector
+}
+
+// NewKubeletCollector returns a new Collector mirroring pod count and
+// ephemeral storage pressure from the local kubelet's stats summary.
+func NewKubeletCollector() (Collector, error) {
+	var token string
+	if *kubeletBearerTokenFile != "" {
+		data, err := readFSFile(localFS(*kubeletBearerTokenFile))
+		if err != nil {
+			return nil, fmt.Errorf("couldn't read kubelet bearer token: %s", err)
+		}
+		token = strings.TrimSpace(string(data))
+	}
+	client := &http.Client{
+		Timeout: *kubeletTimeout,
+		Transport: &http.Transport{
+			TLSClientConfig: &tls.Config{InsecureSkipVerify: *kubeletInsecure},
+		},
+	}
+	return newKubeletCollector(client, *kubeletURL, token), nil
+}
+
+func newKubeletCollector(client *http.Client, url, token string) *kubeletCollector {
+	fsLabels := []string{"filesystem"}
+	return &kubeletCollector{
+		client: client,
+		url:    url,
+		token:  token,
+		pods: prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, kubeletSubsystem, "pods"),
+			"Number of pods running on the node according to the kubelet.",
+			nil, nil,
+		),
+		podsEphemeral: prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, kubeletSubsystem, "pods_ephemeral_storage_used_bytes"),
+			"Ephemeral storage used by all pods on the node.",
+			nil, nil,
+		),
+		fsAvailable: prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, kubeletSubsystem, "filesystem_available_bytes"),
+			"Bytes available on the kubelet filesystem as seen by the kubelet's eviction manager.",
+			fsLabels, nil,
+		),
+		fsCapacity: prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, kubeletSubsystem, "filesystem_capacity_bytes"),
+			"Capacity of the kubelet filesystem.",
+			fsLabels, nil,
+		),
+		fsInodesFree: prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, kubeletSubsystem, "filesystem_inodes_free"),
+			"Free inodes on the kubelet filesystem.",
+			fsLabels, nil,
+		),
+		fsInodes: prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, kubeletSubsystem, "filesystem_inodes"),
+			"Total inodes on the kubelet filesystem.",
+			fsLabels, nil,
+		),
+		fsAvailableRatio: prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, kubeletSubsystem, "filesystem_available_ratio"),
+			"Ratio of available to total bytes of the kubelet filesystem, the signal used for nodefs and imagefs pressure.",
+			fsLabels, nil,
+		),
+	}
+}
+
+func (c *kubeletCollector) Update(ch chan<- prometheus.Metric) (err error) {
+	req, err := http.NewRequest("GET", c.url, nil)
+	if err != nil {
+		return err
+	}
+	if c.token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.token)
+	}
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("couldn't query kubelet: %s", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("kubelet returned %s", resp.Status)
+	}
+
+	var summary kubeletSummary
+	if err := json.NewDecoder(resp.Body).Decode(&summary); err != nil {
+		return fmt.Errorf("couldn't decode kubelet summary: %s", err)
+	}
+
+	var ephemeral uint64
+	for _, p := range summary.Pods {
+		if p.EphemeralStorage != nil && p.EphemeralStorage.UsedBytes != nil {
+			ephemeral += *p.EphemeralStorage.UsedBytes
+		}
+	}
+	ch <- prometheus.MustNewConstMetric(c.pods, prometheus.GaugeValue, float64(len(summary.Pods)))
+	ch <- prometheus.MustNewConstMetric(c.podsEphemeral, prometheus.GaugeValue, float64(ephemeral))
+
+	c.collectFs(ch, "nodefs", summary.Node.Fs)
+	if summary.Node.Runtime != nil {
+		c.collectFs(ch, "imagefs", summary.Node.Runtime.ImageFs)
+	}
+	return nil
+}
+
+func (c *kubeletCollector) collectFs(ch chan<- prometheus.Metric, name string, fs *kubeletFsStats) {
+	if fs == nil {
+		return
+	}
+	if fs.AvailableBytes != nil {
+		ch <- prometheus.MustNewConstMetric(c.fsAvailable, prometheus.GaugeValue, float64(*fs.AvailableBytes), name)
+	}
+	if fs.CapacityBytes != nil {
+		ch <- prometheus.MustNewConstMetric(c.fsCapacity, prometheus.GaugeValue, float64(*fs.CapacityBytes), name)
+	}
+	if fs.InodesFree != nil {
+		ch <- prometheus.MustNewConstMetric(c.fsInodesFree, prometheus.GaugeValue, float64(*fs.InodesFree), name)
+	}
+	if fs.Inodes != nil {
+		ch <- prometheus.MustNewConstMetric(c.fsInodes, prometheus.GaugeValue, float64(*fs.Inodes), name)
+	}
+	if fs.AvailableBytes != nil && fs.CapacityBytes != nil && *fs.CapacityBytes > 0 {
+		ratio := float64(*fs.AvailableBytes) / float64(*fs.CapacityBytes)
+		ch <- prometheus.MustNewConstMetric(c.fsAvailableRatio, prometheus.GaugeValue, ratio, name)
+	}
+}
@@ -0,0 +1,65 @@
+// Copyright 2016 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collector
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+)
+
+func TestKubeletCollector(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer secret" {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		http.ServeFile(w, r, "fixtures/kubelet-summary.json")
+	}))
+	defer server.Close()
+
+	c := newKubeletCollector(http.DefaultClient, server.URL, "secret")
+	ch := make(chan prometheus.Metric, 32)
+	if err := c.Update(ch); err != nil {
+		t.Fatal(err)
+	}
+	close(ch)
+
+	values := map[string]float64{}
+	for m := range ch {
+		pb := &dto.Metric{}
+		m.Write(pb)
+		key := m.Desc().String()
+		for _, l := range pb.Label {
+			key += l.GetValue()
+		}
+		values[key] = pb.Gauge.GetValue()
+	}
+
+	if want, got := 3.0, values[c.pods.String()]; want != got {
+		t.Errorf("want %f pods, got %f", want, got)
+	}
+	if want, got := 3145728.0, values[c.podsEphemeral.String()]; want != got {
+		t.Errorf("want %f ephemeral bytes, got %f", want, got)
+	}
+	if want, got := 0.25, values[c.fsAvailableRatio.String()+"nodefs"]; want != got {
+		t.Errorf("want nodefs available ratio %f, got %f", want, got)
+	}
+	if want, got := 0.5, values[c.fsAvailableRatio.String()+"imagefs"]; want != got {
+		t.Errorf("want imagefs available ratio %f, got %f", want, got)
+	}
+}
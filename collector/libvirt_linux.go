@@ -0,0 +1,202 @@
+// Copyright 2016 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// This collector is only built with -tags libvirt, as most hosts are not
+// hypervisors.
+
+// +build libvirt
+
+package collector
+
+import (
+	"bufio"
+	"bytes"
+	"flag"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const libvirtSubsystem = "libvirt"
+
+var (
+	virshCommand = flag.String("collector.libvirt.virsh-command", "virsh", "Command to run virsh.")
+	libvirtURI   = flag.String("collector.libvirt.uri", "qemu:///system", "Libvirt connection URI.")
+)
+
+// libvirtDomain holds the raw statistics of a domain as printed by
+// virsh domstats, keyed by statistic name.
+type libvirtDomain struct {
+	name  string
+	stats map[string]string
+}
+
+type libvirtCollector struct {
+	state      *prometheus.Desc
+	cpu        *prometheus.Desc
+	vcpu       *prometheus.Desc
+	balloon    *prometheus.Desc
+	blockRead  *prometheus.Desc
+	blockWrite *prometheus.Desc
+	netRx      *prometheus.Desc
+	netTx      *prometheus.Desc
+}
+
+func init() {
+	Factories["libvirt"] = NewLibvirtCollector
+}
+
+// NewLibvirtCollector returns a new Collector exposing per domain resource
+// usage of libvirt guests.
+func NewLibvirtCollector() (Collector, error) {
+	return &libvirtCollector{
+		state: prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, libvirtSubsystem, "domain_state"),
+			"Libvirt state of the domain (1 running, 3 paused, 5 shut off, ...).",
+			[]string{"domain"}, nil,
+		),
+		cpu: prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, libvirtSubsystem, "domain_cpu_seconds_total"),
+			"CPU time used by the domain.",
+			[]string{"domain"}, nil,
+		),
+		vcpu: prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, libvirtSubsystem, "domain_vcpu_seconds_total"),
+			"CPU time used by each virtual CPU of the domain.",
+			[]string{"domain", "vcpu"}, nil,
+		),
+		balloon: prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, libvirtSubsystem, "domain_balloon_bytes"),
+			"Current memory balloon size of the domain.",
+			[]string{"domain"}, nil,
+		),
+		blockRead: prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, libvirtSubsystem, "domain_block_read_bytes_total"),
+			"Number of bytes read from the block device.",
+			[]string{"domain", "device"}, nil,
+		),
+		blockWrite: prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, libvirtSubsystem, "domain_block_written_bytes_total"),
+			"Number of bytes written to the block device.",
+			[]string{"domain", "device"}, nil,
+		),
+		netRx: prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, libvirtSubsystem, "domain_network_receive_bytes_total"),
+			"Number of bytes received on the network interface.",
+			[]string{"domain", "interface"}, nil,
+		),
+		netTx: prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, libvirtSubsystem, "domain_network_transmit_bytes_total"),
+			"Number of bytes transmitted on the network interface.",
+			[]string{"domain", "interface"}, nil,
+		),
+	}, nil
+}
+
+func (c *libvirtCollector) Update(ch chan<- prometheus.Metric) (err error) {
+	out, err := localeCommand(*virshCommand, "--connect", *libvirtURI, "--readonly", "domstats", "--raw").Output()
+	if err != nil {
+		return fmt.Errorf("couldn't get domain stats: %s", err)
+	}
+	domains, err := parseVirshDomstats(bytes.NewReader(out))
+	if err != nil {
+		return err
+	}
+
+	for _, d := range domains {
+		if v, ok := d.float("state.state"); ok {
+			ch <- prometheus.MustNewConstMetric(c.state, prometheus.GaugeValue, v, d.name)
+		}
+		if v, ok := d.float("cpu.time"); ok {
+			ch <- prometheus.MustNewConstMetric(c.cpu, prometheus.CounterValue, v/1e9, d.name)
+		}
+		if v, ok := d.float("balloon.current"); ok {
+			// Reported in KiB.
+			ch <- prometheus.MustNewConstMetric(c.balloon, prometheus.GaugeValue, v*1024, d.name)
+		}
+		n, _ := d.float("vcpu.maximum")
+		for i := 0; i < int(n); i++ {
+			if v, ok := d.float(fmt.Sprintf("vcpu.%d.time", i)); ok {
+				ch <- prometheus.MustNewConstMetric(c.vcpu, prometheus.CounterValue, v/1e9, d.name, strconv.Itoa(i))
+			}
+		}
+		n, _ = d.float("block.count")
+		for i := 0; i < int(n); i++ {
+			prefix := fmt.Sprintf("block.%d.", i)
+			dev := d.stats[prefix+"name"]
+			if v, ok := d.float(prefix + "rd.bytes"); ok {
+				ch <- prometheus.MustNewConstMetric(c.blockRead, prometheus.CounterValue, v, d.name, dev)
+			}
+			if v, ok := d.float(prefix + "wr.bytes"); ok {
+				ch <- prometheus.MustNewConstMetric(c.blockWrite, prometheus.CounterValue, v, d.name, dev)
+			}
+		}
+		n, _ = d.float("net.count")
+		for i := 0; i < int(n); i++ {
+			prefix := fmt.Sprintf("net.%d.", i)
+			iface := d.stats[prefix+"name"]
+			if v, ok := d.float(prefix + "rx.bytes"); ok {
+				ch <- prometheus.MustNewConstMetric(c.netRx, prometheus.CounterValue, v, d.name, iface)
+			}
+			if v, ok := d.float(prefix + "tx.bytes"); ok {
+				ch <- prometheus.MustNewConstMetric(c.netTx, prometheus.CounterValue, v, d.name, iface)
+			}
+		}
+	}
+	return nil
+}
+
+func (d *libvirtDomain) float(key string) (float64, bool) {
+	s, ok := d.stats[key]
+	if !ok {
+		return 0, false
+	}
+	v, err := strconv.ParseFloat(s, 64)
+	return v, err == nil
+}
+
+func parseVirshDomstats(r io.Reader) ([]*libvirtDomain, error) {
+	var (
+		domains []*libvirtDomain
+		current *libvirtDomain
+		scanner = bufio.NewScanner(r)
+	)
+
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		if strings.HasPrefix(line, "Domain:") {
+			current = &libvirtDomain{
+				name:  strings.Trim(strings.TrimSpace(line[len("Domain:"):]), "'"),
+				stats: map[string]string{},
+			}
+			domains = append(domains, current)
+			continue
+		}
+		if current == nil {
+			return nil, fmt.Errorf("statistic outside of domain: %q", line)
+		}
+		parts := strings.SplitN(line, "=", 2)
+		if len(parts) != 2 {
+			return nil, fmt.Errorf("invalid statistic line: %q", line)
+		}
+		current.stats[parts[0]] = parts[1]
+	}
+
+	return domains, scanner.Err()
+}
@@ -0,0 +1,51 @@
+// Copyright 2016 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// +build libvirt
+
+package collector
+
+import (
+	"os"
+	"testing"
+)
+
+func TestParseVirshDomstats(t *testing.T) {
+	file, err := os.Open("fixtures/virsh-domstats.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+
+	domains, err := parseVirshDomstats(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want, got := 2, len(domains); want != got {
+		t.Fatalf("want %d domains, got %d", want, got)
+	}
+
+	web := domains[0]
+	if want, got := "web01", web.name; want != got {
+		t.Errorf("want domain %q, got %q", want, got)
+	}
+	if v, _ := web.float("balloon.current"); v != 2097152 {
+		t.Errorf("want balloon size 2097152, got %f", v)
+	}
+	if want, got := "vda", web.stats["block.0.name"]; want != got {
+		t.Errorf("want block device %q, got %q", want, got)
+	}
+	if v, _ := domains[1].float("state.state"); v != 5 {
+		t.Errorf("want db01 to be shut off, got state %f", v)
+	}
+}
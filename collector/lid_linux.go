@@ -0,0 +1,256 @@
+// Copyright 2016 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// +build !nolid
+
+package collector
+
+import (
+	"os"
+	"path"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+	"unsafe"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/common/log"
+)
+
+const (
+	lidSubsystem = "lid"
+
+	// Switches of linux/input-event-codes.h.
+	swLid        = 0
+	swTabletMode = 1
+)
+
+type lidCollector struct {
+	proc, sys fileSystem
+	// readSwitches returns the state of the switches of an event device.
+	readSwitches func(event string) (uint64, error)
+	// events receives the changes between reads, nil disables recording.
+	events *EventLog
+
+	mtx  sync.Mutex
+	last map[string]lidState
+
+	open       *prometheus.Desc
+	tabletMode *prometheus.Desc
+}
+
+// lidState is the state of an ACPI lid or of an input device with lid or
+// tablet mode switches. Switches the device doesn't have are -1.
+type lidState struct {
+	device           string
+	open, tabletMode int
+}
+
+func init() {
+	Factories["lid"] = NewLidCollector
+	Maturities["lid"] = MaturityExperimental
+}
+
+// NewLidCollector returns a new Collector exposing whether the lid of a
+// laptop is open and whether a convertible is in tablet mode, read from
+// /proc/acpi/button and the switches of input devices. Changes between
+// scrapes are recorded in Events.
+func NewLidCollector() (Collector, error) {
+	return newLidCollector(procFS, sysFS, readEventSwitches, Events), nil
+}
+
+func newLidCollector(proc, sys fileSystem, readSwitches func(string) (uint64, error), events *EventLog) *lidCollector {
+	return &lidCollector{
+		proc:         proc,
+		sys:          sys,
+		readSwitches: readSwitches,
+		events:       events,
+		open: prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, lidSubsystem, "open"),
+			"Whether the lid is open.",
+			[]string{"device"}, nil,
+		),
+		tabletMode: prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, lidSubsystem, "tablet_mode"),
+			"Whether the device is folded or detached into tablet mode.",
+			[]string{"device"}, nil,
+		),
+	}
+}
+
+func (c *lidCollector) Update(ch chan<- prometheus.Metric) (err error) {
+	states, err := readLidStates(c.proc, c.sys, c.readSwitches)
+	if err != nil {
+		return err
+	}
+	c.recordEvents(states, time.Now())
+	for _, s := range states {
+		if s.open >= 0 {
+			ch <- prometheus.MustNewConstMetric(c.open, prometheus.GaugeValue, float64(s.open), s.device)
+		}
+		if s.tabletMode >= 0 {
+			ch <- prometheus.MustNewConstMetric(c.tabletMode, prometheus.GaugeValue, float64(s.tabletMode), s.device)
+		}
+	}
+	return nil
+}
+
+func (c *lidCollector) recordEvents(states []lidState, now time.Time) {
+	if c.events == nil {
+		return
+	}
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	last := make(map[string]lidState, len(states))
+	for _, s := range states {
+		last[s.device] = s
+		prev, ok := c.last[s.device]
+		if !ok {
+			continue
+		}
+		if prev.open != s.open {
+			c.events.Record(Event{Time: now, Collector: lidSubsystem, Source: s.device, Kind: "lid",
+				From: lidSwitchState(prev.open, "open", "closed"), To: lidSwitchState(s.open, "open", "closed")})
+		}
+		if prev.tabletMode != s.tabletMode {
+			c.events.Record(Event{Time: now, Collector: lidSubsystem, Source: s.device, Kind: "tablet_mode",
+				From: lidSwitchState(prev.tabletMode, "on", "off"), To: lidSwitchState(s.tabletMode, "on", "off")})
+		}
+	}
+	c.last = last
+}
+
+func lidSwitchState(v int, on, off string) string {
+	switch v {
+	case 1:
+		return on
+	case 0:
+		return off
+	}
+	return ""
+}
+
+// readLidStates returns the lids in acpi/button/lid and the input devices
+// in class/input with lid or tablet mode switches. ACPI lids also have an
+// input device, whose lid switch is left out as it would duplicate the ACPI
+// state, which unlike the input device is readable without root.
+func readLidStates(proc, sys fileSystem, readSwitches func(string) (uint64, error)) ([]lidState, error) {
+	files, err := proc.Glob("acpi/button/lid/*/state")
+	if err != nil {
+		return nil, err
+	}
+	var states []lidState
+	for _, file := range files {
+		data, err := readFSFile(proc, file)
+		if err != nil {
+			return nil, err
+		}
+		// e.g. "state:      open"
+		s := lidState{device: path.Base(path.Dir(file)), open: -1, tabletMode: -1}
+		switch strings.TrimSpace(strings.TrimPrefix(string(data), "state:")) {
+		case "open":
+			s.open = 1
+		case "closed":
+			s.open = 0
+		}
+		states = append(states, s)
+	}
+	acpiLid := len(states) > 0
+
+	files, err = sys.Glob("class/input/input*/capabilities/sw")
+	if err != nil {
+		return nil, err
+	}
+	for _, file := range files {
+		data, err := readFSFile(sys, file)
+		if err != nil {
+			return nil, err
+		}
+		// The capabilities are a bitmap of longs in hex, the most
+		// significant first.
+		fields := strings.Fields(string(data))
+		if len(fields) == 0 {
+			continue
+		}
+		caps, err := strconv.ParseUint(fields[len(fields)-1], 16, 64)
+		if err != nil {
+			return nil, err
+		}
+		hasLid := caps&(1<<swLid) != 0 && !acpiLid
+		hasTabletMode := caps&(1<<swTabletMode) != 0
+		if !hasLid && !hasTabletMode {
+			continue
+		}
+		dir := path.Dir(path.Dir(file))
+		events, err := sys.Glob(path.Join(dir, "event*"))
+		if err != nil {
+			return nil, err
+		}
+		if len(events) == 0 {
+			continue
+		}
+		sw, err := readSwitches(path.Base(events[0]))
+		// Event devices are usually only readable by root, and
+		// missing in containers without /dev/input.
+		if os.IsPermission(err) || os.IsNotExist(err) {
+			log.Debugf("Skipping switches of input device %s: %s", path.Base(dir), err)
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		s := lidState{device: path.Base(dir), open: -1, tabletMode: -1}
+		if hasLid {
+			// The switch is on when the lid is closed.
+			s.open = int(^sw >> swLid & 1)
+		}
+		if hasTabletMode {
+			s.tabletMode = int(sw >> swTabletMode & 1)
+		}
+		states = append(states, s)
+	}
+	return states, nil
+}
+
+// readEventSwitches returns the state of the switches of the named event
+// device in /dev/input, as a bitmap indexed by the switch codes.
+func readEventSwitches(event string) (uint64, error) {
+	f, err := openFSFile(devFS, path.Join("input", event), os.O_RDONLY)
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+
+	// The kernel copies the bitmap as an array of longs, all switches fit
+	// into the first.
+	var sw uintptr
+	_, _, errno := syscall.Syscall(syscall.SYS_IOCTL, f.Fd(), eviocgsw(unsafe.Sizeof(sw)), uintptr(unsafe.Pointer(&sw)))
+	if errno != 0 {
+		return 0, errno
+	}
+	return uint64(sw), nil
+}
+
+// eviocgsw returns the EVIOCGSW ioctl request reading size bytes of the
+// switch bitmap. The direction bits differ on MIPS and POWER.
+func eviocgsw(size uintptr) uintptr {
+	read := uintptr(2) << 30
+	switch runtime.GOARCH {
+	case "mips", "mipsle", "mips64", "mips64le", "ppc64", "ppc64le":
+		read = 2 << 29
+	}
+	return read | size<<16 | 'E'<<8 | 0x1b
+}
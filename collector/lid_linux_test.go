@@ -0,0 +1,80 @@
+// Copyright 2016 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collector
+
+import (
+	"os"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestReadLidStates(t *testing.T) {
+	var read []string
+	readSwitches := func(event string) (uint64, error) {
+		read = append(read, event)
+		return 1<<swLid | 1<<swTabletMode, nil
+	}
+	states, err := readLidStates(
+		dirFS{root: func() string { return "fixtures/proc" }},
+		dirFS{root: func() string { return "fixtures/sys" }},
+		readSwitches,
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []lidState{
+		{device: "LID0", open: 1, tabletMode: -1},
+		{device: "input7", open: -1, tabletMode: 1},
+	}
+	if !reflect.DeepEqual(want, states) {
+		t.Errorf("want %+v, got %+v", want, states)
+	}
+	if want := []string{"event7"}; !reflect.DeepEqual(want, read) {
+		t.Errorf("want switches of %v read, got %v", want, read)
+	}
+}
+
+func TestReadLidStatesInput(t *testing.T) {
+	sys := mapFS{
+		"class/input/input2/capabilities/sw": "0 1\n",
+		"class/input/input2/event2/dev":      "13:66\n",
+		"class/input/input5/capabilities/sw": "2\n",
+		"class/input/input5/event5/dev":      "13:69\n",
+	}
+	states, err := readLidStates(mapFS{}, sys, func(event string) (uint64, error) {
+		if event == "event5" {
+			return 0, &os.PathError{Op: "open", Path: event, Err: os.ErrPermission}
+		}
+		return 1 << swLid, nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []lidState{{device: "input2", open: 0, tabletMode: -1}}; !reflect.DeepEqual(want, states) {
+		t.Errorf("want %+v, got %+v", want, states)
+	}
+}
+
+func TestLidEvents(t *testing.T) {
+	events := NewEventLog(10)
+	c := newLidCollector(mapFS{}, mapFS{}, nil, events)
+	at := time.Unix(1500000000, 0)
+	c.recordEvents([]lidState{{device: "LID0", open: 1, tabletMode: -1}}, at)
+	c.recordEvents([]lidState{{device: "LID0", open: 0, tabletMode: -1}}, at)
+	want := []Event{{Time: at, Collector: "lid", Source: "LID0", Kind: "lid", From: "open", To: "closed"}}
+	if got := events.List(); !reflect.DeepEqual(want, got) {
+		t.Errorf("want %+v, got %+v", want, got)
+	}
+}
@@ -17,14 +17,13 @@ package collector
 
 import (
 	"fmt"
-	"io/ioutil"
 	"strconv"
 	"strings"
 )
 
 // Read loadavg from /proc.
 func getLoad() (loads []float64, err error) {
-	data, err := ioutil.ReadFile(procFilePath("loadavg"))
+	data, err := readFSFile(procFS, "loadavg")
 	if err != nil {
 		return nil, err
 	}
@@ -40,7 +39,7 @@ func parseLoad(data string) (loads []float64, err error) {
 	loads = make([]float64, 3)
 	parts := strings.Fields(data)
 	if len(parts) < 3 {
-		return nil, fmt.Errorf("unexpected content in %s", procFilePath("loadavg"))
+		return nil, fmt.Errorf("unexpected content in loadavg")
 	}
 	for i, load := range parts[0:3] {
 		loads[i], err = strconv.ParseFloat(load, 64)
@@ -13,6 +13,7 @@
 
 // +build darwin dragonfly freebsd netbsd openbsd solaris
 // +build !noloadavg
+// +build cgo
 
 package collector
 
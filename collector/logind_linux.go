@@ -17,8 +17,7 @@ package collector
 
 import (
 	"fmt"
-	"os"
-	"strconv"
+	"time"
 
 	"github.com/godbus/dbus"
 	"github.com/prometheus/client_golang/prometheus"
@@ -37,13 +36,15 @@ var (
 	attrTypeValues   = []string{"other", "unspecified", "tty", "x11", "wayland", "mir", "web"}
 	attrClassValues  = []string{"other", "user", "greeter", "lock-screen", "background"}
 
-	sessionsDesc = prometheus.NewDesc(
-		prometheus.BuildFQName(Namespace, logindSubsystem, "sessions"),
-		"Number of sessions registered in logind.", []string{"seat", "remote", "type", "class"}, nil,
-	)
+	// Session types for which lock and idle state is exported.
+	graphicalTypeValues = []string{"x11", "wayland", "mir"}
 )
 
-type logindCollector struct{}
+type logindCollector struct {
+	sessions      *prometheus.Desc
+	sessionLocked *prometheus.Desc
+	sessionIdle   *prometheus.Desc
+}
 
 type logindDbus struct {
 	conn   *dbus.Conn
@@ -54,6 +55,7 @@ type logindInterface interface {
 	listSeats() ([]string, error)
 	listSessions() ([]logindSessionEntry, error)
 	getSession(logindSessionEntry) *logindSession
+	getSessionState(logindSessionEntry) *logindSessionState
 }
 
 type logindSession struct {
@@ -63,6 +65,12 @@ type logindSession struct {
 	class       string
 }
 
+type logindSessionState struct {
+	locked    bool
+	idle      bool
+	idleSince time.Time
+}
+
 // Struct elements must be public for the reflection magic of godbus to work.
 type logindSessionEntry struct {
 	SessionId         string
@@ -84,7 +92,20 @@ func init() {
 // Takes a prometheus registry and returns a new Collector exposing
 // logind statistics.
 func NewLogindCollector() (Collector, error) {
-	return &logindCollector{}, nil
+	return &logindCollector{
+		sessions: prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, logindSubsystem, "sessions"),
+			"Number of sessions registered in logind.", []string{"seat", "remote", "type", "class"}, nil,
+		),
+		sessionLocked: prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, logindSubsystem, "session_locked"),
+			"Whether the graphical session's screen is locked.", []string{"session", "user", "seat"}, nil,
+		),
+		sessionIdle: prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, logindSubsystem, "session_idle_seconds"),
+			"Number of seconds the graphical session has been idle, 0 if it is not idle.", []string{"session", "user", "seat"}, nil,
+		),
+	}, nil
 }
 
 func (lc *logindCollector) Update(ch chan<- prometheus.Metric) error {
@@ -94,10 +115,10 @@ func (lc *logindCollector) Update(ch chan<- prometheus.Metric) error {
 	}
 	defer c.conn.Close()
 
-	return collectMetrics(ch, c)
+	return lc.collectMetrics(ch, c)
 }
 
-func collectMetrics(ch chan<- prometheus.Metric, c logindInterface) error {
+func (lc *logindCollector) collectMetrics(ch chan<- prometheus.Metric, c logindInterface) error {
 	seats, err := c.listSeats()
 	if err != nil {
 		return fmt.Errorf("unable to get seats: %s", err)
@@ -109,12 +130,35 @@ func collectMetrics(ch chan<- prometheus.Metric, c logindInterface) error {
 	}
 
 	sessions := make(map[logindSession]float64)
+	now := time.Now()
 
 	for _, s := range sessionList {
 		session := c.getSession(s)
-		if session != nil {
-			sessions[*session]++
+		if session == nil {
+			continue
+		}
+		sessions[*session]++
+
+		if knownStringOrOther(session.sessionType, graphicalTypeValues) == "other" {
+			continue
 		}
+		state := c.getSessionState(s)
+		if state == nil {
+			continue
+		}
+		locked, idle := 0.0, 0.0
+		if state.locked {
+			locked = 1
+		}
+		if state.idle && !state.idleSince.IsZero() && now.After(state.idleSince) {
+			idle = now.Sub(state.idleSince).Seconds()
+		}
+		ch <- prometheus.MustNewConstMetric(
+			lc.sessionLocked, prometheus.GaugeValue, locked,
+			s.SessionId, s.UserName, s.SeatId)
+		ch <- prometheus.MustNewConstMetric(
+			lc.sessionIdle, prometheus.GaugeValue, idle,
+			s.SessionId, s.UserName, s.SeatId)
 	}
 
 	for _, remote := range attrRemoteValues {
@@ -124,7 +168,7 @@ func collectMetrics(ch chan<- prometheus.Metric, c logindInterface) error {
 					count := sessions[logindSession{seat, remote, sessionType, class}]
 
 					ch <- prometheus.MustNewConstMetric(
-						sessionsDesc, prometheus.GaugeValue, count,
+						lc.sessions, prometheus.GaugeValue, count,
 						seat, remote, sessionType, class)
 				}
 			}
@@ -145,22 +189,8 @@ func knownStringOrOther(value string, known []string) string {
 }
 
 func newDbus() (*logindDbus, error) {
-	conn, err := dbus.SystemBusPrivate()
-	if err != nil {
-		return nil, err
-	}
-
-	methods := []dbus.Auth{dbus.AuthExternal(strconv.Itoa(os.Getuid()))}
-
-	err = conn.Auth(methods)
-	if err != nil {
-		conn.Close()
-		return nil, err
-	}
-
-	err = conn.Hello()
+	conn, err := newSystemBusConn()
 	if err != nil {
-		conn.Close()
 		return nil, err
 	}
 
@@ -266,3 +296,47 @@ func (c *logindDbus) getSession(session logindSessionEntry) *logindSession {
 		class:       knownStringOrOther(classStr, attrClassValues),
 	}
 }
+
+func (c *logindDbus) getSessionState(session logindSessionEntry) *logindSessionState {
+	object := c.conn.Object(dbusObject, session.SessionObjectPath)
+
+	locked, err := object.GetProperty(dbusObject + ".Session.LockedHint")
+	if err != nil {
+		return nil
+	}
+
+	lockedBool, ok := locked.Value().(bool)
+	if !ok {
+		return nil
+	}
+
+	idle, err := object.GetProperty(dbusObject + ".Session.IdleHint")
+	if err != nil {
+		return nil
+	}
+
+	idleBool, ok := idle.Value().(bool)
+	if !ok {
+		return nil
+	}
+
+	idleSince, err := object.GetProperty(dbusObject + ".Session.IdleSinceHint")
+	if err != nil {
+		return nil
+	}
+
+	// IdleSinceHint is given in microseconds since the epoch.
+	idleSinceUsec, ok := idleSince.Value().(uint64)
+	if !ok {
+		return nil
+	}
+
+	state := &logindSessionState{
+		locked: lockedBool,
+		idle:   idleBool,
+	}
+	if idleSinceUsec > 0 {
+		state.idleSince = time.Unix(0, int64(idleSinceUsec)*int64(time.Microsecond))
+	}
+	return state
+}
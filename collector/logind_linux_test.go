@@ -15,6 +15,7 @@ package collector
 
 import (
 	"testing"
+	"time"
 
 	"github.com/godbus/dbus"
 	"github.com/prometheus/client_golang/prometheus"
@@ -66,6 +67,10 @@ func (c *testLogindInterface) getSession(session logindSessionEntry) *logindSess
 	return sessions[session.SessionObjectPath]
 }
 
+func (c *testLogindInterface) getSessionState(session logindSessionEntry) *logindSessionState {
+	return &logindSessionState{locked: true, idle: true, idleSince: time.Now().Add(-time.Minute)}
+}
+
 func TestLogindCollectorKnownStringOrOther(t *testing.T) {
 	known := []string{"foo", "bar"}
 
@@ -84,9 +89,13 @@ func TestLogindCollectorKnownStringOrOther(t *testing.T) {
 }
 
 func TestLogindCollectorCollectMetrics(t *testing.T) {
+	lc, err := NewLogindCollector()
+	if err != nil {
+		t.Fatal(err)
+	}
 	ch := make(chan prometheus.Metric)
 	go func() {
-		collectMetrics(ch, &testLogindInterface{})
+		lc.(*logindCollector).collectMetrics(ch, &testLogindInterface{})
 		close(ch)
 	}()
 
@@ -95,7 +104,8 @@ func TestLogindCollectorCollectMetrics(t *testing.T) {
 		count++
 	}
 
-	expected := len(testSeats) * len(attrRemoteValues) * len(attrTypeValues) * len(attrClassValues)
+	// Lock and idle state is only exported for the graphical session.
+	expected := len(testSeats)*len(attrRemoteValues)*len(attrTypeValues)*len(attrClassValues) + 2
 	if count != expected {
 		t.Errorf("collectMetrics did not generate the expected number of metrics: got %d, expected %d.", count, expected)
 	}
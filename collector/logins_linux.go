@@ -0,0 +1,247 @@
+// Copyright 2016 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// +build !nologins
+
+package collector
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"syscall"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	loginsSubsystem = "logins"
+
+	// Layout of struct utmp as written by glibc on Linux.
+	utmpRecordSize = 384
+	utmpLineOffset = 8
+	utmpLineSize   = 32
+	utmpHostOffset = 76
+	utmpHostSize   = 256
+	utmpUserProc   = 7
+)
+
+var (
+	loginsWtmpPath = flag.String("collector.logins.wtmp-path", "/var/log/wtmp", "Path to the wtmp file recording successful logins.")
+	loginsBtmpPath = flag.String("collector.logins.btmp-path", "/var/log/btmp", "Path to the btmp file recording failed logins.")
+)
+
+// utmpTail remembers how far a utmp file has been read, so that only new
+// records are processed on every scrape.
+type utmpTail struct {
+	fs   fileSystem
+	name string
+	utmpPosition
+}
+
+// utmpPosition is the offset up to which a utmp file has been read, along
+// with its inode to detect rotation.
+type utmpPosition struct {
+	offset int64
+	ino    uint64
+}
+
+// loginMethods are the methods logins are counted by.
+var loginMethods = []string{"ssh", "console", "graphical", "remote", "other"}
+
+type loginsCollector struct {
+	mtx  sync.Mutex
+	wtmp *utmpTail
+	btmp *utmpTail
+	// counts holds the number of logins by result and method.
+	counts map[string]map[string]float64
+
+	logins *prometheus.Desc
+}
+
+// loginsSaved is the state of the logins collector kept across restarts,
+// the counts along with how far wtmp and btmp were read for them.
+type loginsSaved struct {
+	WtmpOffset int64                         `json:"wtmp_offset"`
+	WtmpIno    uint64                        `json:"wtmp_ino"`
+	BtmpOffset int64                         `json:"btmp_offset"`
+	BtmpIno    uint64                        `json:"btmp_ino"`
+	Counts     map[string]map[string]float64 `json:"counts"`
+}
+
+func init() {
+	Factories["logins"] = NewLoginsCollector
+}
+
+// NewLoginsCollector returns a new Collector counting successful and failed
+// logins per method from wtmp and btmp. With -collector.state-dir, the
+// counts continue where the previous run left off.
+func NewLoginsCollector() (Collector, error) {
+	wtmpFS, wtmp := rootfsFile(*loginsWtmpPath)
+	btmpFS, btmp := rootfsFile(*loginsBtmpPath)
+	c := &loginsCollector{
+		wtmp:   &utmpTail{fs: wtmpFS, name: wtmp},
+		btmp:   &utmpTail{fs: btmpFS, name: btmp},
+		counts: map[string]map[string]float64{},
+		logins: prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, loginsSubsystem, "total"),
+			"Number of logins recorded in wtmp and btmp by result and method.",
+			[]string{"result", "method"}, nil,
+		),
+	}
+	for _, result := range []string{"success", "failure"} {
+		c.counts[result] = map[string]float64{}
+		for _, method := range loginMethods {
+			c.counts[result][method] = 0
+		}
+	}
+
+	var saved loginsSaved
+	if err := loadState(loginsSubsystem, &saved); err != nil {
+		return nil, fmt.Errorf("couldn't restore logins state: %s", err)
+	}
+	if saved.Counts != nil {
+		c.wtmp.offset, c.wtmp.ino = saved.WtmpOffset, saved.WtmpIno
+		c.btmp.offset, c.btmp.ino = saved.BtmpOffset, saved.BtmpIno
+		for result, methods := range saved.Counts {
+			if _, ok := c.counts[result]; !ok {
+				continue
+			}
+			for method, count := range methods {
+				c.counts[result][method] = count
+			}
+		}
+	}
+	return c, nil
+}
+
+func (c *loginsCollector) Update(ch chan<- prometheus.Metric) (err error) {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	wtmp, btmp := c.wtmp.utmpPosition, c.btmp.utmpPosition
+	err = c.wtmp.read(func(record []byte) {
+		if nativeEndian().Uint16(record[0:2]) == utmpUserProc {
+			c.counts["success"][utmpLoginMethod(record)]++
+		}
+	})
+	if err != nil {
+		return fmt.Errorf("couldn't read wtmp: %s", err)
+	}
+	// Every btmp entry is a failed login attempt, regardless of its type.
+	err = c.btmp.read(func(record []byte) {
+		c.counts["failure"][utmpLoginMethod(record)]++
+	})
+	if err != nil {
+		return fmt.Errorf("couldn't read btmp: %s", err)
+	}
+
+	for result, methods := range c.counts {
+		for method, count := range methods {
+			ch <- prometheus.MustNewConstMetric(c.logins, prometheus.CounterValue, count, result, method)
+		}
+	}
+
+	if wtmp == c.wtmp.utmpPosition && btmp == c.btmp.utmpPosition {
+		return nil
+	}
+	saved := loginsSaved{
+		WtmpOffset: c.wtmp.offset,
+		WtmpIno:    c.wtmp.ino,
+		BtmpOffset: c.btmp.offset,
+		BtmpIno:    c.btmp.ino,
+		Counts:     c.counts,
+	}
+	if err := saveState(loginsSubsystem, saved); err != nil {
+		return fmt.Errorf("couldn't save logins state: %s", err)
+	}
+	return nil
+}
+
+// read calls fn for every complete record appended since the last call. If
+// the file was rotated or truncated it is read from the start.
+func (t *utmpTail) read(fn func([]byte)) error {
+	f, err := t.fs.Open(t.name)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	fi, err := f.Stat()
+	if err != nil {
+		return err
+	}
+	var ino uint64
+	if st, ok := fi.Sys().(*syscall.Stat_t); ok {
+		ino = st.Ino
+	}
+	if ino != t.ino || fi.Size() < t.offset {
+		t.ino = ino
+		t.offset = 0
+	}
+	seeker, ok := f.(io.Seeker)
+	if !ok {
+		return &os.PathError{Op: "seek", Path: t.name, Err: os.ErrInvalid}
+	}
+	if _, err := seeker.Seek(t.offset, os.SEEK_SET); err != nil {
+		return err
+	}
+
+	n, err := readUtmpRecords(f, fn)
+	t.offset += n
+	return err
+}
+
+// readUtmpRecords calls fn for every complete utmp record in r and returns
+// the number of bytes consumed.
+func readUtmpRecords(r io.Reader, fn func([]byte)) (int64, error) {
+	var (
+		n      int64
+		record = make([]byte, utmpRecordSize)
+	)
+	for {
+		_, err := io.ReadFull(r, record)
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			return n, nil
+		}
+		if err != nil {
+			return n, err
+		}
+		n += utmpRecordSize
+		fn(record)
+	}
+}
+
+// utmpLoginMethod derives how the user logged in from the line and host
+// fields of a utmp record.
+func utmpLoginMethod(record []byte) string {
+	line := cString(record[utmpLineOffset : utmpLineOffset+utmpLineSize])
+	host := cString(record[utmpHostOffset : utmpHostOffset+utmpHostSize])
+	switch {
+	case strings.HasPrefix(line, "ssh"):
+		return "ssh"
+	case strings.HasPrefix(line, ":") || strings.HasPrefix(host, ":"):
+		return "graphical"
+	case strings.HasPrefix(line, "tty") || line == "console":
+		return "console"
+	case strings.HasPrefix(line, "pts/") && host != "":
+		return "remote"
+	}
+	return "other"
+}
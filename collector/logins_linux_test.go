@@ -0,0 +1,107 @@
+// Copyright 2016 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collector
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+func utmpRecord(typ uint16, line, host string) []byte {
+	record := make([]byte, utmpRecordSize)
+	nativeEndian().PutUint16(record[0:2], typ)
+	copy(record[utmpLineOffset:], line)
+	copy(record[utmpHostOffset:], host)
+	return record
+}
+
+func TestReadUtmpRecords(t *testing.T) {
+	var buf bytes.Buffer
+	buf.Write(utmpRecord(utmpUserProc, "pts/0", "10.0.0.1"))
+	buf.Write(utmpRecord(utmpUserProc, "tty1", ""))
+	buf.Write(utmpRecord(8, "pts/0", ""))
+	buf.Write(utmpRecord(6, "ssh:notty", "203.0.113.7"))
+	// A partially written record must not be consumed.
+	buf.Write(make([]byte, 100))
+
+	var methods []string
+	n, err := readUtmpRecords(&buf, func(record []byte) {
+		methods = append(methods, utmpLoginMethod(record))
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want, got := int64(4*utmpRecordSize), n; want != got {
+		t.Errorf("want %d bytes consumed, got %d", want, got)
+	}
+
+	expected := []string{"remote", "console", "other", "ssh"}
+	if len(methods) != len(expected) {
+		t.Fatalf("want %d records, got %d", len(expected), len(methods))
+	}
+	for i := range expected {
+		if methods[i] != expected[i] {
+			t.Errorf("record %d: want method %q, got %q", i, expected[i], methods[i])
+		}
+	}
+}
+
+func TestLoginsState(t *testing.T) {
+	dir, err := ioutil.TempDir("", "logins")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	defer func(dir, wtmp, btmp string) {
+		*stateDir, *loginsWtmpPath, *loginsBtmpPath = dir, wtmp, btmp
+	}(*stateDir, *loginsWtmpPath, *loginsBtmpPath)
+	wtmp := filepath.Join(dir, "wtmp")
+	*stateDir = dir
+	*loginsWtmpPath = wtmp
+	*loginsBtmpPath = filepath.Join(dir, "btmp")
+
+	if err := ioutil.WriteFile(wtmp, utmpRecord(utmpUserProc, "pts/0", "10.0.0.1"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	c, err := NewLoginsCollector()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Update(make(chan prometheus.Metric, 16)); err != nil {
+		t.Fatal(err)
+	}
+
+	// After a restart, only the records appended since are counted.
+	f, err := os.OpenFile(wtmp, os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.Write(utmpRecord(utmpUserProc, "pts/1", "10.0.0.2"))
+	f.Close()
+	c, err = NewLoginsCollector()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Update(make(chan prometheus.Metric, 16)); err != nil {
+		t.Fatal(err)
+	}
+	if want, got := 2.0, c.(*loginsCollector).counts["success"]["remote"]; want != got {
+		t.Errorf("want %f remote logins, got %f", want, got)
+	}
+}
@@ -0,0 +1,41 @@
+// Copyright 2016 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collector
+
+// Maturity tells how far a collector and its metrics can be relied on.
+type Maturity string
+
+const (
+	// MaturityStable collectors are safe to run anywhere and their metrics
+	// only change with a deprecation period.
+	MaturityStable Maturity = "stable"
+	// MaturityBeta collectors are safe to run, but their metrics may still
+	// change.
+	MaturityBeta Maturity = "beta"
+	// MaturityExperimental collectors may be risky to run, e.g. because
+	// they load eBPF programs or read model specific registers. They are
+	// only created with -collector.enable-experimental.
+	MaturityExperimental Maturity = "experimental"
+)
+
+// Maturities holds the maturity of the collectors that aren't stable.
+var Maturities = make(map[string]Maturity)
+
+// CollectorMaturity returns the maturity of the named collector.
+func CollectorMaturity(name string) Maturity {
+	if m, ok := Maturities[name]; ok {
+		return m
+	}
+	return MaturityStable
+}
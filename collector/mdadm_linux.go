@@ -17,7 +17,7 @@ package collector
 
 import (
 	"fmt"
-	"io/ioutil"
+	"io/fs"
 	"os"
 	"regexp"
 	"strconv"
@@ -42,7 +42,13 @@ type mdStatus struct {
 	blocksSynced int64
 }
 
-type mdadmCollector struct{}
+type mdadmCollector struct {
+	isActive     *prometheus.Desc
+	disksActive  *prometheus.Desc
+	disksTotal   *prometheus.Desc
+	blocksTotal  *prometheus.Desc
+	blocksSynced *prometheus.Desc
+}
 
 func init() {
 	Factories["mdadm"] = NewMdadmCollector
@@ -114,9 +120,10 @@ func evalBuildline(buildline string) (int64, error) {
 	return syncedSize, nil
 }
 
-// Parses an mdstat-file and returns a struct with the relevant infos.
-func parseMdstat(mdStatusFilePath string) ([]mdStatus, error) {
-	content, err := ioutil.ReadFile(mdStatusFilePath)
+// Parses the named mdstat-file of fsys and returns a struct with the relevant
+// infos.
+func parseMdstat(fsys fileSystem, name string) ([]mdStatus, error) {
+	content, err := readFSFile(fsys, name)
 	if err != nil {
 		return []mdStatus{}, fmt.Errorf("error parsing mdstat: %s", err)
 	}
@@ -206,52 +213,46 @@ func parseMdstat(mdStatusFilePath string) ([]mdStatus, error) {
 	return mdStates, nil
 }
 
-// Just returns the pointer to an empty struct as we only use throwaway-metrics.
+// NewMdadmCollector returns a new Collector exposing md device statistics.
 func NewMdadmCollector() (Collector, error) {
-	return &mdadmCollector{}, nil
+	return &mdadmCollector{
+		isActive: prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, "md", "is_active"),
+			"Indicator whether the md-device is active or not.",
+			[]string{"device"},
+			nil,
+		),
+		disksActive: prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, "md", "disks_active"),
+			"Number of active disks of device.",
+			[]string{"device"},
+			nil,
+		),
+		disksTotal: prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, "md", "disks"),
+			"Total number of disks of device.",
+			[]string{"device"},
+			nil,
+		),
+		blocksTotal: prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, "md", "blocks"),
+			"Total number of blocks on device.",
+			[]string{"device"},
+			nil,
+		),
+		blocksSynced: prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, "md", "blocks_synced"),
+			"Number of blocks synced on device.",
+			[]string{"device"},
+			nil,
+		),
+	}, nil
 }
 
-var (
-	isActiveDesc = prometheus.NewDesc(
-		prometheus.BuildFQName(Namespace, "md", "is_active"),
-		"Indicator whether the md-device is active or not.",
-		[]string{"device"},
-		nil,
-	)
-
-	disksActiveDesc = prometheus.NewDesc(
-		prometheus.BuildFQName(Namespace, "md", "disks_active"),
-		"Number of active disks of device.",
-		[]string{"device"},
-		nil,
-	)
-
-	disksTotalDesc = prometheus.NewDesc(
-		prometheus.BuildFQName(Namespace, "md", "disks"),
-		"Total number of disks of device.",
-		[]string{"device"},
-		nil,
-	)
-
-	blocksTotalDesc = prometheus.NewDesc(
-		prometheus.BuildFQName(Namespace, "md", "blocks"),
-		"Total number of blocks on device.",
-		[]string{"device"},
-		nil,
-	)
-
-	blocksSyncedDesc = prometheus.NewDesc(
-		prometheus.BuildFQName(Namespace, "md", "blocks_synced"),
-		"Number of blocks synced on device.",
-		[]string{"device"},
-		nil,
-	)
-)
-
 func (c *mdadmCollector) Update(ch chan<- prometheus.Metric) (err error) {
-	statusfile := procFilePath("mdstat")
+	statusfile := "mdstat"
 	// take care we don't crash on non-existent statusfiles
-	_, err = os.Stat(statusfile)
+	_, err = fs.Stat(procFS, statusfile)
 	if os.IsNotExist(err) {
 		// no such file or directory, nothing to do, just return
 		log.Debugf("Not collecting mdstat, file does not exist: %s", statusfile)
@@ -263,7 +264,7 @@ func (c *mdadmCollector) Update(ch chan<- prometheus.Metric) (err error) {
 	}
 
 	// First parse mdstat-file...
-	mdstate, err := parseMdstat(statusfile)
+	mdstate, err := parseMdstat(procFS, statusfile)
 	if err != nil {
 		return fmt.Errorf("error parsing mdstatus: %s", err)
 	}
@@ -281,35 +282,35 @@ func (c *mdadmCollector) Update(ch chan<- prometheus.Metric) (err error) {
 		}
 
 		ch <- prometheus.MustNewConstMetric(
-			isActiveDesc,
+			c.isActive,
 			prometheus.GaugeValue,
 			isActiveFloat,
 			mds.mdName,
 		)
 
 		ch <- prometheus.MustNewConstMetric(
-			disksActiveDesc,
+			c.disksActive,
 			prometheus.GaugeValue,
 			float64(mds.disksActive),
 			mds.mdName,
 		)
 
 		ch <- prometheus.MustNewConstMetric(
-			disksTotalDesc,
+			c.disksTotal,
 			prometheus.GaugeValue,
 			float64(mds.disksTotal),
 			mds.mdName,
 		)
 
 		ch <- prometheus.MustNewConstMetric(
-			blocksTotalDesc,
+			c.blocksTotal,
 			prometheus.GaugeValue,
 			float64(mds.blocksTotal),
 			mds.mdName,
 		)
 
 		ch <- prometheus.MustNewConstMetric(
-			blocksSyncedDesc,
+			c.blocksSynced,
 			prometheus.GaugeValue,
 			float64(mds.blocksSynced),
 			mds.mdName,
@@ -18,7 +18,7 @@ import (
 )
 
 func TestMdadm(t *testing.T) {
-	mdStates, err := parseMdstat("fixtures/proc/mdstat")
+	mdStates, err := parseMdstat(dirFS{root: func() string { return "fixtures/proc" }}, "mdstat")
 
 	if err != nil {
 		t.Fatalf("parsing of reference-file failed entirely: %s", err)
@@ -48,7 +48,7 @@ func TestMdadm(t *testing.T) {
 }
 
 func TestInvalidMdstat(t *testing.T) {
-	_, err := parseMdstat("fixtures/proc/mdstat_invalid")
+	_, err := parseMdstat(dirFS{root: func() string { return "fixtures/proc" }}, "mdstat_invalid")
 
 	if err == nil {
 		t.Fatalf("parsing of invalid reference file did not find any errors")
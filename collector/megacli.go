@@ -19,7 +19,6 @@ import (
 	"bufio"
 	"flag"
 	"io"
-	"os/exec"
 	"strconv"
 	"strings"
 
@@ -158,7 +157,7 @@ func parseMegaCliAdapter(r io.Reader) (map[string]map[string]string, error) {
 }
 
 func (c *megaCliCollector) updateAdapter() error {
-	cmd := exec.Command(c.cli, "-AdpAllInfo", "-aALL")
+	cmd := localeCommand(c.cli, "-AdpAllInfo", "-aALL")
 	pipe, err := cmd.StdoutPipe()
 	if err != nil {
 		return err
@@ -177,7 +176,7 @@ func (c *megaCliCollector) updateAdapter() error {
 	}
 
 	for k, v := range stats["Device Present"] {
-		value, err := strconv.ParseFloat(v, 64)
+		value, err := parseLocaleFloat(v)
 		if err != nil {
 			return err
 		}
@@ -189,7 +188,7 @@ func (c *megaCliCollector) updateAdapter() error {
 func (c *megaCliCollector) updateDisks() error {
 	var counters = []string{"Media Error Count", "Other Error Count", "Predictive Failure Count"}
 
-	cmd := exec.Command(c.cli, "-PDList", "-aALL")
+	cmd := localeCommand(c.cli, "-PDList", "-aALL")
 	pipe, err := cmd.StdoutPipe()
 	if err != nil {
 		return err
@@ -215,7 +214,7 @@ func (c *megaCliCollector) updateDisks() error {
 			tStr := slotStats["Drive Temperature"]
 			if strings.Index(tStr, "C") > 0 {
 				tStr = tStr[:strings.Index(tStr, "C")]
-				t, err := strconv.ParseFloat(tStr, 64)
+				t, err := parseLocaleFloat(tStr)
 				if err != nil {
 					return err
 				}
@@ -223,7 +222,7 @@ func (c *megaCliCollector) updateDisks() error {
 			}
 
 			for _, i := range counters {
-				counter, err := strconv.ParseFloat(slotStats[i], 64)
+				counter, err := parseLocaleFloat(slotStats[i])
 				if err != nil {
 					return err
 				}
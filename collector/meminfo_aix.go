@@ -0,0 +1,77 @@
+// Copyright 2016 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// +build !nomeminfo
+
+package collector
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	memInfoSubsystem = "memory"
+	// vmstat -v reports in 4 KiB pages regardless of the page size in use.
+	vmstatPageSize = 4096
+)
+
+// vmstatMemInfo maps the `vmstat -v` counts to metric names.
+var vmstatMemInfo = map[string]string{
+	"memory pages": "total_bytes",
+	"free pages":   "free_bytes",
+	"pinned pages": "pinned_bytes",
+}
+
+type meminfoCollector struct {
+	descs map[string]*prometheus.Desc
+}
+
+func init() {
+	Factories["meminfo"] = NewMeminfoCollector
+}
+
+// NewMeminfoCollector returns a new Collector exposing memory stats from
+// `vmstat -v`.
+func NewMeminfoCollector() (Collector, error) {
+	descs := map[string]*prometheus.Desc{}
+	for k, name := range vmstatMemInfo {
+		descs[k] = prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, memInfoSubsystem, name),
+			fmt.Sprintf("Memory in %s reported by vmstat -v, in bytes.", k),
+			nil, nil,
+		)
+	}
+	return &meminfoCollector{descs: descs}, nil
+}
+
+func (c *meminfoCollector) Update(ch chan<- prometheus.Metric) (err error) {
+	out, err := localeCommand("vmstat", "-v").Output()
+	if err != nil {
+		return fmt.Errorf("couldn't run vmstat: %s", err)
+	}
+	counts, err := parseVmstatV(bytes.NewReader(out))
+	if err != nil {
+		return err
+	}
+	for k, desc := range c.descs {
+		pages, ok := counts[k]
+		if !ok {
+			continue
+		}
+		ch <- prometheus.MustNewConstMetric(desc, prometheus.GaugeValue, float64(pages*vmstatPageSize))
+	}
+	return nil
+}
@@ -13,6 +13,7 @@
 
 // +build freebsd darwin,amd64
 // +build !nomeminfo
+// +build cgo
 
 package collector
 
@@ -47,7 +48,7 @@ const (
 )
 
 type meminfoCollector struct {
-	metrics map[string]prometheus.Gauge
+	descs *descCache
 }
 
 func init() {
@@ -58,7 +59,7 @@ func init() {
 // Memory stats.
 func NewMeminfoCollector() (Collector, error) {
 	return &meminfoCollector{
-		metrics: map[string]prometheus.Gauge{},
+		descs: newDescCache(),
 	}, nil
 }
 
@@ -94,17 +95,15 @@ func (c *meminfoCollector) Update(ch chan<- prometheus.Metric) (err error) {
 
 	log.Debugf("Set node_mem: %#v", pages)
 	for k, v := range pages {
-		if _, ok := c.metrics[k]; !ok {
-			c.metrics[k] = prometheus.NewGauge(prometheus.GaugeOpts{
-				Namespace: Namespace,
-				Subsystem: memInfoSubsystem,
-				Name:      k,
-				Help:      k + " from sysctl()",
-			})
-		}
+		desc := c.descs.get(k, func() *prometheus.Desc {
+			return prometheus.NewDesc(
+				prometheus.BuildFQName(Namespace, memInfoSubsystem, k),
+				k+" from sysctl()",
+				nil, nil,
+			)
+		})
 		// Convert metrics to kB (same as Linux meminfo).
-		c.metrics[k].Set(float64(v) * float64(size))
-		c.metrics[k].Collect(ch)
+		ch <- prometheus.MustNewConstMetric(desc, prometheus.GaugeValue, float64(v)*float64(size))
 	}
-	return err
+	return nil
 }
@@ -19,7 +19,6 @@ import (
 	"bufio"
 	"fmt"
 	"io"
-	"os"
 	"regexp"
 	"strconv"
 	"strings"
@@ -33,7 +32,7 @@ const (
 )
 
 type meminfoCollector struct {
-	metrics map[string]prometheus.Gauge
+	descs *descCache
 }
 
 func init() {
@@ -44,7 +43,7 @@ func init() {
 // memory stats.
 func NewMeminfoCollector() (Collector, error) {
 	return &meminfoCollector{
-		metrics: map[string]prometheus.Gauge{},
+		descs: newDescCache(),
 	}, nil
 }
 
@@ -55,22 +54,20 @@ func (c *meminfoCollector) Update(ch chan<- prometheus.Metric) (err error) {
 	}
 	log.Debugf("Set node_mem: %#v", memInfo)
 	for k, v := range memInfo {
-		if _, ok := c.metrics[k]; !ok {
-			c.metrics[k] = prometheus.NewGauge(prometheus.GaugeOpts{
-				Namespace: Namespace,
-				Subsystem: memInfoSubsystem,
-				Name:      k,
-				Help:      fmt.Sprintf("Memory information field %s.", k),
-			})
-		}
-		c.metrics[k].Set(v)
-		c.metrics[k].Collect(ch)
+		desc := c.descs.get(k, func() *prometheus.Desc {
+			return prometheus.NewDesc(
+				prometheus.BuildFQName(Namespace, memInfoSubsystem, k),
+				fmt.Sprintf("Memory information field %s.", k),
+				nil, nil,
+			)
+		})
+		ch <- prometheus.MustNewConstMetric(desc, prometheus.GaugeValue, v)
 	}
-	return err
+	return nil
 }
 
 func getMemInfo() (map[string]float64, error) {
-	file, err := os.Open(procFilePath("meminfo"))
+	file, err := procFS.Open("meminfo")
 	if err != nil {
 		return nil, err
 	}
@@ -89,6 +86,9 @@ func parseMemInfo(r io.Reader) (map[string]float64, error) {
 	for scanner.Scan() {
 		line := scanner.Text()
 		parts := strings.Fields(string(line))
+		if len(parts) < 2 {
+			return nil, fmt.Errorf("invalid line in meminfo: %s", line)
+		}
 		fv, err := strconv.ParseFloat(parts[1], 64)
 		if err != nil {
 			return nil, fmt.Errorf("invalid value in meminfo: %s", err)
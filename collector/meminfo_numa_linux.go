@@ -19,9 +19,7 @@ import (
 	"bufio"
 	"fmt"
 	"io"
-	"os"
 	"path"
-	"path/filepath"
 	"regexp"
 	"strconv"
 	"strings"
@@ -38,7 +36,7 @@ type meminfoKey struct {
 }
 
 type meminfoNumaCollector struct {
-	metricDescs map[string]*prometheus.Desc
+	metricDescs *descCache
 }
 
 func init() {
@@ -49,7 +47,7 @@ func init() {
 // memory stats.
 func NewMeminfoNumaCollector() (Collector, error) {
 	return &meminfoNumaCollector{
-		metricDescs: map[string]*prometheus.Desc{},
+		metricDescs: newDescCache(),
 	}, nil
 }
 
@@ -59,14 +57,12 @@ func (c *meminfoNumaCollector) Update(ch chan<- prometheus.Metric) (err error) {
 		return fmt.Errorf("couldn't get NUMA meminfo: %s", err)
 	}
 	for k, v := range memInfoNuma {
-		desc, ok := c.metricDescs[k.metricName]
-		if !ok {
-			desc = prometheus.NewDesc(
+		desc := c.metricDescs.get(k.metricName, func() *prometheus.Desc {
+			return prometheus.NewDesc(
 				prometheus.BuildFQName(Namespace, memInfoNumaSubsystem, k.metricName),
 				fmt.Sprintf("Memory information field %s.", k.metricName),
 				[]string{"node"}, nil)
-			c.metricDescs[k.metricName] = desc
-		}
+		})
 		ch <- prometheus.MustNewConstMetric(desc, prometheus.GaugeValue, v, k.numaNode)
 	}
 	return nil
@@ -75,12 +71,12 @@ func (c *meminfoNumaCollector) Update(ch chan<- prometheus.Metric) (err error) {
 func getMemInfoNuma() (map[meminfoKey]float64, error) {
 	info := make(map[meminfoKey]float64)
 
-	nodes, err := filepath.Glob(sysFilePath("devices/system/node/node[0-9]*"))
+	nodes, err := sysFS.Glob("devices/system/node/node[0-9]*")
 	if err != nil {
 		return nil, err
 	}
 	for _, node := range nodes {
-		file, err := os.Open(path.Join(node, "meminfo"))
+		file, err := sysFS.Open(path.Join(node, "meminfo"))
 		if err != nil {
 			return nil, err
 		}
@@ -111,6 +107,9 @@ func parseMemInfoNuma(r io.Reader) (map[meminfoKey]float64, error) {
 			continue
 		}
 		parts := strings.Fields(string(line))
+		if len(parts) < 4 {
+			return nil, fmt.Errorf("invalid line in meminfo: %s", line)
+		}
 
 		fv, err := strconv.ParseFloat(parts[3], 64)
 		if err != nil {
@@ -0,0 +1,64 @@
+// Copyright 2016 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// +build !nomeminfo
+
+package collector
+
+import (
+	"os"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	memInfoSubsystem = "memory"
+)
+
+type meminfoCollector struct {
+	descs *descCache
+}
+
+func init() {
+	Factories["meminfo"] = NewMeminfoCollector
+}
+
+// NewMeminfoCollector returns a new Collector exposing memory stats from the
+// unix:0:system_pages kstat.
+func NewMeminfoCollector() (Collector, error) {
+	return &meminfoCollector{
+		descs: newDescCache(),
+	}, nil
+}
+
+func (c *meminfoCollector) Update(ch chan<- prometheus.Metric) (err error) {
+	stats, err := readKstat("unix:0:system_pages:")
+	if err != nil {
+		return err
+	}
+	memInfo, err := kstatMemInfo(stats, os.Getpagesize())
+	if err != nil {
+		return err
+	}
+	for k, v := range memInfo {
+		desc := c.descs.get(k, func() *prometheus.Desc {
+			return prometheus.NewDesc(
+				prometheus.BuildFQName(Namespace, memInfoSubsystem, k),
+				"Memory information field "+k+" from kstat.",
+				nil, nil,
+			)
+		})
+		ch <- prometheus.MustNewConstMetric(desc, prometheus.GaugeValue, v)
+	}
+	return nil
+}
@@ -0,0 +1,181 @@
+// Copyright 2016 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collector
+
+import (
+	"bufio"
+	"io"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// ModuleHint names a kernel module that is available but not loaded while
+// the sysfs interface a collector reads is missing. Loading it, e.g. with
+// modprobe, likely gives the collector something to expose.
+type ModuleHint struct {
+	Collector, Module string
+}
+
+// moduleHintSources maps collectors to the sysfs path they read and the
+// modules providing it.
+var moduleHintSources = map[string]struct {
+	path    string
+	modules []string
+}{
+	"bonding":      {"class/net/bonding_masters", []string{"bonding"}},
+	"hwmon":        {"class/hwmon", []string{"coretemp", "k10temp"}},
+	"power_supply": {"class/power_supply", []string{"battery", "ac"}},
+	"thermal_zone": {"class/thermal", []string{"thermal"}},
+	"typec":        {"class/typec", []string{"typec", "ucsi_acpi"}},
+}
+
+// moduleHintVendors limits the hints of CPU temperature sensor drivers to
+// CPUs of the vendor they support.
+var moduleHintVendors = map[string]string{
+	"coretemp": "GenuineIntel",
+	"k10temp":  "AuthenticAMD",
+}
+
+var (
+	availableModulesMtx sync.Mutex
+	// availableModules caches the modules installed for a kernel release,
+	// by the path of its modules.dep.
+	availableModules = map[string]map[string]bool{}
+)
+
+// ModuleHints returns the hints for the given collectors, sorted by
+// collector.
+func ModuleHints(collectors []string) []ModuleHint {
+	return moduleHints(collectors, procFS, sysFS, rootfsFilePath("/lib/modules"))
+}
+
+func moduleHints(collectors []string, proc, sys fileSystem, modulesPath string) []ModuleHint {
+	var missing []string
+	for _, c := range collectors {
+		if s, ok := moduleHintSources[c]; ok && sysfsPathEmpty(sys, s.path) {
+			missing = append(missing, c)
+		}
+	}
+	if len(missing) == 0 {
+		return nil
+	}
+	sort.Strings(missing)
+
+	release, err := readFSFile(proc, "sys/kernel/osrelease")
+	if err != nil {
+		return nil
+	}
+	available := installedModules(modulesPath, strings.TrimSpace(string(release)))
+	loaded := map[string]bool{}
+	modules, err := readKernelModules(proc, sys)
+	if err != nil {
+		return nil
+	}
+	for _, m := range modules {
+		loaded[m.name] = true
+	}
+
+	vendor := readCPUVendor(proc)
+	var hints []ModuleHint
+	for _, c := range missing {
+		for _, m := range moduleHintSources[c].modules {
+			if v, ok := moduleHintVendors[m]; ok && v != vendor {
+				continue
+			}
+			if available[m] && !loaded[m] {
+				hints = append(hints, ModuleHint{Collector: c, Module: m})
+			}
+		}
+	}
+	return hints
+}
+
+// readCPUVendor returns the vendor_id of the first CPU in cpuinfo of proc.
+// Virtual machines, whose CPUs have the hypervisor flag, have no sensors and
+// get an empty vendor, like CPUs whose vendor is unknown.
+func readCPUVendor(proc fileSystem) string {
+	file, err := proc.Open("cpuinfo")
+	if err != nil {
+		return ""
+	}
+	defer file.Close()
+	var vendor string
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		parts := strings.SplitN(scanner.Text(), ":", 2)
+		if len(parts) != 2 {
+			continue
+		}
+		switch strings.TrimSpace(parts[0]) {
+		case "vendor_id":
+			vendor = strings.TrimSpace(parts[1])
+		case "flags":
+			for _, flag := range strings.Fields(parts[1]) {
+				if flag == "hypervisor" {
+					return ""
+				}
+			}
+			return vendor
+		}
+	}
+	return vendor
+}
+
+// sysfsPathEmpty reports whether name is missing or an empty directory.
+func sysfsPathEmpty(sys fileSystem, name string) bool {
+	f, err := sys.Open(name)
+	if err != nil {
+		return true
+	}
+	defer f.Close()
+	// Reading fails for directories only.
+	if _, err := f.Read(make([]byte, 1)); err == nil || err == io.EOF {
+		return false
+	}
+	entries, err := sys.Glob(name + "/*")
+	return err == nil && len(entries) == 0
+}
+
+// installedModules returns the names of the modules listed in the
+// modules.dep file of release below modulesPath, which only changes with the
+// kernel package.
+func installedModules(modulesPath, release string) map[string]bool {
+	name := path.Join(release, "modules.dep")
+	key := path.Join(modulesPath, name)
+	availableModulesMtx.Lock()
+	defer availableModulesMtx.Unlock()
+	if modules, ok := availableModules[key]; ok {
+		return modules
+	}
+	modules := map[string]bool{}
+	f, err := rootFS(modulesPath).Open(name)
+	if err != nil {
+		return modules
+	}
+	defer f.Close()
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		file := strings.SplitN(scanner.Text(), ":", 2)[0]
+		m := path.Base(file)
+		if i := strings.Index(m, ".ko"); i > 0 {
+			m = m[:i]
+		}
+		// Module names use underscores, file names may use dashes.
+		modules[strings.Replace(m, "-", "_", -1)] = true
+	}
+	availableModules[key] = modules
+	return modules
+}
@@ -0,0 +1,68 @@
+// Copyright 2016 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collector
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestModuleHints(t *testing.T) {
+	proc := dirFS{root: func() string { return "fixtures/proc" }}
+	collectors := []string{"typec", "bonding", "hwmon", "loadavg", "power_supply", "thermal_zone"}
+
+	if hints := moduleHints(collectors, proc, dirFS{root: func() string { return "fixtures/sys" }}, "fixtures/lib/modules"); hints != nil {
+		t.Errorf("want no hints with all sysfs classes present, got %v", hints)
+	}
+
+	dir, err := ioutil.TempDir("", "node_exporter_modhints_test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	os.MkdirAll(filepath.Join(dir, "class", "typec"), 0755)
+	sys := dirFS{root: func() string { return dir }}
+
+	// coretemp, battery and bonding are loaded, thermal isn't installed
+	// and k10temp is for AMD CPUs.
+	want := []ModuleHint{
+		{Collector: "power_supply", Module: "ac"},
+		{Collector: "typec", Module: "typec"},
+		{Collector: "typec", Module: "ucsi_acpi"},
+	}
+	if got := moduleHints(collectors, proc, sys, "fixtures/lib/modules"); !reflect.DeepEqual(want, got) {
+		t.Errorf("want %v, got %v", want, got)
+	}
+	if got := moduleHints(collectors, proc, sys, "fixtures/nonexistent"); got != nil {
+		t.Errorf("want no hints without installed modules, got %v", got)
+	}
+}
+
+func TestReadCPUVendor(t *testing.T) {
+	for _, tt := range []struct {
+		cpuinfo, want string
+	}{
+		{"processor\t: 0\nvendor_id\t: AuthenticAMD\nflags\t\t: fpu vme de\n", "AuthenticAMD"},
+		{"processor\t: 0\nvendor_id\t: GenuineIntel\nflags\t\t: fpu vme hypervisor lahf_lm\n", ""},
+		// Other architectures have no vendor_id.
+		{"processor\t: 0\nBogoMIPS\t: 48.00\nFeatures\t: fp asimd\n", ""},
+	} {
+		if got := readCPUVendor(mapFS{"cpuinfo": tt.cpuinfo}); tt.want != got {
+			t.Errorf("want vendor %q, got %q", tt.want, got)
+		}
+	}
+}
@@ -0,0 +1,28 @@
+// Copyright 2016 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// +build !linux
+
+package collector
+
+// ModuleHint names a kernel module that is available but not loaded while
+// the sysfs interface a collector reads is missing.
+type ModuleHint struct {
+	Collector, Module string
+}
+
+// ModuleHints returns no hints, as kernel modules are only inspected on
+// Linux.
+func ModuleHints(collectors []string) []ModuleHint {
+	return nil
+}
@@ -12,7 +12,7 @@
 // limitations under the License.
 
 // +build !nonetdev
-// +build linux freebsd openbsd
+// +build linux freebsd,cgo openbsd,cgo solaris
 
 package collector
 
@@ -21,6 +21,8 @@ import (
 	"fmt"
 	"regexp"
 	"strconv"
+	"sync"
+	"time"
 
 	"github.com/prometheus/client_golang/prometheus"
 )
@@ -31,10 +33,31 @@ var (
 		"Regexp of net devices to ignore for netdev collector.")
 )
 
+// netDevMinSampleInterval is the minimum time between the samples the
+// utilization is computed from. Scrapes in between, e.g. by a second
+// Prometheus server, get the utilization of the previous interval instead of
+// one over a few milliseconds.
+const netDevMinSampleInterval = 10 * time.Second
+
+// netDevSample holds the byte counters of a device at the previous sample,
+// to compute the utilization since then, and the utilization up to it.
+type netDevSample struct {
+	time     time.Time
+	receive  float64
+	transmit float64
+
+	utilization    [2]float64
+	hasUtilization bool
+}
+
 type netDevCollector struct {
 	subsystem             string
 	ignoredDevicesPattern *regexp.Regexp
-	metricDescs           map[string]*prometheus.Desc
+	metricDescs           *descCache
+	utilization           *prometheus.Desc
+
+	mtx     sync.Mutex
+	samples map[string]netDevSample
 }
 
 func init() {
@@ -47,7 +70,13 @@ func NewNetDevCollector() (Collector, error) {
 	return &netDevCollector{
 		subsystem:             "network",
 		ignoredDevicesPattern: pattern,
-		metricDescs:           map[string]*prometheus.Desc{},
+		metricDescs:           newDescCache(),
+		utilization: prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, "network", "utilization_ratio"),
+			"Ratio of the negotiated link speed used between the last two scrapes at least 10 seconds apart.",
+			[]string{"device", "direction"}, nil,
+		),
+		samples: map[string]netDevSample{},
 	}, nil
 }
 
@@ -58,16 +87,14 @@ func (c *netDevCollector) Update(ch chan<- prometheus.Metric) (err error) {
 	}
 	for dev, devStats := range netDev {
 		for key, value := range devStats {
-			desc, ok := c.metricDescs[key]
-			if !ok {
-				desc = prometheus.NewDesc(
+			desc := c.metricDescs.get(key, func() *prometheus.Desc {
+				return prometheus.NewDesc(
 					prometheus.BuildFQName(Namespace, c.subsystem, key),
 					fmt.Sprintf("Network device statistic %s.", key),
 					[]string{"device"},
 					nil,
 				)
-				c.metricDescs[key] = desc
-			}
+			})
 			v, err := strconv.ParseFloat(value, 64)
 			if err != nil {
 				return fmt.Errorf("invalid value %s in netstats: %s", value, err)
@@ -75,5 +102,57 @@ func (c *netDevCollector) Update(ch chan<- prometheus.Metric) (err error) {
 			ch <- prometheus.MustNewConstMetric(desc, prometheus.GaugeValue, v, dev)
 		}
 	}
+
+	speeds := getNetDevSpeeds(netDev)
+	for dev, u := range c.updateUtilization(netDev, speeds, time.Now()) {
+		ch <- prometheus.MustNewConstMetric(c.utilization, prometheus.GaugeValue, u[0], dev, "receive")
+		ch <- prometheus.MustNewConstMetric(c.utilization, prometheus.GaugeValue, u[1], dev, "transmit")
+	}
 	return nil
 }
+
+// updateUtilization records the byte counters of all devices and returns the
+// receive and transmit utilization since the previous sample of the devices
+// with a known link speed in bits per second. Counters are only sampled
+// netDevMinSampleInterval after the previous sample. Devices whose counters
+// were reset are skipped until the next sample.
+func (c *netDevCollector) updateUtilization(netDev map[string]map[string]string, speeds map[string]float64, now time.Time) map[string][2]float64 {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	utilization := map[string][2]float64{}
+	samples := make(map[string]netDevSample, len(netDev))
+	for dev, devStats := range netDev {
+		receive, err := strconv.ParseFloat(devStats["receive_bytes"], 64)
+		if err != nil {
+			continue
+		}
+		transmit, err := strconv.ParseFloat(devStats["transmit_bytes"], 64)
+		if err != nil {
+			continue
+		}
+		prev, ok := c.samples[dev]
+		if ok && now.Sub(prev.time) >= 0 && now.Sub(prev.time) < netDevMinSampleInterval {
+			samples[dev] = prev
+			if prev.hasUtilization {
+				utilization[dev] = prev.utilization
+			}
+			continue
+		}
+		cur := netDevSample{time: now, receive: receive, transmit: transmit}
+
+		speed := speeds[dev]
+		seconds := cur.time.Sub(prev.time).Seconds()
+		if ok && speed > 0 && seconds > 0 && cur.receive >= prev.receive && cur.transmit >= prev.transmit {
+			cur.utilization = [2]float64{
+				8 * (cur.receive - prev.receive) / seconds / speed,
+				8 * (cur.transmit - prev.transmit) / seconds / speed,
+			}
+			cur.hasUtilization = true
+			utilization[dev] = cur.utilization
+		}
+		samples[dev] = cur
+	}
+	c.samples = samples
+	return utilization
+}
@@ -19,7 +19,7 @@ import (
 	"bufio"
 	"fmt"
 	"io"
-	"os"
+	"path"
 	"regexp"
 	"strings"
 
@@ -31,7 +31,7 @@ var (
 )
 
 func getNetDevStats(ignore *regexp.Regexp) (map[string]map[string]string, error) {
-	file, err := os.Open(procFilePath("net/dev"))
+	file, err := procFS.Open("net/dev")
 	if err != nil {
 		return nil, err
 	}
@@ -51,6 +51,13 @@ func parseNetDevStats(r io.Reader, ignore *regexp.Regexp) (map[string]map[string
 	}
 
 	header := strings.Fields(parts[1])
+	// Build the keys once instead of for every device.
+	receiveKeys := make([]string, len(header))
+	transmitKeys := make([]string, len(header))
+	for i, v := range header {
+		receiveKeys[i] = "receive_" + v
+		transmitKeys[i] = "transmit_" + v
+	}
 	netDev := map[string]map[string]string{}
 	for scanner.Scan() {
 		line := strings.TrimLeft(string(scanner.Text()), " ")
@@ -59,16 +66,36 @@ func parseNetDevStats(r io.Reader, ignore *regexp.Regexp) (map[string]map[string
 			return nil, fmt.Errorf("invalid line in net/dev: %s", scanner.Text())
 		}
 
-		dev := parts[0][:len(parts[0])]
+		dev := parts[0]
 		if ignore.MatchString(dev) {
 			log.Debugf("Ignoring device: %s", dev)
 			continue
 		}
-		netDev[dev] = map[string]string{}
-		for i, v := range header {
-			netDev[dev]["receive_"+v] = parts[i+1]
-			netDev[dev]["transmit_"+v] = parts[i+1+len(header)]
+		dev = labelValues.intern(dev)
+		netDev[dev] = make(map[string]string, 2*len(header))
+		for i := range header {
+			netDev[dev][receiveKeys[i]] = parts[i+1]
+			netDev[dev][transmitKeys[i]] = parts[i+1+len(header)]
 		}
 	}
 	return netDev, nil
 }
+
+func getNetDevSpeeds(netDev map[string]map[string]string) map[string]float64 {
+	return readNetDevSpeeds(sysFS, netDev)
+}
+
+// readNetDevSpeeds returns the negotiated link speed in bits per second of
+// the devices reporting one. Virtual devices and links that are down have
+// none.
+func readNetDevSpeeds(fsys fileSystem, netDev map[string]map[string]string) map[string]float64 {
+	speeds := map[string]float64{}
+	for dev := range netDev {
+		mbps, err := readFSUint(fsys, path.Join("class/net", dev, "speed"))
+		if err != nil || mbps == 0 {
+			continue
+		}
+		speeds[dev] = float64(mbps) * 1e6
+	}
+	return speeds
+}
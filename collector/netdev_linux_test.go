@@ -15,8 +15,10 @@ package collector
 
 import (
 	"os"
+	"reflect"
 	"regexp"
 	"testing"
+	"time"
 )
 
 func TestNetDevStats(t *testing.T) {
@@ -51,3 +53,56 @@ func TestNetDevStats(t *testing.T) {
 		t.Error("want fixture interface veth4B09XN to not exist, but it does")
 	}
 }
+
+func TestNetDevUtilization(t *testing.T) {
+	file, err := os.Open("fixtures/proc/net/dev")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+
+	netStats, err := parseNetDevStats(file, regexp.MustCompile("^veth"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	speeds := readNetDevSpeeds(dirFS{root: func() string { return "fixtures/sys" }}, netStats)
+	if want, got := 1, len(speeds); want != got {
+		t.Fatalf("want speeds of %d devices, got %v", want, got)
+	}
+	if want, got := 1e9, speeds["eth0"]; want != got {
+		t.Errorf("want eth0 speed %f, got %f", want, got)
+	}
+
+	c, err := NewNetDevCollector()
+	if err != nil {
+		t.Fatal(err)
+	}
+	collector := c.(*netDevCollector)
+	now := time.Now()
+	scrape := func(receive, transmit string, at time.Duration) map[string][2]float64 {
+		netStats["eth0"]["receive_bytes"] = receive
+		netStats["eth0"]["transmit_bytes"] = transmit
+		return collector.updateUtilization(netStats, speeds, now.Add(at))
+	}
+	if want, got := 0, len(scrape("1000", "1000", 0)); want != got {
+		t.Errorf("want %d devices without previous scrape, got %d", want, got)
+	}
+
+	// 12.5MB received and 25MB transmitted in ten seconds on a gigabit link.
+	utilization := scrape("12501000", "25001000", 10*time.Second)
+	if want, got := 1, len(utilization); want != got {
+		t.Fatalf("want utilization of %d devices, got %v", want, got)
+	}
+	if want, got := [2]float64{0.01, 0.02}, utilization["eth0"]; want != got {
+		t.Errorf("want eth0 utilization %v, got %v", want, got)
+	}
+
+	// A scrape right after keeps the utilization of the last interval.
+	if want, got := utilization, scrape("12502000", "25002000", 11*time.Second); !reflect.DeepEqual(want, got) {
+		t.Errorf("want utilization %v within minimum interval, got %v", want, got)
+	}
+
+	if want, got := 0, len(scrape("0", "25001000", 20*time.Second)); want != got {
+		t.Errorf("want %d devices after counter reset, got %d", want, got)
+	}
+}
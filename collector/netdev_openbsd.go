@@ -11,7 +11,7 @@
 // See the License for the specific language governing permissions and
 // limitations under the License.
 
-// +build !nonetdev
+// +build !nonetdev,cgo
 
 package collector
 
@@ -66,3 +66,9 @@ func getNetDevStats(ignore *regexp.Regexp) (map[string]map[string]string, error)
 
 	return netDev, nil
 }
+
+// getNetDevSpeeds isn't implemented on this platform, so no utilization is
+// exported.
+func getNetDevSpeeds(netDev map[string]map[string]string) map[string]float64 {
+	return nil
+}
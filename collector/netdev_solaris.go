@@ -0,0 +1,43 @@
+// Copyright 2016 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// +build !nonetdev
+
+package collector
+
+import (
+	"regexp"
+
+	"github.com/prometheus/common/log"
+)
+
+func getNetDevStats(ignore *regexp.Regexp) (map[string]map[string]string, error) {
+	stats, err := readKstat("link:::")
+	if err != nil {
+		return nil, err
+	}
+	netDev := kstatNetDevStats(stats)
+	for dev := range netDev {
+		if ignore.MatchString(dev) {
+			log.Debugf("Ignoring device: %s", dev)
+			delete(netDev, dev)
+		}
+	}
+	return netDev, nil
+}
+
+// getNetDevSpeeds isn't implemented on this platform, so no utilization is
+// exported.
+func getNetDevSpeeds(netDev map[string]map[string]string) map[string]float64 {
+	return nil
+}
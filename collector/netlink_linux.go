@@ -0,0 +1,121 @@
+// Copyright 2016 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collector
+
+import (
+	"encoding/binary"
+	"fmt"
+	"syscall"
+	"unsafe"
+)
+
+// netlinkAttr is a single rtnetlink attribute.
+type netlinkAttr struct {
+	typ   uint16
+	value []byte
+}
+
+func netlinkDump(proto int) ([]syscall.NetlinkMessage, error) {
+	tab, err := syscall.NetlinkRIB(proto, syscall.AF_UNSPEC)
+	if err != nil {
+		return nil, err
+	}
+	return syscall.ParseNetlinkMessage(tab)
+}
+
+// netlinkDumpRequest sends a dump request of the given type carrying payload
+// as family specific header and returns all messages of the reply. Unlike
+// netlinkDump it allows requests that need more than a struct rtgenmsg.
+func netlinkDumpRequest(typ uint16, payload []byte) ([]syscall.NetlinkMessage, error) {
+	s, err := syscall.Socket(syscall.AF_NETLINK, syscall.SOCK_RAW|syscall.SOCK_CLOEXEC, syscall.NETLINK_ROUTE)
+	if err != nil {
+		return nil, err
+	}
+	defer syscall.Close(s)
+
+	sa := &syscall.SockaddrNetlink{Family: syscall.AF_NETLINK}
+	if err := syscall.Bind(s, sa); err != nil {
+		return nil, err
+	}
+
+	req := make([]byte, syscall.NLMSG_HDRLEN+len(payload))
+	ne := nativeEndian()
+	ne.PutUint32(req[0:4], uint32(len(req)))
+	ne.PutUint16(req[4:6], typ)
+	ne.PutUint16(req[6:8], syscall.NLM_F_DUMP|syscall.NLM_F_REQUEST)
+	ne.PutUint32(req[8:12], 1)
+	copy(req[syscall.NLMSG_HDRLEN:], payload)
+	if err := syscall.Sendto(s, req, 0, sa); err != nil {
+		return nil, err
+	}
+
+	var msgs []syscall.NetlinkMessage
+	buf := make([]byte, syscall.Getpagesize()*4)
+	for {
+		n, _, err := syscall.Recvfrom(s, buf, 0)
+		if err != nil {
+			return nil, err
+		}
+		if n < syscall.NLMSG_HDRLEN {
+			return nil, syscall.EINVAL
+		}
+		batch, err := syscall.ParseNetlinkMessage(buf[:n])
+		if err != nil {
+			return nil, err
+		}
+		for _, m := range batch {
+			switch m.Header.Type {
+			case syscall.NLMSG_DONE:
+				return msgs, nil
+			case syscall.NLMSG_ERROR:
+				return nil, fmt.Errorf("netlink request %d failed", typ)
+			}
+			msgs = append(msgs, m)
+		}
+	}
+}
+
+// parseNetlinkAttrs splits b into its rtnetlink attributes. It can be used
+// for both top level and nested attributes.
+func parseNetlinkAttrs(b []byte) ([]netlinkAttr, error) {
+	var attrs []netlinkAttr
+	ne := nativeEndian()
+	for len(b) >= syscall.SizeofRtAttr {
+		l := int(ne.Uint16(b[0:2]))
+		if l < syscall.SizeofRtAttr || l > len(b) {
+			return nil, syscall.EINVAL
+		}
+		attrs = append(attrs, netlinkAttr{
+			typ:   ne.Uint16(b[2:4]) &^ syscall.NLA_F_NESTED,
+			value: b[syscall.SizeofRtAttr:l],
+		})
+		if netlinkAlign(l) >= len(b) {
+			break
+		}
+		b = b[netlinkAlign(l):]
+	}
+	return attrs, nil
+}
+
+func netlinkAlign(l int) int {
+	return (l + syscall.RTA_ALIGNTO - 1) & ^(syscall.RTA_ALIGNTO - 1)
+}
+
+func nativeEndian() binary.ByteOrder {
+	var x uint16 = 1
+	if *(*byte)(unsafe.Pointer(&x)) == 1 {
+		return binary.LittleEndian
+	}
+	return binary.BigEndian
+}
@@ -19,7 +19,6 @@ import (
 	"bufio"
 	"fmt"
 	"io"
-	"os"
 	"strconv"
 	"strings"
 
@@ -31,7 +30,7 @@ const (
 )
 
 type netStatCollector struct {
-	metrics map[string]prometheus.Gauge
+	descs *descCache
 }
 
 func init() {
@@ -42,16 +41,16 @@ func init() {
 // a new Collector exposing network stats.
 func NewNetStatCollector() (Collector, error) {
 	return &netStatCollector{
-		metrics: map[string]prometheus.Gauge{},
+		descs: newDescCache(),
 	}, nil
 }
 
 func (c *netStatCollector) Update(ch chan<- prometheus.Metric) (err error) {
-	netStats, err := getNetStats(procFilePath("net/netstat"))
+	netStats, err := getNetStats(procFS, "net/netstat")
 	if err != nil {
 		return fmt.Errorf("couldn't get netstats: %s", err)
 	}
-	snmpStats, err := getNetStats(procFilePath("net/snmp"))
+	snmpStats, err := getNetStats(procFS, "net/snmp")
 	if err != nil {
 		return fmt.Errorf("couldn't get SNMP stats: %s", err)
 	}
@@ -63,37 +62,31 @@ func (c *netStatCollector) Update(ch chan<- prometheus.Metric) (err error) {
 	for protocol, protocolStats := range netStats {
 		for name, value := range protocolStats {
 			key := protocol + "_" + name
-			if _, ok := c.metrics[key]; !ok {
-				c.metrics[key] = prometheus.NewGauge(
-					prometheus.GaugeOpts{
-						Namespace: Namespace,
-						Subsystem: netStatsSubsystem,
-						Name:      key,
-						Help:      fmt.Sprintf("Protocol %s statistic %s.", protocol, name),
-					},
+			desc := c.descs.get(key, func() *prometheus.Desc {
+				return prometheus.NewDesc(
+					prometheus.BuildFQName(Namespace, netStatsSubsystem, key),
+					fmt.Sprintf("Protocol %s statistic %s.", protocol, name),
+					nil, nil,
 				)
-			}
+			})
 			v, err := strconv.ParseFloat(value, 64)
 			if err != nil {
 				return fmt.Errorf("invalid value %s in netstats: %s", value, err)
 			}
-			c.metrics[key].Set(v)
+			ch <- prometheus.MustNewConstMetric(desc, prometheus.GaugeValue, v)
 		}
 	}
-	for _, m := range c.metrics {
-		m.Collect(ch)
-	}
-	return err
+	return nil
 }
 
-func getNetStats(fileName string) (map[string]map[string]string, error) {
-	file, err := os.Open(fileName)
+func getNetStats(fsys fileSystem, name string) (map[string]map[string]string, error) {
+	file, err := fsys.Open(name)
 	if err != nil {
 		return nil, err
 	}
 	defer file.Close()
 
-	return parseNetStats(file, fileName)
+	return parseNetStats(file, name)
 }
 
 func parseNetStats(r io.Reader, fileName string) (map[string]map[string]string, error) {
@@ -104,8 +97,13 @@ func parseNetStats(r io.Reader, fileName string) (map[string]map[string]string,
 
 	for scanner.Scan() {
 		nameParts := strings.Split(string(scanner.Text()), " ")
-		scanner.Scan()
+		if !scanner.Scan() {
+			return nil, fmt.Errorf("missing values in %s: %s", fileName, nameParts[0])
+		}
 		valueParts := strings.Split(string(scanner.Text()), " ")
+		if !strings.HasSuffix(nameParts[0], ":") {
+			return nil, fmt.Errorf("invalid header in %s: %s", fileName, nameParts[0])
+		}
 		// Remove trailing :.
 		protocol := nameParts[0][:len(nameParts[0])-1]
 		netStats[protocol] = map[string]string{}
@@ -16,6 +16,7 @@ package collector
 import (
 	"flag"
 	"path"
+	"strings"
 
 	"github.com/prometheus/procfs"
 )
@@ -24,12 +25,70 @@ var (
 	// The path of the proc filesystem.
 	procPath = flag.String("collector.procfs", procfs.DefaultMountPoint, "procfs mountpoint.")
 	sysPath  = flag.String("collector.sysfs", "/sys", "sysfs mountpoint.")
+	// The path the host's root filesystem is mounted at, when running in
+	// a container.
+	rootfsPath = flag.String("path.rootfs", "/", "Host root filesystem mountpoint. -collector.procfs, -collector.sysfs, /dev and host files like /etc/machine-id are resolved relative to it, inputs of the exporter itself like -collector.textfile.directory are not.")
 )
 
+func procRoot() string {
+	if root, ok := replayPath("proc"); ok {
+		return root
+	}
+	return rootfsFilePath(*procPath)
+}
+
+func sysRoot() string {
+	if root, ok := replayPath("sys"); ok {
+		return root
+	}
+	return rootfsFilePath(*sysPath)
+}
+
 func procFilePath(name string) string {
-	return path.Join(*procPath, name)
+	p := path.Join(procRoot(), name)
+	recordPath(p)
+	return p
 }
 
 func sysFilePath(name string) string {
-	return path.Join(*sysPath, name)
+	p := path.Join(sysRoot(), name)
+	recordPath(p)
+	return p
+}
+
+// devFilePath returns the path of the device file name below the host's
+// /dev.
+func devFilePath(name string) string {
+	return rootfsFilePath(path.Join("/dev", name))
+}
+
+// rootfsFilePath returns the path of the host file name when the host's root
+// filesystem is mounted at -path.rootfs.
+func rootfsFilePath(name string) string {
+	if *rootfsPath == "/" {
+		return name
+	}
+	return path.Join(*rootfsPath, name)
+}
+
+// rootfsFile returns a fileSystem for the host file p, which is resolved like
+// by rootfsFilePath, and the name of p within it.
+func rootfsFile(p string) (fileSystem, string) {
+	return localFS(rootfsFilePath(p))
+}
+
+// rootfsStripPrefix returns the path a mountpoint below -path.rootfs has on
+// the host, and false for mountpoints outside of it.
+func rootfsStripPrefix(mountPoint string) (string, bool) {
+	root := path.Clean(*rootfsPath)
+	if root == "/" {
+		return mountPoint, true
+	}
+	if mountPoint == root {
+		return "/", true
+	}
+	if !strings.HasPrefix(mountPoint, root+"/") {
+		return "", false
+	}
+	return strings.TrimPrefix(mountPoint, root), true
 }
@@ -75,3 +75,48 @@ func TestCustomSysPath(t *testing.T) {
 		t.Errorf("Expected: %s, Got: %s", want, got)
 	}
 }
+
+func TestRootfsPath(t *testing.T) {
+	defer flag.Set("path.rootfs", "/")
+	if err := flag.Set("collector.procfs", procfs.DefaultMountPoint); err != nil {
+		t.Fatal(err)
+	}
+	if err := flag.Set("path.rootfs", "/host"); err != nil {
+		t.Fatal(err)
+	}
+
+	if got, want := procFilePath("somefile"), "/host/proc/somefile"; got != want {
+		t.Errorf("Expected: %s, Got: %s", want, got)
+	}
+
+	if got, want := rootfsFilePath("/var/run/reboot-required"), "/host/var/run/reboot-required"; got != want {
+		t.Errorf("Expected: %s, Got: %s", want, got)
+	}
+
+	if got, want := devFilePath("ptp0"), "/host/dev/ptp0"; got != want {
+		t.Errorf("Expected: %s, Got: %s", want, got)
+	}
+}
+
+func TestRootfsStripPrefix(t *testing.T) {
+	defer flag.Set("path.rootfs", "/")
+	if err := flag.Set("path.rootfs", "/host/"); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tc := range []struct {
+		mountPoint string
+		want       string
+		ok         bool
+	}{
+		{mountPoint: "/host", want: "/", ok: true},
+		{mountPoint: "/host/boot", want: "/boot", ok: true},
+		{mountPoint: "/hostname", ok: false},
+		{mountPoint: "/etc/hosts", ok: false},
+	} {
+		got, ok := rootfsStripPrefix(tc.mountPoint)
+		if got != tc.want || ok != tc.ok {
+			t.Errorf("rootfsStripPrefix(%q) = %q, %t, want %q, %t", tc.mountPoint, got, ok, tc.want, tc.ok)
+		}
+	}
+}
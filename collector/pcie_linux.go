@@ -0,0 +1,211 @@
+// Copyright 2016 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// +build !nopcie
+
+package collector
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const pcieSubsystem = "pcie"
+
+type pcieCollector struct {
+	fs fileSystem
+
+	aspmPolicy  *prometheus.Desc
+	aspmEnabled *prometheus.Desc
+	speed       *prometheus.Desc
+	maxSpeed    *prometheus.Desc
+	width       *prometheus.Desc
+	maxWidth    *prometheus.Desc
+}
+
+// pcieDevice is the link state of a PCIe device. linkStates maps the files
+// in the device's link directory, e.g. l1_aspm, to whether that power state
+// is enabled.
+type pcieDevice struct {
+	device          string
+	speed, maxSpeed float64
+	width, maxWidth uint64
+	linkStates      map[string]bool
+}
+
+func init() {
+	Factories["pcie"] = NewPCIeCollector
+	Maturities["pcie"] = MaturityBeta
+	SysfsFactories["pcie"] = func(root string) (Collector, error) {
+		return newPCIeCollector(rootFS(root)), nil
+	}
+}
+
+// NewPCIeCollector returns a new Collector exposing the ASPM policy and the
+// link power states, speed and width of PCIe devices.
+func NewPCIeCollector() (Collector, error) {
+	return newPCIeCollector(sysFS), nil
+}
+
+func newPCIeCollector(fsys fileSystem) *pcieCollector {
+	return &pcieCollector{
+		fs: fsys,
+		aspmPolicy: prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, pcieSubsystem, "aspm_policy"),
+			"Active State Power Management policy of the kernel, 1 for the current policy.",
+			[]string{"policy"}, nil,
+		),
+		aspmEnabled: prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, pcieSubsystem, "link_power_state_enabled"),
+			"Whether the link power state, e.g. ASPM L1, is enabled on the device's link.",
+			[]string{"device", "state"}, nil,
+		),
+		speed: prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, pcieSubsystem, "link_speed_gts"),
+			"Current speed of the device's link in GT/s.",
+			[]string{"device"}, nil,
+		),
+		maxSpeed: prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, pcieSubsystem, "link_max_speed_gts"),
+			"Maximum speed of the device's link in GT/s.",
+			[]string{"device"}, nil,
+		),
+		width: prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, pcieSubsystem, "link_width"),
+			"Current number of lanes of the device's link.",
+			[]string{"device"}, nil,
+		),
+		maxWidth: prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, pcieSubsystem, "link_max_width"),
+			"Maximum number of lanes of the device's link.",
+			[]string{"device"}, nil,
+		),
+	}
+}
+
+func (c *pcieCollector) Update(ch chan<- prometheus.Metric) (err error) {
+	policies, current, err := readASPMPolicy(c.fs)
+	switch {
+	case os.IsNotExist(err):
+		// Kernels built without CONFIG_PCIEASPM.
+	case err != nil:
+		return err
+	default:
+		for _, p := range policies {
+			var value float64
+			if p == current {
+				value = 1
+			}
+			ch <- prometheus.MustNewConstMetric(c.aspmPolicy, prometheus.GaugeValue, value, p)
+		}
+	}
+
+	devices, err := readPCIeDevices(c.fs)
+	if err != nil {
+		return err
+	}
+	for _, d := range devices {
+		for state, enabled := range d.linkStates {
+			var value float64
+			if enabled {
+				value = 1
+			}
+			ch <- prometheus.MustNewConstMetric(c.aspmEnabled, prometheus.GaugeValue, value, d.device, state)
+		}
+		if d.maxSpeed == 0 {
+			continue
+		}
+		ch <- prometheus.MustNewConstMetric(c.speed, prometheus.GaugeValue, d.speed, d.device)
+		ch <- prometheus.MustNewConstMetric(c.maxSpeed, prometheus.GaugeValue, d.maxSpeed, d.device)
+		ch <- prometheus.MustNewConstMetric(c.width, prometheus.GaugeValue, float64(d.width), d.device)
+		ch <- prometheus.MustNewConstMetric(c.maxWidth, prometheus.GaugeValue, float64(d.maxWidth), d.device)
+	}
+	return nil
+}
+
+// readASPMPolicy returns the available ASPM policies and the current one,
+// which the kernel marks with brackets.
+func readASPMPolicy(fsys fileSystem) (policies []string, current string, err error) {
+	b, err := readFSFile(fsys, "module/pcie_aspm/parameters/policy")
+	if err != nil {
+		return nil, "", err
+	}
+	policies, current = parseSysfsChoice(string(b))
+	return policies, current, nil
+}
+
+// readPCIeDevices returns the link state of the PCI devices. Speed and
+// width are zero for devices without a PCIe link.
+func readPCIeDevices(fsys fileSystem) ([]pcieDevice, error) {
+	dirs, err := fsys.Glob("bus/pci/devices/*")
+	if err != nil {
+		return nil, err
+	}
+	var devices []pcieDevice
+	for _, dir := range dirs {
+		d := pcieDevice{device: path.Base(dir)}
+		if d.speed, err = readPCIeSpeed(fsys, path.Join(dir, "current_link_speed")); err == nil {
+			if d.maxSpeed, err = readPCIeSpeed(fsys, path.Join(dir, "max_link_speed")); err != nil {
+				return nil, err
+			}
+			if d.width, err = readFSUint(fsys, path.Join(dir, "current_link_width")); err != nil {
+				return nil, err
+			}
+			if d.maxWidth, err = readFSUint(fsys, path.Join(dir, "max_link_width")); err != nil {
+				return nil, err
+			}
+		} else if !os.IsNotExist(err) {
+			return nil, err
+		}
+
+		files, err := fsys.Glob(path.Join(dir, "link/*"))
+		if err != nil {
+			return nil, err
+		}
+		for _, file := range files {
+			enabled, err := readFSUint(fsys, file)
+			if err != nil {
+				return nil, err
+			}
+			if d.linkStates == nil {
+				d.linkStates = map[string]bool{}
+			}
+			d.linkStates[path.Base(file)] = enabled == 1
+		}
+		devices = append(devices, d)
+	}
+	return devices, nil
+}
+
+// readPCIeSpeed parses a link speed like "8.0 GT/s PCIe". Links that are
+// down report an unknown speed, which is returned as 0.
+func readPCIeSpeed(fsys fileSystem, name string) (float64, error) {
+	b, err := readFSFile(fsys, name)
+	if err != nil {
+		return 0, err
+	}
+	fields := strings.Fields(string(b))
+	if len(fields) == 0 || fields[0] == "Unknown" {
+		return 0, nil
+	}
+	speed, err := strconv.ParseFloat(fields[0], 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid link speed %q in %s", b, name)
+	}
+	return speed, nil
+}
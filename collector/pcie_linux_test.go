@@ -0,0 +1,57 @@
+// Copyright 2016 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collector
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestReadASPMPolicy(t *testing.T) {
+	policies, current, err := readASPMPolicy(dirFS{root: func() string { return "fixtures/sys" }})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"default", "performance", "powersave", "powersupersave"}; !reflect.DeepEqual(want, policies) {
+		t.Errorf("want %v, got %v", want, policies)
+	}
+	if want := "powersave"; want != current {
+		t.Errorf("want %s, got %s", want, current)
+	}
+}
+
+func TestReadPCIeDevices(t *testing.T) {
+	devices, err := readPCIeDevices(dirFS{root: func() string { return "fixtures/sys" }})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []pcieDevice{
+		{device: "0000:03:00.0", speed: 2.5, maxSpeed: 16, width: 16, maxWidth: 16},
+		{
+			device: "0000:3c:00.0", speed: 8, maxSpeed: 8, width: 4, maxWidth: 4,
+			linkStates: map[string]bool{
+				"clkpm":      true,
+				"l0s_aspm":   false,
+				"l1_aspm":    true,
+				"l1_1_aspm":  true,
+				"l1_2_aspm":  true,
+				"l1_1_pcipm": true,
+				"l1_2_pcipm": false,
+			},
+		},
+	}
+	if !reflect.DeepEqual(want, devices) {
+		t.Errorf("want %+v, got %+v", want, devices)
+	}
+}
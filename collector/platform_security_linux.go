@@ -0,0 +1,177 @@
+// Copyright 2016 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// +build !noplatform_security
+
+package collector
+
+import (
+	"os"
+	"path"
+	"strings"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+type platformSecurityCollector struct {
+	fs fileSystem
+
+	efiBooted  *prometheus.Desc
+	secureBoot *prometheus.Desc
+	setupMode  *prometheus.Desc
+	tpm        *prometheus.Desc
+	pcrBank    *prometheus.Desc
+}
+
+// tpmDevice is a TPM with the hash algorithms of its PCR banks.
+type tpmDevice struct {
+	device, versionMajor string
+	pcrBanks             []string
+}
+
+func init() {
+	Factories["platform_security"] = NewPlatformSecurityCollector
+	Maturities["platform_security"] = MaturityBeta
+	SysfsFactories["platform_security"] = func(root string) (Collector, error) {
+		return newPlatformSecurityCollector(rootFS(root)), nil
+	}
+}
+
+// NewPlatformSecurityCollector returns a new Collector exposing whether the
+// system booted with UEFI Secure Boot enforced, from efivarfs, and its TPMs
+// with the PCR banks available for measured boot, from /sys/class/tpm.
+func NewPlatformSecurityCollector() (Collector, error) {
+	return newPlatformSecurityCollector(sysFS), nil
+}
+
+func newPlatformSecurityCollector(fsys fileSystem) *platformSecurityCollector {
+	return &platformSecurityCollector{
+		fs: fsys,
+		efiBooted: prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, "efi", "booted"),
+			"Whether the system booted with UEFI rather than a legacy BIOS.",
+			nil, nil,
+		),
+		secureBoot: prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, "secure_boot", "enabled"),
+			"Whether the firmware enforced Secure Boot.",
+			nil, nil,
+		),
+		setupMode: prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, "secure_boot", "setup_mode"),
+			"Whether the firmware is in setup mode, without a platform key enrolled.",
+			nil, nil,
+		),
+		tpm: prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, "tpm", "info"),
+			"TPMs with their major version, 1 for TPM 1.2 and 2 for TPM 2.0.",
+			[]string{"device", "version_major"}, nil,
+		),
+		pcrBank: prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, "tpm", "pcr_bank_info"),
+			"PCR banks of the TPM by hash algorithm, as used for measured boot.",
+			[]string{"device", "algorithm"}, nil,
+		),
+	}
+}
+
+func (c *platformSecurityCollector) Update(ch chan<- prometheus.Metric) (err error) {
+	efi, secureBoot, setupMode, err := readSecureBoot(c.fs)
+	if err != nil {
+		return err
+	}
+	if !efi {
+		ch <- prometheus.MustNewConstMetric(c.efiBooted, prometheus.GaugeValue, 0)
+	} else {
+		ch <- prometheus.MustNewConstMetric(c.efiBooted, prometheus.GaugeValue, 1)
+		ch <- prometheus.MustNewConstMetric(c.secureBoot, prometheus.GaugeValue, secureBoot)
+		ch <- prometheus.MustNewConstMetric(c.setupMode, prometheus.GaugeValue, setupMode)
+	}
+
+	tpms, err := readTPMs(c.fs)
+	if err != nil {
+		return err
+	}
+	for _, t := range tpms {
+		ch <- prometheus.MustNewConstMetric(c.tpm, prometheus.GaugeValue, 1, t.device, t.versionMajor)
+		for _, bank := range t.pcrBanks {
+			ch <- prometheus.MustNewConstMetric(c.pcrBank, prometheus.GaugeValue, 1, t.device, bank)
+		}
+	}
+	return nil
+}
+
+// readSecureBoot reports whether the system booted with UEFI, and if so
+// whether Secure Boot is enabled and the firmware is in setup mode, as 1 or
+// 0. The variables are missing with firmware not supporting Secure Boot.
+func readSecureBoot(fsys fileSystem) (efi bool, secureBoot, setupMode float64, err error) {
+	files, err := fsys.Glob("firmware/efi/*")
+	if err != nil || len(files) == 0 {
+		return false, 0, 0, err
+	}
+	for _, v := range []struct {
+		name string
+		dst  *float64
+	}{
+		{"SecureBoot", &secureBoot},
+		{"SetupMode", &setupMode},
+	} {
+		data, err := readEFIVariable(fsys, v.name, efiGlobalVariable)
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return false, 0, 0, err
+		}
+		if len(data) > 0 && data[0] == 1 {
+			*v.dst = 1
+		}
+	}
+	return true, secureBoot, setupMode, nil
+}
+
+// readTPMs returns the TPMs in class/tpm. TPM 2.0 devices of kernels before
+// 5.6, which lack tpm_version_major, are told apart by the caps only TPM 1.2
+// devices have.
+func readTPMs(fsys fileSystem) ([]tpmDevice, error) {
+	dirs, err := fsys.Glob("class/tpm/tpm[0-9]*")
+	if err != nil {
+		return nil, err
+	}
+	var tpms []tpmDevice
+	for _, dir := range dirs {
+		t := tpmDevice{device: path.Base(dir)}
+		version, err := readFSFile(fsys, path.Join(dir, "tpm_version_major"))
+		switch {
+		case err == nil:
+			t.versionMajor = strings.TrimSpace(string(version))
+		case os.IsNotExist(err):
+			t.versionMajor = "2"
+			if _, err := readFSFile(fsys, path.Join(dir, "device/caps")); err == nil {
+				t.versionMajor = "1"
+			}
+		default:
+			return nil, err
+		}
+		// The PCR banks are exposed since Linux 5.12.
+		banks, err := fsys.Glob(path.Join(dir, "pcr-*"))
+		if err != nil {
+			return nil, err
+		}
+		for _, bank := range banks {
+			t.pcrBanks = append(t.pcrBanks, strings.TrimPrefix(path.Base(bank), "pcr-"))
+		}
+		tpms = append(tpms, t)
+	}
+	return tpms, nil
+}
@@ -0,0 +1,61 @@
+// Copyright 2016 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collector
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestReadSecureBoot(t *testing.T) {
+	for _, tt := range []struct {
+		name                  string
+		fs                    fileSystem
+		efi                   bool
+		secureBoot, setupMode float64
+	}{
+		{"fixture", dirFS{root: func() string { return "fixtures/sys" }}, true, 1, 0},
+		{"legacy BIOS", mapFS{}, false, 0, 0},
+		{"without Secure Boot", mapFS{"firmware/efi/fw_vendor": "0x7a6c5e98\n"}, true, 0, 0},
+	} {
+		efi, secureBoot, setupMode, err := readSecureBoot(tt.fs)
+		if err != nil {
+			t.Fatalf("%s: %s", tt.name, err)
+		}
+		if tt.efi != efi || tt.secureBoot != secureBoot || tt.setupMode != setupMode {
+			t.Errorf("%s: want %t, %f, %f, got %t, %f, %f", tt.name, tt.efi, tt.secureBoot, tt.setupMode, efi, secureBoot, setupMode)
+		}
+	}
+}
+
+func TestReadTPMs(t *testing.T) {
+	tpms, err := readTPMs(dirFS{root: func() string { return "fixtures/sys" }})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []tpmDevice{{device: "tpm0", versionMajor: "2", pcrBanks: []string{"sha1", "sha256"}}}; !reflect.DeepEqual(want, tpms) {
+		t.Errorf("want %+v, got %+v", want, tpms)
+	}
+
+	tpms, err = readTPMs(mapFS{
+		"class/tpm/tpm0/device/caps": "Manufacturer: 0x49465800\nTCG version: 1.2\n",
+		"class/tpm/tpm1/dev":         "10:224\n",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []tpmDevice{{device: "tpm0", versionMajor: "1"}, {device: "tpm1", versionMajor: "2"}}; !reflect.DeepEqual(want, tpms) {
+		t.Errorf("want %+v, got %+v", want, tpms)
+	}
+}
@@ -0,0 +1,130 @@
+// Copyright 2016 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collector
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// The functions below parse the output of commands present on most Unix
+// systems. They back the collectors of systems without procfs or sysctl
+// support, like AIX.
+
+// parseUptimeLoad parses the load averages at the end of uptime(1) output,
+// "... load average: 0.21, 0.37, 0.39" or "... load averages: 0.21 0.37 0.39".
+func parseUptimeLoad(out string) ([]float64, error) {
+	i := strings.LastIndex(out, "load average")
+	if i < 0 {
+		return nil, fmt.Errorf("no load average in uptime output %q", out)
+	}
+	rest := strings.TrimPrefix(out[i+len("load average"):], "s")
+	fields := strings.FieldsFunc(rest, func(r rune) bool {
+		return r == ',' || r == ':' || unicode.IsSpace(r)
+	})
+	if len(fields) < 3 {
+		return nil, fmt.Errorf("unexpected load average in uptime output %q", out)
+	}
+	loads := make([]float64, 3)
+	for i, f := range fields[:3] {
+		load, err := strconv.ParseFloat(f, 64)
+		if err != nil {
+			return nil, fmt.Errorf("could not parse load '%s': %s", f, err)
+		}
+		loads[i] = load
+	}
+	return loads, nil
+}
+
+// parseEtime parses a process' elapsed time in the [[dd-]hh:]mm:ss format
+// of `ps -o etime`.
+func parseEtime(etime string) (float64, error) {
+	s := strings.TrimSpace(etime)
+	var days int
+	if i := strings.Index(s, "-"); i >= 0 {
+		d, err := strconv.Atoi(s[:i])
+		if err != nil {
+			return 0, fmt.Errorf("invalid days in etime %q", etime)
+		}
+		days, s = d, s[i+1:]
+	}
+	parts := strings.Split(s, ":")
+	if len(parts) < 2 || len(parts) > 3 {
+		return 0, fmt.Errorf("invalid etime %q", etime)
+	}
+	var seconds int
+	for _, p := range parts {
+		n, err := strconv.Atoi(p)
+		if err != nil {
+			return 0, fmt.Errorf("invalid etime %q", etime)
+		}
+		seconds = seconds*60 + n
+	}
+	return float64(days*24*60*60 + seconds), nil
+}
+
+type aixMount struct {
+	device, mountPoint, fsType string
+	ro                         bool
+}
+
+// parseAIXMounts parses the table printed by mount(1) on AIX. Remote mounts
+// have an additional leading node column.
+func parseAIXMounts(r io.Reader) ([]aixMount, error) {
+	var mounts []aixMount
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 3 || fields[0] == "node" || strings.HasPrefix(fields[0], "---") {
+			continue
+		}
+		var m aixMount
+		if len(fields) > 3 && !strings.HasPrefix(fields[0], "/") {
+			m.device, fields = fields[0]+":"+fields[1], fields[2:]
+		} else {
+			m.device, fields = fields[0], fields[1:]
+		}
+		m.mountPoint, m.fsType = fields[0], fields[1]
+		for _, opt := range strings.Split(fields[len(fields)-1], ",") {
+			if opt == "ro" {
+				m.ro = true
+			}
+		}
+		mounts = append(mounts, m)
+	}
+	return mounts, scanner.Err()
+}
+
+// parseVmstatV parses the page counts, keyed by description, printed by
+// `vmstat -v` on AIX.
+func parseVmstatV(r io.Reader) (map[string]uint64, error) {
+	counts := map[string]uint64{}
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 3 || fields[len(fields)-1] != "pages" {
+			continue
+		}
+		v, err := strconv.ParseUint(fields[0], 10, 64)
+		if err != nil {
+			continue
+		}
+		counts[strings.Join(fields[1:], " ")] = v
+	}
+	return counts, scanner.Err()
+}
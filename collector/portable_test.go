@@ -0,0 +1,104 @@
+// Copyright 2016 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collector
+
+import (
+	"os"
+	"reflect"
+	"testing"
+)
+
+func TestParseUptimeLoad(t *testing.T) {
+	for _, in := range []string{
+		"  10:41AM   up 12 days,  23:05,  3 users,  load average: 0.21, 0.37, 0.39\n",
+		"10:41  up 3 days, 2:04, 2 users, load averages: 0.21 0.37 0.39\n",
+	} {
+		loads, err := parseUptimeLoad(in)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want, got := []float64{0.21, 0.37, 0.39}, loads; !reflect.DeepEqual(want, got) {
+			t.Errorf("want loads %v, got %v", want, got)
+		}
+	}
+	if _, err := parseUptimeLoad("10:41AM up 12 days"); err == nil {
+		t.Error("expected error for missing load average")
+	}
+}
+
+func TestParseEtime(t *testing.T) {
+	for in, want := range map[string]float64{
+		"   05:09\n":  309,
+		"03:05:09":    3*3600 + 309,
+		"12-03:05:09": 12*86400 + 3*3600 + 309,
+	} {
+		got, err := parseEtime(in)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want != got {
+			t.Errorf("parseEtime(%q): want %f, got %f", in, want, got)
+		}
+	}
+	for _, in := range []string{"", "309", "x-03:05:09", "1:2:3:4"} {
+		if _, err := parseEtime(in); err == nil {
+			t.Errorf("parseEtime(%q): expected error", in)
+		}
+	}
+}
+
+func TestParseAIXMounts(t *testing.T) {
+	file, err := os.Open("fixtures/aix-mount")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+
+	mounts, err := parseAIXMounts(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []aixMount{
+		{device: "/dev/hd4", mountPoint: "/", fsType: "jfs2"},
+		{device: "/dev/hd2", mountPoint: "/usr", fsType: "jfs2"},
+		{device: "/proc", mountPoint: "/proc", fsType: "procfs"},
+		{device: "/dev/cd0", mountPoint: "/mnt/cdrom", fsType: "cdrfs", ro: true},
+		{device: "nfssrv:/export/home", mountPoint: "/home", fsType: "nfs3"},
+	}
+	if !reflect.DeepEqual(want, mounts) {
+		t.Errorf("want %+v, got %+v", want, mounts)
+	}
+}
+
+func TestParseVmstatV(t *testing.T) {
+	file, err := os.Open("fixtures/aix-vmstat-v")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+
+	counts, err := parseVmstatV(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]uint64{
+		"memory pages":  4194304,
+		"lruable pages": 3990336,
+		"free pages":    262035,
+		"pinned pages":  753415,
+	}
+	if !reflect.DeepEqual(want, counts) {
+		t.Errorf("want %v, got %v", want, counts)
+	}
+}
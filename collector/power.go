@@ -0,0 +1,114 @@
+// Copyright 2016 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collector
+
+import (
+	"flag"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/prometheus/common/log"
+)
+
+var (
+	batteryIntervalFactor     = intervalFactor(1)
+	batteryDisabledCollectors = flag.String("collector.battery.disabled-collectors", "", "Comma-separated list of collectors skipped while the host runs on battery.")
+)
+
+func init() {
+	flag.Var(&batteryIntervalFactor, "collector.battery.interval-factor", "Factor background collection intervals are lengthened by while the host runs on battery.")
+}
+
+// intervalFactor is a flag value scaling intervals. It must be positive, as
+// intervals shrunk to nothing would make background work spin.
+type intervalFactor float64
+
+func (f *intervalFactor) Set(s string) error {
+	v, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return err
+	}
+	if v <= 0 {
+		return fmt.Errorf("interval factor must be positive, got %g", v)
+	}
+	*f = intervalFactor(v)
+	return nil
+}
+
+func (f *intervalFactor) String() string {
+	return strconv.FormatFloat(float64(*f), 'g', -1, 64)
+}
+
+// detectBattery reports whether the host runs on battery. It is replaced on
+// platforms that can tell.
+var detectBattery = func() (bool, error) { return false, nil }
+
+// How long a detected power source is cached.
+const powerSourceTTL = 10 * time.Second
+
+var powerSource = struct {
+	sync.Mutex
+	onBattery bool
+	checked   time.Time
+}{}
+
+// OnBattery reports whether the host currently runs on battery.
+func OnBattery() bool {
+	powerSource.Lock()
+	defer powerSource.Unlock()
+	if time.Since(powerSource.checked) < powerSourceTTL {
+		return powerSource.onBattery
+	}
+	onBattery, err := detectBattery()
+	if err != nil {
+		log.Debugf("Couldn't detect power source: %s", err)
+		onBattery = false
+	}
+	if onBattery != powerSource.onBattery {
+		if onBattery {
+			log.Infof("Running on battery, lengthening background intervals by %g and skipping collectors %q", float64(batteryIntervalFactor), *batteryDisabledCollectors)
+		} else {
+			log.Infof("Running on AC power again, restoring background intervals and collectors")
+		}
+	}
+	powerSource.onBattery = onBattery
+	powerSource.checked = time.Now()
+	return onBattery
+}
+
+// BackgroundInterval returns the interval of work done in the background
+// or less often than on every scrape, lengthened while on battery.
+func BackgroundInterval(d time.Duration) time.Duration {
+	if batteryIntervalFactor == 1 || !OnBattery() {
+		return d
+	}
+	return time.Duration(float64(d) * float64(batteryIntervalFactor))
+}
+
+// DisabledOnBattery reports whether the named collector is skipped because
+// the host runs on battery.
+func DisabledOnBattery(name string) bool {
+	if *batteryDisabledCollectors == "" {
+		return false
+	}
+	for _, c := range strings.Split(*batteryDisabledCollectors, ",") {
+		if strings.TrimSpace(c) == name {
+			return OnBattery()
+		}
+	}
+	return false
+}
@@ -0,0 +1,377 @@
+// Copyright 2016 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// +build !nopower_supply
+
+package collector
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"flag"
+	"fmt"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/common/log"
+)
+
+const powerSupplySubsystem = "power_supply"
+
+var (
+	powerSupplyPollInterval    = flag.Duration("collector.power_supply.poll-interval", 5*time.Second, "Interval at which power supplies are polled in the background for the /events log, 0 disables polling.")
+	powerSupplyRedactSerial    = flag.String("collector.power_supply.redact-serial", "", "If set to \"hash\" or \"drop\", replace the serial numbers of power supplies with their SHA-256 hash or the empty string.")
+	powerSupplyRefreshInterval = flag.Duration("collector.power_supply.refresh-interval", 0, "Minimum interval at which batteries are asked to refresh their data by writing to their uevent file before reading, for laptops whose embedded controller only updates it when polled. 0 disables refreshing.")
+)
+
+type powerSupplyCollector struct {
+	fs fileSystem
+	// events receives the changes between reads, nil disables recording.
+	events *EventLog
+
+	mtx  sync.Mutex
+	last map[string]map[string]string
+
+	// redactSerial is "hash" or "drop" to redact serial numbers.
+	redactSerial    string
+	refreshInterval time.Duration
+	refreshMtx      sync.Mutex
+	lastRefresh     time.Time
+
+	online          *prometheus.Desc
+	capacity        *prometheus.Desc
+	chargeBehaviour *prometheus.Desc
+	manufactureDate *prometheus.Desc
+	info            *prometheus.Desc
+}
+
+func init() {
+	Factories["power_supply"] = NewPowerSupplyCollector
+	// Changes of further roots aren't recorded, the events don't say
+	// which root a power supply belongs to.
+	SysfsFactories["power_supply"] = func(root string) (Collector, error) {
+		c := newPowerSupplyCollector(rootFS(root), nil)
+		c.refreshInterval = *powerSupplyRefreshInterval
+		c.redactSerial = *powerSupplyRedactSerial
+		return c, checkPowerSupplyRedaction(c.redactSerial)
+	}
+	detectBattery = func() (bool, error) {
+		supplies, err := readPowerSupplies(sysFS)
+		if err != nil {
+			return false, err
+		}
+		return suppliesOnBattery(supplies), nil
+	}
+}
+
+// NewPowerSupplyCollector returns a new Collector exposing the state of
+// AC adapters and batteries from /sys/class/power_supply. Changes between
+// scrapes are recorded in Events.
+func NewPowerSupplyCollector() (Collector, error) {
+	c := newPowerSupplyCollector(sysFS, Events)
+	c.refreshInterval = *powerSupplyRefreshInterval
+	c.redactSerial = *powerSupplyRedactSerial
+	if err := checkPowerSupplyRedaction(c.redactSerial); err != nil {
+		return nil, err
+	}
+	if *powerSupplyPollInterval > 0 {
+		Go(func() { c.poll(*powerSupplyPollInterval) })
+	}
+	return c, nil
+}
+
+func newPowerSupplyCollector(fsys fileSystem, events *EventLog) *powerSupplyCollector {
+	return &powerSupplyCollector{
+		fs:     fsys,
+		events: events,
+		online: prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, powerSupplySubsystem, "online"),
+			"Whether the power supply is online, e.g. the AC adapter is plugged in.",
+			[]string{"power_supply"}, nil,
+		),
+		capacity: prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, powerSupplySubsystem, "capacity"),
+			"Charge of the battery in percent.",
+			[]string{"power_supply"}, nil,
+		),
+		chargeBehaviour: prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, powerSupplySubsystem, "charge_behaviour"),
+			"Charge behaviour of the battery, e.g. inhibit-charge, 1 for the current behaviour.",
+			[]string{"power_supply", "behaviour"}, nil,
+		),
+		manufactureDate: prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, powerSupplySubsystem, "manufacture_date_seconds"),
+			"Manufacture date of the battery as reported by the driver, in seconds since the epoch.",
+			[]string{"power_supply"}, nil,
+		),
+		info: prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, powerSupplySubsystem, "info"),
+			"Type, status and identification of the power supply.",
+			[]string{"power_supply", "type", "status", "manufacturer", "model_name", "serial_number"}, nil,
+		),
+	}
+}
+
+func (c *powerSupplyCollector) Update(ch chan<- prometheus.Metric) (err error) {
+	c.refresh(time.Now())
+	supplies, err := readPowerSupplies(c.fs)
+	if err != nil {
+		return err
+	}
+	c.recordEvents(supplies, time.Now())
+
+	for name, attrs := range supplies {
+		if v, ok := attrs["online"]; ok {
+			online, err := strconv.ParseFloat(v, 64)
+			if err != nil {
+				return fmt.Errorf("invalid online value %q of power supply %s: %s", v, name, err)
+			}
+			ch <- prometheus.MustNewConstMetric(c.online, prometheus.GaugeValue, online, name)
+		}
+		if v, ok := attrs["capacity"]; ok {
+			capacity, err := strconv.ParseFloat(v, 64)
+			if err != nil {
+				return fmt.Errorf("invalid capacity value %q of power supply %s: %s", v, name, err)
+			}
+			ch <- prometheus.MustNewConstMetric(c.capacity, prometheus.GaugeValue, capacity, name)
+		}
+		if behaviours, current := parseSysfsChoice(attrs["charge_behaviour"]); current != "" {
+			for _, b := range behaviours {
+				var value float64
+				if b == current {
+					value = 1
+				}
+				ch <- prometheus.MustNewConstMetric(c.chargeBehaviour, prometheus.GaugeValue, value, name, b)
+			}
+		}
+		if date, ok := powerSupplyManufactureDate(attrs); ok {
+			ch <- prometheus.MustNewConstMetric(c.manufactureDate, prometheus.GaugeValue, float64(date.Unix()), name)
+		}
+		ch <- prometheus.MustNewConstMetric(c.info, prometheus.GaugeValue, 1,
+			name, attrs["type"], attrs["status"], attrs["manufacturer"], attrs["model_name"], redactSerial(c.redactSerial, attrs["serial_number"]))
+	}
+	return nil
+}
+
+func checkPowerSupplyRedaction(mode string) error {
+	switch mode {
+	case "", "hash", "drop":
+		return nil
+	}
+	return fmt.Errorf("unknown serial redaction %q, must be \"hash\" or \"drop\"", mode)
+}
+
+// redactSerial returns the serial number hashed or dropped according to
+// mode. Unknown serial numbers stay empty.
+func redactSerial(mode, serial string) string {
+	if serial == "" {
+		return ""
+	}
+	switch mode {
+	case "hash":
+		sum := sha256.Sum256([]byte(serial))
+		return hex.EncodeToString(sum[:])
+	case "drop":
+		return ""
+	}
+	return serial
+}
+
+func (c *powerSupplyCollector) poll(interval time.Duration) {
+	for {
+		time.Sleep(BackgroundInterval(interval))
+		c.refresh(time.Now())
+		supplies, err := readPowerSupplies(c.fs)
+		if err != nil {
+			log.Debugf("Error polling power supplies: %s", err)
+			continue
+		}
+		c.recordEvents(supplies, time.Now())
+	}
+}
+
+// refresh makes the kernel query the batteries' current state, at most once
+// per refresh interval, by writing a change event to their uevent files.
+// Failures, e.g. when not running as root, are only logged.
+func (c *powerSupplyCollector) refresh(now time.Time) {
+	if c.refreshInterval <= 0 {
+		return
+	}
+	c.refreshMtx.Lock()
+	defer c.refreshMtx.Unlock()
+	if now.Sub(c.lastRefresh) < c.refreshInterval {
+		return
+	}
+	c.lastRefresh = now
+
+	dirs, err := c.fs.Glob("class/power_supply/*")
+	if err != nil {
+		log.Debugf("Error refreshing power supplies: %s", err)
+		return
+	}
+	for _, dir := range dirs {
+		typ, err := readFSFile(c.fs, path.Join(dir, "type"))
+		if err != nil || strings.TrimSpace(string(typ)) != "Battery" {
+			continue
+		}
+		if err := writeFSFile(c.fs, path.Join(dir, "uevent"), []byte("change")); err != nil {
+			log.Debugf("Error refreshing power supply %s: %s", path.Base(dir), err)
+		}
+	}
+}
+
+// recordEvents records the changes since the previous call in Events: the
+// online state, the status and the capacity level of each supply, and
+// whether its remaining energy or charge fell below the alarm threshold.
+func (c *powerSupplyCollector) recordEvents(supplies map[string]map[string]string, now time.Time) {
+	if c.events == nil {
+		return
+	}
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	for name, attrs := range supplies {
+		prev, ok := c.last[name]
+		if !ok {
+			continue
+		}
+		for _, kind := range []string{"online", "status", "capacity_level"} {
+			if from, to := prev[kind], attrs[kind]; from != to {
+				c.events.Record(Event{Time: now, Collector: powerSupplySubsystem, Source: name, Kind: kind, From: from, To: to})
+			}
+		}
+		if from, to := powerSupplyAlarm(prev), powerSupplyAlarm(attrs); from != to {
+			c.events.Record(Event{Time: now, Collector: powerSupplySubsystem, Source: name, Kind: "alarm", From: from, To: to})
+		}
+	}
+	c.last = supplies
+}
+
+// suppliesOnBattery reports whether no external power supply is online and
+// a battery is discharging. Supplies of peripherals, like the battery of a
+// wireless mouse, don't power the host and are ignored.
+func suppliesOnBattery(supplies map[string]map[string]string) bool {
+	discharging := false
+	for _, attrs := range supplies {
+		if attrs["scope"] == "Device" {
+			continue
+		}
+		if attrs["type"] != "Battery" && attrs["online"] == "1" {
+			return false
+		}
+		if attrs["type"] == "Battery" && attrs["status"] == "Discharging" {
+			discharging = true
+		}
+	}
+	return discharging
+}
+
+// powerSupplyAlarm returns "triggered" if the remaining energy or charge of
+// a battery is at or below its alarm threshold, "cleared" if it is above and
+// the empty string if the battery has no alarm threshold.
+func powerSupplyAlarm(attrs map[string]string) string {
+	alarm, err := strconv.ParseUint(attrs["alarm"], 10, 64)
+	if err != nil || alarm == 0 {
+		return ""
+	}
+	now, ok := attrs["energy_now"]
+	if !ok {
+		now = attrs["charge_now"]
+	}
+	remaining, err := strconv.ParseUint(now, 10, 64)
+	if err != nil {
+		return ""
+	}
+	if remaining <= alarm {
+		return "triggered"
+	}
+	return "cleared"
+}
+
+// powerSupplyManufactureDate returns the manufacture date of a battery, if
+// the driver reports at least the year.
+func powerSupplyManufactureDate(attrs map[string]string) (time.Time, bool) {
+	year, err := strconv.Atoi(attrs["manufacture_year"])
+	if err != nil || year == 0 {
+		return time.Time{}, false
+	}
+	month, err := strconv.Atoi(attrs["manufacture_month"])
+	if err != nil || month == 0 {
+		month = 1
+	}
+	day, err := strconv.Atoi(attrs["manufacture_day"])
+	if err != nil || day == 0 {
+		day = 1
+	}
+	return time.Date(year, time.Month(month), day, 0, 0, 0, 0, time.UTC), true
+}
+
+// readPowerSupplies returns the attributes of all power supplies by name,
+// as found in their uevent files with the POWER_SUPPLY_ prefix removed and
+// lower-cased, and the alarm threshold and charge behaviours of batteries.
+func readPowerSupplies(fsys fileSystem) (map[string]map[string]string, error) {
+	dirs, err := fsys.Glob("class/power_supply/*")
+	if err != nil {
+		return nil, err
+	}
+	supplies := make(map[string]map[string]string, len(dirs))
+	for _, dir := range dirs {
+		attrs, err := readPowerSupplyUevent(fsys, path.Join(dir, "uevent"))
+		// Unprivileged apps on Android may only read some supplies.
+		if os.IsPermission(err) {
+			log.Debugf("Skipping power supply %s: %s", path.Base(dir), err)
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		// The alarm threshold isn't part of the uevent.
+		if alarm, err := readFSFile(fsys, path.Join(dir, "alarm")); err == nil {
+			attrs["alarm"] = strings.TrimSpace(string(alarm))
+		}
+		// The uevent only has the current charge behaviour, the
+		// attribute lists all the driver supports.
+		if behaviour, err := readFSFile(fsys, path.Join(dir, "charge_behaviour")); err == nil {
+			attrs["charge_behaviour"] = strings.TrimSpace(string(behaviour))
+		}
+		supplies[path.Base(dir)] = attrs
+	}
+	return supplies, nil
+}
+
+func readPowerSupplyUevent(fsys fileSystem, name string) (map[string]string, error) {
+	file, err := fsys.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	attrs := map[string]string{}
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		parts := strings.SplitN(scanner.Text(), "=", 2)
+		if len(parts) != 2 || !strings.HasPrefix(parts[0], "POWER_SUPPLY_") {
+			continue
+		}
+		attrs[strings.ToLower(strings.TrimPrefix(parts[0], "POWER_SUPPLY_"))] = parts[1]
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("couldn't read %s: %s", name, err)
+	}
+	return attrs, nil
+}
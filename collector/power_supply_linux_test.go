@@ -0,0 +1,229 @@
+// Copyright 2016 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collector
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestReadPowerSupplies(t *testing.T) {
+	supplies, err := readPowerSupplies(dirFS{root: func() string { return "fixtures/sys" }})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want, got := 2, len(supplies); want != got {
+		t.Fatalf("want %d power supplies, got %d", want, got)
+	}
+	for _, tt := range []struct {
+		supply, attr, want string
+	}{
+		{"AC", "online", "0"},
+		{"BAT0", "status", "Discharging"},
+		{"BAT0", "model_name", "DELL GPM0365"},
+		{"BAT0", "alarm", "2400000"},
+		{"BAT0", "charge_behaviour", "auto [inhibit-charge] force-discharge"},
+		{"BAT0", "manufacture_year", "2021"},
+	} {
+		if got := supplies[tt.supply][tt.attr]; tt.want != got {
+			t.Errorf("want %s %s %q, got %q", tt.supply, tt.attr, tt.want, got)
+		}
+	}
+}
+
+func TestReadPowerSuppliesPermissionDenied(t *testing.T) {
+	supplies, err := readPowerSupplies(mapFS{
+		"class/power_supply/battery/uevent": "POWER_SUPPLY_NAME=battery\nPOWER_SUPPLY_CAPACITY=84\n",
+		"class/power_supply/usb/uevent":     mapFSDenied,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]map[string]string{"battery": {"name": "battery", "capacity": "84"}}
+	if !reflect.DeepEqual(want, supplies) {
+		t.Errorf("want %v, got %v", want, supplies)
+	}
+}
+
+func TestPowerSupplyEvents(t *testing.T) {
+	defer func(events *EventLog) { Events = events }(Events)
+	Events = NewEventLog(10)
+
+	c := newPowerSupplyCollector(sysFS, Events)
+	at := time.Unix(1500000000, 0)
+	c.recordEvents(map[string]map[string]string{
+		"AC":   {"online": "1"},
+		"BAT0": {"status": "Charging", "alarm": "2400000", "energy_now": "35160000"},
+	}, at)
+	if got := Events.List(); len(got) != 0 {
+		t.Fatalf("want no events for the first observation, got %v", got)
+	}
+
+	c.recordEvents(map[string]map[string]string{
+		"AC":   {"online": "0"},
+		"BAT0": {"status": "Charging", "alarm": "2400000", "energy_now": "2000000"},
+	}, at)
+	c.recordEvents(map[string]map[string]string{
+		"AC":   {"online": "0"},
+		"BAT0": {"status": "Discharging", "alarm": "2400000", "energy_now": "2000000"},
+	}, at)
+
+	want := []Event{
+		{Time: at, Collector: "power_supply", Source: "AC", Kind: "online", From: "1", To: "0"},
+		{Time: at, Collector: "power_supply", Source: "BAT0", Kind: "alarm", From: "cleared", To: "triggered"},
+		{Time: at, Collector: "power_supply", Source: "BAT0", Kind: "status", From: "Charging", To: "Discharging"},
+	}
+	got := Events.List()
+	// Events of one observation are recorded in map order.
+	if len(got) == 3 && got[0].Kind == "alarm" {
+		got[0], got[1] = got[1], got[0]
+	}
+	if !reflect.DeepEqual(want, got) {
+		t.Errorf("want events %v, got %v", want, got)
+	}
+}
+
+func TestEventLog(t *testing.T) {
+	l := NewEventLog(3)
+	for _, kind := range []string{"a", "b", "c", "d"} {
+		l.Record(Event{Kind: kind})
+	}
+	var kinds []string
+	for _, e := range l.List() {
+		kinds = append(kinds, e.Kind)
+	}
+	if want, got := []string{"b", "c", "d"}, kinds; !reflect.DeepEqual(want, got) {
+		t.Errorf("want events %v, got %v", want, got)
+	}
+}
+
+func TestSuppliesOnBattery(t *testing.T) {
+	for _, tt := range []struct {
+		supplies  map[string]map[string]string
+		onBattery bool
+	}{
+		{map[string]map[string]string{}, false},
+		{map[string]map[string]string{
+			"AC":   {"type": "Mains", "online": "0"},
+			"BAT0": {"type": "Battery", "status": "Discharging"},
+		}, true},
+		{map[string]map[string]string{
+			"AC":   {"type": "Mains", "online": "1"},
+			"BAT0": {"type": "Battery", "status": "Discharging"},
+		}, false},
+		{map[string]map[string]string{
+			"BAT0": {"type": "Battery", "status": "Full"},
+		}, false},
+		{map[string]map[string]string{
+			"BAT0":            {"type": "Battery", "status": "Full"},
+			"hidpp_battery_0": {"type": "Battery", "status": "Discharging", "scope": "Device"},
+		}, false},
+		{map[string]map[string]string{
+			"hidpp_battery_0": {"type": "Battery", "status": "Discharging", "scope": "Device"},
+		}, false},
+	} {
+		if got := suppliesOnBattery(tt.supplies); tt.onBattery != got {
+			t.Errorf("%v: want on battery %v, got %v", tt.supplies, tt.onBattery, got)
+		}
+	}
+}
+
+func TestRedactSerial(t *testing.T) {
+	for _, tt := range []struct {
+		mode, serial, want string
+	}{
+		{"", "ABC123", "ABC123"},
+		{"drop", "ABC123", ""},
+		{"hash", "ABC123", "e0bebd22819993425814866b62701e2919ea26f1370499c1037b53b9d49c2c8a"},
+		{"hash", "", ""},
+	} {
+		if got := redactSerial(tt.mode, tt.serial); tt.want != got {
+			t.Errorf("%q: want serial %q, got %q", tt.mode, tt.want, got)
+		}
+	}
+	if err := checkPowerSupplyRedaction("scramble"); err == nil {
+		t.Error("want error for unknown redaction")
+	}
+}
+
+func TestPowerSupplyManufactureDate(t *testing.T) {
+	for _, tt := range []struct {
+		attrs map[string]string
+		want  time.Time
+		ok    bool
+	}{
+		{map[string]string{"manufacture_year": "2021", "manufacture_month": "3", "manufacture_day": "14"}, time.Date(2021, 3, 14, 0, 0, 0, 0, time.UTC), true},
+		{map[string]string{"manufacture_year": "2021"}, time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC), true},
+		{map[string]string{"manufacture_month": "3"}, time.Time{}, false},
+	} {
+		got, ok := powerSupplyManufactureDate(tt.attrs)
+		if !tt.want.Equal(got) || tt.ok != ok {
+			t.Errorf("%v: want %v, %v, got %v, %v", tt.attrs, tt.want, tt.ok, got, ok)
+		}
+	}
+}
+
+func TestPowerSupplyRefresh(t *testing.T) {
+	dir, err := ioutil.TempDir("", "node_exporter_power_supply")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	for name, typ := range map[string]string{"AC": "Mains", "BAT0": "Battery"} {
+		supply := filepath.Join(dir, "class/power_supply", name)
+		if err := os.MkdirAll(supply, 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(filepath.Join(supply, "type"), []byte(typ+"\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(filepath.Join(supply, "uevent"), nil, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	uevent := func(name string) string {
+		b, err := ioutil.ReadFile(filepath.Join(dir, "class/power_supply", name, "uevent"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return string(b)
+	}
+
+	c := newPowerSupplyCollector(dirFS{root: func() string { return dir }}, nil)
+	c.refreshInterval = 10 * time.Second
+	at := time.Unix(1500000000, 0)
+	for _, tt := range []struct {
+		after time.Duration
+		want  string
+	}{
+		{0, "change"},
+		{time.Second, ""},
+		{10 * time.Second, "change"},
+	} {
+		if err := ioutil.WriteFile(filepath.Join(dir, "class/power_supply/BAT0/uevent"), nil, 0644); err != nil {
+			t.Fatal(err)
+		}
+		c.refresh(at.Add(tt.after))
+		if got := uevent("BAT0"); tt.want != got {
+			t.Errorf("after %s: want BAT0 uevent %q, got %q", tt.after, tt.want, got)
+		}
+		if got := uevent("AC"); got != "" {
+			t.Errorf("after %s: want AC uevent untouched, got %q", tt.after, got)
+		}
+	}
+}
@@ -0,0 +1,65 @@
+// Copyright 2016 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collector
+
+import (
+	"flag"
+	"testing"
+	"time"
+)
+
+func TestPowerSourceScheduling(t *testing.T) {
+	defer func(detect func() (bool, error)) {
+		detectBattery = detect
+		powerSource.checked = time.Time{}
+		flag.Set("collector.battery.interval-factor", "1")
+		flag.Set("collector.battery.disabled-collectors", "")
+	}(detectBattery)
+	flag.Set("collector.battery.interval-factor", "4")
+	flag.Set("collector.battery.disabled-collectors", "filehash,cloud")
+
+	for _, onBattery := range []bool{true, false} {
+		detectBattery = func() (bool, error) { return onBattery, nil }
+		powerSource.checked = time.Time{}
+
+		want := time.Minute
+		if onBattery {
+			want = 4 * time.Minute
+		}
+		if got := BackgroundInterval(time.Minute); want != got {
+			t.Errorf("on battery %v: want interval %s, got %s", onBattery, want, got)
+		}
+		if want, got := onBattery, DisabledOnBattery("cloud"); want != got {
+			t.Errorf("on battery %v: want cloud disabled %v, got %v", onBattery, want, got)
+		}
+		if DisabledOnBattery("cpu") {
+			t.Errorf("on battery %v: want cpu enabled", onBattery)
+		}
+	}
+}
+
+func TestIntervalFactorFlag(t *testing.T) {
+	defer flag.Set("collector.battery.interval-factor", "1")
+	for _, v := range []string{"0", "-2", "often"} {
+		if err := flag.Set("collector.battery.interval-factor", v); err == nil {
+			t.Errorf("want error for interval factor %s", v)
+		}
+	}
+	if err := flag.Set("collector.battery.interval-factor", "2.5"); err != nil {
+		t.Fatal(err)
+	}
+	if want, got := 2.5, float64(batteryIntervalFactor); want != got {
+		t.Errorf("want interval factor %g, got %g", want, got)
+	}
+}
@@ -0,0 +1,218 @@
+// Copyright 2016 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collector
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/prometheus/common/log"
+)
+
+// Sources like MSRs and, since Linux 5.10, the RAPL energy counters need
+// privileges the exporter shouldn't run with. A privileged helper process,
+// started with node_exporter -privileged-helper.listen, reads them on behalf
+// of the unprivileged exporter over a unix socket.
+
+const (
+	// privilegedReadMax limits the size of a single read through the
+	// helper.
+	privilegedReadMax = 1 << 20
+	privilegedTimeout = 5 * time.Second
+)
+
+var privilegedHelperSocket = flag.String("collector.privileged-helper.socket", "", "Unix socket of the privileged helper to read privileged sources through. If empty, they are read directly.")
+
+// privilegedRequest asks the helper for size bytes at offset of path.
+type privilegedRequest struct {
+	Path   string `json:"path"`
+	Offset int64  `json:"offset"`
+	Size   int    `json:"size"`
+}
+
+type privilegedResponse struct {
+	Data  []byte `json:"data"`
+	Error string `json:"error,omitempty"`
+}
+
+// privilegedRead reads up to size bytes at offset of path, through the
+// privileged helper if one is configured.
+func privilegedRead(path string, offset int64, size int) ([]byte, error) {
+	if size < 0 || size > privilegedReadMax {
+		return nil, fmt.Errorf("invalid read size %d", size)
+	}
+	if *privilegedHelperSocket == "" {
+		return readAt(path, offset, size)
+	}
+
+	conn, err := net.DialTimeout("unix", *privilegedHelperSocket, privilegedTimeout)
+	if err != nil {
+		return nil, fmt.Errorf("couldn't connect to privileged helper: %s", err)
+	}
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(privilegedTimeout))
+
+	if err := json.NewEncoder(conn).Encode(privilegedRequest{Path: path, Offset: offset, Size: size}); err != nil {
+		return nil, err
+	}
+	var resp privilegedResponse
+	if err := json.NewDecoder(conn).Decode(&resp); err != nil {
+		return nil, fmt.Errorf("invalid response from privileged helper: %s", err)
+	}
+	if resp.Error != "" {
+		return nil, fmt.Errorf("privileged helper: %s", resp.Error)
+	}
+	return resp.Data, nil
+}
+
+// readPrivilegedUint returns the integer stored in the named file of fsys,
+// reading it through the privileged helper if the exporter itself isn't
+// permitted to.
+func readPrivilegedUint(fsys fileSystem, name string) (uint64, error) {
+	v, err := readFSUint(fsys, name)
+	d, ok := fsys.(dirFS)
+	if !os.IsPermission(err) || !ok || *privilegedHelperSocket == "" {
+		return v, err
+	}
+	path, err := d.path("open", name)
+	if err != nil {
+		return 0, err
+	}
+	data, err := privilegedRead(path, 0, 64)
+	if err != nil {
+		return 0, err
+	}
+	return readUint(bytes.NewReader(data), name)
+}
+
+func readAt(path string, offset int64, size int) ([]byte, error) {
+	fsys, name := localFS(path)
+	f, err := openFSFile(fsys, name, os.O_RDONLY)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	buf := make([]byte, size)
+	n, err := f.ReadAt(buf, offset)
+	if err != nil && err != io.EOF {
+		return nil, err
+	}
+	return buf[:n], nil
+}
+
+// PrivilegedHelper serves reads of the files matching its allowed glob
+// patterns to the exporter.
+type PrivilegedHelper struct {
+	allowed []string
+	wg      sync.WaitGroup
+}
+
+// NewPrivilegedHelper returns a helper allowing reads of the files
+// matching any of the glob patterns.
+func NewPrivilegedHelper(allowed []string) (*PrivilegedHelper, error) {
+	for _, p := range allowed {
+		if _, err := filepath.Match(p, ""); err != nil {
+			return nil, fmt.Errorf("invalid pattern %q: %s", p, err)
+		}
+		if !filepath.IsAbs(p) {
+			return nil, fmt.Errorf("pattern %q is not an absolute path", p)
+		}
+	}
+	return &PrivilegedHelper{allowed: allowed}, nil
+}
+
+// Serve answers requests on l until it is closed.
+func (h *PrivilegedHelper) Serve(l net.Listener) error {
+	defer h.wg.Wait()
+	for {
+		conn, err := l.Accept()
+		if err != nil {
+			if ne, ok := err.(net.Error); ok && ne.Temporary() {
+				continue
+			}
+			return err
+		}
+		h.wg.Add(1)
+		Go(func() {
+			defer h.wg.Done()
+			h.handle(conn)
+		})
+	}
+}
+
+func (h *PrivilegedHelper) handle(conn net.Conn) {
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(privilegedTimeout))
+
+	var (
+		req  privilegedRequest
+		resp privilegedResponse
+	)
+	if err := json.NewDecoder(bufio.NewReader(conn)).Decode(&req); err != nil {
+		resp.Error = fmt.Sprintf("invalid request: %s", err)
+	} else if data, err := h.read(req); err != nil {
+		log.Debugf("Privileged helper refused or failed to read %s: %s", req.Path, err)
+		resp.Error = err.Error()
+	} else {
+		resp.Data = data
+	}
+	if err := json.NewEncoder(conn).Encode(resp); err != nil {
+		log.Debugf("Privileged helper couldn't write response: %s", err)
+	}
+}
+
+var errPrivilegedNotAllowed = errors.New("path not allowed")
+
+func (h *PrivilegedHelper) read(req privilegedRequest) ([]byte, error) {
+	if req.Size < 0 || req.Size > privilegedReadMax || req.Offset < 0 {
+		return nil, fmt.Errorf("invalid read of %d bytes at %d", req.Size, req.Offset)
+	}
+	// Other paths aren't even resolved, so requests can't probe which
+	// files exist.
+	if !h.allows(req.Path) {
+		return nil, errPrivilegedNotAllowed
+	}
+	// Symlinks are resolved so they can't point an allowed name at
+	// another file.
+	path, err := filepath.EvalSymlinks(req.Path)
+	if err != nil {
+		return nil, err
+	}
+	if !h.allows(path) {
+		return nil, errPrivilegedNotAllowed
+	}
+	return readAt(path, req.Offset, req.Size)
+}
+
+func (h *PrivilegedHelper) allows(path string) bool {
+	if !filepath.IsAbs(path) || filepath.Clean(path) != path {
+		return false
+	}
+	for _, p := range h.allowed {
+		if ok, _ := filepath.Match(p, path); ok {
+			return true
+		}
+	}
+	return false
+}
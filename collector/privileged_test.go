@@ -0,0 +1,82 @@
+// Copyright 2016 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collector
+
+import (
+	"flag"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestPrivilegedHelper(t *testing.T) {
+	dir, err := ioutil.TempDir("", "privileged")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	allowed := filepath.Join(dir, "msr")
+	secret := filepath.Join(dir, "secret")
+	link := filepath.Join(dir, "msr-link")
+	for _, f := range []string{allowed, secret} {
+		if err := ioutil.WriteFile(f, []byte("0123456789"), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.Symlink(secret, link); err != nil {
+		t.Fatal(err)
+	}
+
+	h, err := NewPrivilegedHelper([]string{filepath.Join(dir, "msr*")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	socket := filepath.Join(dir, "helper.sock")
+	l, err := net.Listen("unix", socket)
+	if err != nil {
+		t.Fatal(err)
+	}
+	done := make(chan struct{})
+	go func() {
+		h.Serve(l)
+		close(done)
+	}()
+	defer func() {
+		l.Close()
+		<-done
+	}()
+
+	if err := flag.Set("collector.privileged-helper.socket", socket); err != nil {
+		t.Fatal(err)
+	}
+	defer flag.Set("collector.privileged-helper.socket", "")
+
+	data, err := privilegedRead(allowed, 2, 4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want, got := "2345", string(data); want != got {
+		t.Errorf("want %q, got %q", want, got)
+	}
+
+	for _, path := range []string{secret, link, filepath.Join(dir, "msr", "..", "secret"), filepath.Join(dir, "missing")} {
+		if _, err := privilegedRead(path, 0, 4); err == nil || !strings.Contains(err.Error(), "not allowed") {
+			t.Errorf("want %s to be refused, got %v", path, err)
+		}
+	}
+}
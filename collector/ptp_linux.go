@@ -0,0 +1,224 @@
+// Copyright 2016 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// +build !noptp
+
+package collector
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"os"
+	"path"
+	"strings"
+	"syscall"
+	"unsafe"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/common/log"
+)
+
+const (
+	ptpSubsystem = "ptp"
+
+	// Not exported by the syscall package.
+	clockRealtime = 0
+
+	// Dynamic posix clocks are addressed by a clock id derived from the
+	// file descriptor, see FD_TO_CLOCKID in linux/posix-timers.h.
+	clockFD = 3
+)
+
+var (
+	ptpPmcCommand = flag.String("collector.ptp.pmc-command", "", "Command to run pmc for querying ptp4l. Offset from master and path delay are only exported if set.")
+)
+
+// ptpDataSet holds the values of the CURRENT_DATA_SET reported by ptp4l,
+// offset and delay are in nanoseconds.
+type ptpDataSet struct {
+	stepsRemoved     float64
+	offsetFromMaster float64
+	meanPathDelay    float64
+}
+
+type ptpCollector struct {
+	info             *prometheus.Desc
+	maxAdjustment    *prometheus.Desc
+	clockOffset      *prometheus.Desc
+	stepsRemoved     *prometheus.Desc
+	offsetFromMaster *prometheus.Desc
+	meanPathDelay    *prometheus.Desc
+}
+
+func init() {
+	Factories["ptp"] = NewPTPCollector
+}
+
+// NewPTPCollector returns a new Collector exposing PTP hardware clocks and,
+// if configured, the synchronization state of ptp4l.
+func NewPTPCollector() (Collector, error) {
+	return &ptpCollector{
+		info: prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, ptpSubsystem, "clock_info"),
+			"Name of the PTP hardware clock as reported by the driver.",
+			[]string{"clock", "name"}, nil,
+		),
+		maxAdjustment: prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, ptpSubsystem, "clock_max_adjustment_ppb"),
+			"Maximum frequency adjustment of the PTP hardware clock in parts per billion.",
+			[]string{"clock"}, nil,
+		),
+		clockOffset: prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, ptpSubsystem, "clock_offset_seconds"),
+			"Offset of the PTP hardware clock from the system realtime clock.",
+			[]string{"clock"}, nil,
+		),
+		stepsRemoved: prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, ptpSubsystem, "steps_removed"),
+			"Number of communication paths between the local clock and the grandmaster.",
+			nil, nil,
+		),
+		offsetFromMaster: prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, ptpSubsystem, "offset_from_master_seconds"),
+			"Offset of the local clock from the master as measured by ptp4l.",
+			nil, nil,
+		),
+		meanPathDelay: prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, ptpSubsystem, "mean_path_delay_seconds"),
+			"Mean propagation delay to the master as measured by ptp4l.",
+			nil, nil,
+		),
+	}, nil
+}
+
+func (c *ptpCollector) Update(ch chan<- prometheus.Metric) (err error) {
+	clocks, err := sysFS.Glob("class/ptp/ptp[0-9]*")
+	if err != nil {
+		return err
+	}
+	for _, dir := range clocks {
+		clock := path.Base(dir)
+
+		name, err := readFSFile(sysFS, path.Join(dir, "clock_name"))
+		if err != nil {
+			return fmt.Errorf("couldn't get name of %s: %s", clock, err)
+		}
+		ch <- prometheus.MustNewConstMetric(c.info, prometheus.GaugeValue, 1, clock, strings.TrimSpace(string(name)))
+
+		if max, err := readFSUint(sysFS, path.Join(dir, "max_adjustment")); err == nil {
+			ch <- prometheus.MustNewConstMetric(c.maxAdjustment, prometheus.GaugeValue, float64(max), clock)
+		}
+
+		offset, err := phcOffset(devFS, clock)
+		switch {
+		case os.IsPermission(err):
+			// Unprivileged exporters can still expose the rest.
+			log.Debugf("Couldn't open %s: %s", clock, err)
+		case err != nil:
+			return fmt.Errorf("couldn't read %s: %s", clock, err)
+		default:
+			ch <- prometheus.MustNewConstMetric(c.clockOffset, prometheus.GaugeValue, offset, clock)
+		}
+	}
+
+	if *ptpPmcCommand == "" {
+		return nil
+	}
+	cmd := localeCommand(*ptpPmcCommand, "-u", "-b", "0", "GET CURRENT_DATA_SET")
+	out, err := cmd.Output()
+	if err != nil {
+		return fmt.Errorf("couldn't query ptp4l: %s", err)
+	}
+	ds, err := parsePmcCurrentDataSet(string(out))
+	if err != nil {
+		return err
+	}
+	ch <- prometheus.MustNewConstMetric(c.stepsRemoved, prometheus.GaugeValue, ds.stepsRemoved)
+	ch <- prometheus.MustNewConstMetric(c.offsetFromMaster, prometheus.GaugeValue, ds.offsetFromMaster/1e9)
+	ch <- prometheus.MustNewConstMetric(c.meanPathDelay, prometheus.GaugeValue, ds.meanPathDelay/1e9)
+	return nil
+}
+
+// phcOffset returns the difference between the hardware clock behind the
+// named device of dev and the system clock in seconds. The system clock is
+// read before and after the hardware clock and the midpoint is used.
+func phcOffset(dev fileSystem, device string) (float64, error) {
+	f, err := openFSFile(dev, device, os.O_RDONLY)
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+
+	clockid := (^int(f.Fd()) << 3) | clockFD
+	var before, phc, after syscall.Timespec
+	for _, c := range []struct {
+		id int
+		ts *syscall.Timespec
+	}{
+		{clockRealtime, &before},
+		{clockid, &phc},
+		{clockRealtime, &after},
+	} {
+		_, _, errno := syscall.Syscall(syscall.SYS_CLOCK_GETTIME, uintptr(c.id), uintptr(unsafe.Pointer(c.ts)), 0)
+		if errno != 0 {
+			return 0, errno
+		}
+	}
+	return phcOffsetSeconds(before, phc, after), nil
+}
+
+func phcOffsetSeconds(before, phc, after syscall.Timespec) float64 {
+	mid := (before.Nano() + after.Nano()) / 2
+	return float64(phc.Nano()-mid) / 1e9
+}
+
+// parsePmcCurrentDataSet parses the response of pmc to a GET
+// CURRENT_DATA_SET request.
+func parsePmcCurrentDataSet(out string) (ptpDataSet, error) {
+	var (
+		ds    ptpDataSet
+		found int
+	)
+	scanner := bufio.NewScanner(strings.NewReader(out))
+	for scanner.Scan() {
+		parts := strings.Fields(scanner.Text())
+		if len(parts) != 2 {
+			continue
+		}
+		var dst *float64
+		switch parts[0] {
+		case "stepsRemoved":
+			dst = &ds.stepsRemoved
+		case "offsetFromMaster":
+			dst = &ds.offsetFromMaster
+		case "meanPathDelay":
+			dst = &ds.meanPathDelay
+		default:
+			continue
+		}
+		v, err := parseLocaleFloat(parts[1])
+		if err != nil {
+			return ptpDataSet{}, fmt.Errorf("invalid value for %s: %s", parts[0], err)
+		}
+		*dst = v
+		found++
+	}
+	if err := scanner.Err(); err != nil {
+		return ptpDataSet{}, err
+	}
+	if found != 3 {
+		return ptpDataSet{}, fmt.Errorf("no CURRENT_DATA_SET in pmc output")
+	}
+	return ds, nil
+}
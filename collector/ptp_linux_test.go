@@ -0,0 +1,48 @@
+// Copyright 2016 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collector
+
+import (
+	"syscall"
+	"testing"
+)
+
+func TestPhcOffsetSeconds(t *testing.T) {
+	before := syscall.Timespec{Sec: 100, Nsec: 0}
+	after := syscall.Timespec{Sec: 100, Nsec: 2000}
+	phc := syscall.Timespec{Sec: 137, Nsec: 1500}
+	if want, got := 37.0000005, phcOffsetSeconds(before, phc, after); want != got {
+		t.Errorf("want offset %v, got %v", want, got)
+	}
+}
+
+func TestParsePmcCurrentDataSet(t *testing.T) {
+	out := `sending: GET CURRENT_DATA_SET
+	90e2ba.fffe.2dc4ac-0 seq 0 RESPONSE MANAGEMENT CURRENT_DATA_SET
+		stepsRemoved     1
+		offsetFromMaster -12.0
+		meanPathDelay    583.0
+`
+	ds, err := parsePmcCurrentDataSet(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want, got := (ptpDataSet{stepsRemoved: 1, offsetFromMaster: -12, meanPathDelay: 583}), ds; want != got {
+		t.Errorf("want %+v, got %+v", want, got)
+	}
+
+	if _, err := parsePmcCurrentDataSet("sending: GET CURRENT_DATA_SET\n"); err == nil {
+		t.Error("expected error for missing response")
+	}
+}
@@ -0,0 +1,205 @@
+// Copyright 2016 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// +build !noqdisc
+
+package collector
+
+import (
+	"fmt"
+	"net"
+	"strconv"
+	"syscall"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	qdiscSubsystem = "qdisc"
+
+	// Size of struct tcmsg from linux/rtnetlink.h.
+	sizeofTcMsg = 20
+
+	// Attributes from linux/rtnetlink.h and linux/gen_stats.h.
+	tcaKind        = 1
+	tcaStats2      = 7
+	tcaStatsBasic  = 1
+	tcaStatsQueue  = 3
+	tcStatsBasicSz = 12
+	tcStatsQueueSz = 20
+)
+
+type qdiscStats struct {
+	ifindex    int32
+	handle     uint32
+	parent     uint32
+	kind       string
+	bytes      uint64
+	packets    uint32
+	qlen       uint32
+	backlog    uint32
+	drops      uint32
+	requeues   uint32
+	overlimits uint32
+}
+
+type qdiscCollector struct {
+	bytes      *prometheus.Desc
+	packets    *prometheus.Desc
+	drops      *prometheus.Desc
+	requeues   *prometheus.Desc
+	overlimits *prometheus.Desc
+	qlen       *prometheus.Desc
+	backlog    *prometheus.Desc
+}
+
+func init() {
+	Factories["qdisc"] = NewQdiscCollector
+}
+
+// NewQdiscCollector returns a new Collector exposing queueing discipline
+// statistics read via rtnetlink.
+func NewQdiscCollector() (Collector, error) {
+	labels := []string{"device", "kind", "handle", "parent"}
+	return &qdiscCollector{
+		bytes: prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, qdiscSubsystem, "bytes_total"),
+			"Number of bytes sent by the qdisc.", labels, nil,
+		),
+		packets: prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, qdiscSubsystem, "packets_total"),
+			"Number of packets sent by the qdisc.", labels, nil,
+		),
+		drops: prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, qdiscSubsystem, "drops_total"),
+			"Number of packets dropped by the qdisc.", labels, nil,
+		),
+		requeues: prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, qdiscSubsystem, "requeues_total"),
+			"Number of packets requeued by the qdisc.", labels, nil,
+		),
+		overlimits: prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, qdiscSubsystem, "overlimits_total"),
+			"Number of overlimit events of the qdisc.", labels, nil,
+		),
+		qlen: prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, qdiscSubsystem, "current_queue_length"),
+			"Number of packets currently queued in the qdisc.", labels, nil,
+		),
+		backlog: prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, qdiscSubsystem, "backlog_bytes"),
+			"Number of bytes currently queued in the qdisc.", labels, nil,
+		),
+	}, nil
+}
+
+func (c *qdiscCollector) Update(ch chan<- prometheus.Metric) (err error) {
+	msgs, err := netlinkDumpRequest(syscall.RTM_GETQDISC, make([]byte, sizeofTcMsg))
+	if err != nil {
+		return fmt.Errorf("couldn't dump qdiscs: %s", err)
+	}
+	qdiscs, err := parseQdiscMessages(msgs)
+	if err != nil {
+		return err
+	}
+
+	for _, q := range qdiscs {
+		device := strconv.Itoa(int(q.ifindex))
+		if iface, err := net.InterfaceByIndex(int(q.ifindex)); err == nil {
+			device = iface.Name
+		}
+		labels := []string{device, q.kind, tcHandleString(q.handle), tcHandleString(q.parent)}
+
+		ch <- prometheus.MustNewConstMetric(c.bytes, prometheus.CounterValue, float64(q.bytes), labels...)
+		ch <- prometheus.MustNewConstMetric(c.packets, prometheus.CounterValue, float64(q.packets), labels...)
+		ch <- prometheus.MustNewConstMetric(c.drops, prometheus.CounterValue, float64(q.drops), labels...)
+		ch <- prometheus.MustNewConstMetric(c.requeues, prometheus.CounterValue, float64(q.requeues), labels...)
+		ch <- prometheus.MustNewConstMetric(c.overlimits, prometheus.CounterValue, float64(q.overlimits), labels...)
+		ch <- prometheus.MustNewConstMetric(c.qlen, prometheus.GaugeValue, float64(q.qlen), labels...)
+		ch <- prometheus.MustNewConstMetric(c.backlog, prometheus.GaugeValue, float64(q.backlog), labels...)
+	}
+	return nil
+}
+
+func parseQdiscMessages(msgs []syscall.NetlinkMessage) ([]qdiscStats, error) {
+	var (
+		qdiscs []qdiscStats
+		ne     = nativeEndian()
+	)
+	for _, m := range msgs {
+		if m.Header.Type != syscall.RTM_NEWQDISC {
+			continue
+		}
+		if len(m.Data) < sizeofTcMsg {
+			return nil, fmt.Errorf("short qdisc message: %d bytes", len(m.Data))
+		}
+		q := qdiscStats{
+			ifindex: int32(ne.Uint32(m.Data[4:8])),
+			handle:  ne.Uint32(m.Data[8:12]),
+			parent:  ne.Uint32(m.Data[12:16]),
+		}
+		attrs, err := parseNetlinkAttrs(m.Data[sizeofTcMsg:])
+		if err != nil {
+			return nil, fmt.Errorf("couldn't parse qdisc attributes: %s", err)
+		}
+		for _, a := range attrs {
+			switch a.typ {
+			case tcaKind:
+				q.kind = cString(a.value)
+			case tcaStats2:
+				nested, err := parseNetlinkAttrs(a.value)
+				if err != nil {
+					return nil, fmt.Errorf("couldn't parse qdisc stats: %s", err)
+				}
+				for _, s := range nested {
+					switch {
+					case s.typ == tcaStatsBasic && len(s.value) >= tcStatsBasicSz:
+						q.bytes = ne.Uint64(s.value[0:8])
+						q.packets = ne.Uint32(s.value[8:12])
+					case s.typ == tcaStatsQueue && len(s.value) >= tcStatsQueueSz:
+						q.qlen = ne.Uint32(s.value[0:4])
+						q.backlog = ne.Uint32(s.value[4:8])
+						q.drops = ne.Uint32(s.value[8:12])
+						q.requeues = ne.Uint32(s.value[12:16])
+						q.overlimits = ne.Uint32(s.value[16:20])
+					}
+				}
+			}
+		}
+		qdiscs = append(qdiscs, q)
+	}
+	return qdiscs, nil
+}
+
+// tcHandleString formats a traffic control handle the way tc(8) does.
+func tcHandleString(h uint32) string {
+	switch h {
+	case 0xffffffff:
+		return "root"
+	case 0xfffffff1:
+		return "ingress"
+	}
+	if h&0xffff == 0 {
+		return fmt.Sprintf("%x:", h>>16)
+	}
+	return fmt.Sprintf("%x:%x", h>>16, h&0xffff)
+}
+
+func cString(b []byte) string {
+	for i, c := range b {
+		if c == 0 {
+			return string(b[:i])
+		}
+	}
+	return string(b)
+}
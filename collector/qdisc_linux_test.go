@@ -0,0 +1,86 @@
+// Copyright 2016 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collector
+
+import (
+	"syscall"
+	"testing"
+)
+
+func netlinkAttrBytes(typ uint16, value []byte) []byte {
+	l := syscall.SizeofRtAttr + len(value)
+	b := make([]byte, netlinkAlign(l))
+	nativeEndian().PutUint16(b[0:2], uint16(l))
+	nativeEndian().PutUint16(b[2:4], typ)
+	copy(b[syscall.SizeofRtAttr:], value)
+	return b
+}
+
+func TestQdiscMessages(t *testing.T) {
+	ne := nativeEndian()
+
+	basic := make([]byte, tcStatsBasicSz)
+	ne.PutUint64(basic[0:8], 123456)
+	ne.PutUint32(basic[8:12], 789)
+	queue := make([]byte, tcStatsQueueSz)
+	ne.PutUint32(queue[0:4], 3)
+	ne.PutUint32(queue[4:8], 4500)
+	ne.PutUint32(queue[8:12], 17)
+	ne.PutUint32(queue[12:16], 2)
+	ne.PutUint32(queue[16:20], 9)
+	stats2 := append(netlinkAttrBytes(tcaStatsBasic, basic), netlinkAttrBytes(tcaStatsQueue, queue)...)
+
+	data := make([]byte, sizeofTcMsg)
+	ne.PutUint32(data[4:8], 2)
+	ne.PutUint32(data[8:12], 0x80010000)
+	ne.PutUint32(data[12:16], 0xffffffff)
+	data = append(data, netlinkAttrBytes(tcaKind, []byte("fq_codel\x00"))...)
+	data = append(data, netlinkAttrBytes(tcaStats2|syscall.NLA_F_NESTED, stats2)...)
+
+	qdiscs, err := parseQdiscMessages([]syscall.NetlinkMessage{
+		{Header: syscall.NlMsghdr{Type: syscall.RTM_NEWQDISC}, Data: data},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want, got := 1, len(qdiscs); want != got {
+		t.Fatalf("want %d qdiscs, got %d", want, got)
+	}
+
+	q := qdiscs[0]
+	if want, got := "fq_codel", q.kind; want != got {
+		t.Errorf("want kind %q, got %q", want, got)
+	}
+	if want, got := "8001:", tcHandleString(q.handle); want != got {
+		t.Errorf("want handle %q, got %q", want, got)
+	}
+	if want, got := "root", tcHandleString(q.parent); want != got {
+		t.Errorf("want parent %q, got %q", want, got)
+	}
+	if want, got := uint64(123456), q.bytes; want != got {
+		t.Errorf("want %d bytes, got %d", want, got)
+	}
+	if want, got := uint32(17), q.drops; want != got {
+		t.Errorf("want %d drops, got %d", want, got)
+	}
+	if want, got := uint32(2), q.requeues; want != got {
+		t.Errorf("want %d requeues, got %d", want, got)
+	}
+	if want, got := uint32(9), q.overlimits; want != got {
+		t.Errorf("want %d overlimits, got %d", want, got)
+	}
+	if want, got := uint32(4500), q.backlog; want != got {
+		t.Errorf("want %d backlog bytes, got %d", want, got)
+	}
+}
@@ -0,0 +1,105 @@
+// Copyright 2016 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// +build !noreboot
+
+package collector
+
+import (
+	"flag"
+	"fmt"
+	"io/fs"
+	"os"
+	"strings"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var (
+	rebootFlagFile    = flag.String("collector.reboot.flag-file", "/var/run/reboot-required", "File whose presence indicates that a reboot is required.")
+	rebootModulesPath = flag.String("collector.reboot.modules-path", "/lib/modules", "Directory containing one subdirectory per installed kernel.")
+)
+
+type rebootCollector struct {
+	required *prometheus.Desc
+	kernel   *prometheus.Desc
+}
+
+func init() {
+	Factories["reboot"] = NewRebootCollector
+}
+
+// NewRebootCollector returns a new Collector exposing whether the host needs
+// to be rebooted, either because a package requested it or because a newer
+// kernel than the running one is installed.
+func NewRebootCollector() (Collector, error) {
+	return &rebootCollector{
+		required: prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, "reboot", "required"),
+			"Whether a reboot is required, by reason.",
+			[]string{"reason"}, nil,
+		),
+		kernel: prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, "reboot", "kernel_info"),
+			"Running and newest installed kernel release.",
+			[]string{"running", "installed"}, nil,
+		),
+	}, nil
+}
+
+func (c *rebootCollector) Update(ch chan<- prometheus.Metric) (err error) {
+	flagFile := 0.0
+	if _, err := fs.Stat(rootfsFile(*rebootFlagFile)); err == nil {
+		flagFile = 1
+	} else if !os.IsNotExist(err) {
+		return fmt.Errorf("couldn't stat %s: %s", *rebootFlagFile, err)
+	}
+	ch <- prometheus.MustNewConstMetric(c.required, prometheus.GaugeValue, flagFile, "flag_file")
+
+	modulesFS, modules := rootfsFile(*rebootModulesPath)
+	running, installed, err := kernelReleases(procFS, modulesFS, modules)
+	if err != nil {
+		return err
+	}
+	kernel := 0.0
+	if installed != "" && installed != running {
+		kernel = 1
+	}
+	ch <- prometheus.MustNewConstMetric(c.required, prometheus.GaugeValue, kernel, "kernel")
+	ch <- prometheus.MustNewConstMetric(c.kernel, prometheus.GaugeValue, 1, running, installed)
+	return nil
+}
+
+// kernelReleases returns the running kernel release and the newest release
+// found in the named modules directory of fsys.
+func kernelReleases(proc, fsys fileSystem, modules string) (running, installed string, err error) {
+	data, err := readFSFile(proc, "sys/kernel/osrelease")
+	if err != nil {
+		return "", "", fmt.Errorf("couldn't get running kernel release: %s", err)
+	}
+	running = strings.TrimSpace(string(data))
+
+	dirs, err := fs.ReadDir(fsys, modules)
+	if err != nil && !os.IsNotExist(err) {
+		return "", "", fmt.Errorf("couldn't list installed kernels: %s", err)
+	}
+	for _, d := range dirs {
+		if !d.IsDir() {
+			continue
+		}
+		if installed == "" || compareKernelReleases(d.Name(), installed) > 0 {
+			installed = d.Name()
+		}
+	}
+	return running, installed, nil
+}
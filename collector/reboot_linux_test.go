@@ -0,0 +1,47 @@
+// Copyright 2016 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collector
+
+import (
+	"testing"
+)
+
+func TestKernelReleases(t *testing.T) {
+	running, installed, err := kernelReleases(dirFS{root: func() string { return "fixtures/proc" }}, dirFS{root: func() string { return "fixtures" }}, "lib/modules")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want, got := "4.4.0-21-generic", running; want != got {
+		t.Errorf("want running kernel %q, got %q", want, got)
+	}
+	if want, got := "4.4.0-31-generic", installed; want != got {
+		t.Errorf("want installed kernel %q, got %q", want, got)
+	}
+}
+
+func TestCompareKernelReleases(t *testing.T) {
+	for _, tc := range []struct {
+		a, b string
+		cmp  int
+	}{
+		{"4.4.0-9-generic", "4.4.0-21-generic", -1},
+		{"4.6.4-301.fc24.x86_64", "4.6.3-300.fc24.x86_64", 1},
+		{"3.10.0-327.el7.x86_64", "3.10.0-327.el7.x86_64", 0},
+	} {
+		got := compareKernelReleases(tc.a, tc.b)
+		if (got < 0) != (tc.cmp < 0) || (got > 0) != (tc.cmp > 0) {
+			t.Errorf("compareKernelReleases(%q, %q) = %d, want sign of %d", tc.a, tc.b, got, tc.cmp)
+		}
+	}
+}
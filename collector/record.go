@@ -0,0 +1,80 @@
+// Copyright 2016 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collector
+
+import (
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// RecordedPath is a path in procfs or sysfs read by a collector while
+// recording.
+type RecordedPath struct {
+	// Tree is "proc" or "sys".
+	Tree string
+	// Root is where the tree is mounted, Name the path relative to it.
+	Root, Name string
+}
+
+var (
+	recordMtx   sync.Mutex
+	recordPaths map[string]bool
+)
+
+// StartRecording starts remembering the paths collectors read, through
+// procFS and sysFS, other file systems rooted in procfs or sysfs, and
+// procFilePath and sysFilePath. Paths joined to a directory returned by the
+// latter aren't seen.
+func StartRecording() {
+	recordMtx.Lock()
+	defer recordMtx.Unlock()
+	recordPaths = map[string]bool{}
+}
+
+// StopRecording stops recording and returns the recorded paths within
+// procfs and sysfs, sorted by tree and name.
+func StopRecording() []RecordedPath {
+	recordMtx.Lock()
+	paths := recordPaths
+	recordPaths = nil
+	recordMtx.Unlock()
+
+	trees := []RecordedPath{{Tree: "proc", Root: procRoot()}, {Tree: "sys", Root: sysRoot()}}
+	var recorded []RecordedPath
+	for _, t := range trees {
+		root := filepath.Clean(t.Root)
+		var names []string
+		for p := range paths {
+			if rel, err := filepath.Rel(root, p); err == nil && rel != "." && !strings.HasPrefix(rel, "..") {
+				names = append(names, filepath.ToSlash(rel))
+			}
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			recorded = append(recorded, RecordedPath{Tree: t.Tree, Root: root, Name: name})
+		}
+	}
+	return recorded
+}
+
+// recordPath remembers that name was read, if recording.
+func recordPath(name string) {
+	recordMtx.Lock()
+	defer recordMtx.Unlock()
+	if recordPaths != nil {
+		recordPaths[filepath.Clean(name)] = true
+	}
+}
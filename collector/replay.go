@@ -0,0 +1,48 @@
+// Copyright 2016 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collector
+
+import (
+	"path"
+	"sync"
+)
+
+var (
+	replayMtx  sync.RWMutex
+	replayRoot string
+)
+
+// SetReplayRoot makes collectors read procfs and sysfs from the proc and sys
+// directories below root, which holds a recorded snapshot, instead of the
+// paths given by -collector.procfs and -collector.sysfs. An empty root
+// restores them.
+//
+// Collectors that open their files once when created, like ipvs, keep
+// reading the live system.
+func SetReplayRoot(root string) {
+	replayMtx.Lock()
+	defer replayMtx.Unlock()
+	replayRoot = root
+}
+
+// replayPath returns the named directory of the snapshot being replayed, or
+// false if none is.
+func replayPath(name string) (string, bool) {
+	replayMtx.RLock()
+	defer replayMtx.RUnlock()
+	if replayRoot == "" {
+		return "", false
+	}
+	return path.Join(replayRoot, name), true
+}
@@ -0,0 +1,152 @@
+// Copyright 2016 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// +build !noresolved
+
+package collector
+
+import (
+	"fmt"
+
+	"github.com/godbus/dbus"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	resolvedSubsystem = "resolved"
+	resolvedObject    = "org.freedesktop.resolve1"
+	resolvedPath      = "/org/freedesktop/resolve1"
+)
+
+type resolvedCollector struct {
+	cacheSize           *prometheus.Desc
+	cacheHits           *prometheus.Desc
+	cacheMisses         *prometheus.Desc
+	transactions        *prometheus.Desc
+	currentTransactions *prometheus.Desc
+	dnssec              *prometheus.Desc
+}
+
+type resolvedDbus struct {
+	conn   *dbus.Conn
+	object dbus.BusObject
+}
+
+type resolvedInterface interface {
+	getStatistics(name string) ([]uint64, error)
+}
+
+func init() {
+	Factories["resolved"] = NewResolvedCollector
+}
+
+// NewResolvedCollector returns a new Collector exposing cache, transaction
+// and DNSSEC statistics of systemd-resolved.
+func NewResolvedCollector() (Collector, error) {
+	return &resolvedCollector{
+		cacheSize: prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, resolvedSubsystem, "cache_size"),
+			"Number of entries in the systemd-resolved cache.", nil, nil,
+		),
+		cacheHits: prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, resolvedSubsystem, "cache_hits_total"),
+			"Number of systemd-resolved cache hits.", nil, nil,
+		),
+		cacheMisses: prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, resolvedSubsystem, "cache_misses_total"),
+			"Number of systemd-resolved cache misses.", nil, nil,
+		),
+		transactions: prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, resolvedSubsystem, "transactions_total"),
+			"Number of DNS transactions performed by systemd-resolved.", nil, nil,
+		),
+		currentTransactions: prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, resolvedSubsystem, "current_transactions"),
+			"Number of DNS transactions currently in flight.", nil, nil,
+		),
+		dnssec: prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, resolvedSubsystem, "dnssec_verdicts_total"),
+			"Number of DNSSEC validation verdicts by result.", []string{"result"}, nil,
+		),
+	}, nil
+}
+
+func (rc *resolvedCollector) Update(ch chan<- prometheus.Metric) error {
+	conn, err := newSystemBusConn()
+	if err != nil {
+		return fmt.Errorf("unable to connect to dbus: %s", err)
+	}
+	defer conn.Close()
+
+	c := &resolvedDbus{
+		conn:   conn,
+		object: conn.Object(resolvedObject, dbus.ObjectPath(resolvedPath)),
+	}
+	return rc.collectMetrics(ch, c)
+}
+
+func (rc *resolvedCollector) collectMetrics(ch chan<- prometheus.Metric, c resolvedInterface) error {
+	cache, err := c.getStatistics("CacheStatistics")
+	if err != nil {
+		return err
+	}
+	if len(cache) != 3 {
+		return fmt.Errorf("unexpected cache statistics: %v", cache)
+	}
+	ch <- prometheus.MustNewConstMetric(rc.cacheSize, prometheus.GaugeValue, float64(cache[0]))
+	ch <- prometheus.MustNewConstMetric(rc.cacheHits, prometheus.CounterValue, float64(cache[1]))
+	ch <- prometheus.MustNewConstMetric(rc.cacheMisses, prometheus.CounterValue, float64(cache[2]))
+
+	transactions, err := c.getStatistics("TransactionStatistics")
+	if err != nil {
+		return err
+	}
+	if len(transactions) != 2 {
+		return fmt.Errorf("unexpected transaction statistics: %v", transactions)
+	}
+	ch <- prometheus.MustNewConstMetric(rc.currentTransactions, prometheus.GaugeValue, float64(transactions[0]))
+	ch <- prometheus.MustNewConstMetric(rc.transactions, prometheus.CounterValue, float64(transactions[1]))
+
+	dnssec, err := c.getStatistics("DNSSECStatistics")
+	if err != nil {
+		return err
+	}
+	if len(dnssec) != 4 {
+		return fmt.Errorf("unexpected DNSSEC statistics: %v", dnssec)
+	}
+	for i, result := range []string{"secure", "insecure", "bogus", "indeterminate"} {
+		ch <- prometheus.MustNewConstMetric(rc.dnssec, prometheus.CounterValue, float64(dnssec[i]), result)
+	}
+
+	return nil
+}
+
+// getStatistics returns the fields of one of the statistics structs exposed
+// as properties of the resolve1 Manager.
+func (c *resolvedDbus) getStatistics(name string) ([]uint64, error) {
+	v, err := c.object.GetProperty(resolvedObject + ".Manager." + name)
+	if err != nil {
+		return nil, fmt.Errorf("unable to get %s: %s", name, err)
+	}
+	fields, ok := v.Value().([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("unexpected type %s for %s", v.Signature(), name)
+	}
+	stats := make([]uint64, len(fields))
+	for i, f := range fields {
+		if stats[i], ok = f.(uint64); !ok {
+			return nil, fmt.Errorf("unexpected type %s for %s", v.Signature(), name)
+		}
+	}
+	return stats, nil
+}
@@ -0,0 +1,64 @@
+// Copyright 2016 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collector
+
+import (
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+)
+
+type testResolvedInterface struct{}
+
+func (c *testResolvedInterface) getStatistics(name string) ([]uint64, error) {
+	return map[string][]uint64{
+		"CacheStatistics":       {42, 1200, 300},
+		"TransactionStatistics": {1, 1500},
+		"DNSSECStatistics":      {10, 20, 3, 0},
+	}[name], nil
+}
+
+func TestResolvedCollector(t *testing.T) {
+	rc, err := NewResolvedCollector()
+	if err != nil {
+		t.Fatal(err)
+	}
+	ch := make(chan prometheus.Metric, 32)
+	if err := rc.(*resolvedCollector).collectMetrics(ch, &testResolvedInterface{}); err != nil {
+		t.Fatal(err)
+	}
+	close(ch)
+
+	var values []float64
+	for m := range ch {
+		pb := &dto.Metric{}
+		m.Write(pb)
+		if pb.Gauge != nil {
+			values = append(values, pb.Gauge.GetValue())
+		} else {
+			values = append(values, pb.Counter.GetValue())
+		}
+	}
+
+	expected := []float64{42, 1200, 300, 1, 1500, 10, 20, 3, 0}
+	if len(values) != len(expected) {
+		t.Fatalf("want %d metrics, got %d", len(expected), len(values))
+	}
+	for i := range expected {
+		if values[i] != expected[i] {
+			t.Errorf("metric %d: want %f, got %f", i, expected[i], values[i])
+		}
+	}
+}
@@ -0,0 +1,262 @@
+// Copyright 2016 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// +build !noroute
+
+package collector
+
+import (
+	"fmt"
+	"strconv"
+	"syscall"
+	"unsafe"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	routeSubsystem = "route"
+
+	// Not exported by the syscall package.
+	rtaTable = 15
+
+	// Size of struct ndmsg from linux/neighbour.h.
+	sizeofNdMsg = 12
+)
+
+var (
+	routeProtocols = map[uint8]string{
+		syscall.RTPROT_UNSPEC:   "unspec",
+		syscall.RTPROT_REDIRECT: "redirect",
+		syscall.RTPROT_KERNEL:   "kernel",
+		syscall.RTPROT_BOOT:     "boot",
+		syscall.RTPROT_STATIC:   "static",
+		syscall.RTPROT_GATED:    "gated",
+		syscall.RTPROT_RA:       "ra",
+		syscall.RTPROT_MRT:      "mrt",
+		syscall.RTPROT_ZEBRA:    "zebra",
+		syscall.RTPROT_BIRD:     "bird",
+		syscall.RTPROT_DNROUTED: "dnrouted",
+		syscall.RTPROT_XORP:     "xorp",
+		syscall.RTPROT_NTK:      "ntk",
+		syscall.RTPROT_DHCP:     "dhcp",
+		42:                      "babel",
+		186:                     "bgp",
+		187:                     "isis",
+		188:                     "ospf",
+		189:                     "rip",
+	}
+	routeTables = map[uint32]string{
+		syscall.RT_TABLE_DEFAULT: "default",
+		syscall.RT_TABLE_MAIN:    "main",
+		syscall.RT_TABLE_LOCAL:   "local",
+	}
+	neighborStates = []struct {
+		state uint16
+		name  string
+	}{
+		{0x01, "incomplete"},
+		{0x02, "reachable"},
+		{0x04, "stale"},
+		{0x08, "delay"},
+		{0x10, "probe"},
+		{0x20, "failed"},
+		{0x40, "noarp"},
+		{0x80, "permanent"},
+	}
+	routeFamilies = map[uint8]string{
+		syscall.AF_INET:  "inet",
+		syscall.AF_INET6: "inet6",
+	}
+)
+
+type routeKey struct {
+	family, table, protocol string
+}
+
+type neighborKey struct {
+	family, state string
+}
+
+type routeStats struct {
+	routes    map[routeKey]int
+	defaults  map[routeKey]int
+	neighbors map[neighborKey]int
+}
+
+type routeCollector struct {
+	routes    *prometheus.Desc
+	defaults  *prometheus.Desc
+	neighbors *prometheus.Desc
+}
+
+func init() {
+	Factories["route"] = NewRouteCollector
+}
+
+// NewRouteCollector returns a new Collector exposing routing table and
+// neighbor statistics read via rtnetlink.
+func NewRouteCollector() (Collector, error) {
+	return &routeCollector{
+		routes: prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, routeSubsystem, "entries"),
+			"Number of routes per address family, routing table and protocol.",
+			[]string{"family", "table", "protocol"}, nil,
+		),
+		defaults: prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, routeSubsystem, "default_present"),
+			"Whether a default route is present per address family and routing table.",
+			[]string{"family", "table"}, nil,
+		),
+		neighbors: prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, routeSubsystem, "neighbor_entries"),
+			"Number of neighbor cache entries per address family and state.",
+			[]string{"family", "state"}, nil,
+		),
+	}, nil
+}
+
+func (c *routeCollector) Update(ch chan<- prometheus.Metric) (err error) {
+	stats := routeStats{
+		routes:    map[routeKey]int{},
+		defaults:  map[routeKey]int{},
+		neighbors: map[neighborKey]int{},
+	}
+
+	msgs, err := netlinkDump(syscall.RTM_GETROUTE)
+	if err != nil {
+		return fmt.Errorf("couldn't dump routes: %s", err)
+	}
+	if err := parseRouteMessages(msgs, &stats); err != nil {
+		return err
+	}
+
+	msgs, err = netlinkDump(syscall.RTM_GETNEIGH)
+	if err != nil {
+		return fmt.Errorf("couldn't dump neighbors: %s", err)
+	}
+	if err := parseNeighborMessages(msgs, &stats); err != nil {
+		return err
+	}
+
+	for k, v := range stats.routes {
+		ch <- prometheus.MustNewConstMetric(
+			c.routes, prometheus.GaugeValue, float64(v), k.family, k.table, k.protocol)
+	}
+	// Always report the main tables so that a missing default route is
+	// visible as 0 rather than an absent series.
+	for _, family := range routeFamilies {
+		k := routeKey{family: family, table: "main"}
+		if _, ok := stats.defaults[k]; !ok {
+			stats.defaults[k] = 0
+		}
+	}
+	for k, v := range stats.defaults {
+		present := 0.0
+		if v > 0 {
+			present = 1
+		}
+		ch <- prometheus.MustNewConstMetric(
+			c.defaults, prometheus.GaugeValue, present, k.family, k.table)
+	}
+	for k, v := range stats.neighbors {
+		ch <- prometheus.MustNewConstMetric(
+			c.neighbors, prometheus.GaugeValue, float64(v), k.family, k.state)
+	}
+	return nil
+}
+
+func parseRouteMessages(msgs []syscall.NetlinkMessage, stats *routeStats) error {
+	for _, m := range msgs {
+		if m.Header.Type == syscall.NLMSG_DONE {
+			break
+		}
+		if m.Header.Type != syscall.RTM_NEWROUTE {
+			continue
+		}
+		if len(m.Data) < syscall.SizeofRtMsg {
+			return fmt.Errorf("short route message: %d bytes", len(m.Data))
+		}
+		rtm := (*syscall.RtMsg)(unsafe.Pointer(&m.Data[0]))
+		family, ok := routeFamilies[rtm.Family]
+		if !ok {
+			continue
+		}
+
+		table := uint32(rtm.Table)
+		attrs, err := syscall.ParseNetlinkRouteAttr(&m)
+		if err != nil {
+			return fmt.Errorf("couldn't parse route attributes: %s", err)
+		}
+		for _, a := range attrs {
+			if a.Attr.Type == rtaTable && len(a.Value) >= 4 {
+				table = nativeEndian().Uint32(a.Value)
+			}
+		}
+
+		k := routeKey{
+			family:   family,
+			table:    routeTableName(table),
+			protocol: routeProtocolName(rtm.Protocol),
+		}
+		stats.routes[k]++
+		if rtm.Dst_len == 0 && rtm.Type == syscall.RTN_UNICAST {
+			stats.defaults[routeKey{family: k.family, table: k.table}]++
+		}
+	}
+	return nil
+}
+
+func parseNeighborMessages(msgs []syscall.NetlinkMessage, stats *routeStats) error {
+	for _, m := range msgs {
+		if m.Header.Type == syscall.NLMSG_DONE {
+			break
+		}
+		if m.Header.Type != syscall.RTM_NEWNEIGH {
+			continue
+		}
+		if len(m.Data) < sizeofNdMsg {
+			return fmt.Errorf("short neighbor message: %d bytes", len(m.Data))
+		}
+		family, ok := routeFamilies[m.Data[0]]
+		if !ok {
+			continue
+		}
+		state := nativeEndian().Uint16(m.Data[8:10])
+		stats.neighbors[neighborKey{family: family, state: neighborStateName(state)}]++
+	}
+	return nil
+}
+
+func routeTableName(table uint32) string {
+	if name, ok := routeTables[table]; ok {
+		return name
+	}
+	return strconv.FormatUint(uint64(table), 10)
+}
+
+func routeProtocolName(proto uint8) string {
+	if name, ok := routeProtocols[proto]; ok {
+		return name
+	}
+	return strconv.Itoa(int(proto))
+}
+
+func neighborStateName(state uint16) string {
+	for _, s := range neighborStates {
+		if state&s.state != 0 {
+			return s.name
+		}
+	}
+	return "none"
+}
@@ -0,0 +1,88 @@
+// Copyright 2016 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collector
+
+import (
+	"syscall"
+	"testing"
+)
+
+func routeMessage(family, dstLen, table, proto, typ uint8) syscall.NetlinkMessage {
+	data := make([]byte, syscall.SizeofRtMsg)
+	data[0] = family
+	data[1] = dstLen
+	data[4] = table
+	data[5] = proto
+	data[7] = typ
+	return syscall.NetlinkMessage{
+		Header: syscall.NlMsghdr{Type: syscall.RTM_NEWROUTE},
+		Data:   data,
+	}
+}
+
+func neighborMessage(family uint8, state uint16) syscall.NetlinkMessage {
+	data := make([]byte, sizeofNdMsg)
+	data[0] = family
+	nativeEndian().PutUint16(data[8:10], state)
+	return syscall.NetlinkMessage{
+		Header: syscall.NlMsghdr{Type: syscall.RTM_NEWNEIGH},
+		Data:   data,
+	}
+}
+
+func TestRouteMessages(t *testing.T) {
+	stats := routeStats{
+		routes:    map[routeKey]int{},
+		defaults:  map[routeKey]int{},
+		neighbors: map[neighborKey]int{},
+	}
+	msgs := []syscall.NetlinkMessage{
+		routeMessage(syscall.AF_INET, 0, syscall.RT_TABLE_MAIN, syscall.RTPROT_DHCP, syscall.RTN_UNICAST),
+		routeMessage(syscall.AF_INET, 24, syscall.RT_TABLE_MAIN, syscall.RTPROT_KERNEL, syscall.RTN_UNICAST),
+		routeMessage(syscall.AF_INET, 32, syscall.RT_TABLE_LOCAL, syscall.RTPROT_KERNEL, syscall.RTN_LOCAL),
+		routeMessage(syscall.AF_INET6, 64, syscall.RT_TABLE_MAIN, syscall.RTPROT_KERNEL, syscall.RTN_UNICAST),
+		routeMessage(syscall.AF_INET6, 0, 100, 186, syscall.RTN_UNICAST),
+	}
+	if err := parseRouteMessages(msgs, &stats); err != nil {
+		t.Fatal(err)
+	}
+
+	if want, got := 1, stats.routes[routeKey{"inet", "main", "dhcp"}]; want != got {
+		t.Errorf("want %d inet dhcp routes, got %d", want, got)
+	}
+	if want, got := 1, stats.routes[routeKey{"inet6", "100", "bgp"}]; want != got {
+		t.Errorf("want %d inet6 bgp routes in table 100, got %d", want, got)
+	}
+	if want, got := 1, stats.defaults[routeKey{family: "inet", table: "main"}]; want != got {
+		t.Errorf("want %d inet default routes, got %d", want, got)
+	}
+	if _, ok := stats.defaults[routeKey{family: "inet6", table: "main"}]; ok {
+		t.Error("unexpected inet6 default route in main table")
+	}
+
+	msgs = []syscall.NetlinkMessage{
+		neighborMessage(syscall.AF_INET, 0x02),
+		neighborMessage(syscall.AF_INET, 0x02),
+		neighborMessage(syscall.AF_INET6, 0x04),
+	}
+	if err := parseNeighborMessages(msgs, &stats); err != nil {
+		t.Fatal(err)
+	}
+	if want, got := 2, stats.neighbors[neighborKey{"inet", "reachable"}]; want != got {
+		t.Errorf("want %d reachable inet neighbors, got %d", want, got)
+	}
+	if want, got := 1, stats.neighbors[neighborKey{"inet6", "stale"}]; want != got {
+		t.Errorf("want %d stale inet6 neighbors, got %d", want, got)
+	}
+}
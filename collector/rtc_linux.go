@@ -0,0 +1,292 @@
+// Copyright 2016 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// +build !nortc
+
+package collector
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"os"
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/common/log"
+)
+
+const rtcSubsystem = "rtc"
+
+var (
+	// Kernel versions end in the build date, e.g. "#37-Ubuntu SMP Mon Apr
+	// 18 18:33:37 UTC 2016", or Debian's "Debian 6.1.76-1 (2024-02-01)".
+	kernelBuildDateRE = regexp.MustCompile(`[A-Z][a-z]{2} [A-Z][a-z]{2} +\d{1,2} \d\d:\d\d:\d\d \S+ \d{4}`)
+	kernelBuildDayRE  = regexp.MustCompile(`\((\d{4}-\d\d-\d\d)\)`)
+)
+
+type rtcCollector struct {
+	proc, sys fileSystem
+	// readKernelLog returns the kernel log, which has the time the system
+	// clock was set to from the real-time clock at boot.
+	readKernelLog func() ([]byte, error)
+
+	bootOnce sync.Once
+	// bootTime is the time of the real-time clock at boot, -1 if unknown.
+	bootTime int64
+	// buildTime is the kernel's build date, zero if unknown.
+	buildTime time.Time
+
+	time      *prometheus.Desc
+	wakeAlarm *prometheus.Desc
+	reset     *prometheus.Desc
+	info      *prometheus.Desc
+}
+
+// rtcClock is a real-time clock, with times in seconds since the epoch.
+// wakeAlarm is 0 if no wakeup is scheduled.
+type rtcClock struct {
+	device, name    string
+	time, wakeAlarm uint64
+	hctosys         bool
+}
+
+func init() {
+	Factories["rtc"] = NewRTCCollector
+	Maturities["rtc"] = MaturityExperimental
+}
+
+// NewRTCCollector returns a new Collector exposing the time and scheduled
+// wakeups of the real-time clocks in /sys/class/rtc, and whether they were
+// reset, as when their battery is dead.
+func NewRTCCollector() (Collector, error) {
+	return newRTCCollector(procFS, sysFS, readKmsg), nil
+}
+
+func newRTCCollector(proc, sys fileSystem, readKernelLog func() ([]byte, error)) *rtcCollector {
+	return &rtcCollector{
+		proc:          proc,
+		sys:           sys,
+		readKernelLog: readKernelLog,
+		time: prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, rtcSubsystem, "time_seconds"),
+			"Time of the real-time clock in seconds since the epoch, assuming it runs in UTC.",
+			[]string{"device"}, nil,
+		),
+		wakeAlarm: prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, rtcSubsystem, "wakealarm_timestamp_seconds"),
+			"Time the real-time clock is set to wake the system up at, in seconds since the epoch.",
+			[]string{"device"}, nil,
+		),
+		reset: prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, rtcSubsystem, "time_reset"),
+			"Whether the real-time clock was behind the kernel's build date, at boot or now, as after a power loss with a dead CMOS battery.",
+			[]string{"device"}, nil,
+		),
+		info: prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, rtcSubsystem, "info"),
+			"Driver of the real-time clock and whether the system time was set from it at boot.",
+			[]string{"device", "name", "hctosys"}, nil,
+		),
+	}
+}
+
+func (c *rtcCollector) Update(ch chan<- prometheus.Metric) (err error) {
+	clocks, err := readRTCs(c.sys)
+	if err != nil {
+		return err
+	}
+	c.bootOnce.Do(c.readBoot)
+	for _, rtc := range clocks {
+		hctosys := "0"
+		if rtc.hctosys {
+			hctosys = "1"
+		}
+		ch <- prometheus.MustNewConstMetric(c.info, prometheus.GaugeValue, 1, rtc.device, rtc.name, hctosys)
+		ch <- prometheus.MustNewConstMetric(c.time, prometheus.GaugeValue, float64(rtc.time), rtc.device)
+		if rtc.wakeAlarm > 0 {
+			ch <- prometheus.MustNewConstMetric(c.wakeAlarm, prometheus.GaugeValue, float64(rtc.wakeAlarm), rtc.device)
+		}
+		if reset, ok := c.timeReset(rtc); ok {
+			var value float64
+			if reset {
+				value = 1
+			}
+			ch <- prometheus.MustNewConstMetric(c.reset, prometheus.GaugeValue, value, rtc.device)
+		}
+	}
+	return nil
+}
+
+// readBoot reads the kernel's build date and the time of the real-time
+// clock at boot. It is only done once, as the kernel log may be overwritten
+// later on.
+func (c *rtcCollector) readBoot() {
+	c.bootTime = -1
+	if version, err := readFSFile(c.proc, "sys/kernel/version"); err == nil {
+		c.buildTime, _ = parseKernelBuildTime(string(version))
+	}
+	kernelLog, err := c.readKernelLog()
+	if err != nil {
+		log.Debugf("Couldn't read the kernel log for the boot time of the real-time clock: %s", err)
+		return
+	}
+	c.bootTime = parseHctosysTime(kernelLog)
+}
+
+// timeReset reports whether a clock was behind the kernel's build date at
+// boot, if the system time was set from it, or is now. A clock losing power
+// starts over at a date in the past, which the system time is only set from
+// if it isn't corrected before the next boot. ok is false if the build date
+// is unknown.
+func (c *rtcCollector) timeReset(rtc rtcClock) (reset, ok bool) {
+	if c.buildTime.IsZero() {
+		return false, false
+	}
+	build := c.buildTime.Unix()
+	if rtc.hctosys && c.bootTime >= 0 && c.bootTime < build {
+		return true, true
+	}
+	return int64(rtc.time) < build, true
+}
+
+// readRTCs returns the real-time clocks in class/rtc.
+func readRTCs(fsys fileSystem) ([]rtcClock, error) {
+	files, err := fsys.Glob("class/rtc/rtc*/since_epoch")
+	if err != nil {
+		return nil, err
+	}
+	var clocks []rtcClock
+	for _, file := range files {
+		dir := path.Dir(file)
+		rtc := rtcClock{device: path.Base(dir)}
+		if rtc.time, err = readFSUint(fsys, file); err != nil {
+			return nil, err
+		}
+		name, err := readFSFile(fsys, path.Join(dir, "name"))
+		if err != nil {
+			return nil, err
+		}
+		rtc.name = strings.TrimSpace(string(name))
+		if hctosys, err := readFSUint(fsys, path.Join(dir, "hctosys")); err == nil {
+			rtc.hctosys = hctosys == 1
+		} else if !os.IsNotExist(err) {
+			return nil, err
+		}
+		// wakealarm is empty if no alarm is set, and missing if the
+		// clock can't wake the system up.
+		alarm, err := readFSFile(fsys, path.Join(dir, "wakealarm"))
+		if err != nil && !os.IsNotExist(err) {
+			return nil, err
+		}
+		if s := strings.TrimSpace(string(alarm)); s != "" {
+			if rtc.wakeAlarm, err = strconv.ParseUint(s, 10, 64); err != nil {
+				return nil, fmt.Errorf("invalid value in %s: %s", path.Join(dir, "wakealarm"), err)
+			}
+		}
+		clocks = append(clocks, rtc)
+	}
+	return clocks, nil
+}
+
+// parseKernelBuildTime returns the build date at the end of the kernel
+// version in /proc/sys/kernel/version.
+func parseKernelBuildTime(version string) (time.Time, bool) {
+	if m := kernelBuildDayRE.FindStringSubmatch(version); m != nil {
+		t, err := time.Parse("2006-01-02", m[1])
+		return t, err == nil
+	}
+	if m := kernelBuildDateRE.FindString(version); m != "" {
+		t, err := time.Parse("Mon Jan _2 15:04:05 MST 2006", m)
+		return t, err == nil
+	}
+	return time.Time{}, false
+}
+
+// parseHctosysTime returns the time the kernel set the system clock to from
+// the real-time clock at boot, in seconds since the epoch, or -1 if the log
+// doesn't have it. The message looks like "rtc_cmos 00:01: setting system
+// clock to 2016-04-21T09:12:01 UTC (1461229921)".
+func parseHctosysTime(kernelLog []byte) int64 {
+	t := int64(-1)
+	scanner := bufio.NewScanner(bytes.NewReader(kernelLog))
+	for scanner.Scan() {
+		line := scanner.Text()
+		if !strings.Contains(line, "setting system clock to ") || !strings.HasSuffix(line, ")") {
+			continue
+		}
+		i := strings.LastIndex(line, "(")
+		if i < 0 {
+			continue
+		}
+		if v, err := strconv.ParseInt(line[i+1:len(line)-1], 10, 64); err == nil {
+			t = v
+		}
+	}
+	return t
+}
+
+// readKmsg returns the records of the kernel log in /dev/kmsg, one per line.
+// Reading it requires CAP_SYSLOG unless kernel.dmesg_restrict is 0.
+func readKmsg() ([]byte, error) {
+	// Reading non-blocking through the raw descriptor, os.File would wait
+	// for further records in the poller.
+	f, err := openFSFile(devFS, "kmsg", os.O_RDONLY|syscall.O_NONBLOCK)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	conn, err := f.SyscallConn()
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		kernelLog []byte
+		readErr   error
+	)
+	err = conn.Control(func(fd uintptr) {
+		buf := make([]byte, 8192)
+		for {
+			n, err := syscall.Read(int(fd), buf)
+			switch err {
+			case nil:
+				if n == 0 {
+					return
+				}
+				kernelLog = append(kernelLog, buf[:n]...)
+			case syscall.EPIPE:
+				// Records were overwritten while reading, go on
+				// with the next one.
+			case syscall.EAGAIN:
+				return
+			default:
+				readErr = &os.PathError{Op: "read", Path: f.Name(), Err: err}
+				return
+			}
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+	if readErr != nil {
+		return nil, readErr
+	}
+	return kernelLog, nil
+}
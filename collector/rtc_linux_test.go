@@ -0,0 +1,101 @@
+// Copyright 2016 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collector
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestReadRTCs(t *testing.T) {
+	clocks, err := readRTCs(dirFS{root: func() string { return "fixtures/sys" }})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []rtcClock{
+		{device: "rtc0", name: "rtc_cmos", time: 1500000042, wakeAlarm: 1500003600, hctosys: true},
+		{device: "rtc1", name: "pcf8563", time: 946684805},
+	}
+	if !reflect.DeepEqual(want, clocks) {
+		t.Errorf("want %+v, got %+v", want, clocks)
+	}
+}
+
+func TestParseKernelBuildTime(t *testing.T) {
+	for _, tt := range []struct {
+		version string
+		want    time.Time
+		ok      bool
+	}{
+		{"#37-Ubuntu SMP Mon Apr 18 18:33:37 UTC 2016", time.Date(2016, 4, 18, 18, 33, 37, 0, time.UTC), true},
+		{"#40-Ubuntu SMP PREEMPT_DYNAMIC Fri Jul  5 10:34:03 UTC 2024", time.Date(2024, 7, 5, 10, 34, 3, 0, time.UTC), true},
+		{"#1 SMP PREEMPT_DYNAMIC Debian 6.1.76-1 (2024-02-01)", time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC), true},
+		{"#1 SMP PREEMPT", time.Time{}, false},
+	} {
+		got, ok := parseKernelBuildTime(tt.version)
+		if tt.ok != ok || !tt.want.Equal(got) {
+			t.Errorf("%q: want %s, %t, got %s, %t", tt.version, tt.want, tt.ok, got, ok)
+		}
+	}
+}
+
+func TestParseHctosysTime(t *testing.T) {
+	kernelLog := []byte("6,503,1437915,-;rtc_cmos 00:01: RTC can wake from S4\n" +
+		"6,516,1450103,-;rtc_cmos 00:01: setting system clock to 2000-01-01T00:00:04 UTC (946684804)\n")
+	if want, got := int64(946684804), parseHctosysTime(kernelLog); want != got {
+		t.Errorf("want %d, got %d", want, got)
+	}
+	if want, got := int64(-1), parseHctosysTime([]byte("6,503,1437915,-;rtc_cmos 00:01: RTC can wake from S4\n")); want != got {
+		t.Errorf("want %d, got %d", want, got)
+	}
+}
+
+func TestRTCTimeReset(t *testing.T) {
+	proc := dirFS{root: func() string { return "fixtures/proc" }}
+	sys := dirFS{root: func() string { return "fixtures/sys" }}
+	clocks, err := readRTCs(sys)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, tt := range []struct {
+		name      string
+		kernelLog string
+		want      []bool
+	}{
+		{"unreadable log", "", []bool{false, true}},
+		{"reset before boot", "6,516,1450103,-;rtc_cmos 00:01: setting system clock to 2000-01-01T00:00:04 UTC (946684804)\n", []bool{true, true}},
+	} {
+		readKernelLog := func() ([]byte, error) {
+			if tt.kernelLog == "" {
+				return nil, errors.New("permission denied")
+			}
+			return []byte(tt.kernelLog), nil
+		}
+		c := newRTCCollector(proc, sys, readKernelLog)
+		c.bootOnce.Do(c.readBoot)
+		var got []bool
+		for _, rtc := range clocks {
+			reset, ok := c.timeReset(rtc)
+			if !ok {
+				t.Fatalf("%s: build date of the kernel unknown", tt.name)
+			}
+			got = append(got, reset)
+		}
+		if !reflect.DeepEqual(tt.want, got) {
+			t.Errorf("%s: want %v, got %v", tt.name, tt.want, got)
+		}
+	}
+}
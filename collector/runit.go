@@ -81,7 +81,7 @@ func NewRunitCollector() (Collector, error) {
 }
 
 func (c *runitCollector) Update(ch chan<- prometheus.Metric) error {
-	services, err := runit.GetServices("/etc/service")
+	services, err := runit.GetServices(rootfsFilePath("/etc/service"))
 	if err != nil {
 		return err
 	}
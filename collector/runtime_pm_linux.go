@@ -0,0 +1,141 @@
+// Copyright 2016 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// +build !noruntime_pm
+
+package collector
+
+import (
+	"flag"
+	"path"
+	"strings"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const runtimePMSubsystem = "runtime_pm"
+
+var (
+	runtimePMClasses = flag.String("collector.runtime_pm.classes", "drm,net,nvme", "Comma-separated list of device classes whose runtime power management state to expose.")
+
+	// runtimePMStatuses are the values of power/runtime_status.
+	runtimePMStatuses = []string{"active", "suspended", "suspending", "resuming", "error", "unsupported"}
+)
+
+type runtimePMCollector struct {
+	fs fileSystem
+
+	classes []string
+
+	status        *prometheus.Desc
+	activeTime    *prometheus.Desc
+	suspendedTime *prometheus.Desc
+}
+
+// runtimePMDevice is the runtime power management state of the device
+// behind a class device, with times in milliseconds.
+type runtimePMDevice struct {
+	class, device string
+	status        string
+	activeTime    uint64
+	suspendedTime uint64
+}
+
+func init() {
+	Factories["runtime_pm"] = NewRuntimePMCollector
+	Maturities["runtime_pm"] = MaturityBeta
+	SysfsFactories["runtime_pm"] = func(root string) (Collector, error) {
+		return newRuntimePMCollector(rootFS(root)), nil
+	}
+}
+
+// NewRuntimePMCollector returns a new Collector exposing the runtime power
+// management state of the devices of the classes given by
+// -collector.runtime_pm.classes.
+func NewRuntimePMCollector() (Collector, error) {
+	return newRuntimePMCollector(sysFS), nil
+}
+
+func newRuntimePMCollector(fsys fileSystem) *runtimePMCollector {
+	labels := []string{"class", "device"}
+	return &runtimePMCollector{
+		fs:      fsys,
+		classes: strings.Split(*runtimePMClasses, ","),
+		status: prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, runtimePMSubsystem, "status"),
+			"Runtime power management status of the device, 1 for the current status.",
+			append(labels, "status"), nil,
+		),
+		activeTime: prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, runtimePMSubsystem, "active_seconds_total"),
+			"Time the device spent active under runtime power management.",
+			labels, nil,
+		),
+		suspendedTime: prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, runtimePMSubsystem, "suspended_seconds_total"),
+			"Time the device spent suspended under runtime power management.",
+			labels, nil,
+		),
+	}
+}
+
+func (c *runtimePMCollector) Update(ch chan<- prometheus.Metric) (err error) {
+	devices, err := readRuntimePM(c.fs, c.classes)
+	if err != nil {
+		return err
+	}
+	for _, d := range devices {
+		for _, s := range runtimePMStatuses {
+			var value float64
+			if s == d.status {
+				value = 1
+			}
+			ch <- prometheus.MustNewConstMetric(c.status, prometheus.GaugeValue, value, d.class, d.device, s)
+		}
+		ch <- prometheus.MustNewConstMetric(c.activeTime, prometheus.CounterValue, float64(d.activeTime)/1000, d.class, d.device)
+		ch <- prometheus.MustNewConstMetric(c.suspendedTime, prometheus.CounterValue, float64(d.suspendedTime)/1000, d.class, d.device)
+	}
+	return nil
+}
+
+// readRuntimePM returns the runtime power management state of the devices
+// of the given classes. Class devices without a backing device are skipped.
+func readRuntimePM(fsys fileSystem, classes []string) ([]runtimePMDevice, error) {
+	var devices []runtimePMDevice
+	for _, class := range classes {
+		files, err := fsys.Glob(path.Join("class", class, "*/device/power/runtime_status"))
+		if err != nil {
+			return nil, err
+		}
+		for _, file := range files {
+			dir := path.Dir(file)
+			status, err := readFSFile(fsys, file)
+			if err != nil {
+				return nil, err
+			}
+			d := runtimePMDevice{
+				class:  class,
+				device: path.Base(path.Dir(path.Dir(dir))),
+				status: strings.TrimSpace(string(status)),
+			}
+			if d.activeTime, err = readFSUint(fsys, path.Join(dir, "runtime_active_time")); err != nil {
+				return nil, err
+			}
+			if d.suspendedTime, err = readFSUint(fsys, path.Join(dir, "runtime_suspended_time")); err != nil {
+				return nil, err
+			}
+			devices = append(devices, d)
+		}
+	}
+	return devices, nil
+}
@@ -0,0 +1,33 @@
+// Copyright 2016 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collector
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestReadRuntimePM(t *testing.T) {
+	devices, err := readRuntimePM(dirFS{root: func() string { return "fixtures/sys" }}, []string{"drm", "net", "nvme"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []runtimePMDevice{
+		{class: "drm", device: "card0", status: "suspended", activeTime: 507371, suspendedTime: 7632950},
+		{class: "nvme", device: "nvme0", status: "active", activeTime: 8140321},
+	}
+	if !reflect.DeepEqual(want, devices) {
+		t.Errorf("want %+v, got %+v", want, devices)
+	}
+}
@@ -0,0 +1,304 @@
+// Copyright 2016 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// +build !noself
+
+package collector
+
+import (
+	"fmt"
+	"path"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+type selfCollector struct {
+	cpu              *prometheus.Desc
+	wakeups          *prometheus.Desc
+	energy           *prometheus.Desc
+	throttledPeriods *prometheus.Desc
+	throttledSeconds *prometheus.Desc
+	memoryEvents     *prometheus.Desc
+
+	mtx sync.Mutex
+	// last holds the readings of the previous scrape the energy estimate
+	// is based on, estimate accumulates it in joules.
+	last     selfEnergySample
+	estimate float64
+	// tasks holds the timeslices of the exporter's threads in the previous
+	// scrape by thread ID, exited those of the threads that exited since.
+	tasks  map[string]float64
+	exited float64
+}
+
+// selfEnergySample is a reading of the CPU time used by the exporter and by
+// the whole host, in ticks, and of the energy consumed by the CPU packages.
+type selfEnergySample struct {
+	ownTicks, busyTicks float64
+	packages            map[string]raplEnergy
+}
+
+// raplEnergy is the energy counter of a CPU package and its range in
+// joules.
+type raplEnergy struct {
+	joules, maxJoules float64
+}
+
+func init() {
+	Factories["self"] = NewSelfCollector
+	Maturities["self"] = MaturityBeta
+}
+
+// NewSelfCollector returns a new Collector exposing the exporter's own CPU
+// time, wakeups and estimated energy usage.
+func NewSelfCollector() (Collector, error) {
+	return &selfCollector{
+		cpu: prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, "exporter", "self_cpu_seconds_total"),
+			"node_exporter: CPU time used by the exporter, by mode.",
+			[]string{"mode"}, nil,
+		),
+		wakeups: prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, "exporter", "self_wakeups_total"),
+			"node_exporter: Number of times the exporter's threads were scheduled on a CPU.",
+			nil, nil,
+		),
+		energy: prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, "exporter", "self_energy_joules_total"),
+			"node_exporter: Estimated energy used by the exporter, as its share of the host's CPU time times the energy of the CPU packages since the collector started.",
+			nil, nil,
+		),
+		throttledPeriods: prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, "exporter", "self_cpu_throttled_periods_total"),
+			"node_exporter: Number of CPU periods the exporter's cgroup was throttled in.",
+			nil, nil,
+		),
+		throttledSeconds: prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, "exporter", "self_cpu_throttled_seconds_total"),
+			"node_exporter: Time the exporter's cgroup was throttled for.",
+			nil, nil,
+		),
+		memoryEvents: prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, "exporter", "self_memory_events_total"),
+			"node_exporter: Number of times the exporter's cgroup hit a memory boundary or the OOM killer, by event.",
+			[]string{"event"}, nil,
+		),
+	}, nil
+}
+
+func (c *selfCollector) Update(ch chan<- prometheus.Metric) (err error) {
+	user, system, err := readSelfCPUTicks(procFS)
+	if err != nil {
+		return err
+	}
+	ch <- prometheus.MustNewConstMetric(c.cpu, prometheus.CounterValue, user/userHz, "user")
+	ch <- prometheus.MustNewConstMetric(c.cpu, prometheus.CounterValue, system/userHz, "system")
+
+	tasks, err := readSelfWakeups(procFS)
+	if err != nil {
+		return err
+	}
+	ch <- prometheus.MustNewConstMetric(c.wakeups, prometheus.CounterValue, c.addWakeups(tasks))
+
+	// Throttling is only known with cgroup v2.
+	if dir, err := selfCgroup(procFS, sysFS); err == nil {
+		if stat, err := readCgroupKeyValues(sysFS, path.Join(dir, "cpu.stat")); err == nil {
+			if v, ok := stat["nr_throttled"]; ok {
+				ch <- prometheus.MustNewConstMetric(c.throttledPeriods, prometheus.CounterValue, float64(v))
+			}
+			if v, ok := stat["throttled_usec"]; ok {
+				ch <- prometheus.MustNewConstMetric(c.throttledSeconds, prometheus.CounterValue, float64(v)/1e6)
+			}
+		}
+		if events, err := readCgroupKeyValues(sysFS, path.Join(dir, "memory.events")); err == nil {
+			for event, v := range events {
+				ch <- prometheus.MustNewConstMetric(c.memoryEvents, prometheus.CounterValue, float64(v), event)
+			}
+		}
+	}
+
+	packages, err := readRAPLPackageEnergy(sysFS)
+	if err != nil || len(packages) == 0 {
+		// Without RAPL no estimate is possible.
+		return nil
+	}
+	busy, err := readBusyTicks(procFS)
+	if err != nil {
+		return err
+	}
+	estimate := c.addEnergySample(selfEnergySample{
+		ownTicks:  user + system,
+		busyTicks: busy,
+		packages:  packages,
+	})
+	ch <- prometheus.MustNewConstMetric(c.energy, prometheus.CounterValue, estimate)
+	return nil
+}
+
+// addEnergySample adds the exporter's share of the energy used since the
+// previous sample to the estimate and returns it.
+func (c *selfCollector) addEnergySample(s selfEnergySample) float64 {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	last := c.last
+	c.last = s
+	if last.busyTicks == 0 {
+		return c.estimate
+	}
+	var energy float64
+	for zone, p := range s.packages {
+		lp, ok := last.packages[zone]
+		if !ok {
+			continue
+		}
+		e := p.joules - lp.joules
+		if e < 0 {
+			// The counter of this package wrapped around.
+			e += p.maxJoules
+		}
+		energy += e
+	}
+	own, busy := s.ownTicks-last.ownTicks, s.busyTicks-last.busyTicks
+	if busy > 0 && own >= 0 {
+		c.estimate += energy * own / busy
+	}
+	return c.estimate
+}
+
+// readSelfCPUTicks returns the user and system CPU time of the exporter in
+// ticks from /proc/self/stat.
+func readSelfCPUTicks(fsys fileSystem) (user, system float64, err error) {
+	data, err := readFSFile(fsys, "self/stat")
+	if err != nil {
+		return 0, 0, err
+	}
+	// The command may contain spaces and parentheses, the fields start
+	// after the last closing parenthesis with the state.
+	i := strings.LastIndex(string(data), ")")
+	if i < 0 {
+		return 0, 0, fmt.Errorf("invalid /proc/self/stat: %q", data)
+	}
+	fields := strings.Fields(string(data[i+1:]))
+	if len(fields) < 13 {
+		return 0, 0, fmt.Errorf("invalid /proc/self/stat: %q", data)
+	}
+	if user, err = strconv.ParseFloat(fields[11], 64); err != nil {
+		return 0, 0, err
+	}
+	if system, err = strconv.ParseFloat(fields[12], 64); err != nil {
+		return 0, 0, err
+	}
+	return user, system, nil
+}
+
+// addWakeups returns the number of timeslices the exporter's threads ran,
+// given those of the live threads by thread ID. The counts of threads that
+// exited are kept, so that the total doesn't go down.
+func (c *selfCollector) addWakeups(tasks map[string]float64) float64 {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	for tid, n := range c.tasks {
+		// A lower count means the thread ID was reused.
+		if now, ok := tasks[tid]; !ok || now < n {
+			c.exited += n
+		}
+	}
+	c.tasks = tasks
+	total := c.exited
+	for _, n := range tasks {
+		total += n
+	}
+	return total
+}
+
+// readSelfWakeups returns the number of timeslices each of the exporter's
+// threads ran by thread ID from their schedstat files.
+func readSelfWakeups(fsys fileSystem) (map[string]float64, error) {
+	files, err := fsys.Glob("self/task/*/schedstat")
+	if err != nil {
+		return nil, err
+	}
+	tasks := map[string]float64{}
+	for _, file := range files {
+		data, err := readFSFile(fsys, file)
+		if err != nil {
+			// The thread may have exited meanwhile.
+			continue
+		}
+		fields := strings.Fields(string(data))
+		if len(fields) != 3 {
+			return nil, fmt.Errorf("invalid %s: %q", file, data)
+		}
+		n, err := strconv.ParseFloat(fields[2], 64)
+		if err != nil {
+			return nil, err
+		}
+		tasks[path.Base(path.Dir(file))] = n
+	}
+	return tasks, nil
+}
+
+// readBusyTicks returns the non-idle CPU time of the host in ticks from the
+// cpu line of /proc/stat.
+func readBusyTicks(fsys fileSystem) (float64, error) {
+	data, err := readFSFile(fsys, "stat")
+	if err != nil {
+		return 0, err
+	}
+	line := strings.SplitN(string(data), "\n", 2)[0]
+	fields := strings.Fields(line)
+	if len(fields) < 9 || fields[0] != "cpu" {
+		return 0, fmt.Errorf("invalid cpu line in /proc/stat: %q", line)
+	}
+	var busy float64
+	// user, nice, system, irq, softirq and steal; idle and iowait are
+	// left out.
+	for _, i := range []int{1, 2, 3, 6, 7, 8} {
+		v, err := strconv.ParseFloat(fields[i], 64)
+		if err != nil {
+			return 0, err
+		}
+		busy += v
+	}
+	return busy, nil
+}
+
+// readRAPLPackageEnergy returns the energy counters of the CPU packages by
+// powercap zone.
+func readRAPLPackageEnergy(fsys fileSystem) (map[string]raplEnergy, error) {
+	zones, err := fsys.Glob("class/powercap/intel-rapl:*")
+	if err != nil {
+		return nil, err
+	}
+	packages := map[string]raplEnergy{}
+	for _, zone := range zones {
+		name, err := readFSFile(fsys, path.Join(zone, "name"))
+		if err != nil || !strings.HasPrefix(string(name), "package") {
+			continue
+		}
+		// The counter is only readable by root since Linux 5.10.
+		uj, err := readPrivilegedUint(fsys, path.Join(zone, "energy_uj"))
+		if err != nil {
+			return nil, err
+		}
+		maxUJ, err := readFSUint(fsys, path.Join(zone, "max_energy_range_uj"))
+		if err != nil {
+			return nil, err
+		}
+		packages[path.Base(zone)] = raplEnergy{joules: float64(uj) / 1e6, maxJoules: float64(maxUJ) / 1e6}
+	}
+	return packages, nil
+}
@@ -0,0 +1,102 @@
+// Copyright 2016 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collector
+
+import (
+	"reflect"
+	"testing"
+)
+
+var selfTestFS = mapFS{
+	"self/stat":                                       "1234 (node (exporter)) S 1 1234 1234 0 -1 4194560 2403 0 0 0 150 30 0 0 20 0 9 0 1000 741122048 4096 18446744073709551615 1 1 0 0 0 0 0 0 2143420159 0 0 0 17 3 0 0 0 0 0 0 0 0 0 0 0 0 0\n",
+	"self/task/1234/schedstat":                        "41392312 1250891 310\n",
+	"self/task/1240/schedstat":                        "12991022 50101 42\n",
+	"stat":                                            "cpu  301854 612 111922 8979004 3552 2 3944 0 0 0\ncpu0 44490 19 21045 1087069 220 1 3410 0 0 0\n",
+	"class/powercap/intel-rapl:0/name":                "package-0\n",
+	"class/powercap/intel-rapl:0/energy_uj":           "52000000\n",
+	"class/powercap/intel-rapl:0/max_energy_range_uj": "262143328850\n",
+	"class/powercap/intel-rapl:0:0/name":              "core\n",
+}
+
+func TestReadSelf(t *testing.T) {
+	user, system, err := readSelfCPUTicks(selfTestFS)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if user != 150 || system != 30 {
+		t.Errorf("want user 150 and system 30 ticks, got %g and %g", user, system)
+	}
+
+	tasks, err := readSelfWakeups(selfTestFS)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want, got := map[string]float64{"1234": 310, "1240": 42}, tasks; !reflect.DeepEqual(want, got) {
+		t.Errorf("want wakeups %v, got %v", want, got)
+	}
+
+	busy, err := readBusyTicks(selfTestFS)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want, got := 301854.0+612+111922+2+3944, busy; want != got {
+		t.Errorf("want %g busy ticks, got %g", want, got)
+	}
+
+	packages, err := readRAPLPackageEnergy(selfTestFS)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want, got := map[string]raplEnergy{"intel-rapl:0": {joules: 52, maxJoules: 262143.32885}}, packages; !reflect.DeepEqual(want, got) {
+		t.Errorf("want packages %v, got %v", want, got)
+	}
+}
+
+func TestSelfWakeups(t *testing.T) {
+	c := &selfCollector{}
+	for _, tt := range []struct {
+		tasks map[string]float64
+		want  float64
+	}{
+		{map[string]float64{"1": 100, "2": 20}, 120},
+		{map[string]float64{"1": 110, "2": 30}, 140},
+		// Thread 2 exited, its count is kept.
+		{map[string]float64{"1": 120}, 150},
+		// Thread ID 1 was reused by a new thread.
+		{map[string]float64{"1": 5, "3": 10}, 165},
+	} {
+		if got := c.addWakeups(tt.tasks); tt.want != got {
+			t.Errorf("want %g wakeups, got %g", tt.want, got)
+		}
+	}
+}
+
+func TestSelfEnergyEstimate(t *testing.T) {
+	c := &selfCollector{}
+	for _, tt := range []struct {
+		sample selfEnergySample
+		want   float64
+	}{
+		{selfEnergySample{ownTicks: 10, busyTicks: 1000, packages: map[string]raplEnergy{"0": {100, 1000}, "1": {500, 2000}}}, 0},
+		// 10 of 100 busy ticks were the exporter's.
+		{selfEnergySample{ownTicks: 20, busyTicks: 1100, packages: map[string]raplEnergy{"0": {130, 1000}, "1": {520, 2000}}}, 5},
+		// The counter of package 0 wrapped around, only its range is
+		// added.
+		{selfEnergySample{ownTicks: 30, busyTicks: 1200, packages: map[string]raplEnergy{"0": {30, 1000}, "1": {570, 2000}}}, 100},
+	} {
+		if got := c.addEnergySample(tt.sample); tt.want != got {
+			t.Errorf("want estimate %g J, got %g J", tt.want, got)
+		}
+	}
+}
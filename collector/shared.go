@@ -0,0 +1,58 @@
+// Copyright 2016 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collector
+
+import "sync"
+
+// sharedEntry is a value parsed at most once per scrape.
+type sharedEntry struct {
+	once  sync.Once
+	value interface{}
+	err   error
+}
+
+var (
+	sharedMtx     sync.Mutex
+	sharedEntries = map[string]*sharedEntry{}
+)
+
+// BeginScrape discards all values cached by sharedValue. It is called once
+// before the collectors of a scrape are updated.
+//
+// Collectors still running for a previous, concurrent scrape keep the entries
+// they already obtained, later lookups parse their inputs again.
+func BeginScrape() {
+	sharedMtx.Lock()
+	sharedEntries = map[string]*sharedEntry{}
+	sharedMtx.Unlock()
+}
+
+// sharedValue returns the value stored under key for the current scrape,
+// calling parse to produce it on first use. Concurrent callers asking for
+// the same key wait for a single parse and share its result, including
+// errors. The returned value must not be modified.
+func sharedValue(key string, parse func() (interface{}, error)) (interface{}, error) {
+	sharedMtx.Lock()
+	e, ok := sharedEntries[key]
+	if !ok {
+		e = &sharedEntry{}
+		sharedEntries[key] = e
+	}
+	sharedMtx.Unlock()
+
+	e.once.Do(func() {
+		e.value, e.err = parse()
+	})
+	return e.value, e.err
+}
@@ -0,0 +1,65 @@
+// Copyright 2016 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collector
+
+import (
+	"errors"
+	"sync"
+	"testing"
+)
+
+func TestSharedValue(t *testing.T) {
+	BeginScrape()
+
+	var (
+		calls int
+		mtx   sync.Mutex
+		wg    sync.WaitGroup
+	)
+	parse := func() (interface{}, error) {
+		mtx.Lock()
+		calls++
+		mtx.Unlock()
+		return "parsed", nil
+	}
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			v, err := sharedValue("test", parse)
+			if err != nil || v.(string) != "parsed" {
+				t.Errorf("unexpected result %v, %v", v, err)
+			}
+		}()
+	}
+	wg.Wait()
+	if want, got := 1, calls; want != got {
+		t.Errorf("want %d parse calls, got %d", want, got)
+	}
+
+	BeginScrape()
+	if _, err := sharedValue("test", parse); err != nil {
+		t.Fatal(err)
+	}
+	if want, got := 2, calls; want != got {
+		t.Errorf("want %d parse calls after new scrape, got %d", want, got)
+	}
+
+	errParse := errors.New("parse failed")
+	for i := 0; i < 2; i++ {
+		if _, err := sharedValue("failing", func() (interface{}, error) { return nil, errParse }); err != errParse {
+			t.Errorf("want error %v, got %v", errParse, err)
+		}
+	}
+}
@@ -0,0 +1,57 @@
+// Copyright 2016 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collector
+
+import (
+	"bytes"
+	"time"
+)
+
+// fileSnapshot holds the contents of several files read back to back before
+// any of them is parsed, so that values derived from more than one file
+// describe the same instant as closely as possible.
+type fileSnapshot struct {
+	// timestamp is the midpoint between the start of the first and the
+	// end of the last read.
+	timestamp time.Time
+	files     map[string][]byte
+}
+
+// readFileSnapshot reads all named files of fsys into memory. It fails if any
+// of them can't be read.
+func readFileSnapshot(fsys fileSystem, names ...string) (*fileSnapshot, error) {
+	s := &fileSnapshot{files: make(map[string][]byte, len(names))}
+	start := time.Now()
+	for _, name := range names {
+		data, err := readFSFile(fsys, name)
+		if err != nil {
+			return nil, err
+		}
+		s.files[name] = data
+	}
+	end := time.Now()
+	s.timestamp = start.Add(end.Sub(start) / 2)
+	return s, nil
+}
+
+// reader returns a reader over the snapshotted contents of name, which must
+// have been passed to readFileSnapshot.
+func (s *fileSnapshot) reader(name string) *bytes.Reader {
+	return bytes.NewReader(s.files[name])
+}
+
+// unixSeconds returns the snapshot timestamp in seconds since epoch.
+func (s *fileSnapshot) unixSeconds() float64 {
+	return float64(s.timestamp.UnixNano()) / 1e9
+}
@@ -0,0 +1,44 @@
+// Copyright 2016 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collector
+
+import (
+	"io/ioutil"
+	"testing"
+	"time"
+)
+
+func TestReadFileSnapshot(t *testing.T) {
+	before := time.Now()
+	proc := dirFS{root: func() string { return "fixtures/proc" }}
+	s, err := readFileSnapshot(proc, "stat", "uptime")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.timestamp.Before(before) || s.timestamp.After(time.Now()) {
+		t.Errorf("snapshot timestamp %s outside of read window", s.timestamp)
+	}
+
+	data, err := ioutil.ReadAll(s.reader("uptime"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want, got := "4528.59 17826.55\n", string(data); want != got {
+		t.Errorf("want %q, got %q", want, got)
+	}
+
+	if _, err := readFileSnapshot(proc, "stat", "missing"); err == nil {
+		t.Error("expected error for missing file")
+	}
+}
@@ -33,7 +33,7 @@ const (
 var pageSize = os.Getpagesize()
 
 type sockStatCollector struct {
-	metrics map[string]prometheus.Gauge
+	descs *descCache
 }
 
 func init() {
@@ -43,49 +43,43 @@ func init() {
 // NewSockStatCollector returns a new Collector exposing socket stats.
 func NewSockStatCollector() (Collector, error) {
 	return &sockStatCollector{
-		metrics: map[string]prometheus.Gauge{},
+		descs: newDescCache(),
 	}, nil
 }
 
 func (c *sockStatCollector) Update(ch chan<- prometheus.Metric) (err error) {
-	sockStats, err := getSockStats(procFilePath("net/sockstat"))
+	sockStats, err := getSockStats(procFS, "net/sockstat")
 	if err != nil {
 		return fmt.Errorf("couldn't get sockstats: %s", err)
 	}
 	for protocol, protocolStats := range sockStats {
 		for name, value := range protocolStats {
 			key := protocol + "_" + name
-			if _, ok := c.metrics[key]; !ok {
-				c.metrics[key] = prometheus.NewGauge(
-					prometheus.GaugeOpts{
-						Namespace: Namespace,
-						Subsystem: sockStatSubsystem,
-						Name:      key,
-						Help:      fmt.Sprintf("Number of %s sockets in state %s.", protocol, name),
-					},
+			desc := c.descs.get(key, func() *prometheus.Desc {
+				return prometheus.NewDesc(
+					prometheus.BuildFQName(Namespace, sockStatSubsystem, key),
+					fmt.Sprintf("Number of %s sockets in state %s.", protocol, name),
+					nil, nil,
 				)
-			}
+			})
 			v, err := strconv.ParseFloat(value, 64)
 			if err != nil {
 				return fmt.Errorf("invalid value %s in sockstats: %s", value, err)
 			}
-			c.metrics[key].Set(v)
+			ch <- prometheus.MustNewConstMetric(desc, prometheus.GaugeValue, v)
 		}
 	}
-	for _, m := range c.metrics {
-		m.Collect(ch)
-	}
-	return err
+	return nil
 }
 
-func getSockStats(fileName string) (map[string]map[string]string, error) {
-	file, err := os.Open(fileName)
+func getSockStats(fsys fileSystem, name string) (map[string]map[string]string, error) {
+	file, err := fsys.Open(name)
 	if err != nil {
 		return nil, err
 	}
 	defer file.Close()
 
-	return parseSockStats(file, fileName)
+	return parseSockStats(file, name)
 }
 
 func parseSockStats(r io.Reader, fileName string) (map[string]map[string]string, error) {
@@ -96,6 +90,9 @@ func parseSockStats(r io.Reader, fileName string) (map[string]map[string]string,
 
 	for scanner.Scan() {
 		line := strings.Split(string(scanner.Text()), " ")
+		if line[0] == "" {
+			continue
+		}
 		// Remove trailing ':'.
 		protocol := line[0][:len(line[0])-1]
 		sockStat[protocol] = map[string]string{}
@@ -0,0 +1,57 @@
+// Copyright 2016 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// +build !nostat
+
+package collector
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+type statCollector struct {
+	btime *prometheus.Desc
+}
+
+func init() {
+	Factories["stat"] = NewStatCollector
+}
+
+// NewStatCollector returns a new Collector exposing the boot time, derived
+// from the elapsed time of init.
+func NewStatCollector() (Collector, error) {
+	return &statCollector{
+		btime: prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, "", "boot_time"),
+			"Node boot time, in unixtime.",
+			nil, nil,
+		),
+	}, nil
+}
+
+func (c *statCollector) Update(ch chan<- prometheus.Metric) (err error) {
+	out, err := localeCommand("ps", "-o", "etime=", "-p", "1").Output()
+	if err != nil {
+		return fmt.Errorf("couldn't get elapsed time of init: %s", err)
+	}
+	uptime, err := parseEtime(string(out))
+	if err != nil {
+		return err
+	}
+	btime := float64(time.Now().Unix()) - uptime
+	ch <- prometheus.MustNewConstMetric(c.btime, prometheus.GaugeValue, btime)
+	return nil
+}
@@ -17,7 +17,7 @@ package collector
 
 import (
 	"bufio"
-	"os"
+	"io/ioutil"
 	"strconv"
 	"strings"
 
@@ -36,6 +36,9 @@ type statCollector struct {
 	btime        *prometheus.Desc
 	procsRunning *prometheus.Desc
 	procsBlocked *prometheus.Desc
+	uptime       *prometheus.Desc
+	snapshotTime *prometheus.Desc
+	topology     *prometheus.Desc
 }
 
 func init() {
@@ -81,18 +84,54 @@ func NewStatCollector() (Collector, error) {
 			"Number of processes blocked waiting for I/O to complete.",
 			nil, nil,
 		),
+		uptime: prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, "", "uptime_seconds"),
+			"Seconds since boot as reported by /proc/uptime.",
+			nil, nil,
+		),
+		snapshotTime: prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, "stat", "snapshot_timestamp_seconds"),
+			"Unix time at which /proc/stat and /proc/uptime were read.",
+			nil, nil,
+		),
+		topology: prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, "cpu", "topology_info"),
+			"Physical package, die, core and NUMA node of each cpu, for joining with node_cpu.",
+			[]string{"cpu", "package", "die", "core", "node"}, nil,
+		),
 	}, nil
 }
 
 // Expose kernel and system statistics.
 func (c *statCollector) Update(ch chan<- prometheus.Metric) (err error) {
-	file, err := os.Open(procFilePath("stat"))
+	snapshot, err := procStatSnapshot()
+	if err != nil {
+		return err
+	}
+	ch <- prometheus.MustNewConstMetric(c.snapshotTime, prometheus.GaugeValue, snapshot.unixSeconds())
+
+	uptime, err := ioutil.ReadAll(snapshot.reader("uptime"))
+	if err != nil {
+		return err
+	}
+	if parts := strings.Fields(string(uptime)); len(parts) > 0 {
+		value, err := strconv.ParseFloat(parts[0], 64)
+		if err != nil {
+			return err
+		}
+		ch <- prometheus.MustNewConstMetric(c.uptime, prometheus.GaugeValue, value)
+	}
+
+	topology, err := sharedCPUTopology()
 	if err != nil {
 		return err
 	}
-	defer file.Close()
+	for _, t := range topology {
+		ch <- prometheus.MustNewConstMetric(c.topology, prometheus.GaugeValue, 1,
+			"cpu"+strconv.Itoa(t.cpu), t.packageID, t.dieID, t.coreID, t.numaNode)
+	}
 
-	scanner := bufio.NewScanner(file)
+	scanner := bufio.NewScanner(snapshot.reader("stat"))
 	for scanner.Scan() {
 		parts := strings.Fields(scanner.Text())
 		if len(parts) == 0 {
@@ -161,3 +200,16 @@ func (c *statCollector) Update(ch chan<- prometheus.Metric) (err error) {
 	}
 	return err
 }
+
+// procStatSnapshot returns /proc/stat and /proc/uptime, read back to back so
+// that counters and uptime refer to the same instant. The files are read once
+// per scrape and shared with other collectors.
+func procStatSnapshot() (*fileSnapshot, error) {
+	v, err := sharedValue("proc_stat", func() (interface{}, error) {
+		return readFileSnapshot(procFS, "stat", "uptime")
+	})
+	if err != nil {
+		return nil, err
+	}
+	return v.(*fileSnapshot), nil
+}
@@ -0,0 +1,69 @@
+// Copyright 2016 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collector
+
+import (
+	"encoding/json"
+	"flag"
+	"os"
+	"path/filepath"
+)
+
+var stateDir = flag.String("collector.state-dir", "", "Directory in which collectors keep the counters they derive themselves, like filehash changes, login counts and bpftrace retransmits, so that restarts don't reset them. Empty keeps them in memory only.")
+
+// loadState decodes the state last saved by the named collector into v. v
+// is left alone if there is none or -collector.state-dir isn't set.
+func loadState(collector string, v interface{}) error {
+	if *stateDir == "" {
+		return nil
+	}
+	data, err := readFSFile(rootFS(*stateDir), collector+".json")
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, v)
+}
+
+// saveState replaces the state of the named collector with v. The file is
+// flushed to disk and renamed into place, so that a crash or power loss
+// leaves either the old or the new state.
+func saveState(collector string, v interface{}) error {
+	if *stateDir == "" {
+		return nil
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	name := filepath.Join(*stateDir, collector+".json")
+	f, err := os.Create(name + ".tmp")
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	return os.Rename(name+".tmp", name)
+}
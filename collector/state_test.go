@@ -0,0 +1,58 @@
+// Copyright 2016 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collector
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestState(t *testing.T) {
+	dir, err := ioutil.TempDir("", "state")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	defer func(dir string) { *stateDir = dir }(*stateDir)
+
+	*stateDir = ""
+	if err := saveState("test", map[string]float64{"a": 1}); err != nil {
+		t.Fatal(err)
+	}
+	got := map[string]float64{}
+	if err := loadState("test", &got); err != nil || len(got) != 0 {
+		t.Errorf("want nothing restored without a state directory, got %v, %v", got, err)
+	}
+
+	*stateDir = dir
+	if err := loadState("test", &got); err != nil || len(got) != 0 {
+		t.Errorf("want nothing restored before the first save, got %v, %v", got, err)
+	}
+	want := map[string]float64{"a": 1, "b": 2}
+	if err := saveState("test", want); err != nil {
+		t.Fatal(err)
+	}
+	if err := loadState("test", &got); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(want, got) {
+		t.Errorf("want %v, got %v", want, got)
+	}
+	if files, _ := filepath.Glob(filepath.Join(dir, "*")); len(files) != 1 {
+		t.Errorf("want only the state file left, got %v", files)
+	}
+}
@@ -0,0 +1,150 @@
+// Copyright 2016 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// +build !notcp_settings
+
+package collector
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// tcpBufferLimits are the fields of tcp_rmem and tcp_wmem.
+var tcpBufferLimits = []string{"min", "default", "max"}
+
+type tcpSettingsCollector struct {
+	fs fileSystem
+
+	congestionControl *prometheus.Desc
+	rmemMax           *prometheus.Desc
+	wmemMax           *prometheus.Desc
+	tcpRmem           *prometheus.Desc
+	tcpWmem           *prometheus.Desc
+	twReuse           *prometheus.Desc
+}
+
+func init() {
+	Factories["tcp_settings"] = NewTCPSettingsCollector
+	Maturities["tcp_settings"] = MaturityExperimental
+}
+
+// NewTCPSettingsCollector returns a new Collector exposing the TCP
+// congestion control algorithm, socket buffer limits and TIME-WAIT reuse
+// settings from /proc/sys/net.
+func NewTCPSettingsCollector() (Collector, error) {
+	return newTCPSettingsCollector(procFS), nil
+}
+
+func newTCPSettingsCollector(fsys fileSystem) *tcpSettingsCollector {
+	return &tcpSettingsCollector{
+		fs: fsys,
+		congestionControl: prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, "net_ipv4", "tcp_congestion_control"),
+			"Available TCP congestion control algorithms, 1 for the default one.",
+			[]string{"algorithm"}, nil,
+		),
+		rmemMax: prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, "net_core", "rmem_max_bytes"),
+			"Maximum receive buffer size applications may set.",
+			nil, nil,
+		),
+		wmemMax: prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, "net_core", "wmem_max_bytes"),
+			"Maximum send buffer size applications may set.",
+			nil, nil,
+		),
+		tcpRmem: prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, "net_ipv4", "tcp_rmem_bytes"),
+			"Minimum, default and maximum receive buffer size of TCP sockets auto-tuning chooses from.",
+			[]string{"limit"}, nil,
+		),
+		tcpWmem: prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, "net_ipv4", "tcp_wmem_bytes"),
+			"Minimum, default and maximum send buffer size of TCP sockets auto-tuning chooses from.",
+			[]string{"limit"}, nil,
+		),
+		twReuse: prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, "net_ipv4", "tcp_tw_reuse"),
+			"Reuse of TIME-WAIT sockets for new outgoing connections: 0 disabled, 1 enabled, 2 for loopback only.",
+			nil, nil,
+		),
+	}
+}
+
+func (c *tcpSettingsCollector) Update(ch chan<- prometheus.Metric) (err error) {
+	current, err := readFSFile(c.fs, "sys/net/ipv4/tcp_congestion_control")
+	if err != nil {
+		return err
+	}
+	available, err := readFSFile(c.fs, "sys/net/ipv4/tcp_available_congestion_control")
+	if err != nil {
+		return err
+	}
+	for _, algorithm := range strings.Fields(string(available)) {
+		var value float64
+		if algorithm == strings.TrimSpace(string(current)) {
+			value = 1
+		}
+		ch <- prometheus.MustNewConstMetric(c.congestionControl, prometheus.GaugeValue, value, algorithm)
+	}
+
+	for _, s := range []struct {
+		file string
+		desc *prometheus.Desc
+	}{
+		{"sys/net/core/rmem_max", c.rmemMax},
+		{"sys/net/core/wmem_max", c.wmemMax},
+		{"sys/net/ipv4/tcp_tw_reuse", c.twReuse},
+	} {
+		v, err := readFSUint(c.fs, s.file)
+		// net.core isn't namespaced before Linux 4.15 and missing in
+		// containers of older kernels.
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		ch <- prometheus.MustNewConstMetric(s.desc, prometheus.GaugeValue, float64(v))
+	}
+
+	for _, s := range []struct {
+		file string
+		desc *prometheus.Desc
+	}{
+		{"sys/net/ipv4/tcp_rmem", c.tcpRmem},
+		{"sys/net/ipv4/tcp_wmem", c.tcpWmem},
+	} {
+		data, err := readFSFile(c.fs, s.file)
+		if err != nil {
+			return err
+		}
+		fields := strings.Fields(string(data))
+		if len(fields) != len(tcpBufferLimits) {
+			return fmt.Errorf("invalid value in %s: %q", s.file, data)
+		}
+		for i, limit := range tcpBufferLimits {
+			v, err := strconv.ParseUint(fields[i], 10, 64)
+			if err != nil {
+				return fmt.Errorf("invalid value in %s: %s", s.file, err)
+			}
+			ch <- prometheus.MustNewConstMetric(s.desc, prometheus.GaugeValue, float64(v), limit)
+		}
+	}
+	return nil
+}
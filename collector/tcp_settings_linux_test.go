@@ -0,0 +1,57 @@
+// Copyright 2016 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collector
+
+import (
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+)
+
+func TestTCPSettings(t *testing.T) {
+	c := newTCPSettingsCollector(dirFS{root: func() string { return "fixtures/proc" }})
+	ch := make(chan prometheus.Metric, 16)
+	if err := c.Update(ch); err != nil {
+		t.Fatal(err)
+	}
+	close(ch)
+
+	values := map[string]float64{}
+	for m := range ch {
+		pb := &dto.Metric{}
+		m.Write(pb)
+		key := m.Desc().String()
+		for _, l := range pb.Label {
+			key += l.GetValue()
+		}
+		values[key] = pb.Gauge.GetValue()
+	}
+	for _, tt := range []struct {
+		desc  *prometheus.Desc
+		label string
+		want  float64
+	}{
+		{c.congestionControl, "bbr", 1},
+		{c.congestionControl, "cubic", 0},
+		{c.rmemMax, "", 16777216},
+		{c.tcpRmem, "default", 131072},
+		{c.tcpWmem, "max", 4194304},
+		{c.twReuse, "", 2},
+	} {
+		if got, ok := values[tt.desc.String()+tt.label]; !ok || tt.want != got {
+			t.Errorf("want %s %s %f, got %f", tt.desc, tt.label, tt.want, got)
+		}
+	}
+}
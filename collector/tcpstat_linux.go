@@ -66,22 +66,18 @@ func NewTCPStatCollector() (Collector, error) {
 }
 
 func (c *tcpStatCollector) Update(ch chan<- prometheus.Metric) (err error) {
-	tcpStats, err := getTCPStats(procFilePath("net/tcp"))
+	tcpStats, err := getTCPStats(procFS, "net/tcp")
 	if err != nil {
 		return fmt.Errorf("couldn't get tcpstats: %s", err)
 	}
 
 	// if enabled ipv6 system
-	tcp6File := procFilePath("net/tcp6")
-	if _, hasIPv6 := os.Stat(tcp6File); hasIPv6 == nil {
-		tcp6Stats, err := getTCPStats(tcp6File)
-		if err != nil {
-			return fmt.Errorf("couldn't get tcp6stats: %s", err)
-		}
-
-		for st, value := range tcp6Stats {
-			tcpStats[st] += value
-		}
+	tcp6Stats, err := getTCPStats(procFS, "net/tcp6")
+	if err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("couldn't get tcp6stats: %s", err)
+	}
+	for st, value := range tcp6Stats {
+		tcpStats[st] += value
 	}
 
 	for st, value := range tcpStats {
@@ -89,11 +85,11 @@ func (c *tcpStatCollector) Update(ch chan<- prometheus.Metric) (err error) {
 	}
 
 	c.metric.Collect(ch)
-	return err
+	return nil
 }
 
-func getTCPStats(statsFile string) (map[TCPConnectionState]float64, error) {
-	file, err := os.Open(statsFile)
+func getTCPStats(fsys fileSystem, name string) (map[TCPConnectionState]float64, error) {
+	file, err := fsys.Open(name)
 	if err != nil {
 		return nil, err
 	}
@@ -116,6 +112,9 @@ func parseTCPStats(r io.Reader) (map[TCPConnectionState]float64, error) {
 		if strings.HasPrefix(parts[0], "sl") {
 			continue
 		}
+		if len(parts) < 4 {
+			return nil, fmt.Errorf("invalid line in tcp stats: %s", scanner.Text())
+		}
 		st, err := strconv.ParseInt(parts[3], 16, 8)
 		if err != nil {
 			return nil, err
@@ -18,8 +18,7 @@ package collector
 import (
 	"flag"
 	"fmt"
-	"io/ioutil"
-	"os"
+	"io/fs"
 	"path/filepath"
 	"sort"
 	"strings"
@@ -73,17 +72,28 @@ func (c *textFileCollector) parseTextFiles() []*dto.MetricFamily {
 	mtimes := map[string]time.Time{}
 
 	// Iterate over files and accumulate their metrics.
-	files, err := ioutil.ReadDir(c.path)
-	if err != nil && c.path != "" {
-		log.Errorf("Error reading textfile collector directory %s: %s", c.path, err)
-		error = 1.0
+	var files []fs.DirEntry
+	fsys := rootFS(c.path)
+	if c.path != "" {
+		entries, err := fs.ReadDir(fsys, ".")
+		if err != nil {
+			log.Errorf("Error reading textfile collector directory %s: %s", c.path, err)
+			error = 1.0
+		}
+		files = entries
 	}
 	for _, f := range files {
 		if !strings.HasSuffix(f.Name(), ".prom") {
 			continue
 		}
 		path := filepath.Join(c.path, f.Name())
-		file, err := os.Open(path)
+		info, err := f.Info()
+		if err != nil {
+			log.Errorf("Error opening %s: %v", path, err)
+			error = 1.0
+			continue
+		}
+		file, err := fsys.Open(f.Name())
 		if err != nil {
 			log.Errorf("Error opening %s: %v", path, err)
 			error = 1.0
@@ -99,7 +109,7 @@ func (c *textFileCollector) parseTextFiles() []*dto.MetricFamily {
 		}
 		// Only set this once it has been parsed, so that
 		// a failure does not appear fresh.
-		mtimes[f.Name()] = f.ModTime()
+		mtimes[f.Name()] = info.ModTime()
 		for _, mf := range parsedFamilies {
 			if mf.Help == nil {
 				help := fmt.Sprintf("Metric read from %s", path)
@@ -112,7 +122,7 @@ func (c *textFileCollector) parseTextFiles() []*dto.MetricFamily {
 	// Export the mtimes of the successful files.
 	if len(mtimes) > 0 {
 		mtimeMetricFamily := dto.MetricFamily{
-			Name:   proto.String("node_textfile_mtime"),
+			Name:   proto.String(Namespace + "_textfile_mtime"),
 			Help:   proto.String("Unixtime mtime of textfiles successfully read."),
 			Type:   dto.MetricType_GAUGE.Enum(),
 			Metric: []*dto.Metric{},
@@ -142,7 +152,7 @@ func (c *textFileCollector) parseTextFiles() []*dto.MetricFamily {
 	}
 	// Export if there were errors.
 	metricFamilies = append(metricFamilies, &dto.MetricFamily{
-		Name: proto.String("node_textfile_scrape_error"),
+		Name: proto.String(Namespace + "_textfile_scrape_error"),
 		Help: proto.String("1 if there was an error opening or reading a file, 0 otherwise"),
 		Type: dto.MetricType_GAUGE.Enum(),
 		Metric: []*dto.Metric{
@@ -0,0 +1,190 @@
+// Copyright 2016 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// +build !nothermal_zone
+
+package collector
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/common/log"
+)
+
+const thermalZoneSubsystem = "thermal_zone"
+
+type thermalZoneCollector struct {
+	fs fileSystem
+
+	temp      *prometheus.Desc
+	tripPoint *prometheus.Desc
+	headroom  *prometheus.Desc
+}
+
+// thermalZone is a thermal zone with its trip points in millidegrees.
+type thermalZone struct {
+	zone, typ string
+	temp      float64
+	trips     []thermalTrip
+}
+
+type thermalTrip struct {
+	point, typ string
+	temp       float64
+}
+
+func init() {
+	Factories["thermal_zone"] = NewThermalZoneCollector
+	Maturities["thermal_zone"] = MaturityBeta
+	SysfsFactories["thermal_zone"] = func(root string) (Collector, error) {
+		return newThermalZoneCollector(rootFS(root)), nil
+	}
+}
+
+// NewThermalZoneCollector returns a new Collector exposing thermal zone
+// temperatures and their distance to throttling from /sys/class/thermal.
+func NewThermalZoneCollector() (Collector, error) {
+	return newThermalZoneCollector(sysFS), nil
+}
+
+func newThermalZoneCollector(fsys fileSystem) *thermalZoneCollector {
+	labels := []string{"zone", "type"}
+	return &thermalZoneCollector{
+		fs: fsys,
+		temp: prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, thermalZoneSubsystem, "temp_celsius"),
+			"Temperature of the thermal zone.",
+			labels, nil,
+		),
+		tripPoint: prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, thermalZoneSubsystem, "trip_point_celsius"),
+			"Temperature of the thermal zone's trip point.",
+			append(labels, "trip_point", "trip_type"), nil,
+		),
+		headroom: prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, thermalZoneSubsystem, "headroom_celsius"),
+			"Distance of the thermal zone's temperature to its nearest passive or critical trip point, i.e. to throttling or shutdown.",
+			labels, nil,
+		),
+	}
+}
+
+func (c *thermalZoneCollector) Update(ch chan<- prometheus.Metric) (err error) {
+	zones, err := readThermalZones(c.fs)
+	if err != nil {
+		return err
+	}
+	for _, z := range zones {
+		ch <- prometheus.MustNewConstMetric(c.temp, prometheus.GaugeValue, z.temp/1000, z.zone, z.typ)
+		for _, t := range z.trips {
+			ch <- prometheus.MustNewConstMetric(c.tripPoint, prometheus.GaugeValue, t.temp/1000, z.zone, z.typ, t.point, t.typ)
+		}
+		if headroom, ok := z.headroom(); ok {
+			ch <- prometheus.MustNewConstMetric(c.headroom, prometheus.GaugeValue, headroom/1000, z.zone, z.typ)
+		}
+	}
+	return nil
+}
+
+// headroom returns the distance to the nearest passive or critical trip
+// point, which may be negative once it is crossed.
+func (z thermalZone) headroom() (float64, bool) {
+	var (
+		headroom float64
+		found    bool
+	)
+	for _, t := range z.trips {
+		if t.typ != "passive" && t.typ != "critical" {
+			continue
+		}
+		if d := t.temp - z.temp; !found || d < headroom {
+			headroom, found = d, true
+		}
+	}
+	return headroom, found
+}
+
+// readThermalZones returns the thermal zones with a readable temperature
+// and their enabled trip points.
+func readThermalZones(fsys fileSystem) ([]thermalZone, error) {
+	dirs, err := fsys.Glob("class/thermal/thermal_zone*")
+	if err != nil {
+		return nil, err
+	}
+	var zones []thermalZone
+	for _, dir := range dirs {
+		typ, err := readFSFile(fsys, path.Join(dir, "type"))
+		// Unprivileged apps on Android may only read some zones.
+		if os.IsPermission(err) {
+			log.Debugf("Skipping thermal zone %s: %s", path.Base(dir), err)
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		z := thermalZone{
+			zone: strings.TrimPrefix(path.Base(dir), "thermal_zone"),
+			typ:  strings.TrimSpace(string(typ)),
+		}
+		// Zones of devices that are powered down fail to read.
+		if z.temp, err = readThermalTemp(fsys, path.Join(dir, "temp")); err != nil {
+			log.Debugf("Skipping thermal zone %s: %s", z.zone, err)
+			continue
+		}
+		trips, err := fsys.Glob(path.Join(dir, "trip_point_*_type"))
+		if err != nil {
+			return nil, err
+		}
+		for _, file := range trips {
+			point := strings.TrimSuffix(strings.TrimPrefix(path.Base(file), "trip_point_"), "_type")
+			ttyp, err := readFSFile(fsys, file)
+			if os.IsPermission(err) {
+				continue
+			}
+			if err != nil {
+				return nil, err
+			}
+			temp, err := readThermalTemp(fsys, path.Join(dir, "trip_point_"+point+"_temp"))
+			if os.IsPermission(err) {
+				continue
+			}
+			if err != nil {
+				return nil, err
+			}
+			// Drivers report unused trip points as 0 or below.
+			if temp <= 0 {
+				continue
+			}
+			z.trips = append(z.trips, thermalTrip{point: point, typ: strings.TrimSpace(string(ttyp)), temp: temp})
+		}
+		zones = append(zones, z)
+	}
+	return zones, nil
+}
+
+func readThermalTemp(fsys fileSystem, name string) (float64, error) {
+	b, err := readFSFile(fsys, name)
+	if err != nil {
+		return 0, err
+	}
+	temp, err := strconv.ParseFloat(strings.TrimSpace(string(b)), 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid temperature in %s: %s", name, err)
+	}
+	return temp, nil
+}
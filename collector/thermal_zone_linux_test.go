@@ -0,0 +1,90 @@
+// Copyright 2016 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collector
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestReadThermalZones(t *testing.T) {
+	zones, err := readThermalZones(dirFS{root: func() string { return "fixtures/sys" }})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []thermalZone{
+		{
+			zone: "0",
+			typ:  "acpitz",
+			temp: 27800,
+			trips: []thermalTrip{
+				{point: "0", typ: "critical", temp: 105000},
+				{point: "1", typ: "passive", temp: 95000},
+				{point: "2", typ: "active", temp: 50000},
+			},
+		},
+		{zone: "1", typ: "x86_pkg_temp", temp: 55000},
+	}
+	if !reflect.DeepEqual(want, zones) {
+		t.Errorf("want %+v, got %+v", want, zones)
+	}
+}
+
+func TestThermalZoneHeadroom(t *testing.T) {
+	for _, tc := range []struct {
+		zone   thermalZone
+		want   float64
+		wantOK bool
+	}{
+		{
+			zone: thermalZone{temp: 27800, trips: []thermalTrip{
+				{typ: "critical", temp: 105000},
+				{typ: "passive", temp: 95000},
+				{typ: "active", temp: 50000},
+			}},
+			want:   67200,
+			wantOK: true,
+		},
+		{
+			zone:   thermalZone{temp: 101000, trips: []thermalTrip{{typ: "critical", temp: 100000}}},
+			want:   -1000,
+			wantOK: true,
+		},
+		{
+			zone: thermalZone{temp: 55000, trips: []thermalTrip{{typ: "active", temp: 60000}}},
+		},
+	} {
+		got, ok := tc.zone.headroom()
+		if tc.want != got || tc.wantOK != ok {
+			t.Errorf("want %v, %v, got %v, %v", tc.want, tc.wantOK, got, ok)
+		}
+	}
+}
+
+func TestReadThermalZonesPermissionDenied(t *testing.T) {
+	zones, err := readThermalZones(mapFS{
+		"class/thermal/thermal_zone0/type":              "battery\n",
+		"class/thermal/thermal_zone0/temp":              "31000\n",
+		"class/thermal/thermal_zone0/trip_point_0_type": "critical\n",
+		"class/thermal/thermal_zone0/trip_point_0_temp": mapFSDenied,
+		"class/thermal/thermal_zone1/type":              mapFSDenied,
+		"class/thermal/thermal_zone1/temp":              "42000\n",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []thermalZone{{zone: "0", typ: "battery", temp: 31000}}; !reflect.DeepEqual(want, zones) {
+		t.Errorf("want %+v, got %+v", want, zones)
+	}
+}
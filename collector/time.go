@@ -16,14 +16,20 @@
 package collector
 
 import (
+	"errors"
+	"fmt"
 	"time"
 
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/common/log"
 )
 
+var errLeapSecondUnsupported = errors.New("leap second status not supported on this platform")
+
 type timeCollector struct {
 	metric prometheus.Counter
+	zone   *prometheus.Desc
+	leap   *prometheus.Desc
 }
 
 func init() {
@@ -39,6 +45,16 @@ func NewTimeCollector() (Collector, error) {
 			Name:      "time",
 			Help:      "System time in seconds since epoch (1970).",
 		}),
+		zone: prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, "time", "zone_offset_seconds"),
+			"Offset of the local time zone from UTC.",
+			[]string{"time_zone"}, nil,
+		),
+		leap: prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, "time", "leap_second_pending"),
+			"Leap second announced by the kernel: 1 for insertion, -1 for deletion, 0 if none.",
+			nil, nil,
+		),
 	}, nil
 }
 
@@ -47,5 +63,17 @@ func (c *timeCollector) Update(ch chan<- prometheus.Metric) (err error) {
 	log.Debugf("Set time: %f", now)
 	c.metric.Set(now)
 	c.metric.Collect(ch)
-	return err
+
+	zone, offset := time.Now().Zone()
+	ch <- prometheus.MustNewConstMetric(c.zone, prometheus.GaugeValue, float64(offset), zone)
+
+	leap, err := getLeapSecondPending()
+	if err == errLeapSecondUnsupported {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("couldn't get leap second status: %s", err)
+	}
+	ch <- prometheus.MustNewConstMetric(c.leap, prometheus.GaugeValue, leap)
+	return nil
 }
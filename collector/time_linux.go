@@ -0,0 +1,42 @@
+// Copyright 2016 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// +build !notime
+
+package collector
+
+import "syscall"
+
+const (
+	// Status flags from linux/timex.h.
+	staIns = 0x0010
+	staDel = 0x0020
+)
+
+func getLeapSecondPending() (float64, error) {
+	var tx syscall.Timex
+	if _, err := syscall.Adjtimex(&tx); err != nil {
+		return 0, err
+	}
+	return leapSecondFromStatus(tx.Status), nil
+}
+
+func leapSecondFromStatus(status int32) float64 {
+	switch {
+	case status&staIns != 0:
+		return 1
+	case status&staDel != 0:
+		return -1
+	}
+	return 0
+}
@@ -0,0 +1,31 @@
+// Copyright 2016 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collector
+
+import "testing"
+
+func TestLeapSecondFromStatus(t *testing.T) {
+	for _, tc := range []struct {
+		status int32
+		want   float64
+	}{
+		{0x2001, 0},
+		{0x2011, 1},
+		{0x2021, -1},
+	} {
+		if got := leapSecondFromStatus(tc.status); got != tc.want {
+			t.Errorf("leapSecondFromStatus(%#x) = %v, want %v", tc.status, got, tc.want)
+		}
+	}
+}
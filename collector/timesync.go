@@ -0,0 +1,263 @@
+// Copyright 2016 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// +build !notimesync
+
+package collector
+
+import (
+	"encoding/binary"
+	"flag"
+	"fmt"
+	"math"
+	"math/rand"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	timesyncSubsystem = "timesync"
+
+	// chronyd command protocol, see candm.h in the chrony sources.
+	chronyProtoVersion  = 6
+	chronyPktRequest    = 1
+	chronyPktReply      = 2
+	chronyReqTracking   = 33
+	chronyRpyTracking   = 5
+	chronyStatusSuccess = 0
+	chronyRequestSize   = 104
+	chronyReplyHdrSize  = 28
+	chronyTrackingSize  = 76
+)
+
+var (
+	timesyncDaemon       = flag.String("collector.timesync.daemon", "chrony", "Time synchronization daemon to query (chrony or ntpd).")
+	timesyncChronyAddr   = flag.String("collector.timesync.chrony-address", "127.0.0.1:323", "Address of chronyd's command port.")
+	timesyncNtpqCommand  = flag.String("collector.timesync.ntpq-command", "ntpq", "Command to run ntpq.")
+	timesyncQueryTimeout = flag.Duration("collector.timesync.timeout", time.Second, "Timeout for querying the daemon.")
+)
+
+// timesyncStatus is the daemon's view of the clock. Values are in seconds.
+type timesyncStatus struct {
+	stratum        float64
+	offset         float64
+	rootDelay      float64
+	rootDispersion float64
+	frequencyPPM   float64
+	leapStatus     float64
+}
+
+type timesyncCollector struct {
+	query func() (timesyncStatus, error)
+
+	stratum        *prometheus.Desc
+	offset         *prometheus.Desc
+	rootDelay      *prometheus.Desc
+	rootDispersion *prometheus.Desc
+	frequency      *prometheus.Desc
+	leap           *prometheus.Desc
+}
+
+func init() {
+	Factories["timesync"] = NewTimesyncCollector
+}
+
+// NewTimesyncCollector returns a new Collector exposing the clock state as
+// seen by chronyd or ntpd.
+func NewTimesyncCollector() (Collector, error) {
+	c := &timesyncCollector{
+		stratum: prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, timesyncSubsystem, "stratum"),
+			"Stratum of the local clock.", nil, nil,
+		),
+		offset: prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, timesyncSubsystem, "offset_seconds"),
+			"Estimated offset of the local clock from the reference.", nil, nil,
+		),
+		rootDelay: prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, timesyncSubsystem, "root_delay_seconds"),
+			"Total round-trip delay to the stratum 1 reference.", nil, nil,
+		),
+		rootDispersion: prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, timesyncSubsystem, "root_dispersion_seconds"),
+			"Total dispersion to the stratum 1 reference.", nil, nil,
+		),
+		frequency: prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, timesyncSubsystem, "frequency_ppm"),
+			"Frequency error of the local clock in parts per million.", nil, nil,
+		),
+		leap: prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, timesyncSubsystem, "leap_status"),
+			"Leap status (0 normal, 1 insert second, 2 delete second, 3 unsynchronised).", nil, nil,
+		),
+	}
+	switch *timesyncDaemon {
+	case "chrony":
+		c.query = func() (timesyncStatus, error) {
+			return queryChronyTracking(*timesyncChronyAddr, *timesyncQueryTimeout)
+		}
+	case "ntpd":
+		c.query = func() (timesyncStatus, error) {
+			out, err := localeCommand(*timesyncNtpqCommand, "-c", "rv 0 stratum,offset,rootdelay,rootdisp,frequency,leap").Output()
+			if err != nil {
+				return timesyncStatus{}, err
+			}
+			return parseNtpqVariables(string(out))
+		}
+	default:
+		return nil, fmt.Errorf("unsupported time synchronization daemon %q", *timesyncDaemon)
+	}
+	return c, nil
+}
+
+func (c *timesyncCollector) Update(ch chan<- prometheus.Metric) (err error) {
+	s, err := c.query()
+	if err != nil {
+		return fmt.Errorf("couldn't query %s: %s", *timesyncDaemon, err)
+	}
+	ch <- prometheus.MustNewConstMetric(c.stratum, prometheus.GaugeValue, s.stratum)
+	ch <- prometheus.MustNewConstMetric(c.offset, prometheus.GaugeValue, s.offset)
+	ch <- prometheus.MustNewConstMetric(c.rootDelay, prometheus.GaugeValue, s.rootDelay)
+	ch <- prometheus.MustNewConstMetric(c.rootDispersion, prometheus.GaugeValue, s.rootDispersion)
+	ch <- prometheus.MustNewConstMetric(c.frequency, prometheus.GaugeValue, s.frequencyPPM)
+	ch <- prometheus.MustNewConstMetric(c.leap, prometheus.GaugeValue, s.leapStatus)
+	return nil
+}
+
+func queryChronyTracking(addr string, timeout time.Duration) (timesyncStatus, error) {
+	conn, err := net.Dial("udp", addr)
+	if err != nil {
+		return timesyncStatus{}, err
+	}
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(timeout))
+
+	// The request is padded to the size of the reply, chronyd refuses
+	// shorter requests to prevent traffic amplification.
+	seq := rand.Uint32()
+	req := make([]byte, chronyRequestSize)
+	req[0] = chronyProtoVersion
+	req[1] = chronyPktRequest
+	binary.BigEndian.PutUint16(req[4:6], chronyReqTracking)
+	binary.BigEndian.PutUint32(req[8:12], seq)
+	if _, err := conn.Write(req); err != nil {
+		return timesyncStatus{}, err
+	}
+
+	resp := make([]byte, 1024)
+	n, err := conn.Read(resp)
+	if err != nil {
+		return timesyncStatus{}, err
+	}
+	return parseChronyTracking(resp[:n], seq)
+}
+
+// parseChronyTracking decodes a tracking reply from chronyd.
+func parseChronyTracking(b []byte, seq uint32) (timesyncStatus, error) {
+	if len(b) < chronyReplyHdrSize+chronyTrackingSize {
+		return timesyncStatus{}, fmt.Errorf("short reply: %d bytes", len(b))
+	}
+	if b[0] != chronyProtoVersion || b[1] != chronyPktReply {
+		return timesyncStatus{}, fmt.Errorf("unexpected reply version %d or type %d", b[0], b[1])
+	}
+	if status := binary.BigEndian.Uint16(b[8:10]); status != chronyStatusSuccess {
+		return timesyncStatus{}, fmt.Errorf("chronyd returned status %d", status)
+	}
+	if rpy := binary.BigEndian.Uint16(b[6:8]); rpy != chronyRpyTracking {
+		return timesyncStatus{}, fmt.Errorf("unexpected reply %d", rpy)
+	}
+	if got := binary.BigEndian.Uint32(b[16:20]); got != seq {
+		return timesyncStatus{}, fmt.Errorf("sequence mismatch: sent %d, got %d", seq, got)
+	}
+
+	// ref_id(4) ip_addr(20) stratum(2) leap_status(2) ref_time(12) followed
+	// by current_correction, last_offset, rms_offset, freq_ppm,
+	// resid_freq_ppm, skew_ppm, root_delay, root_dispersion and
+	// last_update_interval in chrony's float format.
+	d := b[chronyReplyHdrSize:]
+	float := func(i int) float64 {
+		off := 40 + 4*i
+		return chronyFloat(binary.BigEndian.Uint32(d[off : off+4]))
+	}
+	return timesyncStatus{
+		stratum:        float64(binary.BigEndian.Uint16(d[24:26])),
+		leapStatus:     float64(binary.BigEndian.Uint16(d[26:28])),
+		offset:         float(1),
+		frequencyPPM:   float(3),
+		rootDelay:      float(6),
+		rootDispersion: float(7),
+	}, nil
+}
+
+// chronyFloat decodes chrony's 32 bit float format consisting of a 7 bit
+// signed exponent and a 25 bit signed coefficient.
+func chronyFloat(x uint32) float64 {
+	const (
+		expBits  = 7
+		coefBits = 25
+	)
+	exp := int32(x >> coefBits)
+	if exp >= 1<<(expBits-1) {
+		exp -= 1 << expBits
+	}
+	exp -= coefBits
+
+	coef := int32(x % (1 << coefBits))
+	if coef >= 1<<(coefBits-1) {
+		coef -= 1 << coefBits
+	}
+	return float64(coef) * math.Pow(2, float64(exp))
+}
+
+// parseNtpqVariables parses the output of ntpq -c rv. ntpd reports offset,
+// delay and dispersion in milliseconds.
+func parseNtpqVariables(out string) (timesyncStatus, error) {
+	vars := map[string]float64{}
+	for _, field := range strings.Split(strings.Replace(out, "\n", ",", -1), ",") {
+		parts := strings.SplitN(strings.TrimSpace(field), "=", 2)
+		if len(parts) != 2 {
+			continue
+		}
+		if parts[0] == "leap" {
+			// The leap indicator is given as its two bits, e.g. 01.
+			leap, err := strconv.ParseUint(parts[1], 2, 8)
+			if err != nil {
+				continue
+			}
+			vars[parts[0]] = float64(leap)
+			continue
+		}
+		v, err := strconv.ParseFloat(parts[1], 64)
+		if err != nil {
+			continue
+		}
+		vars[parts[0]] = v
+	}
+	for _, name := range []string{"stratum", "offset", "rootdelay", "rootdisp"} {
+		if _, ok := vars[name]; !ok {
+			return timesyncStatus{}, fmt.Errorf("variable %s missing in ntpq output", name)
+		}
+	}
+	return timesyncStatus{
+		stratum:        vars["stratum"],
+		offset:         vars["offset"] / 1e3,
+		rootDelay:      vars["rootdelay"] / 1e3,
+		rootDispersion: vars["rootdisp"] / 1e3,
+		frequencyPPM:   vars["frequency"],
+		leapStatus:     vars["leap"],
+	}, nil
+}
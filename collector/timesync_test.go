@@ -0,0 +1,82 @@
+// Copyright 2016 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collector
+
+import (
+	"encoding/binary"
+	"testing"
+)
+
+func TestChronyFloat(t *testing.T) {
+	for _, tc := range []struct {
+		in   uint32
+		want float64
+	}{
+		{0x00000000, 0},
+		// Exponent 2, coefficient 1<<23: 2^23 * 2^(2-25) = 1.
+		{0x04800000, 1},
+		// Exponent -10 (0x76), coefficient -1<<24: -2^24 * 2^(-10-25).
+		{0xed000000, -1.0 / 2048},
+	} {
+		if got := chronyFloat(tc.in); got != tc.want {
+			t.Errorf("chronyFloat(%#x) = %g, want %g", tc.in, got, tc.want)
+		}
+	}
+}
+
+func TestParseChronyTracking(t *testing.T) {
+	b := make([]byte, chronyReplyHdrSize+chronyTrackingSize)
+	b[0] = chronyProtoVersion
+	b[1] = chronyPktReply
+	binary.BigEndian.PutUint16(b[6:8], chronyRpyTracking)
+	binary.BigEndian.PutUint32(b[16:20], 42)
+	d := b[chronyReplyHdrSize:]
+	binary.BigEndian.PutUint16(d[24:26], 3)
+	binary.BigEndian.PutUint32(d[44:48], 0xed000000) // last_offset
+	binary.BigEndian.PutUint32(d[64:68], 0x04800000) // root_delay
+
+	s, err := parseChronyTracking(b, 42)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want, got := (timesyncStatus{stratum: 3, offset: -1.0 / 2048, rootDelay: 1}), s; want != got {
+		t.Errorf("want %+v, got %+v", want, got)
+	}
+
+	if _, err := parseChronyTracking(b, 43); err == nil {
+		t.Error("expected sequence mismatch error")
+	}
+}
+
+func TestParseNtpqVariables(t *testing.T) {
+	for leap, want := range map[string]float64{"00": 0, "01": 1, "10": 2, "11": 3} {
+		out := "associd=0 status=0615 leap_none, sync_ntp, 1 event, clock_sync,\n" +
+			"stratum=2, offset=-0.5, rootdelay=12.5, rootdisp=28.25,\n" +
+			"frequency=-14.254, leap=" + leap + "\n"
+		s, err := parseNtpqVariables(out)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want, got := (timesyncStatus{
+			stratum:        2,
+			offset:         -0.0005,
+			rootDelay:      0.0125,
+			rootDispersion: 0.02825,
+			frequencyPPM:   -14.254,
+			leapStatus:     want,
+		}), s; want != got {
+			t.Errorf("leap=%s: want %+v, got %+v", leap, want, got)
+		}
+	}
+}
@@ -0,0 +1,271 @@
+// Copyright 2016 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// +build !notypec
+
+package collector
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const typecSubsystem = "typec"
+
+type typecCollector struct {
+	fs fileSystem
+
+	portInfo      *prometheus.Desc
+	partnerInfo   *prometheus.Desc
+	pdoVoltage    *prometheus.Desc
+	pdoCurrent    *prometheus.Desc
+	altModeActive *prometheus.Desc
+}
+
+type typecPort struct {
+	port                             string
+	dataRole, powerRole, powerOpMode string
+	partner                          *typecPartner
+}
+
+// typecPartner is the device connected to a port, e.g. a dock or charger.
+type typecPartner struct {
+	vendorID, productID string
+	pdRevision          string
+	accessoryMode       string
+	pdos                []typecPDO
+	altModes            []typecAltMode
+}
+
+// typecPDO is a power data object the partner advertises, in millivolts
+// and milliamperes.
+type typecPDO struct {
+	role, pdo, typ string
+	voltage        float64
+	current        float64
+}
+
+type typecAltMode struct {
+	svid, mode, description string
+	active                  bool
+}
+
+func init() {
+	Factories["typec"] = NewTypecCollector
+	Maturities["typec"] = MaturityBeta
+	SysfsFactories["typec"] = func(root string) (Collector, error) {
+		return newTypecCollector(rootFS(root)), nil
+	}
+}
+
+// NewTypecCollector returns a new Collector exposing USB Type-C ports, their
+// partners' identity, power delivery capabilities and alternate modes from
+// /sys/class/typec.
+func NewTypecCollector() (Collector, error) {
+	return newTypecCollector(sysFS), nil
+}
+
+func newTypecCollector(fsys fileSystem) *typecCollector {
+	pdoLabels := []string{"port", "role", "pdo", "type"}
+	return &typecCollector{
+		fs: fsys,
+		portInfo: prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, typecSubsystem, "port_info"),
+			"Current data and power role and power operation mode of the port.",
+			[]string{"port", "data_role", "power_role", "power_operation_mode"}, nil,
+		),
+		partnerInfo: prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, typecSubsystem, "partner_info"),
+			"Identity and USB Power Delivery revision of the device connected to the port.",
+			[]string{"port", "vendor_id", "product_id", "usb_power_delivery_revision", "accessory_mode"}, nil,
+		),
+		pdoVoltage: prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, typecSubsystem, "partner_pdo_voltage_volts"),
+			"Voltage, or maximum voltage of variable supplies, of the power data object advertised by the partner.",
+			pdoLabels, nil,
+		),
+		pdoCurrent: prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, typecSubsystem, "partner_pdo_max_current_amps"),
+			"Maximum current of the power data object advertised by the partner.",
+			pdoLabels, nil,
+		),
+		altModeActive: prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, typecSubsystem, "partner_alt_mode_active"),
+			"Whether the alternate mode of the partner, e.g. DisplayPort, is entered.",
+			[]string{"port", "svid", "mode", "description"}, nil,
+		),
+	}
+}
+
+func (c *typecCollector) Update(ch chan<- prometheus.Metric) (err error) {
+	ports, err := readTypecPorts(c.fs)
+	if err != nil {
+		return err
+	}
+	for _, p := range ports {
+		ch <- prometheus.MustNewConstMetric(c.portInfo, prometheus.GaugeValue, 1, p.port, p.dataRole, p.powerRole, p.powerOpMode)
+		if p.partner == nil {
+			continue
+		}
+		ch <- prometheus.MustNewConstMetric(c.partnerInfo, prometheus.GaugeValue, 1,
+			p.port, p.partner.vendorID, p.partner.productID, p.partner.pdRevision, p.partner.accessoryMode)
+		for _, pdo := range p.partner.pdos {
+			ch <- prometheus.MustNewConstMetric(c.pdoVoltage, prometheus.GaugeValue, pdo.voltage/1000, p.port, pdo.role, pdo.pdo, pdo.typ)
+			ch <- prometheus.MustNewConstMetric(c.pdoCurrent, prometheus.GaugeValue, pdo.current/1000, p.port, pdo.role, pdo.pdo, pdo.typ)
+		}
+		for _, m := range p.partner.altModes {
+			var active float64
+			if m.active {
+				active = 1
+			}
+			ch <- prometheus.MustNewConstMetric(c.altModeActive, prometheus.GaugeValue, active, p.port, m.svid, m.mode, m.description)
+		}
+	}
+	return nil
+}
+
+// readTypecPorts returns the Type-C ports with their partners, if any.
+func readTypecPorts(fsys fileSystem) ([]typecPort, error) {
+	dirs, err := fsys.Glob("class/typec/port*")
+	if err != nil {
+		return nil, err
+	}
+	var ports []typecPort
+	for _, dir := range dirs {
+		// Partners, cables and plugs are listed next to the ports.
+		if strings.Contains(path.Base(dir), "-") {
+			continue
+		}
+		p := typecPort{
+			port:        path.Base(dir),
+			dataRole:    readTypecChoice(fsys, path.Join(dir, "data_role")),
+			powerRole:   readTypecChoice(fsys, path.Join(dir, "power_role")),
+			powerOpMode: readTypecAttr(fsys, path.Join(dir, "power_operation_mode")),
+		}
+		if p.partner, err = readTypecPartner(fsys, dir+"-partner"); err != nil {
+			return nil, err
+		}
+		ports = append(ports, p)
+	}
+	return ports, nil
+}
+
+// readTypecPartner returns the partner in dir, or nil if nothing is
+// connected to the port.
+func readTypecPartner(fsys fileSystem, dir string) (*typecPartner, error) {
+	if _, err := readFSFile(fsys, path.Join(dir, "accessory_mode")); os.IsNotExist(err) {
+		return nil, nil
+	}
+	p := &typecPartner{
+		pdRevision:    readTypecAttr(fsys, path.Join(dir, "usb_power_delivery_revision")),
+		accessoryMode: readTypecAttr(fsys, path.Join(dir, "accessory_mode")),
+	}
+	// The vendor ID is in the low half of the ID header VDO and the
+	// product ID in the high half of the product VDO.
+	if header, err := strconv.ParseUint(readTypecAttr(fsys, path.Join(dir, "identity/id_header")), 0, 32); err == nil && header != 0 {
+		p.vendorID = fmt.Sprintf("%04x", header&0xffff)
+	}
+	if product, err := strconv.ParseUint(readTypecAttr(fsys, path.Join(dir, "identity/product")), 0, 32); err == nil && product != 0 {
+		p.productID = fmt.Sprintf("%04x", product>>16)
+	}
+
+	for _, role := range []string{"source", "sink"} {
+		// Power data objects are named by position and type, next to
+		// entries like uevent and power.
+		pdos, err := fsys.Glob(path.Join(dir, "usb_power_delivery", role+"-capabilities/*:*"))
+		if err != nil {
+			return nil, err
+		}
+		for _, pdoDir := range pdos {
+			pdo, err := readTypecPDO(fsys, pdoDir)
+			if err != nil {
+				return nil, err
+			}
+			pdo.role = role
+			p.pdos = append(p.pdos, pdo)
+		}
+	}
+
+	modes, err := fsys.Glob(path.Join(dir, path.Base(dir)+".*"))
+	if err != nil {
+		return nil, err
+	}
+	for _, m := range modes {
+		p.altModes = append(p.altModes, typecAltMode{
+			svid:        readTypecAttr(fsys, path.Join(m, "svid")),
+			mode:        readTypecAttr(fsys, path.Join(m, "mode")),
+			description: readTypecAttr(fsys, path.Join(m, "description")),
+			active:      readTypecAttr(fsys, path.Join(m, "active")) == "yes",
+		})
+	}
+	return p, nil
+}
+
+// readTypecPDO reads a power data object directory like 1:fixed_supply.
+// Variable supplies only have a voltage range, of which the maximum is
+// returned.
+func readTypecPDO(fsys fileSystem, dir string) (typecPDO, error) {
+	parts := strings.SplitN(path.Base(dir), ":", 2)
+	if len(parts) != 2 {
+		return typecPDO{}, fmt.Errorf("invalid power data object %s", dir)
+	}
+	pdo := typecPDO{pdo: parts[0], typ: parts[1]}
+	voltage := readTypecAttr(fsys, path.Join(dir, "voltage"))
+	if voltage == "" {
+		voltage = readTypecAttr(fsys, path.Join(dir, "maximum_voltage"))
+	}
+	var err error
+	if pdo.voltage, err = parseTypecUnit(voltage, "mV"); err != nil {
+		return typecPDO{}, fmt.Errorf("invalid voltage of %s: %s", dir, err)
+	}
+	current := readTypecAttr(fsys, path.Join(dir, "maximum_current"))
+	if current == "" {
+		// Sink capabilities give the operational current.
+		current = readTypecAttr(fsys, path.Join(dir, "operational_current"))
+	}
+	if pdo.current, err = parseTypecUnit(current, "mA"); err != nil {
+		return typecPDO{}, fmt.Errorf("invalid current of %s: %s", dir, err)
+	}
+	return pdo, nil
+}
+
+// parseTypecUnit parses a value like "5000mV". Empty values, e.g. the
+// current of battery supplies which only give a power, are 0.
+func parseTypecUnit(s, unit string) (float64, error) {
+	if s == "" {
+		return 0, nil
+	}
+	return strconv.ParseFloat(strings.TrimSuffix(s, unit), 64)
+}
+
+// readTypecAttr returns the trimmed content of the attribute, or the empty
+// string if the driver doesn't provide it.
+func readTypecAttr(fsys fileSystem, name string) string {
+	b, err := readFSFile(fsys, name)
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(b))
+}
+
+// readTypecChoice returns the current value of a role attribute like
+// "[host] device".
+func readTypecChoice(fsys fileSystem, name string) string {
+	_, current := parseSysfsChoice(readTypecAttr(fsys, name))
+	return current
+}
@@ -0,0 +1,53 @@
+// Copyright 2016 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collector
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestReadTypecPorts(t *testing.T) {
+	ports, err := readTypecPorts(dirFS{root: func() string { return "fixtures/sys" }})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []typecPort{
+		{
+			port:        "port0",
+			dataRole:    "host",
+			powerRole:   "sink",
+			powerOpMode: "usb_power_delivery",
+			partner: &typecPartner{
+				vendorID:      "17ef",
+				productID:     "a396",
+				pdRevision:    "3.0",
+				accessoryMode: "none",
+				pdos: []typecPDO{
+					{role: "source", pdo: "1", typ: "fixed_supply", voltage: 5000, current: 3000},
+					{role: "source", pdo: "2", typ: "fixed_supply", voltage: 9000, current: 3000},
+					{role: "source", pdo: "3", typ: "fixed_supply", voltage: 20000, current: 3250},
+					{role: "source", pdo: "4", typ: "programmable_supply", voltage: 21000, current: 3000},
+				},
+				altModes: []typecAltMode{
+					{svid: "ff01", mode: "1", description: "DisplayPort", active: true},
+				},
+			},
+		},
+		{port: "port1", dataRole: "host", powerRole: "source", powerOpMode: "default"},
+	}
+	if !reflect.DeepEqual(want, ports) {
+		t.Errorf("want %+v, got %+v", want, ports)
+	}
+}
@@ -0,0 +1,106 @@
+// Copyright 2016 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collector
+
+import (
+	"bufio"
+	"flag"
+	"path"
+	"strings"
+)
+
+var udevDataPath = flag.String("collector.udev.data-path", "/run/udev/data", "Directory of the udev database, read for the udev_properties of the config file.")
+
+// UdevReader reads properties of block devices and network interfaces from
+// the udev database, e.g. ID_SERIAL or ID_PATH. Block devices are looked up
+// by device number (b8:0), network interfaces by index (n2).
+//
+// Properties are cached per UdevReader, so a new one should be used for
+// every scrape. It is not safe for concurrent use.
+type UdevReader struct {
+	properties []string
+	sys        fileSystem
+	data       fileSystem
+
+	cache map[string][]string
+}
+
+// NewUdevReader returns a reader of the given properties.
+func NewUdevReader(properties []string) *UdevReader {
+	return newUdevReader(properties, sysFS, rootFS(rootfsFilePath(*udevDataPath)))
+}
+
+func newUdevReader(properties []string, sys, data fileSystem) *UdevReader {
+	return &UdevReader{
+		properties: properties,
+		sys:        sys,
+		data:       data,
+		cache:      map[string][]string{},
+	}
+}
+
+// Properties returns the values of the properties of device, which is either
+// a kernel name like sda or a device path like /dev/sda1. Unknown devices
+// and properties have empty values.
+func (u *UdevReader) Properties(device string) []string {
+	values, ok := u.cache[device]
+	if !ok {
+		values = u.read(strings.TrimPrefix(device, "/dev/"))
+		u.cache[device] = values
+	}
+	return values
+}
+
+func (u *UdevReader) read(name string) []string {
+	values := make([]string, len(u.properties))
+	file, ok := u.dataFile(name)
+	if !ok {
+		return values
+	}
+	f, err := u.data.Open(file)
+	if err != nil {
+		return values
+	}
+	defer f.Close()
+
+	props := map[string]string{}
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if !strings.HasPrefix(line, "E:") {
+			continue
+		}
+		if kv := strings.SplitN(line[2:], "=", 2); len(kv) == 2 {
+			props[kv[0]] = kv[1]
+		}
+	}
+	for i, p := range u.properties {
+		values[i] = props[p]
+	}
+	return values
+}
+
+// dataFile returns the name of the device's file in the udev database.
+func (u *UdevReader) dataFile(name string) (string, bool) {
+	if strings.Contains(name, "/") {
+		return "", false
+	}
+	if index, err := readFSFile(u.sys, path.Join("class/net", name, "ifindex")); err == nil {
+		return "n" + strings.TrimSpace(string(index)), true
+	}
+	if dev, err := readFSFile(u.sys, path.Join("class/block", name, "dev")); err == nil {
+		return "b" + strings.TrimSpace(string(dev)), true
+	}
+	return "", false
+}
@@ -0,0 +1,39 @@
+// Copyright 2016 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collector
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestUdevReader(t *testing.T) {
+	u := newUdevReader(
+		[]string{"ID_SERIAL", "ID_MODEL", "ID_PATH"},
+		dirFS{root: func() string { return "fixtures/sys" }},
+		dirFS{root: func() string { return "fixtures/run/udev/data" }},
+	)
+	for device, want := range map[string][]string{
+		"sda":                 {"WDC_WD40EFRX-68N32N0_WD-WCC7K1234567", "WDC_WD40EFRX-68N32N0", "pci-0000:00:17.0-ata-1"},
+		"/dev/sda":            {"WDC_WD40EFRX-68N32N0_WD-WCC7K1234567", "WDC_WD40EFRX-68N32N0", "pci-0000:00:17.0-ata-1"},
+		"eth0":                {"", "", "pci-0000:00:1f.6"},
+		"sda1":                {"", "", ""},
+		"bond0":               {"", "", ""},
+		"/dev/mapper/vg-root": {"", "", ""},
+	} {
+		if got := u.Properties(device); !reflect.DeepEqual(want, got) {
+			t.Errorf("want properties %q of %s, got %q", want, device, got)
+		}
+	}
+}
@@ -21,21 +21,9 @@ import (
 	"github.com/prometheus/client_golang/prometheus"
 )
 
-var unameDesc = prometheus.NewDesc(
-	prometheus.BuildFQName(Namespace, "uname", "info"),
-	"Labeled system information as provided by the uname system call.",
-	[]string{
-		"sysname",
-		"release",
-		"version",
-		"machine",
-		"nodename",
-		"domainname",
-	},
-	nil,
-)
-
-type unameCollector struct{}
+type unameCollector struct {
+	info *prometheus.Desc
+}
 
 func init() {
 	Factories["uname"] = newUnameCollector
@@ -43,16 +31,30 @@ func init() {
 
 // NewUnameCollector returns new unameCollector.
 func newUnameCollector() (Collector, error) {
-	return &unameCollector{}, nil
+	return &unameCollector{
+		info: prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, "uname", "info"),
+			"Labeled system information as provided by the uname system call.",
+			[]string{
+				"sysname",
+				"release",
+				"version",
+				"machine",
+				"nodename",
+				"domainname",
+			},
+			nil,
+		),
+	}, nil
 }
 
-func (c unameCollector) Update(ch chan<- prometheus.Metric) error {
+func (c *unameCollector) Update(ch chan<- prometheus.Metric) error {
 	var uname syscall.Utsname
 	if err := syscall.Uname(&uname); err != nil {
 		return err
 	}
 
-	ch <- prometheus.MustNewConstMetric(unameDesc, prometheus.GaugeValue, 1,
+	ch <- prometheus.MustNewConstMetric(c.info, prometheus.GaugeValue, 1,
 		unameToString(uname.Sysname),
 		unameToString(uname.Release),
 		unameToString(uname.Version),
@@ -0,0 +1,34 @@
+// Copyright 2016 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collector
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestUnameNamespace(t *testing.T) {
+	defer func(ns string) { Namespace = ns }(Namespace)
+	if err := SetNamespace("acme"); err != nil {
+		t.Fatal(err)
+	}
+
+	c, err := newUnameCollector()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if desc := c.(*unameCollector).info.String(); !strings.Contains(desc, `"acme_uname_info"`) {
+		t.Errorf("want uname_info in namespace acme, got %s", desc)
+	}
+}
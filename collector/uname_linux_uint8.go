@@ -11,7 +11,7 @@
 // See the License for the specific language governing permissions and
 // limitations under the License.
 
-// +build !nouname,linux,arm !nouname,linux,ppc64 !nouname,linux,ppc64le
+// +build !nouname,linux,arm !nouname,linux,ppc64 !nouname,linux,ppc64le !nouname,linux,riscv64 !nouname,linux,s390x
 
 package collector
 
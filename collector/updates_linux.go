@@ -0,0 +1,265 @@
+// Copyright 2016 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// +build !noupdates
+
+package collector
+
+import (
+	"bufio"
+	"bytes"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/common/log"
+)
+
+var (
+	updatesManager  = flag.String("collector.updates.manager", "", "Package manager to query for pending updates (apt, dnf or pacman). Detected automatically if empty.")
+	updatesInterval = flag.Duration("collector.updates.interval", time.Hour, "Minimum interval between two queries of the package manager.")
+	updatesTimeout  = flag.Duration("collector.updates.timeout", 5*time.Minute, "Timeout after which a package manager query is killed.")
+)
+
+// updatesBackend runs a package manager and returns the number of pending
+// updates and pending security updates.
+type updatesBackend func(timeout time.Duration) (all, security int, err error)
+
+var updatesBackends = map[string]struct {
+	command string
+	run     updatesBackend
+}{
+	"apt":    {"apt-get", updatesApt},
+	"dnf":    {"dnf", updatesDnf},
+	"pacman": {"checkupdates", updatesPacman},
+}
+
+type updatesCollector struct {
+	backend  updatesBackend
+	interval time.Duration
+	timeout  time.Duration
+
+	mtx         sync.Mutex
+	refreshing  bool
+	lastRefresh time.Time
+	all         int
+	security    int
+	failed      bool
+
+	pending    *prometheus.Desc
+	refreshed  *prometheus.Desc
+	queryError *prometheus.Desc
+}
+
+func init() {
+	Factories["updates"] = NewUpdatesCollector
+}
+
+// NewUpdatesCollector returns a new Collector exposing the number of pending
+// package updates. The package manager is queried in the background at most
+// once per -collector.updates.interval, scrapes always return cached values.
+func NewUpdatesCollector() (Collector, error) {
+	manager := *updatesManager
+	if manager == "" {
+		for _, name := range []string{"apt", "dnf", "pacman"} {
+			if _, err := exec.LookPath(updatesBackends[name].command); err == nil {
+				manager = name
+				break
+			}
+		}
+		if manager == "" {
+			return nil, fmt.Errorf("no supported package manager found, see -collector.updates.manager")
+		}
+	}
+	backend, ok := updatesBackends[manager]
+	if !ok {
+		return nil, fmt.Errorf("unsupported package manager %q", manager)
+	}
+
+	return &updatesCollector{
+		backend:  backend.run,
+		interval: *updatesInterval,
+		timeout:  *updatesTimeout,
+		pending: prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, "", "pending_updates"),
+			"Number of pending package updates by type.",
+			[]string{"type"}, nil,
+		),
+		refreshed: prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, "pending_updates", "last_refresh_timestamp_seconds"),
+			"Unixtime of the last successful package manager query.",
+			nil, nil,
+		),
+		queryError: prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, "pending_updates", "query_error"),
+			"1 if the last package manager query failed, 0 otherwise.",
+			nil, nil,
+		),
+	}, nil
+}
+
+func (c *updatesCollector) Update(ch chan<- prometheus.Metric) (err error) {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	if !c.refreshing && time.Since(c.lastRefresh) >= c.interval {
+		c.refreshing = true
+		Go(c.refresh)
+	}
+	if c.lastRefresh.IsZero() && !c.failed {
+		// Nothing to report until the first query finished.
+		return nil
+	}
+
+	failed := 0.0
+	if c.failed {
+		failed = 1
+	}
+	ch <- prometheus.MustNewConstMetric(c.queryError, prometheus.GaugeValue, failed)
+	if c.lastRefresh.IsZero() {
+		return nil
+	}
+	ch <- prometheus.MustNewConstMetric(c.pending, prometheus.GaugeValue, float64(c.all), "all")
+	ch <- prometheus.MustNewConstMetric(c.pending, prometheus.GaugeValue, float64(c.security), "security")
+	ch <- prometheus.MustNewConstMetric(c.refreshed, prometheus.GaugeValue, float64(c.lastRefresh.Unix()))
+	return nil
+}
+
+func (c *updatesCollector) refresh() {
+	all, security, err := c.backend(c.timeout)
+
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	c.refreshing = false
+	if err != nil {
+		log.Errorf("Error querying pending updates: %s", err)
+		c.failed = true
+		return
+	}
+	c.failed = false
+	c.lastRefresh = time.Now()
+	c.all, c.security = all, security
+}
+
+// runUpdatesCommand runs the command with a minimal environment, at the
+// lowest scheduling priority and killed after timeout. Exit codes listed in
+// okCodes are not treated as errors.
+func runUpdatesCommand(timeout time.Duration, okCodes []int, name string, args ...string) ([]byte, error) {
+	var out bytes.Buffer
+	cmd := localeCommand(name, args...)
+	cmd.Env = []string{"PATH=" + os.Getenv("PATH"), "LANG=C", "LC_ALL=C"}
+	cmd.Stdout = &out
+	if err := cmd.Start(); err != nil {
+		return nil, err
+	}
+	syscall.Setpriority(syscall.PRIO_PROCESS, cmd.Process.Pid, 19)
+
+	done := make(chan error, 1)
+	Go(func() { done <- cmd.Wait() })
+	select {
+	case err := <-done:
+		if exitErr, ok := err.(*exec.ExitError); ok {
+			if status, ok := exitErr.Sys().(syscall.WaitStatus); ok {
+				for _, code := range okCodes {
+					if status.ExitStatus() == code {
+						return out.Bytes(), nil
+					}
+				}
+			}
+		}
+		return out.Bytes(), err
+	case <-time.After(timeout):
+		cmd.Process.Kill()
+		<-done
+		return nil, fmt.Errorf("%s timed out after %s", name, timeout)
+	}
+}
+
+func updatesApt(timeout time.Duration) (int, int, error) {
+	out, err := runUpdatesCommand(timeout, nil, "apt-get", "--simulate", "--quiet", "upgrade")
+	if err != nil {
+		return 0, 0, err
+	}
+	return parseAptSimulate(bytes.NewReader(out))
+}
+
+func updatesDnf(timeout time.Duration) (int, int, error) {
+	// check-update exits with 100 if updates are available.
+	out, err := runUpdatesCommand(timeout, []int{100}, "dnf", "--quiet", "--cacheonly", "check-update")
+	if err != nil {
+		return 0, 0, err
+	}
+	all, err := parseDnfCheckUpdate(bytes.NewReader(out))
+	if err != nil {
+		return 0, 0, err
+	}
+	out, err = runUpdatesCommand(timeout, []int{100}, "dnf", "--quiet", "--cacheonly", "--security", "check-update")
+	if err != nil {
+		return 0, 0, err
+	}
+	security, err := parseDnfCheckUpdate(bytes.NewReader(out))
+	return all, security, err
+}
+
+func updatesPacman(timeout time.Duration) (int, int, error) {
+	// checkupdates exits with 2 if no updates are available. Arch Linux has
+	// no notion of security updates.
+	out, err := runUpdatesCommand(timeout, []int{2}, "checkupdates")
+	if err != nil {
+		return 0, 0, err
+	}
+	return len(strings.Fields(string(out))) / 4, 0, nil
+}
+
+// parseAptSimulate counts the packages apt-get --simulate upgrade would
+// install, and how many of them come from a security archive.
+func parseAptSimulate(r io.Reader) (all, security int, err error) {
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if !strings.HasPrefix(line, "Inst ") {
+			continue
+		}
+		all++
+		if strings.Contains(line, "-security") {
+			security++
+		}
+	}
+	return all, security, scanner.Err()
+}
+
+// parseDnfCheckUpdate counts the package lines of dnf check-update output,
+// stopping at the list of obsoleted packages.
+func parseDnfCheckUpdate(r io.Reader) (int, error) {
+	var (
+		count   int
+		scanner = bufio.NewScanner(r)
+	)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if strings.HasPrefix(line, "Obsoleting Packages") {
+			break
+		}
+		if len(strings.Fields(line)) == 3 && !strings.HasPrefix(line, "Last metadata") {
+			count++
+		}
+	}
+	return count, scanner.Err()
+}
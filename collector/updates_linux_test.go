@@ -0,0 +1,54 @@
+// Copyright 2016 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collector
+
+import (
+	"os"
+	"testing"
+)
+
+func TestParseAptSimulate(t *testing.T) {
+	file, err := os.Open("fixtures/apt-get-simulate.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+
+	all, security, err := parseAptSimulate(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want, got := 3, all; want != got {
+		t.Errorf("want %d pending updates, got %d", want, got)
+	}
+	if want, got := 2, security; want != got {
+		t.Errorf("want %d pending security updates, got %d", want, got)
+	}
+}
+
+func TestParseDnfCheckUpdate(t *testing.T) {
+	file, err := os.Open("fixtures/dnf-check-update.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+
+	all, err := parseDnfCheckUpdate(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want, got := 3, all; want != got {
+		t.Errorf("want %d pending updates, got %d", want, got)
+	}
+}
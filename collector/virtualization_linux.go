@@ -0,0 +1,207 @@
+// Copyright 2016 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// +build !novirtualization
+
+package collector
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const virtualizationSubsystem = "virtualization"
+
+var (
+	// Substrings of DMI vendor or product names identifying a hypervisor,
+	// checked in order.
+	dmiHypervisors = []struct {
+		match, hypervisor string
+	}{
+		{"KVM", "kvm"},
+		{"QEMU", "kvm"},
+		{"Amazon EC2", "amazon"},
+		{"Google", "google"},
+		{"VMware", "vmware"},
+		{"VirtualBox", "virtualbox"},
+		{"innotek", "virtualbox"},
+		{"Xen", "xen"},
+		{"Parallels", "parallels"},
+		{"Bochs", "bochs"},
+		{"Virtual Machine", "hyperv"},
+	}
+)
+
+// cpuTimes holds the aggregated steal and total time from /proc/stat in
+// ticks.
+type cpuTimes struct {
+	steal, total float64
+}
+
+type virtualizationCollector struct {
+	mtx  sync.Mutex
+	prev cpuTimes
+
+	info       *prometheus.Desc
+	steal      *prometheus.Desc
+	stealRatio *prometheus.Desc
+}
+
+func init() {
+	Factories["virtualization"] = NewVirtualizationCollector
+}
+
+// NewVirtualizationCollector returns a new Collector exposing the detected
+// hypervisor and how much CPU time it steals from this guest.
+func NewVirtualizationCollector() (Collector, error) {
+	return &virtualizationCollector{
+		info: prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, virtualizationSubsystem, "info"),
+			"Detected hypervisor, \"none\" on bare metal.",
+			[]string{"hypervisor", "product"}, nil,
+		),
+		steal: prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, virtualizationSubsystem, "steal_seconds_total"),
+			"Seconds all CPUs spent waiting for the hypervisor to schedule them.",
+			nil, nil,
+		),
+		stealRatio: prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, virtualizationSubsystem, "steal_ratio"),
+			"Ratio of CPU time stolen by the hypervisor since the previous scrape.",
+			nil, nil,
+		),
+	}, nil
+}
+
+func (c *virtualizationCollector) Update(ch chan<- prometheus.Metric) (err error) {
+	hypervisor, product, err := detectHypervisor()
+	if err != nil {
+		return fmt.Errorf("couldn't detect hypervisor: %s", err)
+	}
+	ch <- prometheus.MustNewConstMetric(c.info, prometheus.GaugeValue, 1, hypervisor, product)
+
+	snapshot, err := procStatSnapshot()
+	if err != nil {
+		return fmt.Errorf("couldn't get cpu times: %s", err)
+	}
+	times, err := readCPUTimes(snapshot.reader("stat"))
+	if err != nil {
+		return fmt.Errorf("couldn't get cpu times: %s", err)
+	}
+	ch <- prometheus.MustNewConstMetric(c.steal, prometheus.CounterValue, times.steal/userHz)
+
+	c.mtx.Lock()
+	prev := c.prev
+	c.prev = times
+	c.mtx.Unlock()
+	if ratio, ok := stealRatio(prev, times); ok {
+		ch <- prometheus.MustNewConstMetric(c.stealRatio, prometheus.GaugeValue, ratio)
+	}
+	return nil
+}
+
+// detectHypervisor identifies the hypervisor from /sys/hypervisor, the DMI
+// strings and finally the hypervisor cpu flag.
+func detectHypervisor() (hypervisor, product string, err error) {
+	if t, err := readFSFile(sysFS, "hypervisor/type"); err == nil {
+		return strings.TrimSpace(string(t)), "", nil
+	}
+
+	var dmi []string
+	for _, f := range []string{"sys_vendor", "product_name", "bios_vendor"} {
+		data, err := readFSFile(sysFS, "class/dmi/id/"+f)
+		if err != nil && !os.IsNotExist(err) {
+			return "", "", err
+		}
+		dmi = append(dmi, strings.TrimSpace(string(data)))
+	}
+	product = dmi[1]
+	for _, h := range dmiHypervisors {
+		for _, s := range dmi {
+			if strings.Contains(s, h.match) {
+				return h.hypervisor, product, nil
+			}
+		}
+	}
+
+	file, err := procFS.Open("cpuinfo")
+	if os.IsNotExist(err) {
+		return "none", product, nil
+	}
+	if err != nil {
+		return "", "", err
+	}
+	defer file.Close()
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if !strings.HasPrefix(line, "flags") {
+			continue
+		}
+		for _, flag := range strings.Fields(line) {
+			if flag == "hypervisor" {
+				return "unknown", product, nil
+			}
+		}
+		break
+	}
+	return "none", product, scanner.Err()
+}
+
+func readCPUTimes(r io.Reader) (cpuTimes, error) {
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		parts := strings.Fields(scanner.Text())
+		if len(parts) == 0 || parts[0] != "cpu" {
+			continue
+		}
+		var times cpuTimes
+		// Guest time is already included in user time.
+		for i, v := range parts[1:] {
+			if i >= 8 {
+				break
+			}
+			value, err := strconv.ParseFloat(v, 64)
+			if err != nil {
+				return cpuTimes{}, err
+			}
+			times.total += value
+			if i == 7 {
+				times.steal = value
+			}
+		}
+		return times, nil
+	}
+	if err := scanner.Err(); err != nil {
+		return cpuTimes{}, err
+	}
+	return cpuTimes{}, errors.New("no cpu line in /proc/stat")
+}
+
+// stealRatio returns the share of stolen time between two readings. It
+// returns false if there is no previous reading or the counters reset.
+func stealRatio(prev, cur cpuTimes) (float64, bool) {
+	total := cur.total - prev.total
+	if prev.total == 0 || total <= 0 || cur.steal < prev.steal {
+		return 0, false
+	}
+	return (cur.steal - prev.steal) / total, true
+}
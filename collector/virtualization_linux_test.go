@@ -0,0 +1,64 @@
+// Copyright 2016 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collector
+
+import (
+	"flag"
+	"os"
+	"testing"
+)
+
+func TestDetectHypervisor(t *testing.T) {
+	if err := flag.Set("collector.sysfs", "fixtures/sys"); err != nil {
+		t.Fatal(err)
+	}
+	if err := flag.Set("collector.procfs", "fixtures/proc"); err != nil {
+		t.Fatal(err)
+	}
+
+	hypervisor, product, err := detectHypervisor()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want, got := "kvm", hypervisor; want != got {
+		t.Errorf("want hypervisor %q, got %q", want, got)
+	}
+	if want, got := "Standard PC (i440FX + PIIX, 1996)", product; want != got {
+		t.Errorf("want product %q, got %q", want, got)
+	}
+}
+
+func TestCPUTimes(t *testing.T) {
+	file, err := os.Open("fixtures/proc/stat")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+
+	times, err := readCPUTimes(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want, got := 9400890.0, times.total; want != got {
+		t.Errorf("want total %f, got %f", want, got)
+	}
+
+	if _, ok := stealRatio(cpuTimes{}, times); ok {
+		t.Error("expected no ratio without previous reading")
+	}
+	ratio, ok := stealRatio(cpuTimes{steal: 100, total: 1000}, cpuTimes{steal: 150, total: 1200})
+	if !ok || ratio != 0.25 {
+		t.Errorf("want steal ratio 0.25, got %f (%v)", ratio, ok)
+	}
+}
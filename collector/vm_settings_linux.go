@@ -0,0 +1,83 @@
+// Copyright 2016 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// +build !novm_settings
+
+package collector
+
+import (
+	"os"
+	"path"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const vmSettingsSubsystem = "vm"
+
+// vmSettings are the virtual memory sysctls in /proc/sys/vm, with the
+// factor converting them to the metric's unit.
+var vmSettings = []struct {
+	file, name, help string
+	scale            float64
+}{
+	{"overcommit_memory", "overcommit_memory", "Overcommit mode: 0 heuristic, 1 always, 2 never overcommit beyond the commit limit.", 1},
+	{"overcommit_ratio", "overcommit_ratio", "Percentage of physical memory in the commit limit in overcommit mode 2, unless overcommit_kbytes is set.", 1},
+	{"overcommit_kbytes", "overcommit_bytes", "Physical memory in the commit limit in overcommit mode 2, 0 if overcommit_ratio is used.", 1024},
+	{"swappiness", "swappiness", "Relative cost of swapping compared to dropping file pages, from 0 to 200.", 1},
+	{"dirty_ratio", "dirty_ratio", "Percentage of available memory that may be dirty before writers are throttled, unless dirty_bytes is set.", 1},
+	{"dirty_bytes", "dirty_bytes", "Memory that may be dirty before writers are throttled, 0 if dirty_ratio is used.", 1},
+	{"dirty_background_ratio", "dirty_background_ratio", "Percentage of available memory that may be dirty before background writeback starts, unless dirty_background_bytes is set.", 1},
+	{"dirty_background_bytes", "dirty_background_bytes", "Memory that may be dirty before background writeback starts, 0 if dirty_background_ratio is used.", 1},
+}
+
+type vmSettingsCollector struct {
+	fs    fileSystem
+	descs []*prometheus.Desc
+}
+
+func init() {
+	Factories["vm_settings"] = NewVMSettingsCollector
+	Maturities["vm_settings"] = MaturityExperimental
+}
+
+// NewVMSettingsCollector returns a new Collector exposing the overcommit,
+// swappiness and dirty page settings of the virtual memory subsystem.
+func NewVMSettingsCollector() (Collector, error) {
+	return newVMSettingsCollector(procFS), nil
+}
+
+func newVMSettingsCollector(fsys fileSystem) *vmSettingsCollector {
+	c := &vmSettingsCollector{fs: fsys}
+	for _, s := range vmSettings {
+		c.descs = append(c.descs, prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, vmSettingsSubsystem, s.name),
+			s.help, nil, nil,
+		))
+	}
+	return c
+}
+
+func (c *vmSettingsCollector) Update(ch chan<- prometheus.Metric) (err error) {
+	for i, s := range vmSettings {
+		v, err := readFSUint(c.fs, path.Join("sys/vm", s.file))
+		// Older kernels lack some of the settings.
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		ch <- prometheus.MustNewConstMetric(c.descs[i], prometheus.GaugeValue, float64(v)*s.scale)
+	}
+	return nil
+}
@@ -0,0 +1,50 @@
+// Copyright 2016 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collector
+
+import (
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+)
+
+func TestVMSettings(t *testing.T) {
+	c := newVMSettingsCollector(mapFS{
+		"sys/vm/overcommit_memory": "2\n",
+		"sys/vm/overcommit_ratio":  "80\n",
+		"sys/vm/overcommit_kbytes": "4\n",
+		"sys/vm/swappiness":        "10\n",
+	})
+	ch := make(chan prometheus.Metric, len(vmSettings))
+	if err := c.Update(ch); err != nil {
+		t.Fatal(err)
+	}
+	close(ch)
+
+	values := map[string]float64{}
+	for m := range ch {
+		pb := &dto.Metric{}
+		m.Write(pb)
+		values[m.Desc().String()] = pb.Gauge.GetValue()
+	}
+	if want, got := 4, len(values); want != got {
+		t.Fatalf("want %d settings, got %d", want, got)
+	}
+	for i, want := range []float64{2, 80, 4096, 10} {
+		if got := values[c.descs[i].String()]; want != got {
+			t.Errorf("want %s %f, got %f", vmSettings[i].name, want, got)
+		}
+	}
+}
@@ -18,7 +18,6 @@ package collector
 import (
 	"bufio"
 	"fmt"
-	"os"
 	"strconv"
 	"strings"
 
@@ -42,7 +41,7 @@ func NewvmStatCollector() (Collector, error) {
 }
 
 func (c *vmStatCollector) Update(ch chan<- prometheus.Metric) (err error) {
-	file, err := os.Open(procFilePath("vmstat"))
+	file, err := procFS.Open("vmstat")
 	if err != nil {
 		return err
 	}
@@ -0,0 +1,173 @@
+// Copyright 2016 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// This collector is only built with -tags xen, as it is only useful on Xen
+// dom0 hosts.
+
+// +build xen
+
+package collector
+
+import (
+	"bufio"
+	"bytes"
+	"flag"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const xenSubsystem = "xen"
+
+var (
+	xentopCommand = flag.String("collector.xen.xentop-command", "xentop", "Command to run xentop, which reads domain statistics via xenstat.")
+)
+
+// xenDomain holds the statistics of a single domain as reported by xentop.
+type xenDomain struct {
+	name      string
+	cpuSecs   float64
+	memBytes  float64
+	maxMem    float64 // 0 if unlimited.
+	vcpus     float64
+	netTx     float64
+	netRx     float64
+	vbdRdReqs float64
+	vbdWrReqs float64
+}
+
+type xenCollector struct {
+	cpu    *prometheus.Desc
+	mem    *prometheus.Desc
+	maxMem *prometheus.Desc
+	vcpus  *prometheus.Desc
+	netTx  *prometheus.Desc
+	netRx  *prometheus.Desc
+	vbdRd  *prometheus.Desc
+	vbdWr  *prometheus.Desc
+}
+
+func init() {
+	Factories["xen"] = NewXenCollector
+}
+
+// NewXenCollector returns a new Collector exposing per domain CPU, memory,
+// network and block device usage on Xen dom0 hosts.
+func NewXenCollector() (Collector, error) {
+	desc := func(name, help string) *prometheus.Desc {
+		return prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, xenSubsystem, name),
+			help, []string{"domain"}, nil,
+		)
+	}
+	return &xenCollector{
+		cpu:    desc("domain_cpu_seconds_total", "CPU time used by the domain."),
+		mem:    desc("domain_memory_bytes", "Memory currently assigned to the domain."),
+		maxMem: desc("domain_memory_max_bytes", "Maximum memory of the domain, absent if unlimited."),
+		vcpus:  desc("domain_vcpus", "Number of virtual CPUs of the domain."),
+		netTx:  desc("domain_network_transmit_bytes_total", "Number of bytes transmitted by the domain."),
+		netRx:  desc("domain_network_receive_bytes_total", "Number of bytes received by the domain."),
+		vbdRd:  desc("domain_block_read_requests_total", "Number of read requests on virtual block devices of the domain."),
+		vbdWr:  desc("domain_block_write_requests_total", "Number of write requests on virtual block devices of the domain."),
+	}, nil
+}
+
+func (c *xenCollector) Update(ch chan<- prometheus.Metric) (err error) {
+	cmd := localeCommand(*xentopCommand, "--batch", "--iterations=1")
+	out, err := cmd.Output()
+	if err != nil {
+		return fmt.Errorf("couldn't run xentop: %s", err)
+	}
+	domains, err := parseXentop(bytes.NewReader(out))
+	if err != nil {
+		return err
+	}
+
+	for _, d := range domains {
+		ch <- prometheus.MustNewConstMetric(c.cpu, prometheus.CounterValue, d.cpuSecs, d.name)
+		ch <- prometheus.MustNewConstMetric(c.mem, prometheus.GaugeValue, d.memBytes, d.name)
+		if d.maxMem > 0 {
+			ch <- prometheus.MustNewConstMetric(c.maxMem, prometheus.GaugeValue, d.maxMem, d.name)
+		}
+		ch <- prometheus.MustNewConstMetric(c.vcpus, prometheus.GaugeValue, d.vcpus, d.name)
+		ch <- prometheus.MustNewConstMetric(c.netTx, prometheus.CounterValue, d.netTx, d.name)
+		ch <- prometheus.MustNewConstMetric(c.netRx, prometheus.CounterValue, d.netRx, d.name)
+		ch <- prometheus.MustNewConstMetric(c.vbdRd, prometheus.CounterValue, d.vbdRdReqs, d.name)
+		ch <- prometheus.MustNewConstMetric(c.vbdWr, prometheus.CounterValue, d.vbdWrReqs, d.name)
+	}
+	return nil
+}
+
+// parseXentop parses the output of xentop --batch. Columns are looked up by
+// their header so that differing xentop versions are supported.
+func parseXentop(r io.Reader) ([]xenDomain, error) {
+	var (
+		domains []xenDomain
+		header  map[string]int
+		scanner = bufio.NewScanner(r)
+	)
+
+	for scanner.Scan() {
+		line := strings.Replace(scanner.Text(), "no limit", "no_limit", -1)
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+		if fields[0] == "NAME" {
+			header = map[string]int{}
+			for i, f := range fields {
+				header[f] = i
+			}
+			continue
+		}
+		if header == nil {
+			continue
+		}
+		if len(fields) < len(header) {
+			return nil, fmt.Errorf("invalid xentop line: %q", line)
+		}
+
+		var (
+			d   = xenDomain{name: fields[header["NAME"]]}
+			err error
+		)
+		value := func(column string, scale float64) float64 {
+			i, ok := header[column]
+			if !ok || err != nil {
+				return 0
+			}
+			var v float64
+			if fields[i] == "no_limit" || fields[i] == "n/a" {
+				return 0
+			}
+			v, err = parseLocaleFloat(fields[i])
+			return v * scale
+		}
+		d.cpuSecs = value("CPU(sec)", 1)
+		d.memBytes = value("MEM(k)", 1024)
+		d.maxMem = value("MAXMEM(k)", 1024)
+		d.vcpus = value("VCPUS", 1)
+		d.netTx = value("NETTX(k)", 1024)
+		d.netRx = value("NETRX(k)", 1024)
+		d.vbdRdReqs = value("VBD_RD", 1)
+		d.vbdWrReqs = value("VBD_WR", 1)
+		if err != nil {
+			return nil, fmt.Errorf("invalid xentop line %q: %s", line, err)
+		}
+		domains = append(domains, d)
+	}
+
+	return domains, scanner.Err()
+}
@@ -0,0 +1,56 @@
+// Copyright 2016 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// +build xen
+
+package collector
+
+import (
+	"os"
+	"testing"
+)
+
+func TestParseXentop(t *testing.T) {
+	file, err := os.Open("fixtures/xentop.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+
+	domains, err := parseXentop(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want, got := 2, len(domains); want != got {
+		t.Fatalf("want %d domains, got %d", want, got)
+	}
+
+	dom0 := domains[0]
+	if want, got := "Domain-0", dom0.name; want != got {
+		t.Errorf("want domain %q, got %q", want, got)
+	}
+	if want, got := 0.0, dom0.maxMem; want != got {
+		t.Errorf("want unlimited max memory, got %f", got)
+	}
+
+	web := domains[1]
+	if want, got := 8812.0, web.cpuSecs; want != got {
+		t.Errorf("want %f cpu seconds, got %f", want, got)
+	}
+	if want, got := 2097152.0*1024, web.memBytes; want != got {
+		t.Errorf("want %f memory bytes, got %f", want, got)
+	}
+	if want, got := 8122.0, web.vbdWrReqs; want != got {
+		t.Errorf("want %f write requests, got %f", want, got)
+	}
+}
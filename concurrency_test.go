@@ -0,0 +1,65 @@
+// Copyright 2016 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/prometheus/node_exporter/collector"
+)
+
+// TestConcurrentCollect runs overlapping scrapes with all per scrape checks
+// enabled, for the race detector to find unguarded shared state. Run it with
+// go test -race.
+func TestConcurrentCollect(t *testing.T) {
+	initExporterMetrics()
+	redactor, err := newLabelRedactor(redactHash, "n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	n := NodeCollector{
+		collectors: map[string]collector.Collector{
+			"ok":     checkTestCollector{series: 10},
+			"failed": checkTestCollector{series: 5, err: errors.New("boom")},
+		},
+		maxSeries: 8,
+		validate:  true,
+		lint:      true,
+		errorLog:  newErrorLogLimiter(time.Minute),
+		redactor:  redactor,
+		devices:   newDeviceTracker("n", time.Minute),
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			ch := make(chan prometheus.Metric)
+			go func() {
+				n.Collect(ch)
+				close(ch)
+			}()
+			for m := range ch {
+				m.Write(&dto.Metric{})
+			}
+		}()
+	}
+	wg.Wait()
+}
@@ -0,0 +1,256 @@
+// Copyright 2016 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/prometheus/common/model"
+	"github.com/prometheus/node_exporter/collector"
+)
+
+// config is the content of the file given by -config.file.
+type config struct {
+	Collectors map[string]collectorConfig `json:"collectors"`
+	Alerts     []alertRule                `json:"alerts"`
+	Agent      agentConfig                `json:"agent"`
+}
+
+// collectorConfig holds the settings of a single collector.
+type collectorConfig struct {
+	// DropLabels lists the names of labels removed from all metrics of
+	// the collector.
+	DropLabels []string `json:"drop_labels"`
+	// DeviceIdentity replaces kernel names in the device label by stable
+	// names, "by-id" or "by-path".
+	DeviceIdentity string `json:"device_identity"`
+	// UdevProperties lists udev properties, like ID_SERIAL, added as
+	// lower case labels to metrics with a device label.
+	UdevProperties []string `json:"udev_properties"`
+	// MaxSeries overrides -collector.max-series for the collector, 0
+	// disables the limit.
+	MaxSeries *int `json:"max_series"`
+}
+
+// agentConfig holds the settings of the agent mode.
+type agentConfig struct {
+	Downsampling []downsampleRule `json:"downsampling"`
+}
+
+// loadConfig reads the JSON config file at path. An empty path results in
+// an empty config.
+func loadConfig(path string) (*config, error) {
+	c := &config{}
+	if path == "" {
+		return c, nil
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	if err := json.NewDecoder(f).Decode(c); err != nil {
+		return nil, fmt.Errorf("couldn't parse %s: %s", path, err)
+	}
+	for name, cc := range c.Collectors {
+		if _, ok := collector.Factories[name]; !ok {
+			return nil, fmt.Errorf("%s: collector '%s' not available", path, name)
+		}
+		if cc.DeviceIdentity != "" {
+			if _, err := collector.NewDeviceIdentifier(cc.DeviceIdentity); err != nil {
+				return nil, fmt.Errorf("%s: collector '%s': %s", path, name, err)
+			}
+		}
+		if cc.MaxSeries != nil && *cc.MaxSeries < 0 {
+			return nil, fmt.Errorf("%s: collector '%s': negative max_series %d", path, name, *cc.MaxSeries)
+		}
+		for _, p := range cc.UdevProperties {
+			if !model.LabelName(udevLabelName(p)).IsValid() {
+				return nil, fmt.Errorf("%s: collector '%s': invalid udev property %q", path, name, p)
+			}
+		}
+	}
+	for _, r := range c.Alerts {
+		if err := r.validate(); err != nil {
+			return nil, fmt.Errorf("%s: %s", path, err)
+		}
+	}
+	for i := range c.Agent.Downsampling {
+		if err := c.Agent.Downsampling[i].compile(); err != nil {
+			return nil, fmt.Errorf("%s: %s", path, err)
+		}
+	}
+	return c, nil
+}
+
+// dropLabels returns the label names to drop by collector.
+func (c *config) dropLabels() map[string]map[string]bool {
+	drop := map[string]map[string]bool{}
+	for name, cc := range c.Collectors {
+		if len(cc.DropLabels) == 0 {
+			continue
+		}
+		drop[name] = map[string]bool{}
+		for _, l := range cc.DropLabels {
+			drop[name][l] = true
+		}
+	}
+	return drop
+}
+
+// deviceIdentities returns the device identity scheme by collector.
+func (c *config) deviceIdentities() map[string]string {
+	schemes := map[string]string{}
+	for name, cc := range c.Collectors {
+		if cc.DeviceIdentity != "" {
+			schemes[name] = cc.DeviceIdentity
+		}
+	}
+	return schemes
+}
+
+// udevProperties returns the udev properties to add by collector.
+func (c *config) udevProperties() map[string][]string {
+	props := map[string][]string{}
+	for name, cc := range c.Collectors {
+		if len(cc.UdevProperties) > 0 {
+			props[name] = cc.UdevProperties
+		}
+	}
+	return props
+}
+
+// seriesLimits returns the overridden series limits by collector.
+func (c *config) seriesLimits() map[string]int {
+	limits := map[string]int{}
+	for name, cc := range c.Collectors {
+		if cc.MaxSeries != nil {
+			limits[name] = *cc.MaxSeries
+		}
+	}
+	return limits
+}
+
+// labelFilter rewrites the labels of the metrics of a single collector
+// during a scrape, e.g. removing or redacting some. Series that only
+// differed in rewritten labels are merged by mergeMetric, so the metrics are
+// held until the collector is done.
+type labelFilter struct {
+	transforms []labelTransform
+	series     map[string]int
+	metrics    []prometheus.Metric
+	merged     []*dto.Metric
+}
+
+func newLabelFilter(transforms ...labelTransform) *labelFilter {
+	return &labelFilter{transforms: transforms, series: map[string]int{}}
+}
+
+// add rewrites the labels of m, merging it with an earlier metric that ends
+// up with the same labels.
+func (f *labelFilter) add(m prometheus.Metric) {
+	fm := relabel(m, f.transforms...)
+	pb := &dto.Metric{}
+	if err := fm.Write(pb); err != nil {
+		// Let the registry report the error.
+		f.metrics = append(f.metrics, fm)
+		f.merged = append(f.merged, nil)
+		return
+	}
+	pairs := make([]string, 0, len(pb.Label))
+	for _, lp := range pb.Label {
+		pairs = append(pairs, fmt.Sprintf("%s=%q", lp.GetName(), lp.GetValue()))
+	}
+	sort.Strings(pairs)
+	series := m.Desc().String() + "{" + strings.Join(pairs, ",") + "}"
+	i, ok := f.series[series]
+	if !ok {
+		f.series[series] = len(f.metrics)
+		f.metrics = append(f.metrics, fm)
+		f.merged = append(f.merged, pb)
+		return
+	}
+	mergeMetric(f.merged[i], pb)
+	f.metrics[i] = mergedMetric{desc: m.Desc(), pb: f.merged[i]}
+}
+
+// result returns the rewritten metrics in the order they were first added.
+func (f *labelFilter) result() []prometheus.Metric {
+	return f.metrics
+}
+
+// mergeMetric adds the value of from to into, two samples of the same series.
+// Counters, histograms and the counts and sums of summaries are summed, so
+// that rates cover all merged series; summary quantiles can't be merged and
+// are left out. Gauges and untyped metrics keep the maximum, so that info
+// metrics stay 1.
+func mergeMetric(into, from *dto.Metric) {
+	switch {
+	case into.Counter != nil && from.Counter != nil:
+		into.Counter = &dto.Counter{Value: proto.Float64(into.Counter.GetValue() + from.Counter.GetValue())}
+	case into.Gauge != nil && from.Gauge != nil:
+		into.Gauge = &dto.Gauge{Value: proto.Float64(math.Max(into.Gauge.GetValue(), from.Gauge.GetValue()))}
+	case into.Untyped != nil && from.Untyped != nil:
+		into.Untyped = &dto.Untyped{Value: proto.Float64(math.Max(into.Untyped.GetValue(), from.Untyped.GetValue()))}
+	case into.Histogram != nil && from.Histogram != nil:
+		counts := map[float64]uint64{}
+		for _, h := range []*dto.Histogram{into.Histogram, from.Histogram} {
+			for _, b := range h.Bucket {
+				counts[b.GetUpperBound()] += b.GetCumulativeCount()
+			}
+		}
+		bounds := make([]float64, 0, len(counts))
+		for bound := range counts {
+			bounds = append(bounds, bound)
+		}
+		sort.Float64s(bounds)
+		buckets := make([]*dto.Bucket, 0, len(bounds))
+		for _, bound := range bounds {
+			buckets = append(buckets, &dto.Bucket{UpperBound: proto.Float64(bound), CumulativeCount: proto.Uint64(counts[bound])})
+		}
+		into.Histogram = &dto.Histogram{
+			SampleCount: proto.Uint64(into.Histogram.GetSampleCount() + from.Histogram.GetSampleCount()),
+			SampleSum:   proto.Float64(into.Histogram.GetSampleSum() + from.Histogram.GetSampleSum()),
+			Bucket:      buckets,
+		}
+	case into.Summary != nil && from.Summary != nil:
+		into.Summary = &dto.Summary{
+			SampleCount: proto.Uint64(into.Summary.GetSampleCount() + from.Summary.GetSampleCount()),
+			SampleSum:   proto.Float64(into.Summary.GetSampleSum() + from.Summary.GetSampleSum()),
+		}
+	}
+}
+
+// mergedMetric is a metric merged from several series by a labelFilter.
+type mergedMetric struct {
+	desc *prometheus.Desc
+	pb   *dto.Metric
+}
+
+func (m mergedMetric) Desc() *prometheus.Desc {
+	return m.desc
+}
+
+func (m mergedMetric) Write(pb *dto.Metric) error {
+	*pb = *m.pb
+	return nil
+}
@@ -0,0 +1,156 @@
+// Copyright 2016 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"reflect"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/prometheus/node_exporter/collector"
+)
+
+func TestLoadConfig(t *testing.T) {
+	f, err := ioutil.TempFile("", "node_exporter_config")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(f.Name())
+	f.WriteString(`{"collectors": {"loadavg": {"drop_labels": ["serial"]}, "time": {"max_series": 0}, "netdev": {"device_identity": "by-path", "udev_properties": ["ID_PATH"], "max_series": 100}}}`)
+	f.Close()
+
+	c, err := loadConfig(f.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]map[string]bool{"loadavg": {"serial": true}}
+	if got := c.dropLabels(); !reflect.DeepEqual(want, got) {
+		t.Errorf("want dropped labels %v, got %v", want, got)
+	}
+	if want, got := map[string]string{"netdev": "by-path"}, c.deviceIdentities(); !reflect.DeepEqual(want, got) {
+		t.Errorf("want device identities %v, got %v", want, got)
+	}
+	if want, got := map[string][]string{"netdev": {"ID_PATH"}}, c.udevProperties(); !reflect.DeepEqual(want, got) {
+		t.Errorf("want udev properties %v, got %v", want, got)
+	}
+	if want, got := map[string]int{"time": 0, "netdev": 100}, c.seriesLimits(); !reflect.DeepEqual(want, got) {
+		t.Errorf("want series limits %v, got %v", want, got)
+	}
+
+	ioutil.WriteFile(f.Name(), []byte(`{"collectors": {"nonexistent": {}}}`), 0644)
+	if _, err := loadConfig(f.Name()); err == nil {
+		t.Error("want error for unknown collector")
+	}
+	ioutil.WriteFile(f.Name(), []byte(`{"collectors": {"netdev": {"device_identity": "by-label"}}}`), 0644)
+	if _, err := loadConfig(f.Name()); err == nil {
+		t.Error("want error for unknown device identity")
+	}
+	ioutil.WriteFile(f.Name(), []byte(`{"collectors": {"netdev": {"udev_properties": ["ID.PATH"]}}}`), 0644)
+	if _, err := loadConfig(f.Name()); err == nil {
+		t.Error("want error for invalid udev property")
+	}
+	ioutil.WriteFile(f.Name(), []byte(`{"collectors": {"netdev": {"max_series": -1}}}`), 0644)
+	if _, err := loadConfig(f.Name()); err == nil {
+		t.Error("want error for negative series limit")
+	}
+
+	c, err = loadConfig("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := c.dropLabels(); len(got) != 0 {
+		t.Errorf("want no dropped labels without config file, got %v", got)
+	}
+}
+
+func TestLabelFilter(t *testing.T) {
+	info := prometheus.NewDesc("test_info", "Test.", []string{"model", "serial"}, nil)
+	errors := prometheus.NewDesc("test_errors_total", "Test.", []string{"model", "serial"}, nil)
+	latency := prometheus.NewDesc("test_latency_seconds", "Test.", []string{"model", "serial"}, nil)
+	f := newLabelFilter(dropLabels(map[string]bool{"serial": true}))
+	for _, lv := range [][]string{{"a", "1"}, {"a", "2"}, {"b", "3"}} {
+		f.add(prometheus.MustNewConstMetric(info, prometheus.GaugeValue, 1, lv...))
+		f.add(prometheus.MustNewConstMetric(errors, prometheus.CounterValue, 2, lv...))
+		f.add(prometheus.MustNewConstHistogram(latency, 3, 1.5, map[float64]uint64{0.1: 1, 1: 2}, lv...))
+	}
+
+	var got []string
+	for _, m := range f.result() {
+		pb := &dto.Metric{}
+		if err := m.Write(pb); err != nil {
+			t.Fatal(err)
+		}
+		if want, got := 1, len(pb.Label); want != got {
+			t.Fatalf("want %d label, got %d", want, got)
+		}
+		var value string
+		switch {
+		case pb.Gauge != nil:
+			value = fmt.Sprint(pb.Gauge.GetValue())
+		case pb.Counter != nil:
+			value = fmt.Sprint(pb.Counter.GetValue())
+		case pb.Histogram != nil:
+			value = fmt.Sprint(pb.Histogram.GetSampleCount(), pb.Histogram.GetSampleSum())
+			for _, b := range pb.Histogram.Bucket {
+				value += fmt.Sprintf(" %g:%d", b.GetUpperBound(), b.GetCumulativeCount())
+			}
+		}
+		name := descNameRE.FindStringSubmatch(m.Desc().String())[1]
+		got = append(got, fmt.Sprintf("%s{model=%s} %s", name, pb.Label[0].GetValue(), value))
+	}
+	want := []string{
+		"test_info{model=a} 1",
+		"test_errors_total{model=a} 4",
+		"test_latency_seconds{model=a} 6 3 0.1:2 1:4",
+		"test_info{model=b} 1",
+		"test_errors_total{model=b} 2",
+		"test_latency_seconds{model=b} 3 1.5 0.1:1 1:2",
+	}
+	if !reflect.DeepEqual(want, got) {
+		t.Errorf("want %q, got %q", want, got)
+	}
+}
+
+func TestSeriesLimits(t *testing.T) {
+	initExporterMetrics()
+	n := NodeCollector{
+		collectors: map[string]collector.Collector{
+			"default":  checkTestCollector{series: 10},
+			"override": checkTestCollector{series: 10},
+		},
+		maxSeries:    3,
+		seriesLimits: map[string]int{"override": 5},
+	}
+	ch := make(chan prometheus.Metric)
+	go func() {
+		n.Collect(ch)
+		close(ch)
+	}()
+	got := map[string]float64{}
+	for m := range ch {
+		if m.Desc() != seriesCount {
+			continue
+		}
+		pb := &dto.Metric{}
+		m.Write(pb)
+		got[pb.Label[0].GetValue()] = pb.GetGauge().GetValue()
+	}
+	if want := map[string]float64{"default": 3, "override": 5}; !reflect.DeepEqual(want, got) {
+		t.Errorf("want series %v, got %v", want, got)
+	}
+}
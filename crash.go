@@ -0,0 +1,174 @@
+// Copyright 2016 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/common/log"
+	"github.com/prometheus/common/version"
+	"github.com/prometheus/node_exporter/collector"
+)
+
+// crashCountFile holds the number of crashes in the crash directory.
+const crashCountFile = "crashes"
+
+// crashReporter writes a report to its directory when the exporter panics
+// and counts the crashes in a file, so that they survive the restart.
+type crashReporter struct {
+	dir        string
+	collectors string
+	// count is the number of crashes before this start.
+	count float64
+}
+
+// newCrashReporter returns a reporter writing to dir, which is created if
+// needed. collectors lists the enabled collectors for the reports.
+func newCrashReporter(dir, collectors string) (*crashReporter, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+	r := &crashReporter{dir: dir, collectors: collectors}
+	count, err := r.readCount()
+	if err != nil {
+		return nil, err
+	}
+	r.count = count
+	return r, nil
+}
+
+func (r *crashReporter) readCount() (float64, error) {
+	data, err := ioutil.ReadFile(filepath.Join(r.dir, crashCountFile))
+	if os.IsNotExist(err) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	count, err := strconv.ParseUint(strings.TrimSpace(string(data)), 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid crash count in %s: %s", r.dir, err)
+	}
+	return float64(count), nil
+}
+
+// metric returns a counter of the crashes before this start.
+func (r *crashReporter) metric() prometheus.CounterFunc {
+	return prometheus.NewCounterFunc(
+		prometheus.CounterOpts{
+			Namespace: collector.Namespace,
+			Subsystem: "exporter",
+			Name:      "crashes_total",
+			Help:      "node_exporter: Number of times the exporter crashed with a panic, as counted in -crash.dir.",
+		},
+		func() float64 { return r.count },
+	)
+}
+
+// handle reports a panic of the calling goroutine and resumes panicking. It
+// must be deferred at the start of goroutines not started with
+// collector.Go. A nil reporter leaves panics alone.
+func (r *crashReporter) handle() {
+	if r == nil {
+		return
+	}
+	if v := recover(); v != nil {
+		r.crash(v)
+	}
+}
+
+// crash reports the recovered panic v and resumes panicking. It is the
+// collector.OnPanic of the exporter.
+func (r *crashReporter) crash(v interface{}) {
+	if err := r.report(v, time.Now()); err != nil {
+		log.Errorf("Couldn't write crash report: %s", err)
+	}
+	panic(v)
+}
+
+// report writes a report with the stacks of all goroutines and increments
+// the crash count.
+func (r *crashReporter) report(v interface{}, now time.Time) error {
+	if err := r.writeReport("node_exporter crashed", v, now); err != nil {
+		return err
+	}
+	// Reread the count, in case the file was reset since the start.
+	count, err := r.readCount()
+	if err != nil {
+		count = r.count
+	}
+	tmp := filepath.Join(r.dir, crashCountFile+".tmp")
+	if err := writeSynced(tmp, []byte(fmt.Sprintf("%d\n", uint64(count)+1))); err != nil {
+		return err
+	}
+	return os.Rename(tmp, filepath.Join(r.dir, crashCountFile))
+}
+
+// reportCollector writes a report for a panic of the named collector, which
+// was recovered and doesn't count as crash.
+func (r *crashReporter) reportCollector(name string, v interface{}, now time.Time) error {
+	return r.writeReport(name+" collector panicked", v, now)
+}
+
+func (r *crashReporter) writeReport(what string, v interface{}, now time.Time) error {
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "%s at %s\n", what, now.UTC().Format(time.RFC3339))
+	fmt.Fprintf(&buf, "Version: %s\n", version.Info())
+	fmt.Fprintf(&buf, "Build context: %s\n", version.BuildContext())
+	fmt.Fprintf(&buf, "Collectors: %s\n", r.collectors)
+	fmt.Fprintf(&buf, "Panic: %v\n\n", v)
+	buf.Write(allStacks())
+
+	name := filepath.Join(r.dir, fmt.Sprintf("crash-%s.txt", now.UTC().Format("20060102T150405.000Z")))
+	return ioutil.WriteFile(name, buf.Bytes(), 0644)
+}
+
+// writeSynced writes data to the named file and flushes it to disk, as the
+// process is about to die.
+func writeSynced(name string, data []byte) error {
+	f, err := os.Create(name)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// allStacks returns the stacks of all goroutines.
+func allStacks() []byte {
+	buf := make([]byte, 64<<10)
+	for {
+		n := runtime.Stack(buf, true)
+		if n < len(buf) || len(buf) >= 16<<20 {
+			return buf[:n]
+		}
+		buf = make([]byte, 2*len(buf))
+	}
+}
@@ -0,0 +1,204 @@
+// Copyright 2016 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/prometheus/node_exporter/collector"
+)
+
+func TestCrashReporter(t *testing.T) {
+	dir, err := ioutil.TempDir("", "node_exporter_crash_test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	r, err := newCrashReporter(dir, "loadavg,meminfo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want, got := 0.0, r.count; want != got {
+		t.Errorf("want %v crashes initially, got %v", want, got)
+	}
+
+	// handle reports the panic and keeps panicking.
+	var recovered interface{}
+	func() {
+		defer func() { recovered = recover() }()
+		defer r.handle()
+		panic("boom")
+	}()
+	if want, got := "boom", recovered; want != got {
+		t.Errorf("want panic %q to continue, got %v", want, got)
+	}
+	if err := r.report("bang", time.Now().Add(time.Second)); err != nil {
+		t.Fatal(err)
+	}
+
+	reports, err := filepath.Glob(filepath.Join(dir, "crash-*.txt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want, got := 2, len(reports); want != got {
+		t.Fatalf("want %d reports, got %d", want, got)
+	}
+	data, err := ioutil.ReadFile(reports[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"Collectors: loadavg,meminfo\n", "Panic: boom\n", "TestCrashReporter"} {
+		if !strings.Contains(string(data), want) {
+			t.Errorf("want %q in report, got:\n%s", want, data)
+		}
+	}
+
+	r, err = newCrashReporter(dir, "loadavg")
+	if err != nil {
+		t.Fatal(err)
+	}
+	pb := &dto.Metric{}
+	r.metric().Write(pb)
+	if want, got := 2.0, pb.GetCounter().GetValue(); want != got {
+		t.Errorf("want %v crashes after restart, got %v", want, got)
+	}
+
+	ioutil.WriteFile(filepath.Join(dir, crashCountFile), []byte("many\n"), 0644)
+	if _, err := newCrashReporter(dir, "loadavg"); err == nil {
+		t.Error("want error for invalid crash count")
+	}
+
+	var disabled *crashReporter
+	func() {
+		defer func() { recovered = recover() }()
+		defer disabled.handle()
+		panic("boom")
+	}()
+	if want, got := "boom", recovered; want != got {
+		t.Errorf("want panic %q to continue without reporter, got %v", want, got)
+	}
+}
+
+type panicTestCollector struct{}
+
+func (panicTestCollector) Update(ch chan<- prometheus.Metric) error {
+	panic("boom")
+}
+
+func TestCollectorPanic(t *testing.T) {
+	dir, err := ioutil.TempDir("", "node_exporter_crash_test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	r, err := newCrashReporter(dir, "ok,panic")
+	if err != nil {
+		t.Fatal(err)
+	}
+	initExporterMetrics()
+	n := NodeCollector{
+		collectors: map[string]collector.Collector{
+			"ok":    checkTestCollector{series: 2},
+			"panic": panicTestCollector{},
+		},
+		errorLog: newErrorLogLimiter(time.Minute),
+		crashes:  r,
+	}
+
+	ch := make(chan prometheus.Metric)
+	go func() {
+		n.Collect(ch)
+		close(ch)
+	}()
+	series := 0
+	for m := range ch {
+		if strings.Contains(m.Desc().String(), `"node_check_test"`) {
+			series++
+		}
+	}
+	if want, got := 2, series; want != got {
+		t.Errorf("want %d series of the other collector, got %d", want, got)
+	}
+
+	pb := &dto.Metric{}
+	scrapeDurations.WithLabelValues("panic", "error").(prometheus.Summary).Write(pb)
+	if want, got := uint64(1), pb.GetSummary().GetSampleCount(); want != got {
+		t.Errorf("want %d failed scrape of the panicking collector, got %d", want, got)
+	}
+
+	reports, err := filepath.Glob(filepath.Join(dir, "crash-*.txt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want, got := 1, len(reports); want != got {
+		t.Fatalf("want %d report, got %d", want, got)
+	}
+	data, err := ioutil.ReadFile(reports[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"panic collector panicked at", "Panic: boom\n"} {
+		if !strings.Contains(string(data), want) {
+			t.Errorf("want %q in report, got:\n%s", want, data)
+		}
+	}
+	if count, err := r.readCount(); err != nil || count != 0 {
+		t.Errorf("want collector panic not counted as crash, got %v (%v)", count, err)
+	}
+}
+
+func TestBackgroundPanic(t *testing.T) {
+	dir, err := ioutil.TempDir("", "node_exporter_crash_test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	r, err := newCrashReporter(dir, "loadavg")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { collector.OnPanic = nil }()
+	recovered := make(chan interface{})
+	// The reporter resumes panicking, which is caught here instead of
+	// ending the test.
+	collector.OnPanic = func(v interface{}) {
+		defer func() { recovered <- recover() }()
+		r.crash(v)
+	}
+
+	collector.Go(func() { panic("boom") })
+	if want, got := "boom", <-recovered; want != got {
+		t.Errorf("want panic %q to continue, got %v", want, got)
+	}
+	reports, err := filepath.Glob(filepath.Join(dir, "crash-*.txt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want, got := 1, len(reports); want != got {
+		t.Errorf("want %d report, got %d", want, got)
+	}
+	if count, err := r.readCount(); err != nil || count != 1 {
+		t.Errorf("want 1 crash counted, got %v (%v)", count, err)
+	}
+}
@@ -0,0 +1,41 @@
+// Copyright 2016 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/prometheus/node_exporter/collector"
+)
+
+// identifyDevice returns m with its device label replaced by the stable name
+// of the device. The name is resolved right away, as the registry may write
+// m concurrently with later calls of d.
+func identifyDevice(m prometheus.Metric, d *collector.DeviceIdentifier) prometheus.Metric {
+	pb := &dto.Metric{}
+	if err := m.Write(pb); err != nil {
+		// Let the registry report the error.
+		return m
+	}
+	for _, lp := range pb.Label {
+		if lp.GetName() != "device" {
+			continue
+		}
+		if id := d.Identify(lp.GetValue()); id != lp.GetValue() {
+			return relabel(m, setLabel("device", id))
+		}
+		break
+	}
+	return m
+}
@@ -0,0 +1,55 @@
+// Copyright 2016 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"flag"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/prometheus/node_exporter/collector"
+)
+
+func TestIdentifyDevice(t *testing.T) {
+	flag.Set("path.rootfs", "collector/fixtures")
+	defer flag.Set("path.rootfs", "/")
+	d, err := collector.NewDeviceIdentifier(collector.DeviceIdentityByPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	desc := prometheus.NewDesc("node_test_bytes", "Test metric.", []string{"device", "mode"}, nil)
+	for device, want := range map[string]string{
+		"sda":   "pci-0000:00:17.0-ata-1",
+		"eth0":  "pci-0000:00:1f.6",
+		"loop0": "loop0",
+	} {
+		m := identifyDevice(prometheus.MustNewConstMetric(desc, prometheus.GaugeValue, 1, device, "read"), d)
+		pb := &dto.Metric{}
+		if err := m.Write(pb); err != nil {
+			t.Fatal(err)
+		}
+		labels := map[string]string{}
+		for _, lp := range pb.Label {
+			labels[lp.GetName()] = lp.GetValue()
+		}
+		if got := labels["device"]; want != got {
+			t.Errorf("want %s to be identified as %q, got %q", device, want, got)
+		}
+		if want, got := "read", labels["mode"]; want != got {
+			t.Errorf("want mode %q, got %q", want, got)
+		}
+	}
+}
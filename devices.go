@@ -0,0 +1,117 @@
+// Copyright 2016 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+)
+
+// deviceTracker remembers the devices each collector exposed in its last
+// successful scrape, so that devices that disappeared since, like removed
+// batteries or unplugged disks, can be reported. Removals are reported for a
+// period of time rather than a number of updates, as the collectors are also
+// run by the alerter, the agent and others besides Prometheus.
+type deviceTracker struct {
+	labels    map[string]bool
+	retention time.Duration
+
+	mtx     sync.Mutex
+	devices map[string]map[string]bool
+	removed map[string]map[string]time.Time
+}
+
+// deviceRemoval is a device found missing at the given time.
+type deviceRemoval struct {
+	device string
+	time   time.Time
+}
+
+// newDeviceTracker returns a tracker taking the device from the first of the
+// comma-separated label names a metric has, which reports removals for the
+// retention period. No labels disable tracking and return nil.
+func newDeviceTracker(labels string, retention time.Duration) *deviceTracker {
+	t := &deviceTracker{
+		labels:    map[string]bool{},
+		retention: retention,
+		devices:   map[string]map[string]bool{},
+		removed:   map[string]map[string]time.Time{},
+	}
+	for _, l := range strings.Split(labels, ",") {
+		if l = strings.TrimSpace(l); l != "" {
+			t.labels[l] = true
+		}
+	}
+	if len(t.labels) == 0 {
+		return nil
+	}
+	return t
+}
+
+// device returns the device m belongs to, or false if it has no device
+// label.
+func (t *deviceTracker) device(m prometheus.Metric) (string, bool) {
+	pb := &dto.Metric{}
+	if err := m.Write(pb); err != nil {
+		return "", false
+	}
+	for _, lp := range pb.Label {
+		if t.labels[lp.GetName()] && lp.GetValue() != "" {
+			return lp.GetValue(), true
+		}
+	}
+	return "", false
+}
+
+// update records the devices seen at now in a successful scrape of the named
+// collector and returns those found missing within the retention period,
+// unless they reappeared since.
+func (t *deviceTracker) update(name string, seen map[string]bool, now time.Time) []deviceRemoval {
+	t.mtx.Lock()
+	defer t.mtx.Unlock()
+
+	removed := t.removed[name]
+	if removed == nil {
+		removed = map[string]time.Time{}
+		t.removed[name] = removed
+	}
+	for dev := range t.devices[name] {
+		if !seen[dev] {
+			removed[dev] = now
+		}
+	}
+	t.devices[name] = seen
+
+	var removals []deviceRemoval
+	for dev, at := range removed {
+		if seen[dev] || now.Sub(at) >= t.retention {
+			delete(removed, dev)
+			continue
+		}
+		removals = append(removals, deviceRemoval{device: dev, time: at})
+	}
+	sort.Sort(byDevice(removals))
+	return removals
+}
+
+type byDevice []deviceRemoval
+
+func (r byDevice) Len() int           { return len(r) }
+func (r byDevice) Less(i, j int) bool { return r[i].device < r[j].device }
+func (r byDevice) Swap(i, j int)      { r[i], r[j] = r[j], r[i] }
@@ -0,0 +1,70 @@
+// Copyright 2016 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+func TestDeviceTracker(t *testing.T) {
+	if newDeviceTracker(" ,", time.Minute) != nil {
+		t.Error("want no tracker without labels")
+	}
+
+	tracker := newDeviceTracker("device,power_supply", time.Minute)
+	disk := prometheus.NewDesc("node_test_disk", "Test metric.", []string{"device"}, nil)
+	battery := prometheus.NewDesc("node_test_battery", "Test metric.", []string{"power_supply"}, nil)
+	load := prometheus.NewDesc("node_test_load", "Test metric.", nil, nil)
+
+	for _, tc := range []struct {
+		m      prometheus.Metric
+		device string
+	}{
+		{prometheus.MustNewConstMetric(disk, prometheus.GaugeValue, 1, "sda"), "sda"},
+		{prometheus.MustNewConstMetric(battery, prometheus.GaugeValue, 1, "BAT0"), "BAT0"},
+		{prometheus.MustNewConstMetric(disk, prometheus.GaugeValue, 1, ""), ""},
+		{prometheus.MustNewConstMetric(load, prometheus.GaugeValue, 1), ""},
+	} {
+		dev, ok := tracker.device(tc.m)
+		if want, got := tc.device, dev; want != got || ok != (want != "") {
+			t.Errorf("want device %q, got %q (%t)", want, got, ok)
+		}
+	}
+
+	now := time.Unix(1500000000, 0)
+	if removed := tracker.update("diskstats", map[string]bool{"sda": true, "sdb": true, "sdc": true}, now); len(removed) != 0 {
+		t.Errorf("want no removals on first scrape, got %v", removed)
+	}
+	if removed := tracker.update("power_supply", map[string]bool{}, now); len(removed) != 0 {
+		t.Errorf("want no removals of other collectors, got %v", removed)
+	}
+	removedAt := now.Add(15 * time.Second)
+	removed := tracker.update("diskstats", map[string]bool{"sda": true}, removedAt)
+	if want, got := []deviceRemoval{{"sdb", removedAt}, {"sdc", removedAt}}, removed; !reflect.DeepEqual(want, got) {
+		t.Errorf("want removed devices %v, got %v", want, got)
+	}
+	// Updates by other callers in between don't end the report.
+	tracker.update("diskstats", map[string]bool{"sda": true}, removedAt.Add(time.Second))
+	removed = tracker.update("diskstats", map[string]bool{"sda": true, "sdc": true}, removedAt.Add(30*time.Second))
+	if want, got := []deviceRemoval{{"sdb", removedAt}}, removed; !reflect.DeepEqual(want, got) {
+		t.Errorf("want removals reported until reappearing, got %v", got)
+	}
+	if removed := tracker.update("diskstats", map[string]bool{"sda": true, "sdc": true}, removedAt.Add(time.Minute)); len(removed) != 0 {
+		t.Errorf("want removals reported for the retention period, got %v", removed)
+	}
+}
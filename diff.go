@@ -0,0 +1,191 @@
+// Copyright 2016 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	dto "github.com/prometheus/client_model/go"
+	"github.com/prometheus/common/expfmt"
+)
+
+const (
+	diffAdded   = "added"
+	diffRemoved = "removed"
+	diffRetyped = "retyped"
+)
+
+// diffIgnoredPrefixes are the metrics of the exporter process itself, which
+// change with the Go and client library versions rather than the collectors.
+var diffIgnoredPrefixes = []string{"go_", "process_", "http_", "promhttp_"}
+
+// diffChange is a single difference between two scrapes.
+type diffChange struct {
+	change string
+	metric string
+	detail string
+}
+
+// fetchMetrics scrapes the exporter at url and returns the parsed metric
+// families.
+func fetchMetrics(url string, timeout time.Duration) (map[string]*dto.MetricFamily, error) {
+	req, err := http.NewRequest("GET", url, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", string(expfmt.FmtText))
+	resp, err := (&http.Client{Timeout: timeout}).Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("scrape of %s failed with status %s", url, resp.Status)
+	}
+
+	families := map[string]*dto.MetricFamily{}
+	dec := expfmt.NewDecoder(resp.Body, expfmt.ResponseFormat(resp.Header))
+	for {
+		var mf dto.MetricFamily
+		if err := dec.Decode(&mf); err == io.EOF {
+			return families, nil
+		} else if err != nil {
+			return nil, fmt.Errorf("couldn't parse scrape of %s: %s", url, err)
+		}
+		families[mf.GetName()] = &mf
+	}
+}
+
+// labelNames returns the sorted names of the labels used by any metric of mf.
+func labelNames(mf *dto.MetricFamily) []string {
+	seen := map[string]bool{}
+	names := []string{}
+	for _, m := range mf.Metric {
+		for _, l := range m.Label {
+			if !seen[l.GetName()] {
+				seen[l.GetName()] = true
+				names = append(names, l.GetName())
+			}
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+func diffIgnored(name string) bool {
+	for _, p := range diffIgnoredPrefixes {
+		if strings.HasPrefix(name, p) {
+			return true
+		}
+	}
+	return false
+}
+
+// diffMetrics compares the metric names, label names and types of two
+// scrapes, ordered by metric name.
+func diffMetrics(old, current map[string]*dto.MetricFamily) []diffChange {
+	names := []string{}
+	for n := range old {
+		names = append(names, n)
+	}
+	for n := range current {
+		if _, ok := old[n]; !ok {
+			names = append(names, n)
+		}
+	}
+	sort.Strings(names)
+
+	var changes []diffChange
+	for _, n := range names {
+		if diffIgnored(n) {
+			continue
+		}
+		was, now := old[n], current[n]
+		switch {
+		case was == nil:
+			changes = append(changes, diffChange{diffAdded, n, labelDetail(now)})
+			continue
+		case now == nil:
+			changes = append(changes, diffChange{diffRemoved, n, labelDetail(was)})
+			continue
+		}
+		if was.GetType() != now.GetType() {
+			changes = append(changes, diffChange{diffRetyped, n, fmt.Sprintf("%s -> %s", typeName(was), typeName(now))})
+		}
+		oldLabels := map[string]bool{}
+		for _, l := range labelNames(was) {
+			oldLabels[l] = true
+		}
+		for _, l := range labelNames(now) {
+			if oldLabels[l] {
+				delete(oldLabels, l)
+				continue
+			}
+			changes = append(changes, diffChange{diffAdded, fmt.Sprintf("%s{%s}", n, l), "label"})
+		}
+		removed := []string{}
+		for l := range oldLabels {
+			removed = append(removed, l)
+		}
+		sort.Strings(removed)
+		for _, l := range removed {
+			changes = append(changes, diffChange{diffRemoved, fmt.Sprintf("%s{%s}", n, l), "label"})
+		}
+	}
+	return changes
+}
+
+func typeName(mf *dto.MetricFamily) string {
+	return strings.ToLower(mf.GetType().String())
+}
+
+func labelDetail(mf *dto.MetricFamily) string {
+	labels := labelNames(mf)
+	if len(labels) == 0 {
+		return typeName(mf)
+	}
+	return fmt.Sprintf("%s {%s}", typeName(mf), strings.Join(labels, ","))
+}
+
+// diffAgainst scrapes the exporter at url, e.g. an older release or the
+// upstream node_exporter, and writes the differences to one collection of
+// handler to w. It returns false if metrics or labels were removed or
+// retyped, which may break dashboards and alerts relying on them.
+func diffAgainst(w io.Writer, url string, handler http.Handler) (bool, error) {
+	old, err := fetchMetrics(url, 30*time.Second)
+	if err != nil {
+		return false, err
+	}
+	current, err := gatherMetrics(handler)
+	if err != nil {
+		return false, err
+	}
+
+	ok := true
+	tw := tabwriter.NewWriter(w, 0, 8, 2, ' ', 0)
+	fmt.Fprintln(tw, "CHANGE\tMETRIC\tDETAIL")
+	for _, c := range diffMetrics(old, current) {
+		if c.change != diffAdded {
+			ok = false
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\n", c.change, c.metric, c.detail)
+	}
+	return ok, tw.Flush()
+}
@@ -0,0 +1,107 @@
+// Copyright 2016 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+const diffOld = `# TYPE go_goroutines gauge
+go_goroutines 8
+# TYPE node_boot_time gauge
+node_boot_time 1.5e+09
+# TYPE node_cpu counter
+node_cpu{cpu="cpu0",mode="idle"} 100
+# TYPE node_entropy_available_bits gauge
+node_entropy_available_bits 3000
+# TYPE node_hwmon_temp_celsius gauge
+node_hwmon_temp_celsius{chip="platform_coretemp_0",sensor="temp1",serial="x"} 40
+`
+
+const diffNew = `# TYPE go_goroutines gauge
+go_goroutines 12
+# TYPE go_threads gauge
+go_threads 10
+# TYPE node_boot_time untyped
+node_boot_time 1.5e+09
+# TYPE node_cpu counter
+node_cpu{cpu="cpu0",mode="idle"} 100
+# TYPE node_hwmon_temp_celsius gauge
+node_hwmon_temp_celsius{chip="platform_coretemp_0",label="Core 0",sensor="temp1"} 40
+# TYPE node_rtc_time_seconds gauge
+node_rtc_time_seconds{device="rtc0"} 1.6e+09
+`
+
+func textHandler(body string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+		io.WriteString(w, body)
+	})
+}
+
+func TestDiffAgainst(t *testing.T) {
+	server := httptest.NewServer(textHandler(diffOld))
+	defer server.Close()
+
+	var buf bytes.Buffer
+	ok, err := diffAgainst(&buf, server.URL, textHandler(diffNew))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ok {
+		t.Error("want removed and retyped metrics to be reported as failure")
+	}
+	want := `CHANGE   METRIC                           DETAIL
+retyped  node_boot_time                   gauge -> untyped
+removed  node_entropy_available_bits      gauge
+added    node_hwmon_temp_celsius{label}   label
+removed  node_hwmon_temp_celsius{serial}  label
+added    node_rtc_time_seconds            gauge {device}
+`
+	if got := buf.String(); got != want {
+		t.Errorf("want output:\n%s\ngot:\n%s", want, got)
+	}
+}
+
+func TestDiffAgainstAdded(t *testing.T) {
+	server := httptest.NewServer(textHandler(diffOld))
+	defer server.Close()
+
+	var buf bytes.Buffer
+	ok, err := diffAgainst(&buf, server.URL, textHandler(diffOld+"# TYPE node_new gauge\nnode_new 1\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !ok {
+		t.Errorf("want added metrics only to succeed, got:\n%s", buf.String())
+	}
+	if !strings.Contains(buf.String(), "added   node_new") {
+		t.Errorf("want node_new reported as added, got:\n%s", buf.String())
+	}
+}
+
+func TestDiffAgainstFailedScrape(t *testing.T) {
+	server := httptest.NewServer(http.NotFoundHandler())
+	defer server.Close()
+
+	if _, err := diffAgainst(ioutil.Discard, server.URL, textHandler(diffNew)); err == nil {
+		t.Error("want error for failed scrape")
+	}
+}
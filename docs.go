@@ -0,0 +1,249 @@
+// Copyright 2016 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"path"
+	"reflect"
+	"regexp"
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/prometheus/common/log"
+	"github.com/prometheus/node_exporter/collector"
+)
+
+var descHelpRE = regexp.MustCompile(`^Desc\{fqName: "(?:[^"]*)", help: "((?:[^"\\]|\\.)*)"`)
+
+// Units recognised by metric name suffix, longest first where they
+// overlap.
+var metricUnits = []struct {
+	suffix, unit string
+}{
+	{"_seconds", "seconds"},
+	{"_ms", "milliseconds"},
+	{"_bytes", "bytes"},
+	{"_celsius", "celsius"},
+	{"_hertz", "hertz"},
+	{"_joules", "joules"},
+	{"_watts", "watts"},
+	{"_volts", "volts"},
+	{"_amperes", "amperes"},
+	{"_ppm", "ppm"},
+	{"_ratio", "ratio"},
+}
+
+// metricDoc documents a metric family a collector exposes.
+type metricDoc struct {
+	Name      string   `json:"name"`
+	Help      string   `json:"help"`
+	Type      string   `json:"type"`
+	Unit      string   `json:"unit,omitempty"`
+	Labels    []string `json:"labels"`
+	Collector string   `json:"collector"`
+	Source    string   `json:"source"`
+}
+
+// metricUnit infers the unit of a metric from its name.
+func metricUnit(name string) string {
+	name = strings.TrimSuffix(name, "_total")
+	for _, u := range metricUnits {
+		if strings.HasSuffix(name, u.suffix) {
+			return u.unit
+		}
+	}
+	return ""
+}
+
+// collectorSource returns the file implementing c's Update method, relative
+// to the repository root.
+func collectorSource(c collector.Collector) string {
+	m, ok := reflect.TypeOf(c).MethodByName("Update")
+	if !ok {
+		return ""
+	}
+	f := runtime.FuncForPC(m.Func.Pointer())
+	if f == nil {
+		return ""
+	}
+	file, _ := f.FileLine(f.Entry())
+	if dir := path.Base(path.Dir(file)); dir == "collector" {
+		return path.Join(dir, path.Base(file))
+	}
+	return path.Base(file)
+}
+
+var descLabelsRE = regexp.MustCompile(`constLabels: \{(.*)\}, variableLabels: \[(.*)\]\}$`)
+
+var constLabelRE = regexp.MustCompile(`([a-zA-Z_][a-zA-Z0-9_]*)="(?:[^"\\]|\\.)*"`)
+
+// metricTypes remembers the value types of the metrics scrapes exposed, by
+// descriptor, as descriptors don't carry them.
+type metricTypes struct {
+	mtx   sync.Mutex
+	types map[*prometheus.Desc]string
+}
+
+// maxMetricTypes bounds the types remembered, as some collectors create new
+// descriptors on every scrape.
+const maxMetricTypes = 10000
+
+func newMetricTypes() *metricTypes {
+	return &metricTypes{types: map[*prometheus.Desc]string{}}
+}
+
+// record remembers the type of m.
+func (t *metricTypes) record(m prometheus.Metric) {
+	if t == nil {
+		return
+	}
+	desc := m.Desc()
+	t.mtx.Lock()
+	_, ok := t.types[desc]
+	t.mtx.Unlock()
+	if ok {
+		return
+	}
+	pb := &dto.Metric{}
+	if err := m.Write(pb); err != nil {
+		return
+	}
+	t.mtx.Lock()
+	defer t.mtx.Unlock()
+	if len(t.types) >= maxMetricTypes {
+		t.types = map[*prometheus.Desc]string{}
+	}
+	t.types[desc] = metricType(pb)
+}
+
+// get returns the type of the metrics of desc. Until they were scraped,
+// the type is inferred from the name.
+func (t *metricTypes) get(desc *prometheus.Desc, name string) string {
+	if t != nil {
+		t.mtx.Lock()
+		typ, ok := t.types[desc]
+		t.mtx.Unlock()
+		if ok {
+			return typ
+		}
+	}
+	if strings.HasSuffix(name, "_total") {
+		return "counter"
+	}
+	return "untyped"
+}
+
+// documentCollector documents the metric families c exposes from the
+// descriptors it holds, without running it.
+func documentCollector(name string, c collector.Collector, types *metricTypes) []metricDoc {
+	var (
+		descs  = make(chan *prometheus.Desc)
+		docs   = map[string]*metricDoc{}
+		labels = map[string]map[string]bool{}
+		source = collectorSource(c)
+		done   = make(chan struct{})
+	)
+	collector.Go(func() {
+		for d := range descs {
+			desc := d.String()
+			match := descNameRE.FindStringSubmatch(desc)
+			if match == nil {
+				continue
+			}
+			fqName := match[1]
+			doc, ok := docs[fqName]
+			if !ok {
+				doc = &metricDoc{
+					Name:      fqName,
+					Type:      types.get(d, fqName),
+					Unit:      metricUnit(fqName),
+					Collector: name,
+					Source:    source,
+				}
+				if help := descHelpRE.FindStringSubmatch(desc); help != nil {
+					doc.Help = strings.Replace(help[1], `\"`, `"`, -1)
+				}
+				docs[fqName] = doc
+				labels[fqName] = map[string]bool{}
+			}
+			if l := descLabelsRE.FindStringSubmatch(desc); l != nil {
+				for _, c := range constLabelRE.FindAllStringSubmatch(l[1], -1) {
+					labels[fqName][c[1]] = true
+				}
+				for _, v := range strings.Fields(l[2]) {
+					labels[fqName][v] = true
+				}
+			}
+		}
+		close(done)
+	})
+	collector.Describe(c, descs)
+	close(descs)
+	<-done
+
+	result := make([]metricDoc, 0, len(docs))
+	for fqName, doc := range docs {
+		doc.Labels = []string{}
+		for l := range labels[fqName] {
+			doc.Labels = append(doc.Labels, l)
+		}
+		sort.Strings(doc.Labels)
+		result = append(result, *doc)
+	}
+	return result
+}
+
+func metricType(pb *dto.Metric) string {
+	switch {
+	case pb.Counter != nil:
+		return "counter"
+	case pb.Gauge != nil:
+		return "gauge"
+	case pb.Summary != nil:
+		return "summary"
+	case pb.Histogram != nil:
+		return "histogram"
+	}
+	return "untyped"
+}
+
+type byName []metricDoc
+
+func (d byName) Len() int           { return len(d) }
+func (d byName) Swap(i, j int)      { d[i], d[j] = d[j], d[i] }
+func (d byName) Less(i, j int) bool { return d[i].Name < d[j].Name }
+
+// docsHandler serves the documentation of the metrics the collectors
+// expose as JSON, with the types seen by scrapes.
+func docsHandler(collectors map[string]collector.Collector, types *metricTypes) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		docs := []metricDoc{}
+		for name, c := range collectors {
+			docs = append(docs, documentCollector(name, c, types)...)
+		}
+		sort.Sort(byName(docs))
+
+		w.Header().Set("Content-Type", "application/json")
+		enc := json.NewEncoder(w)
+		if err := enc.Encode(docs); err != nil {
+			log.Errorf("Couldn't write metric documentation: %s", err)
+		}
+	})
+}
@@ -0,0 +1,89 @@
+// Copyright 2016 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http/httptest"
+	"reflect"
+	"sync"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/node_exporter/collector"
+)
+
+type docsTestCollector struct {
+	updates int
+	mtx     sync.Mutex
+	read    *prometheus.Desc
+	descs   map[string]*prometheus.Desc
+}
+
+func newDocsTestCollector() *docsTestCollector {
+	return &docsTestCollector{
+		read: prometheus.NewDesc("node_test_read_seconds_total", `Seconds spent reading "test" devices.`, []string{"device"}, nil),
+		descs: map[string]*prometheus.Desc{
+			"temp": prometheus.NewDesc("node_test_temp_celsius", "Temperature.", []string{"sensor"}, prometheus.Labels{"chip": "test"}),
+		},
+	}
+}
+
+func (c *docsTestCollector) Update(ch chan<- prometheus.Metric) error {
+	c.mtx.Lock()
+	c.updates++
+	c.mtx.Unlock()
+	ch <- prometheus.MustNewConstMetric(c.descs["temp"], prometheus.GaugeValue, 40, "temp1")
+	return errors.New("partial failure")
+}
+
+func TestDocsHandler(t *testing.T) {
+	c := newDocsTestCollector()
+	types := newMetricTypes()
+	ch := make(chan prometheus.Metric, 1)
+	c.Update(ch)
+	types.record(<-ch)
+
+	rec := httptest.NewRecorder()
+	docsHandler(map[string]collector.Collector{"test": c}, types).ServeHTTP(rec, httptest.NewRequest("GET", "/metrics-docs", nil))
+
+	var docs []metricDoc
+	if err := json.Unmarshal(rec.Body.Bytes(), &docs); err != nil {
+		t.Fatal(err)
+	}
+	want := []metricDoc{{
+		Name:      "node_test_read_seconds_total",
+		Help:      `Seconds spent reading "test" devices.`,
+		Type:      "counter",
+		Unit:      "seconds",
+		Labels:    []string{"device"},
+		Collector: "test",
+		Source:    "docs_test.go",
+	}, {
+		Name:      "node_test_temp_celsius",
+		Help:      "Temperature.",
+		Type:      "gauge",
+		Unit:      "celsius",
+		Labels:    []string{"chip", "sensor"},
+		Collector: "test",
+		Source:    "docs_test.go",
+	}}
+	if !reflect.DeepEqual(want, docs) {
+		t.Errorf("want %+v, got %+v", want, docs)
+	}
+	if c.updates != 1 {
+		t.Errorf("want the collector not to be run for documentation, got %d updates", c.updates)
+	}
+}
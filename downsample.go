@@ -0,0 +1,119 @@
+// Copyright 2016 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"time"
+)
+
+// downsampleRule reduces the samples the agent pushes for the metrics whose
+// name matches Metric, to limit bandwidth on metered uplinks.
+type downsampleRule struct {
+	// Metric is a regular expression matched against whole metric names.
+	Metric string `json:"metric"`
+	// KeepOneIn keeps the samples of every nth scrape.
+	KeepOneIn int `json:"keep_one_in"`
+	// Resolution keeps the samples of the first scrape in every window
+	// of this duration, e.g. "5m".
+	Resolution string `json:"resolution"`
+
+	re         *regexp.Regexp
+	resolution time.Duration
+}
+
+// compile validates the rule and prepares it for use.
+func (r *downsampleRule) compile() error {
+	re, err := regexp.Compile("^(?:" + r.Metric + ")$")
+	if err != nil {
+		return fmt.Errorf("downsampling rule %q: %s", r.Metric, err)
+	}
+	r.re = re
+	if r.Resolution != "" {
+		if r.resolution, err = time.ParseDuration(r.Resolution); err != nil {
+			return fmt.Errorf("downsampling rule %q: %s", r.Metric, err)
+		}
+		// Windows are counted in milliseconds.
+		if r.resolution > 0 && r.resolution < time.Millisecond {
+			return fmt.Errorf("downsampling rule %q: resolution must be at least 1ms", r.Metric)
+		}
+	}
+	if (r.KeepOneIn > 0) == (r.resolution > 0) {
+		return fmt.Errorf("downsampling rule %q: exactly one of keep_one_in and resolution must be set", r.Metric)
+	}
+	return nil
+}
+
+// downsampler applies the first matching rule to every series of a
+// scrape. Series not matching any rule are kept.
+type downsampler struct {
+	rules []downsampleRule
+	// scrapes counts the scrapes seen by each KeepOneIn rule, windows
+	// holds the last window each Resolution rule kept samples in.
+	scrapes []int
+	windows []int64
+}
+
+func newDownsampler(rules []downsampleRule) *downsampler {
+	return &downsampler{
+		rules:   rules,
+		scrapes: make([]int, len(rules)),
+		windows: make([]int64, len(rules)),
+	}
+}
+
+// filter returns the series of the scrape at ts, in milliseconds, that are
+// kept.
+func (d *downsampler) filter(series []*prompbTimeSeries, ts int64) []*prompbTimeSeries {
+	if d == nil || len(d.rules) == 0 {
+		return series
+	}
+	keep := make([]bool, len(d.rules))
+	for i, r := range d.rules {
+		if r.KeepOneIn > 0 {
+			keep[i] = d.scrapes[i]%r.KeepOneIn == 0
+			d.scrapes[i]++
+			continue
+		}
+		// Windows start at 1, so the first scrape is always kept.
+		window := ts/int64(r.resolution/time.Millisecond) + 1
+		keep[i] = window != d.windows[i]
+		d.windows[i] = window
+	}
+
+	kept := series[:0]
+	for _, s := range series {
+		if d.keep(s, keep) {
+			kept = append(kept, s)
+		}
+	}
+	return kept
+}
+
+func (d *downsampler) keep(s *prompbTimeSeries, keep []bool) bool {
+	var name string
+	for _, l := range s.Labels {
+		if l.Name == "__name__" {
+			name = l.Value
+			break
+		}
+	}
+	for i, r := range d.rules {
+		if r.re.MatchString(name) {
+			return keep[i]
+		}
+	}
+	return true
+}
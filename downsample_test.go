@@ -0,0 +1,74 @@
+// Copyright 2016 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestDownsampler(t *testing.T) {
+	rules := []downsampleRule{
+		{Metric: "node_cpu_.*", KeepOneIn: 3},
+		{Metric: "node_filesystem_.*", Resolution: "1m"},
+	}
+	for i := range rules {
+		if err := rules[i].compile(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	d := newDownsampler(rules)
+
+	var got [][]string
+	for _, ts := range []int64{0, 15000, 30000, 45000, 60000, 75000} {
+		var kept []string
+		for _, s := range d.filter(timeSeriesNamed("node_cpu_seconds_total", "node_filesystem_avail", "node_load1"), ts) {
+			kept = append(kept, s.Labels[0].Value)
+		}
+		got = append(got, kept)
+	}
+	want := [][]string{
+		{"node_cpu_seconds_total", "node_filesystem_avail", "node_load1"},
+		{"node_load1"},
+		{"node_load1"},
+		{"node_cpu_seconds_total", "node_load1"},
+		{"node_filesystem_avail", "node_load1"},
+		{"node_load1"},
+	}
+	if !reflect.DeepEqual(want, got) {
+		t.Errorf("want %v, got %v", want, got)
+	}
+}
+
+func TestDownsampleRuleCompile(t *testing.T) {
+	for _, r := range []downsampleRule{
+		{Metric: "node_(", KeepOneIn: 2},
+		{Metric: "node_.*"},
+		{Metric: "node_.*", KeepOneIn: 2, Resolution: "1m"},
+		{Metric: "node_.*", Resolution: "often"},
+		{Metric: "node_.*", Resolution: "500us"},
+	} {
+		if err := r.compile(); err == nil {
+			t.Errorf("want error for %+v", r)
+		}
+	}
+}
+
+func timeSeriesNamed(names ...string) []*prompbTimeSeries {
+	series := make([]*prompbTimeSeries, 0, len(names))
+	for _, n := range names {
+		series = append(series, &prompbTimeSeries{Labels: []*prompbLabel{{Name: "__name__", Value: n}}})
+	}
+	return series
+}
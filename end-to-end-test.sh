@@ -19,6 +19,26 @@ collectors=$(cat << COLLECTORS
   textfile
   bonding
   megacli
+  certificate
+  reboot
+  virtualization
+  power_supply
+  hwmon
+  thermal_zone
+  runtime_pm
+  pcie
+  typec
+  kernel_info
+  devfreq
+  cros_ec
+  lid
+  als
+  rtc
+  vm_settings
+  tcp_settings
+  platform_security
+  efi_vars
+  kdump
 COLLECTORS
 )
 
@@ -27,7 +47,7 @@ cd "$(dirname $0)"
 port="$((10000 + (RANDOM % 10000)))"
 tmpdir=$(mktemp -d /tmp/node_exporter_e2e_test.XXXXXX)
 
-skip_re="^(go_|node_exporter_|process_|node_textfile_mtime)"
+skip_re="^(go_|node_exporter_|process_|node_textfile_mtime|node_stat_snapshot_timestamp_seconds)"
 
 keep=0; update=0; verbose=0
 while getopts 'hkuv' opt
@@ -63,8 +83,12 @@ fi
   -collector.procfs="collector/fixtures/proc" \
   -collector.sysfs="collector/fixtures/sys" \
   -collectors.enabled="$(echo ${collectors} | tr ' ' ',')" \
+  -collector.enable-experimental \
   -collector.textfile.directory="collector/fixtures/textfile/two_metric_files/" \
   -collector.megacli.command="collector/fixtures/megacli" \
+  -collector.certificate.globs="collector/fixtures/certificate/*.pem" \
+  -collector.reboot.flag-file="collector/fixtures/reboot-required" \
+  -collector.reboot.modules-path="collector/fixtures/lib/modules" \
   -web.listen-address "127.0.0.1:${port}" \
   -log.level="debug" > "${tmpdir}/node_exporter.log" 2>&1 &
 
@@ -0,0 +1,47 @@
+// Copyright 2016 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/prometheus/node_exporter/collector"
+)
+
+func TestEventsHandler(t *testing.T) {
+	events := collector.NewEventLog(4)
+	at := time.Date(2017, 1, 2, 3, 4, 5, 0, time.UTC)
+	events.Record(collector.Event{Time: at, Collector: "power_supply", Source: "AC", Kind: "online", From: "1", To: "0"})
+
+	rec := httptest.NewRecorder()
+	eventsHandler(events).ServeHTTP(rec, httptest.NewRequest("GET", "/events", nil))
+
+	var got []collector.Event
+	if err := json.Unmarshal(rec.Body.Bytes(), &got); err != nil {
+		t.Fatal(err)
+	}
+	if want := events.List(); !reflect.DeepEqual(want, got) {
+		t.Errorf("want %+v, got %+v", want, got)
+	}
+
+	rec = httptest.NewRecorder()
+	eventsHandler(collector.NewEventLog(4)).ServeHTTP(rec, httptest.NewRequest("GET", "/events", nil))
+	if want, got := "[]\n", rec.Body.String(); want != got {
+		t.Errorf("want %q without events, got %q", want, got)
+	}
+}
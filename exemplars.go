@@ -0,0 +1,125 @@
+// Copyright 2016 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"math"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/node_exporter/collector"
+)
+
+// exemplar links an observation to the trace of the scrape it was made in.
+type exemplar struct {
+	traceID string
+	value   float64
+	at      time.Time
+}
+
+// durationHistogram is a histogram of collector durations that remembers
+// the latest exemplar of each bucket. The client library doesn't support
+// exemplars, they are added when rendering OpenMetrics.
+type durationHistogram struct {
+	desc    *prometheus.Desc
+	buckets []float64
+
+	mtx    sync.Mutex
+	series map[string]*durationSeries
+}
+
+type durationSeries struct {
+	counts    []uint64 // Per bucket, not cumulative.
+	count     uint64
+	sum       float64
+	exemplars []*exemplar // Per bucket, the last one for +Inf.
+}
+
+func newDurationHistogram() *durationHistogram {
+	return &durationHistogram{
+		desc: prometheus.NewDesc(
+			prometheus.BuildFQName(collector.Namespace, "exporter", "collector_duration_seconds"),
+			"node_exporter: Duration of a collector's update, with exemplars referencing the scrape's trace.",
+			[]string{"collector"}, nil,
+		),
+		buckets: prometheus.DefBuckets,
+		series:  map[string]*durationSeries{},
+	}
+}
+
+// observe records a duration of the named collector. An exemplar is kept if
+// traceID isn't empty.
+func (h *durationHistogram) observe(name string, seconds float64, traceID string) {
+	i := sort.SearchFloat64s(h.buckets, seconds)
+
+	h.mtx.Lock()
+	defer h.mtx.Unlock()
+	s, ok := h.series[name]
+	if !ok {
+		s = &durationSeries{
+			counts:    make([]uint64, len(h.buckets)),
+			exemplars: make([]*exemplar, len(h.buckets)+1),
+		}
+		h.series[name] = s
+	}
+	if i < len(h.buckets) {
+		s.counts[i]++
+	}
+	s.count++
+	s.sum += seconds
+	if traceID != "" {
+		s.exemplars[i] = &exemplar{traceID: traceID, value: seconds, at: time.Now()}
+	}
+}
+
+// exemplar returns the exemplar of the bucket with the given upper bound of
+// the named collector's series, or nil.
+func (h *durationHistogram) exemplar(name string, upperBound float64) *exemplar {
+	h.mtx.Lock()
+	defer h.mtx.Unlock()
+	s, ok := h.series[name]
+	if !ok {
+		return nil
+	}
+	if math.IsInf(upperBound, 1) {
+		return s.exemplars[len(h.buckets)]
+	}
+	i := sort.SearchFloat64s(h.buckets, upperBound)
+	if i == len(h.buckets) || h.buckets[i] != upperBound {
+		return nil
+	}
+	return s.exemplars[i]
+}
+
+// Describe implements the prometheus.Collector interface.
+func (h *durationHistogram) Describe(ch chan<- *prometheus.Desc) {
+	ch <- h.desc
+}
+
+// Collect implements the prometheus.Collector interface.
+func (h *durationHistogram) Collect(ch chan<- prometheus.Metric) {
+	h.mtx.Lock()
+	defer h.mtx.Unlock()
+	for name, s := range h.series {
+		buckets := make(map[float64]uint64, len(h.buckets))
+		var cumulative uint64
+		for i, b := range h.buckets {
+			cumulative += s.counts[i]
+			buckets[b] = cumulative
+		}
+		ch <- prometheus.MustNewConstHistogram(h.desc, s.count, s.sum, buckets, name)
+	}
+}
@@ -0,0 +1,80 @@
+// Copyright 2016 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"bytes"
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/prometheus/common/expfmt"
+)
+
+func TestDurationHistogramExemplar(t *testing.T) {
+	h := newDurationHistogram()
+	h.observe("cpu", 0.003, "aaaa")
+	h.observe("cpu", 0.004, "bbbb")
+	h.observe("cpu", 100, "cccc")
+
+	if want, got := "bbbb", h.exemplar("cpu", 0.005); got == nil || got.traceID != want {
+		t.Errorf("want exemplar %q for 0.005, got %v", want, got)
+	}
+	if got := h.exemplar("cpu", 0.01); got != nil {
+		t.Errorf("want no exemplar for 0.01, got %v", got)
+	}
+	if want, got := "cccc", h.exemplar("cpu", math.Inf(1)); got == nil || got.traceID != want {
+		t.Errorf("want exemplar %q for +Inf, got %v", want, got)
+	}
+	if got := h.exemplar("meminfo", 0.005); got != nil {
+		t.Errorf("want no exemplar for unknown collector, got %v", got)
+	}
+}
+
+func TestWriteOpenMetrics(t *testing.T) {
+	h := newDurationHistogram()
+	h.observe("cpu", 0.003, "0123456789abcdef0123456789abcdef")
+
+	text := `# HELP test_requests_total Test.
+# TYPE test_requests_total counter
+test_requests_total 1
+# HELP node_exporter_collector_duration_seconds Test.
+# TYPE node_exporter_collector_duration_seconds histogram
+node_exporter_collector_duration_seconds_bucket{collector="cpu",le="0.005"} 1
+node_exporter_collector_duration_seconds_bucket{collector="cpu",le="+Inf"} 1
+node_exporter_collector_duration_seconds_sum{collector="cpu"} 0.003
+node_exporter_collector_duration_seconds_count{collector="cpu"} 1
+`
+	var parser expfmt.TextParser
+	families, err := parser.TextToMetricFamilies(strings.NewReader(text))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	if err := writeOpenMetrics(&buf, families, h); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, want := range []string{
+		"# TYPE test_requests counter\n",
+		"test_requests_total 1\n",
+		`node_exporter_collector_duration_seconds_bucket{collector="cpu",le="0.005"} 1 # {trace_id="0123456789abcdef0123456789abcdef"} 0.003 `,
+		`node_exporter_collector_duration_seconds_bucket{collector="cpu",le="+Inf"} 1` + "\n",
+		"# EOF\n",
+	} {
+		if !strings.Contains(buf.String(), want) {
+			t.Errorf("want %q in output:\n%s", want, buf.String())
+		}
+	}
+}
@@ -0,0 +1,190 @@
+// Copyright 2016 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/prometheus/common/log"
+)
+
+// Lint rules, following promlint.
+const (
+	lintNoHelp           = "no_help"
+	lintCounterSuffix    = "counter_without_total"
+	lintNonCounterTotal  = "non_counter_with_total"
+	lintCamelCase        = "camel_case"
+	lintNonBaseUnit      = "non_base_unit"
+	lintAbbreviatedUnit  = "abbreviated_unit"
+	lintReservedChars    = "reserved_chars"
+	lintReservedLabel    = "reserved_label"
+	lintMetricTypeInName = "type_in_name"
+)
+
+var (
+	camelCaseRE = regexp.MustCompile(`[a-z][A-Z]`)
+
+	// lintBaseUnits maps units to the base unit to use instead.
+	lintBaseUnits = map[string]string{
+		"seconds": "seconds", "minutes": "seconds", "hours": "seconds", "days": "seconds", "weeks": "seconds",
+		"bytes": "bytes", "bits": "bytes",
+		"meters": "meters", "metres": "meters",
+		"grams":   "grams",
+		"joules":  "joules",
+		"volts":   "volts",
+		"amperes": "amperes",
+		"celsius": "celsius", "fahrenheit": "celsius",
+		"percent": "ratio",
+	}
+	lintUnitPrefixes     = []string{"pico", "nano", "micro", "milli", "centi", "deci", "deca", "hecto", "kilo", "kibi", "mega", "mebi", "giga", "gibi", "tera", "tebi", "peta", "pebi"}
+	lintAbbreviatedUnits = map[string]bool{"s": true, "ms": true, "us": true, "ns": true, "sec": true, "b": true, "kb": true, "mb": true, "gb": true, "tb": true, "pb": true, "m": true, "h": true, "d": true}
+
+	// lintReported holds the problems already logged, each is only logged
+	// once.
+	lintReported = struct {
+		sync.Mutex
+		problems map[string]bool
+	}{problems: map[string]bool{}}
+)
+
+// metricLinter checks the metrics of a single scrape against the Prometheus
+// naming conventions, like promlint does for an exposition. Each metric name
+// is checked once per scrape.
+type metricLinter struct {
+	mtx      sync.Mutex
+	checked  map[string]bool
+	problems map[[2]string]int
+}
+
+func newMetricLinter() *metricLinter {
+	return &metricLinter{
+		checked:  map[string]bool{},
+		problems: map[[2]string]int{},
+	}
+}
+
+// check lints m, exposed by the named collector, unless a metric of the same
+// name was already checked in this scrape.
+func (l *metricLinter) check(collectorName string, m prometheus.Metric) {
+	desc := m.Desc().String()
+	match := descNameRE.FindStringSubmatch(desc)
+	if match == nil {
+		return
+	}
+	name := match[1]
+	l.mtx.Lock()
+	checked := l.checked[name]
+	l.checked[name] = true
+	l.mtx.Unlock()
+	if checked {
+		return
+	}
+
+	var help string
+	if match := descHelpRE.FindStringSubmatch(desc); match != nil {
+		help = match[1]
+	}
+	pb := &dto.Metric{}
+	if err := m.Write(pb); err != nil {
+		return
+	}
+	for _, p := range lintMetric(name, help, pb) {
+		l.mtx.Lock()
+		l.problems[[2]string{collectorName, p.rule}]++
+		l.mtx.Unlock()
+
+		lintReported.Lock()
+		if !lintReported.problems[name+" "+p.rule] {
+			lintReported.problems[name+" "+p.rule] = true
+			log.Warnf("%s collector exposes %s: %s", collectorName, name, p.text)
+		}
+		lintReported.Unlock()
+	}
+}
+
+// collect sends the number of metrics per collector and rule that have
+// problems.
+func (l *metricLinter) collect(ch chan<- prometheus.Metric, desc *prometheus.Desc) {
+	l.mtx.Lock()
+	defer l.mtx.Unlock()
+	for k, n := range l.problems {
+		ch <- prometheus.MustNewConstMetric(desc, prometheus.GaugeValue, float64(n), k[0], k[1])
+	}
+}
+
+type lintProblem struct {
+	rule, text string
+}
+
+// lintMetric returns the naming convention problems of a metric.
+func lintMetric(name, help string, pb *dto.Metric) []lintProblem {
+	var problems []lintProblem
+	add := func(rule, format string, args ...interface{}) {
+		problems = append(problems, lintProblem{rule, fmt.Sprintf(format, args...)})
+	}
+
+	if help == "" {
+		add(lintNoHelp, "no help text")
+	}
+	isCounter := pb.Counter != nil
+	if isCounter && !strings.HasSuffix(name, "_total") {
+		add(lintCounterSuffix, `counter metrics should have "_total" suffix`)
+	}
+	if !isCounter && strings.HasSuffix(name, "_total") {
+		add(lintNonCounterTotal, `non-counter metrics should not have "_total" suffix`)
+	}
+	if camelCaseRE.MatchString(name) {
+		add(lintCamelCase, "metric names should be written in 'snake_case' not 'camelCase'")
+	}
+	for _, lp := range pb.Label {
+		if camelCaseRE.MatchString(lp.GetName()) {
+			add(lintCamelCase, "label names should be written in 'snake_case' not 'camelCase'")
+		}
+		if lp.GetName() == "le" && pb.Histogram == nil {
+			add(lintReservedLabel, `non-histogram metrics should not have "le" label`)
+		}
+		if lp.GetName() == "quantile" && pb.Summary == nil {
+			add(lintReservedLabel, `non-summary metrics should not have "quantile" label`)
+		}
+	}
+	if strings.Contains(name, ":") {
+		add(lintReservedChars, "metric names should not contain ':'")
+	}
+	for _, token := range strings.Split(name, "_") {
+		if lintAbbreviatedUnits[token] {
+			add(lintAbbreviatedUnit, "metric names should not contain abbreviated units")
+		}
+		switch token {
+		case "counter", "gauge", "histogram", "summary", "untyped":
+			add(lintMetricTypeInName, "metric name should not include type '%s'", token)
+		}
+		if base, ok := lintBaseUnits[token]; ok && base != token {
+			add(lintNonBaseUnit, "use base unit %q instead of %q", base, token)
+			continue
+		}
+		for _, prefix := range lintUnitPrefixes {
+			if unit := strings.TrimPrefix(token, prefix); unit != token {
+				if base, ok := lintBaseUnits[unit]; ok {
+					add(lintNonBaseUnit, "use base unit %q instead of %q", base, token)
+				}
+			}
+		}
+	}
+	return problems
+}
@@ -0,0 +1,83 @@
+// Copyright 2016 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+)
+
+func TestLintMetric(t *testing.T) {
+	counter := &dto.Metric{Counter: &dto.Counter{}}
+	gauge := &dto.Metric{Gauge: &dto.Gauge{}}
+	for _, tt := range []struct {
+		name, help string
+		pb         *dto.Metric
+		want       []string
+	}{
+		{"node_network_receive_bytes_total", "Received bytes.", counter, nil},
+		{"node_hwmon_temp_celsius", "Temperature.", gauge, nil},
+		{"node_test", "", gauge, []string{lintNoHelp}},
+		{"node_network_receive_bytes", "Received bytes.", counter, []string{lintCounterSuffix}},
+		{"node_files_total", "Open files.", gauge, []string{lintNonCounterTotal}},
+		{"node_memory_MemFree", "Free memory.", gauge, []string{lintCamelCase}},
+		{"node_disk_read_time_ms", "Read time.", gauge, []string{lintAbbreviatedUnit}},
+		{"node_disk_read_milliseconds", "Read time.", gauge, []string{lintNonBaseUnit}},
+		{"node_uptime_hours", "Uptime.", gauge, []string{lintNonBaseUnit}},
+		{"node_temp_fahrenheit", "Temperature.", gauge, []string{lintNonBaseUnit}},
+		{"node:load:avg", "Load.", gauge, []string{lintReservedChars}},
+		{"node_requests_counter", "Requests.", gauge, []string{lintMetricTypeInName}},
+		{"node_test", "Test.", &dto.Metric{Gauge: &dto.Gauge{}, Label: []*dto.LabelPair{{Name: proto.String("le")}}}, []string{lintReservedLabel}},
+		{"node_test", "Test.", &dto.Metric{Gauge: &dto.Gauge{}, Label: []*dto.LabelPair{{Name: proto.String("deviceName")}}}, []string{lintCamelCase}},
+	} {
+		var got []string
+		for _, p := range lintMetric(tt.name, tt.help, tt.pb) {
+			got = append(got, p.rule)
+		}
+		if !reflect.DeepEqual(tt.want, got) {
+			t.Errorf("%s: want %v, got %v", tt.name, tt.want, got)
+		}
+	}
+}
+
+func TestMetricLinter(t *testing.T) {
+	desc := prometheus.NewDesc("node_test_ms", "Test.", []string{"n"}, nil)
+	l := newMetricLinter()
+	// Series of the same metric are only checked once.
+	l.check("test", prometheus.MustNewConstMetric(desc, prometheus.GaugeValue, 1, "a"))
+	l.check("test", prometheus.MustNewConstMetric(desc, prometheus.GaugeValue, 1, "b"))
+
+	problems := prometheus.NewDesc("problems", "Test.", []string{"collector", "rule"}, nil)
+	ch := make(chan prometheus.Metric, 10)
+	l.collect(ch, problems)
+	close(ch)
+	var got []float64
+	for m := range ch {
+		pb := &dto.Metric{}
+		if err := m.Write(pb); err != nil {
+			t.Fatal(err)
+		}
+		if want, got := "abbreviated_unit", pb.Label[1].GetValue(); want != got {
+			t.Errorf("want rule %s, got %s", want, got)
+		}
+		got = append(got, pb.GetGauge().GetValue())
+	}
+	if want := []float64{1}; !reflect.DeepEqual(want, got) {
+		t.Errorf("want %v, got %v", want, got)
+	}
+}
@@ -0,0 +1,57 @@
+// Copyright 2016 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"sort"
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/common/log"
+	"github.com/prometheus/node_exporter/collector"
+)
+
+// moduleHinter exposes the kernel modules that would likely give enabled
+// collectors something to expose, and logs each hint once.
+type moduleHinter struct {
+	collectors []string
+	hints      func(collectors []string) []collector.ModuleHint
+
+	mtx    sync.Mutex
+	logged map[collector.ModuleHint]bool
+}
+
+func newModuleHinter(collectors map[string]collector.Collector) *moduleHinter {
+	h := &moduleHinter{hints: collector.ModuleHints, logged: map[collector.ModuleHint]bool{}}
+	for name := range collectors {
+		h.collectors = append(h.collectors, name)
+	}
+	sort.Strings(h.collectors)
+	return h
+}
+
+func (h *moduleHinter) collect(ch chan<- prometheus.Metric) {
+	if h == nil {
+		return
+	}
+	for _, hint := range h.hints(h.collectors) {
+		h.mtx.Lock()
+		if !h.logged[hint] {
+			h.logged[hint] = true
+			log.Warnf("%s collector found nothing to expose, the kernel module %s is installed but not loaded, try modprobe %s", hint.Collector, hint.Module, hint.Module)
+		}
+		h.mtx.Unlock()
+		ch <- prometheus.MustNewConstMetric(moduleNotLoaded, prometheus.GaugeValue, 1, hint.Collector, hint.Module)
+	}
+}
@@ -0,0 +1,56 @@
+// Copyright 2016 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/prometheus/node_exporter/collector"
+)
+
+func TestModuleHinter(t *testing.T) {
+	initExporterMetrics()
+	h := newModuleHinter(map[string]collector.Collector{"power_supply": nil, "loadavg": nil})
+	var asked []string
+	h.hints = func(collectors []string) []collector.ModuleHint {
+		asked = collectors
+		return []collector.ModuleHint{{Collector: "power_supply", Module: "battery"}}
+	}
+
+	for i := 0; i < 2; i++ {
+		ch := make(chan prometheus.Metric, 10)
+		h.collect(ch)
+		close(ch)
+		var got []string
+		for m := range ch {
+			pb := &dto.Metric{}
+			m.Write(pb)
+			got = append(got, pb.Label[0].GetValue()+"/"+pb.Label[1].GetValue())
+		}
+		if want := "power_supply/battery"; len(got) != 1 || got[0] != want {
+			t.Errorf("scrape %d: want hint %s, got %v", i, want, got)
+		}
+	}
+	if want, got := 2, len(asked); want != got || asked[0] != "loadavg" {
+		t.Errorf("want hints for sorted collectors, got %v", asked)
+	}
+	if want, got := 1, len(h.logged); want != got {
+		t.Errorf("want %d logged hint, got %d", want, got)
+	}
+
+	var disabled *moduleHinter
+	disabled.collect(nil)
+}
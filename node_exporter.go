@@ -16,9 +16,11 @@ package main
 import (
 	"flag"
 	"fmt"
+	"net"
 	"net/http"
 	_ "net/http/pprof"
 	"os"
+	"runtime/debug"
 	"sort"
 	"strings"
 	"sync"
@@ -26,15 +28,25 @@ import (
 
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/common/log"
+	"github.com/prometheus/common/model"
 	"github.com/prometheus/common/version"
 	"github.com/prometheus/node_exporter/collector"
 )
 
-const (
-	defaultCollectors = "conntrack,cpu,diskstats,entropy,filefd,filesystem,loadavg,mdadm,meminfo,netdev,netstat,sockstat,stat,textfile,time,uname,vmstat"
+// Exporter metrics, created once the metric namespace is known.
+var (
+	scrapeDurations  *prometheus.SummaryVec
+	seriesCount      *prometheus.Desc
+	seriesTruncated  *prometheus.Desc
+	violations       *prometheus.CounterVec
+	lintProblems     *prometheus.Desc
+	permissionDenied *prometheus.CounterVec
+	suppressedErrors *prometheus.CounterVec
+	deviceRemoved    *prometheus.Desc
+	moduleNotLoaded  *prometheus.Desc
 )
 
-var (
+func initExporterMetrics() {
 	scrapeDurations = prometheus.NewSummaryVec(
 		prometheus.SummaryOpts{
 			Namespace: collector.Namespace,
@@ -44,30 +56,185 @@ var (
 		},
 		[]string{"collector", "result"},
 	)
-)
+	seriesCount = prometheus.NewDesc(
+		prometheus.BuildFQName(collector.Namespace, "exporter", "series_total"),
+		"node_exporter: Number of series exposed by a collector in the last scrape.",
+		[]string{"collector"}, nil,
+	)
+	seriesTruncated = prometheus.NewDesc(
+		prometheus.BuildFQName(collector.Namespace, "exporter", "series_truncated"),
+		"node_exporter: Whether a collector's series were truncated because they exceeded its series limit.",
+		[]string{"collector"}, nil,
+	)
+	violations = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: collector.Namespace,
+			Subsystem: "exporter",
+			Name:      "validation_violations_total",
+			Help:      "node_exporter: Number of invalid series found with -debug.validate.",
+		},
+		[]string{"collector", "type"},
+	)
+	lintProblems = prometheus.NewDesc(
+		prometheus.BuildFQName(collector.Namespace, "exporter", "lint_problems"),
+		"node_exporter: Number of metrics violating a Prometheus naming convention in the last scrape, found with -debug.lint.",
+		[]string{"collector", "rule"}, nil,
+	)
+	permissionDenied = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: collector.Namespace,
+			Subsystem: "exporter",
+			Name:      "permission_denied_total",
+			Help:      "node_exporter: Number of collector failures caused by missing permissions, by the first elements of the path that couldn't be read.",
+		},
+		[]string{"collector", "path"},
+	)
+	suppressedErrors = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: collector.Namespace,
+			Subsystem: "exporter",
+			Name:      "suppressed_errors_total",
+			Help:      "node_exporter: Number of collector errors not logged because the same error was logged within -log.collector-error-interval.",
+		},
+		[]string{"collector"},
+	)
+	deviceRemoved = prometheus.NewDesc(
+		prometheus.BuildFQName(collector.Namespace, "device", "removed_timestamp_seconds"),
+		"node_exporter: Time at which a device exposed by the collector in its previous successful scrape was found missing, present for -collector.device-removal-retention.",
+		[]string{"collector", "device"}, nil,
+	)
+	moduleNotLoaded = prometheus.NewDesc(
+		prometheus.BuildFQName(collector.Namespace, "exporter", "module_not_loaded"),
+		"node_exporter: Kernel module that is installed but not loaded while the sysfs interface of the collector is missing. Loading it likely enables the collector.",
+		[]string{"collector", "module"}, nil,
+	)
+}
 
 // NodeCollector implements the prometheus.Collector interface.
 type NodeCollector struct {
 	collectors map[string]collector.Collector
+	// maxSeries limits the number of series per collector, 0 disables
+	// the limit.
+	maxSeries int
+	// seriesLimits overrides maxSeries by collector.
+	seriesLimits map[string]int
+	// maxLabelLength limits the length of label values in bytes, 0
+	// disables the limit.
+	maxLabelLength int
+	// validate enables checking each scrape for duplicate series and
+	// inconsistent label names.
+	validate bool
+	// lint enables checking the metrics of each scrape against the
+	// naming conventions.
+	lint bool
+	// errorLog limits logging of repeated collector errors, nil logs
+	// every error.
+	errorLog *errorLogLimiter
+	// tracer exports a trace per scrape, nil disables tracing.
+	tracer *tracer
+	// durations records collector durations with exemplars of their
+	// traces, it is only set with tracing.
+	durations *durationHistogram
+	// redactor hashes or drops sensitive label values, nil disables
+	// redaction.
+	redactor *labelRedactor
+	// dropLabels holds the names of the labels to remove by collector.
+	dropLabels map[string]map[string]bool
+	// deviceIdentities holds the device identity scheme by collector.
+	deviceIdentities map[string]string
+	// udevProperties holds the udev properties added as labels by
+	// collector.
+	udevProperties map[string][]string
+	// replay serves recorded snapshots instead of the live system, nil
+	// reads the live system.
+	replay *replayer
+	// devices reports devices that disappeared between scrapes, nil
+	// disables it.
+	devices *deviceTracker
+	// monitor keeps track of running scrapes for the systemd watchdog,
+	// nil disables it.
+	monitor *scrapeMonitor
+	// crashes reports panics of collectors, nil disables reports.
+	crashes *crashReporter
+	// hints exposes kernel modules that would enable collectors, nil
+	// disables the hints.
+	hints *moduleHinter
+	// types records the types of the exposed metrics for their
+	// documentation, nil disables it.
+	types *metricTypes
 }
 
 // Describe implements the prometheus.Collector interface.
 func (n NodeCollector) Describe(ch chan<- *prometheus.Desc) {
 	scrapeDurations.Describe(ch)
+	ch <- seriesCount
+	ch <- seriesTruncated
+	ch <- lintProblems
+	ch <- deviceRemoved
+	ch <- moduleNotLoaded
+	violations.Describe(ch)
+	permissionDenied.Describe(ch)
+	suppressedErrors.Describe(ch)
 }
 
 // Collect implements the prometheus.Collector interface.
 func (n NodeCollector) Collect(ch chan<- prometheus.Metric) {
+	if n.replay != nil {
+		n.replay.begin()
+		defer n.replay.end()
+	}
+	defer n.monitor.end(n.monitor.begin())
+	collector.BeginScrape()
+	trace := n.tracer.startScrape()
+	var validator *metricValidator
+	if n.validate {
+		validator = newMetricValidator()
+	}
+	var linter *metricLinter
+	if n.lint {
+		linter = newMetricLinter()
+	}
 	wg := sync.WaitGroup{}
-	wg.Add(len(n.collectors))
 	for name, c := range n.collectors {
+		if collector.DisabledOnBattery(name) {
+			continue
+		}
+		wg.Add(1)
 		go func(name string, c collector.Collector) {
-			execute(name, c, ch)
+			defer n.crashes.handle()
+			n.execute(name, c, ch, validator, linter, trace)
 			wg.Done()
 		}(name, c)
 	}
 	wg.Wait()
+	trace.finish()
+	n.hints.collect(ch)
+	if linter != nil {
+		linter.collect(ch, lintProblems)
+	}
 	scrapeDurations.Collect(ch)
+	violations.Collect(ch)
+	permissionDenied.Collect(ch)
+	suppressedErrors.Collect(ch)
+}
+
+// update runs the collector's Update. A panic is reported and returned as
+// error, so that the collector fails like one returning an error instead of
+// taking down the exporter with the other collectors.
+func (n NodeCollector) update(name string, c collector.Collector, ch chan<- prometheus.Metric) (err error) {
+	defer func() {
+		v := recover()
+		if v == nil {
+			return
+		}
+		if n.crashes == nil {
+			log.Errorf("%s collector panicked: %v\n%s", name, v, debug.Stack())
+		} else if err := n.crashes.reportCollector(name, v, time.Now()); err != nil {
+			log.Errorf("Couldn't write crash report: %s", err)
+		}
+		err = fmt.Errorf("panic: %v", v)
+	}()
+	return c.Update(ch)
 }
 
 func filterAvailableCollectors(collectors string) string {
@@ -81,23 +248,164 @@ func filterAvailableCollectors(collectors string) string {
 	return strings.Join(availableCollectors, ",")
 }
 
-func execute(name string, c collector.Collector, ch chan<- prometheus.Metric) {
+// execute updates a single collector. If validator is not nil, the series are
+// checked against those of the other collectors in the same scrape, and if
+// linter is not nil, the metrics against the naming conventions. The
+// collector's span is added to trace.
+func (n NodeCollector) execute(name string, c collector.Collector, ch chan<- prometheus.Metric, validator *metricValidator, linter *metricLinter, trace *scrapeTrace) {
+	var (
+		series    int
+		truncated bool
+		metrics   = make(chan prometheus.Metric)
+		done      = make(chan struct{})
+		limit     = n.maxSeries
+		filter    *labelFilter
+		devices   map[string]bool
+		ident     *collector.DeviceIdentifier
+		udev      *collector.UdevReader
+	)
+	if l, ok := n.seriesLimits[name]; ok {
+		limit = l
+	}
+	props := n.udevProperties[name]
+	if len(props) > 0 {
+		udev = collector.NewUdevReader(props)
+	}
+	if scheme, ok := n.deviceIdentities[name]; ok {
+		// The scheme was validated when loading the config.
+		ident, _ = collector.NewDeviceIdentifier(scheme)
+	}
+	if n.devices != nil {
+		devices = map[string]bool{}
+	}
+	// Labels are dropped and redacted once all of them were added.
+	var rewrites []labelTransform
+	if drop, ok := n.dropLabels[name]; ok {
+		rewrites = append(rewrites, dropLabels(drop))
+	}
+	if n.redactor != nil {
+		rewrites = append(rewrites, n.redactor.transform())
+	}
+	if len(rewrites) > 0 {
+		filter = newLabelFilter(rewrites...)
+	}
+	expose := func(m prometheus.Metric) {
+		if limit > 0 && series >= limit {
+			truncated = true
+			return
+		}
+		if validator != nil {
+			if kind, detail := validator.check(m); kind != "" {
+				log.Warnf("%s collector exposed invalid series: %s", name, detail)
+				violations.WithLabelValues(name, kind).Inc()
+			}
+		}
+		if linter != nil {
+			linter.check(name, m)
+		}
+		if devices != nil {
+			if dev, ok := n.devices.device(m); ok {
+				devices[dev] = true
+			}
+		}
+		series++
+		ch <- m
+	}
+	collector.Go(func() {
+		for m := range metrics {
+			n.types.record(m)
+			// Properties are looked up by kernel name, so before the
+			// device is renamed.
+			if udev != nil {
+				m = addUdevLabels(m, udev, props)
+			}
+			if ident != nil {
+				m = identifyDevice(m, ident)
+			}
+			m = relabel(m, sanitizeLabels(n.maxLabelLength))
+			if filter != nil {
+				// Series left without their distinguishing
+				// labels are merged, so they're exposed at the
+				// end.
+				filter.add(m)
+				continue
+			}
+			expose(m)
+		}
+		if filter != nil {
+			for _, m := range filter.result() {
+				expose(m)
+			}
+		}
+		close(done)
+	})
+
+	span := trace.startSpan("collect " + name)
 	begin := time.Now()
-	err := c.Update(ch)
+	err := n.update(name, c, metrics)
 	duration := time.Since(begin)
+	close(metrics)
+	<-done
+	trace.endSpan(span, name, series, err)
+	if n.durations != nil {
+		n.durations.observe(name, duration.Seconds(), trace.TraceID())
+	}
 	var result string
 
 	if err != nil {
-		log.Errorf("ERROR: %s collector failed after %fs: %s", name, duration.Seconds(), err)
+		if ok, suppressed := n.errorLog.allow(name, err); !ok {
+			suppressedErrors.WithLabelValues(name).Inc()
+		} else if suppressed > 0 {
+			log.Errorf("ERROR: %s collector failed after %fs: %s (%d identical errors suppressed)", name, duration.Seconds(), err, suppressed)
+		} else {
+			log.Errorf("ERROR: %s collector failed after %fs: %s", name, duration.Seconds(), err)
+		}
 		result = "error"
+		if path, ok := permissionDeniedPath(err); ok {
+			permissionDenied.WithLabelValues(name, path).Inc()
+		}
 	} else {
 		log.Debugf("OK: %s collector succeeded after %fs.", name, duration.Seconds())
 		result = "success"
 	}
 	scrapeDurations.WithLabelValues(name, result).Observe(duration.Seconds())
+
+	truncatedValue := 0.0
+	if truncated {
+		log.Warnf("%s collector exceeded the limit of %d series, dropped the rest", name, limit)
+		truncatedValue = 1
+	}
+	ch <- prometheus.MustNewConstMetric(seriesCount, prometheus.GaugeValue, float64(series), name)
+	ch <- prometheus.MustNewConstMetric(seriesTruncated, prometheus.GaugeValue, truncatedValue, name)
+
+	// Devices are only known to be gone if the collector saw all of them.
+	if devices != nil && err == nil && !truncated {
+		for _, r := range n.devices.update(name, devices, time.Now()) {
+			ch <- prometheus.MustNewConstMetric(deviceRemoved, prometheus.GaugeValue, float64(r.time.UnixNano())/1e9, name, r.device)
+		}
+	}
+}
+
+// checkMaturity returns an error if an experimental collector is listed
+// without allowing them, and warns about beta collectors.
+func checkMaturity(list string, experimental bool) error {
+	for _, name := range strings.Split(list, ",") {
+		switch collector.CollectorMaturity(name) {
+		case collector.MaturityExperimental:
+			if !experimental {
+				return fmt.Errorf("collector '%s' is experimental, it requires -collector.enable-experimental", name)
+			}
+			log.Warnf("%s collector is experimental and may be unsafe to run", name)
+		case collector.MaturityBeta:
+			log.Infof("%s collector is beta, its metrics may still change", name)
+		}
+	}
+	return nil
 }
 
-func loadCollectors(list string) (map[string]collector.Collector, error) {
+// loadCollectors creates the listed collectors. Collectors supporting it also
+// read the further sysfs roots, labeling their metrics with rootLabel.
+func loadCollectors(list string, roots []sysfsRoot, rootLabel string) (map[string]collector.Collector, error) {
 	collectors := map[string]collector.Collector{}
 	for _, name := range strings.Split(list, ",") {
 		fn, ok := collector.Factories[name]
@@ -108,6 +416,11 @@ func loadCollectors(list string) (map[string]collector.Collector, error) {
 		if err != nil {
 			return nil, err
 		}
+		if _, ok := collector.SysfsFactories[name]; ok && len(roots) > 0 {
+			if c, err = newRootsCollector(name, c, roots, rootLabel); err != nil {
+				return nil, err
+			}
+		}
 		collectors[name] = c
 	}
 	return collectors, nil
@@ -122,8 +435,45 @@ func main() {
 		showVersion       = flag.Bool("version", false, "Print version information.")
 		listenAddress     = flag.String("web.listen-address", ":9100", "Address on which to expose metrics and web interface.")
 		metricsPath       = flag.String("web.telemetry-path", "/metrics", "Path under which to expose metrics.")
+		metricsPrefix     = flag.String("web.telemetry-prefix", collector.Namespace, "Prefix of all exported metric names.")
 		enabledCollectors = flag.String("collectors.enabled", filterAvailableCollectors(defaultCollectors), "Comma-separated list of collectors to use.")
 		printCollectors   = flag.Bool("collectors.print", false, "If true, print available collectors and exit.")
+		validate          = flag.Bool("debug.validate", false, "Check every scrape for duplicate series and metrics with inconsistent label names.")
+		lint              = flag.Bool("debug.lint", false, "Check the metrics of every scrape against the Prometheus naming conventions like promlint, logging problems once and exposing their number.")
+		maxSeries         = flag.Int("collector.max-series", 0, "Maximum number of series a single collector may expose per scrape, further series are dropped. 0 means no limit.")
+		maxLabelLength    = flag.Int("collector.max-label-length", 0, "Maximum length of label values in bytes, longer values are truncated and end in a hash of the whole value. 0 means no limit.")
+		collectPrint      = flag.Bool("collect.print", false, "If true, print the metrics of a single collection to stdout and exit. Use -collectors.enabled to restrict it to some collectors.")
+		experimental      = flag.Bool("collector.enable-experimental", false, "Allow enabling experimental collectors, which may be unsafe to run.")
+		diffURL           = flag.String("diff-against", "", "If set, scrape the exporter at this URL, e.g. an older release or the upstream node_exporter, print the metrics, label names and types one collection of the enabled collectors adds, removes or retypes compared to it and exit. The exit status is non-zero if metrics or labels were removed or retyped.")
+		check             = flag.Bool("check", false, "If true, run every available collector once, print which of them work on this host and exit. The exit status is non-zero if an enabled collector fails.")
+		helperSocket      = flag.String("privileged-helper.listen", "", "If set, run as privileged helper serving reads on this unix socket instead of as exporter.")
+		helperAllowed     = flag.String("privileged-helper.allowed-paths", "/sys/class/powercap/intel-rapl:*/energy_uj,/sys/devices/virtual/powercap/intel-rapl/intel-rapl:*/energy_uj", "Comma-separated glob patterns of the files the privileged helper may read.")
+		errorLogInterval  = flag.Duration("log.collector-error-interval", 5*time.Minute, "Log the same error of a collector at most once per interval. 0 logs every error.")
+		otlpEndpoint      = flag.String("tracing.otlp-endpoint", "", "OTLP/HTTP traces endpoint, e.g. http://localhost:4318/v1/traces, to export a trace of every scrape to. Tracing is disabled if empty.")
+		otlpTimeout       = flag.Duration("tracing.otlp-timeout", 10*time.Second, "Timeout for exporting a trace.")
+		configFile        = flag.String("config.file", "", "Path to a JSON config file with per collector settings and alert rules.")
+		alertInterval     = flag.Duration("alerts.evaluation-interval", 30*time.Second, "Interval at which the alert rules of the config file are evaluated.")
+		redactMode        = flag.String("collector.redact", "", "If set to \"hash\" or \"drop\", replace the values of the labels given by -collector.redact-labels of all collectors with their SHA-256 hash or the empty string.")
+		redactLabels      = flag.String("collector.redact-labels", "serial,serial_number,asset_tag", "Comma-separated names of the labels redacted by -collector.redact.")
+		agentURL          = flag.String("agent.remote-write-url", "", "If set, scrape the exporter itself every -agent.scrape-interval and push the samples to this remote write URL.")
+		agentInterval     = flag.Duration("agent.scrape-interval", 15*time.Second, "Interval at which the agent scrapes the exporter.")
+		agentSpoolDir     = flag.String("agent.spool-dir", "data-agent", "Directory the agent spools scrapes to while the remote write receiver is unreachable.")
+		agentSpoolSize    = flag.Int64("agent.spool-max-size", 100<<20, "Maximum size of the spool in bytes, the oldest scrapes are dropped first. 0 disables the limit.")
+		agentSpoolAge     = flag.Duration("agent.spool-max-age", 7*24*time.Hour, "Maximum age of spooled scrapes. 0 disables the limit.")
+		agentLabels       = flag.String("agent.external-labels", "job=node", "Comma-separated name=value labels added to the pushed series, instance defaults to the hostname.")
+		agentTimeout      = flag.Duration("agent.remote-write-timeout", 30*time.Second, "Timeout for a single remote write request.")
+		cpuLimit          = flag.Float64("limits.cpu", 0, "If set, limit the exporter to this many CPUs, via its cgroup if possible.")
+		memoryLimit       = flag.Int64("limits.memory", 0, "If set, limit the exporter's memory to this many bytes, via its cgroup if possible.")
+		sysfsRoots        = flag.String("collector.sysfs.extra-roots", "", "Comma-separated name=path list of further sysfs trees, e.g. of test rigs mounted over sshfs, read in addition to -collector.sysfs by the collectors supporting it.")
+		sysfsRootLabel    = flag.String("collector.sysfs.extra-roots-label", "sysfs_root", "Label holding the name of the sysfs root of a series when -collector.sysfs.extra-roots is set, empty for -collector.sysfs.")
+		removalLabels     = flag.String("collector.device-removal-labels", "device,power_supply", "Comma-separated names of the labels identifying devices, whose disappearance is reported by node_device_removed_timestamp_seconds. Empty disables it.")
+		removalRetention  = flag.Duration("collector.device-removal-retention", 5*time.Minute, "How long node_device_removed_timestamp_seconds reports a removed device, should span a few scrape intervals.")
+		replayDir         = flag.String("replay.dir", "", "If set, serve metrics from the recorded snapshots in this directory, gzipped tarballs or directories holding proc and sys, advancing to the next one on every scrape.")
+		replayLoop        = flag.Bool("replay.loop", false, "Start over with the first snapshot after the last one was replayed, instead of serving the last one from then on.")
+		snapshotCapture   = flag.String("snapshot.capture", "", "If set, write the procfs and sysfs files read by one collection of the enabled collectors to this gzipped tarball, e.g. for bug reports or -replay.dir, and exit.")
+		snapshotRedact    = flag.String("snapshot.redact-files", "serial,serial_number,product_serial,board_serial,chassis_serial,product_uuid,boot_id", "Comma-separated names of files, and uevent keys ending in them, whose contents -snapshot.capture replaces by their SHA-256 hash.")
+		crashDir          = flag.String("crash.dir", "", "If set, write a report to this directory when the exporter panics, and count the crashes across restarts in node_exporter_crashes_total.")
+		assetsDir         = flag.String("generate-assets", "", "If set, write recommended alerting rules and a Grafana dashboard for the collectors of this build to the given directory and exit.")
 	)
 	flag.Parse()
 
@@ -132,6 +482,13 @@ func main() {
 		os.Exit(0)
 	}
 
+	if *helperSocket != "" {
+		if err := runPrivilegedHelper(*helperSocket, *helperAllowed); err != nil {
+			log.Fatalf("Privileged helper failed: %s", err)
+		}
+		return
+	}
+
 	log.Infoln("Starting node_exporter", version.Info())
 	log.Infoln("Build context", version.BuildContext())
 
@@ -143,11 +500,66 @@ func main() {
 		collectorNames.Sort()
 		fmt.Printf("Available collectors:\n")
 		for _, n := range collectorNames {
+			if m := collector.CollectorMaturity(n); m != collector.MaturityStable {
+				fmt.Printf(" - %s (%s)\n", n, m)
+				continue
+			}
 			fmt.Printf(" - %s\n", n)
 		}
 		return
 	}
-	collectors, err := loadCollectors(*enabledCollectors)
+
+	if err := collector.SetNamespace(*metricsPrefix); err != nil {
+		log.Fatal(err)
+	}
+
+	var crashes *crashReporter
+	if *crashDir != "" {
+		var err error
+		crashes, err = newCrashReporter(*crashDir, *enabledCollectors)
+		if err != nil {
+			log.Fatalf("Couldn't set up crash reports: %s", err)
+		}
+		defer crashes.handle()
+		collector.OnPanic = crashes.crash
+		prometheus.MustRegister(crashes.metric())
+	}
+
+	if *assetsDir != "" {
+		if err := generateAssets(*assetsDir); err != nil {
+			log.Fatalf("Couldn't generate assets: %s", err)
+		}
+		return
+	}
+
+	initExporterMetrics()
+
+	if *cpuLimit > 0 || *memoryLimit > 0 {
+		method, err := collector.ApplySelfLimits(*cpuLimit, *memoryLimit)
+		if err != nil {
+			log.Fatalf("Couldn't apply limits: %s", err)
+		}
+		log.Infof("Limited exporter to %g CPUs and %d bytes of memory via %s", *cpuLimit, *memoryLimit, method)
+	}
+
+	if *check {
+		if !checkCollectors(os.Stdout, strings.Split(*enabledCollectors, ","), *experimental) {
+			os.Exit(1)
+		}
+		return
+	}
+
+	if err := checkMaturity(*enabledCollectors, *experimental); err != nil {
+		log.Fatal(err)
+	}
+	roots, err := parseSysfsRoots(*sysfsRoots)
+	if err != nil {
+		log.Fatalf("Couldn't parse sysfs roots: %s", err)
+	}
+	if len(roots) > 0 && !model.LabelName(*sysfsRootLabel).IsValid() {
+		log.Fatalf("Invalid sysfs root label %q", *sysfsRootLabel)
+	}
+	collectors, err := loadCollectors(*enabledCollectors, roots, *sysfsRootLabel)
 	if err != nil {
 		log.Fatalf("Couldn't load collectors: %s", err)
 	}
@@ -157,25 +569,134 @@ func main() {
 		log.Infof(" - %s", n)
 	}
 
-	nodeCollector := NodeCollector{collectors: collectors}
+	cfg, err := loadConfig(*configFile)
+	if err != nil {
+		log.Fatalf("Couldn't load config: %s", err)
+	}
+	if *maxLabelLength != 0 && *maxLabelLength < minLabelLength {
+		log.Fatalf("-collector.max-label-length must be 0 or at least %d", minLabelLength)
+	}
+	redactor, err := newLabelRedactor(*redactMode, *redactLabels)
+	if err != nil {
+		log.Fatalf("Couldn't set up label redaction: %s", err)
+	}
+
+	nodeCollector := NodeCollector{
+		collectors:       collectors,
+		maxSeries:        *maxSeries,
+		seriesLimits:     cfg.seriesLimits(),
+		maxLabelLength:   *maxLabelLength,
+		validate:         *validate,
+		lint:             *lint,
+		errorLog:         newErrorLogLimiter(*errorLogInterval),
+		redactor:         redactor,
+		dropLabels:       cfg.dropLabels(),
+		devices:          newDeviceTracker(*removalLabels, *removalRetention),
+		deviceIdentities: cfg.deviceIdentities(),
+		udevProperties:   cfg.udevProperties(),
+		crashes:          crashes,
+		hints:            newModuleHinter(collectors),
+		types:            newMetricTypes(),
+	}
+	if *replayDir != "" {
+		nodeCollector.replay, err = newReplayer(*replayDir, *replayLoop)
+		if err != nil {
+			log.Fatalf("Couldn't set up replay: %s", err)
+		}
+		log.Infof("Replaying %d snapshots from %s", len(nodeCollector.replay.snapshots), *replayDir)
+	}
+	if *otlpEndpoint != "" {
+		nodeCollector.tracer = newTracer(*otlpEndpoint, *otlpTimeout)
+		nodeCollector.durations = newDurationHistogram()
+		prometheus.MustRegister(nodeCollector.durations)
+	}
+	if *snapshotCapture != "" {
+		fileRedactor, err := newLabelRedactor(redactHash, *snapshotRedact)
+		if err != nil {
+			log.Fatalf("Couldn't set up snapshot redaction: %s", err)
+		}
+		entries, err := captureSnapshot(*snapshotCapture, nodeCollector, fileRedactor)
+		if err != nil {
+			log.Fatalf("Couldn't capture snapshot: %s", err)
+		}
+		log.Infof("Wrote %d entries to %s", entries, *snapshotCapture)
+		return
+	}
+	notifier := newSystemdNotifier()
+	watchdogTimeout := systemdWatchdogTimeout()
+	if notifier != nil && watchdogTimeout > 0 {
+		nodeCollector.monitor = newScrapeMonitor()
+	}
 	prometheus.MustRegister(nodeCollector)
 
+	if *collectPrint {
+		if err := printMetrics(os.Stdout); err != nil {
+			log.Fatalf("Couldn't print metrics: %s", err)
+		}
+		return
+	}
+
+	if *diffURL != "" {
+		ok, err := diffAgainst(os.Stdout, *diffURL, prometheus.UninstrumentedHandler())
+		if err != nil {
+			log.Fatalf("Couldn't diff metrics: %s", err)
+		}
+		if !ok {
+			os.Exit(1)
+		}
+		return
+	}
+
+	if *agentURL != "" {
+		labels, err := parseAgentLabels(*agentLabels)
+		if err != nil {
+			log.Fatalf("Couldn't parse agent labels: %s", err)
+		}
+		spool, err := newSpool(*agentSpoolDir, *agentSpoolSize, *agentSpoolAge)
+		if err != nil {
+			log.Fatalf("Couldn't set up agent spool: %s", err)
+		}
+		prometheus.MustRegister(spool.dropped)
+		a := newAgent(*agentURL, spool, labels, *agentTimeout, prometheus.UninstrumentedHandler())
+		a.downsample = newDownsampler(cfg.Agent.Downsampling)
+		collector.Go(func() { a.run(*agentInterval) })
+	}
+
+	if len(cfg.Alerts) > 0 {
+		alerter := newAlerter(cfg.Alerts, prometheus.UninstrumentedHandler())
+		collector.Go(func() { alerter.run(*alertInterval) })
+	}
+
 	handler := prometheus.Handler()
+	if nodeCollector.durations != nil {
+		handler = openMetricsHandler(handler, nodeCollector.durations)
+	}
 
 	http.Handle(*metricsPath, handler)
+	http.Handle("/metrics-docs", docsHandler(collectors, nodeCollector.types))
+	http.Handle("/events", eventsHandler(collector.Events))
 	http.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
 		w.Write([]byte(`<html>
 			<head><title>Node Exporter</title></head>
 			<body>
 			<h1>Node Exporter</h1>
 			<p><a href="` + *metricsPath + `">Metrics</a></p>
+			<p><a href="/metrics-docs">Metric documentation</a></p>
+			<p><a href="/events">Events</a></p>
 			</body>
 			</html>`))
 	})
 
 	log.Infoln("Listening on", *listenAddress)
-	err = http.ListenAndServe(*listenAddress, nil)
+	listener, err := net.Listen("tcp", *listenAddress)
 	if err != nil {
 		log.Fatal(err)
 	}
+	if err := notifier.notify("READY=1"); err != nil {
+		log.Errorf("Couldn't notify systemd: %s", err)
+	}
+	if nodeCollector.monitor != nil {
+		collector.Go(func() { notifier.watchdog(watchdogTimeout, nodeCollector.monitor) })
+	}
+	log.Fatal(http.Serve(listener, nil))
 }
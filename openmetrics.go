@@ -0,0 +1,181 @@
+// Copyright 2016 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	dto "github.com/prometheus/client_model/go"
+	"github.com/prometheus/common/log"
+)
+
+const openMetricsContentType = "application/openmetrics-text; version=1.0.0; charset=utf-8"
+
+// openMetricsHandler serves scrapes accepting OpenMetrics, the only format
+// carrying exemplars, by converting the text format output of next and
+// adding the exemplars of durations. Other scrapes are passed to next.
+func openMetricsHandler(next http.Handler, durations *durationHistogram) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !strings.Contains(r.Header.Get("Accept"), "application/openmetrics-text") {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		families, err := gatherMetrics(next)
+		if err != nil {
+			http.Error(w, fmt.Sprintf("couldn't convert metrics to OpenMetrics: %s", err), http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", openMetricsContentType)
+		bw := bufio.NewWriter(w)
+		if err := writeOpenMetrics(bw, families, durations); err != nil {
+			log.Errorf("Couldn't write OpenMetrics: %s", err)
+			return
+		}
+		bw.Flush()
+	})
+}
+
+// writeOpenMetrics writes the families in the OpenMetrics text format.
+// Counters whose name doesn't end in _total can't be expressed as
+// OpenMetrics counters and are written with type unknown.
+func writeOpenMetrics(w io.Writer, families map[string]*dto.MetricFamily, durations *durationHistogram) error {
+	names := make([]string, 0, len(families))
+	for n := range families {
+		names = append(names, n)
+	}
+	sort.Strings(names)
+
+	var durationsName string
+	if durations != nil {
+		durationsName = descNameRE.FindStringSubmatch(durations.desc.String())[1]
+	}
+
+	for _, name := range names {
+		mf := families[name]
+		family, typ := name, "unknown"
+		switch mf.GetType() {
+		case dto.MetricType_COUNTER:
+			if strings.HasSuffix(name, "_total") {
+				family, typ = strings.TrimSuffix(name, "_total"), "counter"
+			}
+		case dto.MetricType_GAUGE:
+			typ = "gauge"
+		case dto.MetricType_SUMMARY:
+			typ = "summary"
+		case dto.MetricType_HISTOGRAM:
+			typ = "histogram"
+		}
+		fmt.Fprintf(w, "# TYPE %s %s\n", family, typ)
+		if mf.Help != nil {
+			fmt.Fprintf(w, "# HELP %s %s\n", family, escapeOpenMetrics(mf.GetHelp(), false))
+		}
+
+		for _, m := range mf.Metric {
+			switch mf.GetType() {
+			case dto.MetricType_COUNTER:
+				writeSample(w, name, m.Label, "", "", m.Counter.GetValue(), m.TimestampMs, nil)
+			case dto.MetricType_GAUGE:
+				writeSample(w, name, m.Label, "", "", m.Gauge.GetValue(), m.TimestampMs, nil)
+			case dto.MetricType_UNTYPED:
+				writeSample(w, name, m.Label, "", "", m.Untyped.GetValue(), m.TimestampMs, nil)
+			case dto.MetricType_SUMMARY:
+				for _, q := range m.Summary.Quantile {
+					writeSample(w, name, m.Label, "quantile", formatFloat(q.GetQuantile()), q.GetValue(), m.TimestampMs, nil)
+				}
+				writeSample(w, name+"_sum", m.Label, "", "", m.Summary.GetSampleSum(), m.TimestampMs, nil)
+				writeSample(w, name+"_count", m.Label, "", "", float64(m.Summary.GetSampleCount()), m.TimestampMs, nil)
+			case dto.MetricType_HISTOGRAM:
+				var collectorName string
+				for _, lp := range m.Label {
+					if lp.GetName() == "collector" {
+						collectorName = lp.GetValue()
+					}
+				}
+				exemplarFor := func(upperBound float64) *exemplar {
+					if name != durationsName {
+						return nil
+					}
+					return durations.exemplar(collectorName, upperBound)
+				}
+				for _, b := range m.Histogram.Bucket {
+					if math.IsInf(b.GetUpperBound(), 1) {
+						continue
+					}
+					writeSample(w, name+"_bucket", m.Label, "le", formatFloat(b.GetUpperBound()), float64(b.GetCumulativeCount()), m.TimestampMs, exemplarFor(b.GetUpperBound()))
+				}
+				writeSample(w, name+"_bucket", m.Label, "le", "+Inf", float64(m.Histogram.GetSampleCount()), m.TimestampMs, exemplarFor(math.Inf(1)))
+				writeSample(w, name+"_sum", m.Label, "", "", m.Histogram.GetSampleSum(), m.TimestampMs, nil)
+				writeSample(w, name+"_count", m.Label, "", "", float64(m.Histogram.GetSampleCount()), m.TimestampMs, nil)
+			}
+		}
+	}
+	_, err := io.WriteString(w, "# EOF\n")
+	return err
+}
+
+// writeSample writes a single sample line, with an additional label if
+// extraName isn't empty and e as exemplar if it isn't nil.
+func writeSample(w io.Writer, name string, labels []*dto.LabelPair, extraName, extraValue string, value float64, timestampMs *int64, e *exemplar) {
+	pairs := make([]string, 0, len(labels)+1)
+	for _, lp := range labels {
+		pairs = append(pairs, fmt.Sprintf(`%s="%s"`, lp.GetName(), escapeOpenMetrics(lp.GetValue(), true)))
+	}
+	if extraName != "" {
+		pairs = append(pairs, fmt.Sprintf(`%s="%s"`, extraName, extraValue))
+	}
+	line := name
+	if len(pairs) > 0 {
+		line += "{" + strings.Join(pairs, ",") + "}"
+	}
+	line += " " + formatFloat(value)
+	if timestampMs != nil {
+		line += " " + formatFloat(float64(*timestampMs)/1000)
+	}
+	if e != nil {
+		line += fmt.Sprintf(` # {trace_id="%s"} %s %s`, e.traceID, formatFloat(e.value), formatFloat(float64(e.at.UnixNano())/1e9))
+	}
+	io.WriteString(w, line+"\n")
+}
+
+func formatFloat(f float64) string {
+	switch {
+	case math.IsInf(f, 1):
+		return "+Inf"
+	case math.IsInf(f, -1):
+		return "-Inf"
+	case math.IsNaN(f):
+		return "NaN"
+	}
+	return strconv.FormatFloat(f, 'g', -1, 64)
+}
+
+// escapeOpenMetrics escapes backslashes and newlines, and double quotes in
+// label values.
+func escapeOpenMetrics(s string, quotes bool) string {
+	s = strings.Replace(s, `\`, `\\`, -1)
+	s = strings.Replace(s, "\n", `\n`, -1)
+	if quotes {
+		s = strings.Replace(s, `"`, `\"`, -1)
+	}
+	return s
+}
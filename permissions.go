@@ -0,0 +1,58 @@
+// Copyright 2016 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"path"
+	"regexp"
+	"strings"
+)
+
+// permissionDeniedPathDepth is the number of path elements kept when
+// reporting a path a collector wasn't allowed to read, so that per-device
+// files like /sys/class/hwmon/hwmon3/temp1_input share a series.
+const permissionDeniedPathDepth = 3
+
+// Errors of the os package quote the path they failed on, e.g.
+// "open /dev/cpu/0/msr: permission denied".
+var permissionDeniedRE = regexp.MustCompile(`(?:open|stat|lstat|readlink|readdirent|dial unix) ([^ :]+): permission denied`)
+
+// permissionDeniedPath returns the bucketed path a collector error reports
+// a permission error for. Permission errors without a path are reported as
+// "unknown".
+func permissionDeniedPath(err error) (string, bool) {
+	msg := err.Error()
+	if !strings.Contains(msg, "permission denied") {
+		return "", false
+	}
+	match := permissionDeniedRE.FindStringSubmatch(msg)
+	if match == nil {
+		return "unknown", true
+	}
+	return bucketPath(match[1]), true
+}
+
+// bucketPath truncates an absolute path to its first
+// permissionDeniedPathDepth elements.
+func bucketPath(p string) string {
+	p = path.Clean(p)
+	if !path.IsAbs(p) {
+		return "unknown"
+	}
+	parts := strings.SplitN(p, "/", permissionDeniedPathDepth+2)
+	if len(parts) > permissionDeniedPathDepth+1 {
+		parts = parts[:permissionDeniedPathDepth+1]
+	}
+	return strings.Join(parts, "/")
+}
@@ -0,0 +1,44 @@
+// Copyright 2016 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"syscall"
+	"testing"
+)
+
+func TestPermissionDeniedPath(t *testing.T) {
+	for _, tc := range []struct {
+		err  error
+		path string
+		ok   bool
+	}{
+		{
+			err:  fmt.Errorf("couldn't read: %s", &os.PathError{Op: "open", Path: "/sys/class/hwmon/hwmon3/temp1_input", Err: syscall.EACCES}),
+			path: "/sys/class/hwmon",
+			ok:   true,
+		},
+		{err: &os.PathError{Op: "open", Path: "/dev/mem", Err: syscall.EACCES}, path: "/dev/mem", ok: true},
+		{err: errors.New("operation failed: permission denied"), path: "unknown", ok: true},
+		{err: &os.PathError{Op: "open", Path: "/proc/net/ip_vs", Err: syscall.ENOENT}, ok: false},
+	} {
+		path, ok := permissionDeniedPath(tc.err)
+		if path != tc.path || ok != tc.ok {
+			t.Errorf("permissionDeniedPath(%q) = %q, %t, want %q, %t", tc.err, path, ok, tc.path, tc.ok)
+		}
+	}
+}
@@ -0,0 +1,75 @@
+// Copyright 2016 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/prometheus/common/expfmt"
+)
+
+// writerResponse is an http.ResponseWriter writing the body to w, so the
+// registry's handler can render a single scrape outside of a server.
+type writerResponse struct {
+	w      io.Writer
+	header http.Header
+	status int
+}
+
+func (r *writerResponse) Header() http.Header         { return r.header }
+func (r *writerResponse) Write(b []byte) (int, error) { return r.w.Write(b) }
+func (r *writerResponse) WriteHeader(status int)      { r.status = status }
+
+// printMetrics writes one scrape of the default registry in the text
+// format to w. The Go runtime and process metrics of the exporter itself
+// are left out.
+func printMetrics(w io.Writer) error {
+	prometheus.Unregister(prometheus.NewGoCollector())
+	prometheus.Unregister(prometheus.NewProcessCollector(os.Getpid(), ""))
+
+	req, err := http.NewRequest("GET", "/metrics", nil)
+	if err != nil {
+		return err
+	}
+	resp := &writerResponse{w: w, header: http.Header{}, status: http.StatusOK}
+	prometheus.UninstrumentedHandler().ServeHTTP(resp, req)
+	if resp.status != http.StatusOK {
+		return fmt.Errorf("collection failed with status %d", resp.status)
+	}
+	return nil
+}
+
+// gatherMetrics renders one scrape of handler and returns the parsed metric
+// families. The vendored client library has no way of gathering the
+// registry directly.
+func gatherMetrics(handler http.Handler) (map[string]*dto.MetricFamily, error) {
+	req, err := http.NewRequest("GET", "/metrics", nil)
+	if err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	resp := &writerResponse{w: &buf, header: http.Header{}, status: http.StatusOK}
+	handler.ServeHTTP(resp, req)
+	if resp.status != http.StatusOK {
+		return nil, fmt.Errorf("collection failed with status %d: %s", resp.status, bytes.TrimSpace(buf.Bytes()))
+	}
+	var parser expfmt.TextParser
+	return parser.TextToMetricFamilies(&buf)
+}
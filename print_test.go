@@ -0,0 +1,41 @@
+// Copyright 2016 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+func TestPrintMetrics(t *testing.T) {
+	g := prometheus.NewGauge(prometheus.GaugeOpts{Name: "node_print_test", Help: "Test metric."})
+	g.Set(42)
+	prometheus.MustRegister(g)
+	defer prometheus.Unregister(g)
+
+	var buf bytes.Buffer
+	if err := printMetrics(&buf); err != nil {
+		t.Fatal(err)
+	}
+	out := buf.String()
+	if want := "# TYPE node_print_test gauge\nnode_print_test 42\n"; !strings.Contains(out, want) {
+		t.Errorf("want %q in output:\n%s", want, out)
+	}
+	if strings.Contains(out, "go_goroutines") {
+		t.Errorf("unexpected Go runtime metrics in output:\n%s", out)
+	}
+}
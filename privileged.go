@@ -0,0 +1,61 @@
+// Copyright 2016 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"net"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+
+	"github.com/prometheus/common/log"
+	"github.com/prometheus/node_exporter/collector"
+)
+
+// runPrivilegedHelper serves privileged reads of the files matching the
+// comma-separated glob patterns on a unix socket until terminated. The
+// socket is made accessible to the group of the helper, which the exporter
+// should be a member of.
+func runPrivilegedHelper(socket, allowed string) error {
+	h, err := collector.NewPrivilegedHelper(strings.Split(allowed, ","))
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(socket); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	l, err := net.Listen("unix", socket)
+	if err != nil {
+		return err
+	}
+	if err := os.Chmod(socket, 0660); err != nil {
+		l.Close()
+		return err
+	}
+
+	sigs := make(chan os.Signal, 1)
+	signal.Notify(sigs, syscall.SIGINT, syscall.SIGTERM)
+	collector.Go(func() {
+		<-sigs
+		l.Close()
+	})
+
+	log.Infof("Privileged helper listening on %s, allowing %s", socket, allowed)
+	err = h.Serve(l)
+	if ne, ok := err.(*net.OpError); ok && strings.Contains(ne.Err.Error(), "use of closed network connection") {
+		return nil
+	}
+	return err
+}
@@ -0,0 +1,73 @@
+// Copyright 2016 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"regexp"
+	"sync"
+	"time"
+)
+
+// Numbers in errors, like ports or byte offsets, often change between
+// scrapes while the error stays the same.
+var errorNumbersRE = regexp.MustCompile(`[0-9]+`)
+
+// errorLogLimiter limits logging of the same error of a collector to once
+// per interval, so a permanently broken source doesn't log every scrape.
+type errorLogLimiter struct {
+	interval time.Duration
+	now      func() time.Time
+
+	mtx  sync.Mutex
+	last map[string]*loggedError
+}
+
+type loggedError struct {
+	key        string
+	at         time.Time
+	suppressed int
+}
+
+func newErrorLogLimiter(interval time.Duration) *errorLogLimiter {
+	return &errorLogLimiter{
+		interval: interval,
+		now:      time.Now,
+		last:     map[string]*loggedError{},
+	}
+}
+
+// allow reports whether err of the named collector should be logged. If
+// so, it also returns how many times the error was suppressed since it was
+// last logged. Only the last error of each collector is remembered.
+func (l *errorLogLimiter) allow(collector string, err error) (bool, int) {
+	if l == nil || l.interval <= 0 {
+		return true, 0
+	}
+	key := errorNumbersRE.ReplaceAllString(err.Error(), "N")
+	now := l.now()
+
+	l.mtx.Lock()
+	defer l.mtx.Unlock()
+	e, ok := l.last[collector]
+	if ok && e.key == key && now.Sub(e.at) < l.interval {
+		e.suppressed++
+		return false, 0
+	}
+	var suppressed int
+	if ok && e.key == key {
+		suppressed = e.suppressed
+	}
+	l.last[collector] = &loggedError{key: key, at: now}
+	return true, suppressed
+}
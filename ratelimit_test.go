@@ -0,0 +1,54 @@
+// Copyright 2016 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestErrorLogLimiter(t *testing.T) {
+	now := time.Unix(0, 0)
+	l := newErrorLogLimiter(5 * time.Minute)
+	l.now = func() time.Time { return now }
+
+	for _, tc := range []struct {
+		after      time.Duration
+		collector  string
+		err        string
+		allow      bool
+		suppressed int
+	}{
+		{0, "timesync", "read udp 127.0.0.1:41692: connection refused", true, 0},
+		{time.Minute, "timesync", "read udp 127.0.0.1:50411: connection refused", false, 0},
+		{time.Minute, "timesync", "read udp 127.0.0.1:50412: connection refused", false, 0},
+		{time.Minute, "hwmon", "read udp 127.0.0.1:50412: connection refused", true, 0},
+		{3 * time.Minute, "timesync", "read udp 127.0.0.1:50413: connection refused", true, 2},
+		{time.Minute, "timesync", "no such file or directory", true, 0},
+	} {
+		now = now.Add(tc.after)
+		allow, suppressed := l.allow(tc.collector, errors.New(tc.err))
+		if allow != tc.allow || suppressed != tc.suppressed {
+			t.Errorf("%s %q: want %t, %d, got %t, %d", tc.collector, tc.err, tc.allow, tc.suppressed, allow, suppressed)
+		}
+	}
+
+	l = newErrorLogLimiter(0)
+	for i := 0; i < 2; i++ {
+		if allow, _ := l.allow("timesync", errors.New("boom")); !allow {
+			t.Error("want every error logged without interval")
+		}
+	}
+}
@@ -0,0 +1,72 @@
+// Copyright 2016 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strings"
+)
+
+const (
+	redactHash = "hash"
+	redactDrop = "drop"
+)
+
+// labelRedactor hashes or drops the values of privacy sensitive labels, like
+// serial numbers and asset tags, of all collectors.
+type labelRedactor struct {
+	labels map[string]bool
+	hash   bool
+}
+
+// newLabelRedactor returns a redactor for the comma-separated label names
+// using mode, which is either "hash" or "drop". An empty mode disables
+// redaction and returns nil.
+func newLabelRedactor(mode, labels string) (*labelRedactor, error) {
+	if mode == "" {
+		return nil, nil
+	}
+	if mode != redactHash && mode != redactDrop {
+		return nil, fmt.Errorf("unknown redaction mode %q, must be %q or %q", mode, redactHash, redactDrop)
+	}
+	r := &labelRedactor{labels: map[string]bool{}, hash: mode == redactHash}
+	for _, l := range strings.Split(labels, ",") {
+		if l = strings.TrimSpace(l); l != "" {
+			r.labels[l] = true
+		}
+	}
+	return r, nil
+}
+
+// redact returns the redacted value of the named label. Dropped values are
+// replaced by the empty string, which Prometheus treats like a missing
+// label, as the metric must keep the label names of its descriptor.
+func (r *labelRedactor) redact(name, value string) string {
+	if !r.labels[name] || value == "" {
+		return value
+	}
+	if !r.hash {
+		return ""
+	}
+	sum := sha256.Sum256([]byte(value))
+	return hex.EncodeToString(sum[:])
+}
+
+// transform returns a transform redacting the labels of a collector's
+// metric.
+func (r *labelRedactor) transform() labelTransform {
+	return mapLabelValues(r.redact)
+}
@@ -0,0 +1,92 @@
+// Copyright 2016 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/prometheus/node_exporter/collector"
+)
+
+func TestLabelRedactor(t *testing.T) {
+	r, err := newLabelRedactor("", "serial")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r != nil {
+		t.Errorf("want no redactor for empty mode, got %v", r)
+	}
+	if _, err := newLabelRedactor("scramble", "serial"); err == nil {
+		t.Error("want error for unknown mode")
+	}
+
+	desc := prometheus.NewDesc("test_info", "Test.", []string{"model", "serial"}, nil)
+	metric := prometheus.MustNewConstMetric(desc, prometheus.GaugeValue, 1, "DELL", "ABC123")
+	for _, tt := range []struct {
+		mode, serial string
+	}{
+		{redactDrop, ""},
+		{redactHash, "e0bebd22819993425814866b62701e2919ea26f1370499c1037b53b9d49c2c8a"},
+	} {
+		r, err := newLabelRedactor(tt.mode, "serial, asset_tag")
+		if err != nil {
+			t.Fatal(err)
+		}
+		pb := &dto.Metric{}
+		if err := relabel(metric, r.transform()).Write(pb); err != nil {
+			t.Fatal(err)
+		}
+		if want, got := "DELL", pb.Label[0].GetValue(); want != got {
+			t.Errorf("%s: want model %q, got %q", tt.mode, want, got)
+		}
+		if want, got := tt.serial, pb.Label[1].GetValue(); want != got {
+			t.Errorf("%s: want serial %q, got %q", tt.mode, want, got)
+		}
+	}
+}
+
+func TestRedactDropDeduplicates(t *testing.T) {
+	initExporterMetrics()
+	r, err := newLabelRedactor(redactDrop, "n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	n := NodeCollector{
+		collectors: map[string]collector.Collector{"test": checkTestCollector{series: 3}},
+		redactor:   r,
+	}
+	ch := make(chan prometheus.Metric)
+	go func() {
+		n.Collect(ch)
+		close(ch)
+	}()
+	series := 0
+	for m := range ch {
+		if m.Desc() == seriesCount {
+			continue
+		}
+		pb := &dto.Metric{}
+		if err := m.Write(pb); err != nil {
+			t.Fatal(err)
+		}
+		if len(pb.Label) == 1 && pb.Label[0].GetName() == "n" {
+			series++
+		}
+	}
+	if want, got := 1, series; want != got {
+		t.Errorf("want %d series after dropping the only distinguishing label, got %d", want, got)
+	}
+}
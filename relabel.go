@@ -0,0 +1,102 @@
+// Copyright 2016 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"sort"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+)
+
+// labelTransform rewrites the label pairs of a metric. The pairs may be
+// shared with the metric, so a transform must return a new slice instead of
+// changing them.
+type labelTransform func([]*dto.LabelPair) []*dto.LabelPair
+
+// relabeledMetric is a metric with its labels rewritten by transforms, which
+// are applied in order.
+type relabeledMetric struct {
+	prometheus.Metric
+	transforms []labelTransform
+}
+
+// relabel returns m with its labels rewritten by transforms. The transforms
+// of an already relabeled metric are extended, so that its labels are only
+// written once.
+func relabel(m prometheus.Metric, transforms ...labelTransform) prometheus.Metric {
+	if r, ok := m.(relabeledMetric); ok {
+		ts := make([]labelTransform, 0, len(r.transforms)+len(transforms))
+		ts = append(append(ts, r.transforms...), transforms...)
+		return relabeledMetric{Metric: r.Metric, transforms: ts}
+	}
+	return relabeledMetric{Metric: m, transforms: transforms}
+}
+
+func (m relabeledMetric) Write(pb *dto.Metric) error {
+	if err := m.Metric.Write(pb); err != nil {
+		return err
+	}
+	for _, t := range m.transforms {
+		pb.Label = t(pb.Label)
+	}
+	return nil
+}
+
+// mapLabelValues returns a transform replacing the value of every label with
+// f of its name and value. The pairs are only copied if a value changes.
+func mapLabelValues(f func(name, value string) string) labelTransform {
+	return func(labels []*dto.LabelPair) []*dto.LabelPair {
+		copied := false
+		for i, lp := range labels {
+			if v := f(lp.GetName(), lp.GetValue()); v != lp.GetValue() {
+				if !copied {
+					labels = append([]*dto.LabelPair(nil), labels...)
+					copied = true
+				}
+				labels[i] = &dto.LabelPair{Name: lp.Name, Value: &v}
+			}
+		}
+		return labels
+	}
+}
+
+// setLabel returns a transform adding the label, replacing one of the same
+// name.
+func setLabel(name, value string) labelTransform {
+	return func(labels []*dto.LabelPair) []*dto.LabelPair {
+		out := make([]*dto.LabelPair, 0, len(labels)+1)
+		for _, lp := range labels {
+			if lp.GetName() != name {
+				out = append(out, lp)
+			}
+		}
+		out = append(out, &dto.LabelPair{Name: &name, Value: &value})
+		sort.Sort(prometheus.LabelPairSorter(out))
+		return out
+	}
+}
+
+// dropLabels returns a transform removing the labels in drop.
+func dropLabels(drop map[string]bool) labelTransform {
+	return func(labels []*dto.LabelPair) []*dto.LabelPair {
+		out := make([]*dto.LabelPair, 0, len(labels))
+		for _, lp := range labels {
+			if !drop[lp.GetName()] {
+				out = append(out, lp)
+			}
+		}
+		return out
+	}
+}
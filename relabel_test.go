@@ -0,0 +1,52 @@
+// Copyright 2016 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+)
+
+func TestRelabel(t *testing.T) {
+	desc := prometheus.NewDesc("test_info", "Test.", []string{"device", "serial"}, nil)
+	orig := prometheus.MustNewConstMetric(desc, prometheus.GaugeValue, 1, "sda", "abc")
+
+	m := relabel(orig, setLabel("root", "dut"), dropLabels(map[string]bool{"serial": true}))
+	m = relabel(m, mapLabelValues(func(_, value string) string { return strings.ToUpper(value) }))
+	if _, ok := m.(relabeledMetric).Metric.(relabeledMetric); ok {
+		t.Error("want transforms of relabeled metric to be extended, got nested metric")
+	}
+
+	labels := func(m prometheus.Metric) map[string]string {
+		pb := &dto.Metric{}
+		if err := m.Write(pb); err != nil {
+			t.Fatal(err)
+		}
+		labels := map[string]string{}
+		for _, lp := range pb.Label {
+			labels[lp.GetName()] = lp.GetValue()
+		}
+		return labels
+	}
+	if want, got := map[string]string{"device": "SDA", "root": "DUT"}, labels(m); !reflect.DeepEqual(want, got) {
+		t.Errorf("want labels %v, got %v", want, got)
+	}
+	if want, got := map[string]string{"device": "sda", "serial": "abc"}, labels(orig); !reflect.DeepEqual(want, got) {
+		t.Errorf("want original labels %v unchanged, got %v", want, got)
+	}
+}
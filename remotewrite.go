@@ -0,0 +1,206 @@
+// Copyright 2016 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"time"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/prometheus/common/version"
+)
+
+// The messages of the Prometheus remote write protocol. The vendored
+// libraries don't include the generated code, so they are declared here
+// with the struct tags the protobuf library needs to encode them.
+
+type prompbWriteRequest struct {
+	Timeseries []*prompbTimeSeries `protobuf:"bytes,1,rep,name=timeseries"`
+}
+
+func (m *prompbWriteRequest) Reset()         { *m = prompbWriteRequest{} }
+func (m *prompbWriteRequest) String() string { return proto.CompactTextString(m) }
+func (*prompbWriteRequest) ProtoMessage()    {}
+
+type prompbTimeSeries struct {
+	Labels  []*prompbLabel  `protobuf:"bytes,1,rep,name=labels"`
+	Samples []*prompbSample `protobuf:"bytes,2,rep,name=samples"`
+}
+
+func (m *prompbTimeSeries) Reset()         { *m = prompbTimeSeries{} }
+func (m *prompbTimeSeries) String() string { return proto.CompactTextString(m) }
+func (*prompbTimeSeries) ProtoMessage()    {}
+
+type prompbLabel struct {
+	Name  string `protobuf:"bytes,1,opt,name=name,proto3"`
+	Value string `protobuf:"bytes,2,opt,name=value,proto3"`
+}
+
+func (m *prompbLabel) Reset()         { *m = prompbLabel{} }
+func (m *prompbLabel) String() string { return proto.CompactTextString(m) }
+func (*prompbLabel) ProtoMessage()    {}
+
+type prompbSample struct {
+	Value     float64 `protobuf:"fixed64,1,opt,name=value,proto3"`
+	Timestamp int64   `protobuf:"varint,2,opt,name=timestamp,proto3"`
+}
+
+func (m *prompbSample) Reset()         { *m = prompbSample{} }
+func (m *prompbSample) String() string { return proto.CompactTextString(m) }
+func (*prompbSample) ProtoMessage()    {}
+
+// encodeWriteRequest returns the snappy compressed protobuf encoding of req,
+// which is the body of a remote write request.
+func encodeWriteRequest(req *prompbWriteRequest) ([]byte, error) {
+	data, err := proto.Marshal(req)
+	if err != nil {
+		return nil, err
+	}
+	return snappyEncode(data), nil
+}
+
+// remoteWriteError is returned by sendRemoteWrite if the receiver rejected
+// the request. Recoverable errors are worth retrying.
+type remoteWriteError struct {
+	status      string
+	recoverable bool
+}
+
+func (e remoteWriteError) Error() string {
+	return fmt.Sprintf("remote write failed with status %s", e.status)
+}
+
+// sendRemoteWrite posts an encoded write request to url.
+func sendRemoteWrite(client *http.Client, url string, body []byte) error {
+	req, err := http.NewRequest("POST", url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Encoding", "snappy")
+	req.Header.Set("Content-Type", "application/x-protobuf")
+	req.Header.Set("User-Agent", "node_exporter/"+version.Version)
+	req.Header.Set("X-Prometheus-Remote-Write-Version", "0.1.0")
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	io.Copy(ioutil.Discard, resp.Body)
+	if resp.StatusCode/100 == 2 {
+		return nil
+	}
+	return remoteWriteError{
+		status:      resp.Status,
+		recoverable: resp.StatusCode/100 == 5 || resp.StatusCode == http.StatusTooManyRequests,
+	}
+}
+
+// retryBackoff returns the delay before the next attempt after failures
+// consecutive failures, doubling from min up to max.
+func retryBackoff(failures int, min, max time.Duration) time.Duration {
+	d := min
+	for i := 1; i < failures && d < max; i++ {
+		d *= 2
+	}
+	if d > max {
+		d = max
+	}
+	return d
+}
+
+// snappyEncode compresses src in the snappy block format expected by
+// remote write receivers, finding matches of at least four bytes with a
+// hash table.
+func snappyEncode(src []byte) []byte {
+	var buf bytes.Buffer
+	var n [binary.MaxVarintLen64]byte
+	buf.Write(n[:binary.PutUvarint(n[:], uint64(len(src)))])
+
+	const tableBits = 14
+	var table [1 << tableBits]int
+	hash := func(u uint32) uint32 { return (u * 0x1e35a7bd) >> (32 - tableBits) }
+	load := func(i int) uint32 { return binary.LittleEndian.Uint32(src[i:]) }
+
+	literal := 0
+	for i := 0; i+4 <= len(src); {
+		h := hash(load(i))
+		// Table entries are stored off by one, so zero means empty.
+		candidate := table[h] - 1
+		table[h] = i + 1
+		if candidate < 0 || i-candidate > 65535 || load(candidate) != load(i) {
+			i++
+			continue
+		}
+		snappyLiteral(&buf, src[literal:i])
+		length := 4
+		for i+length < len(src) && src[candidate+length] == src[i+length] {
+			length++
+		}
+		snappyCopy(&buf, i-candidate, length)
+		i += length
+		literal = i
+	}
+	snappyLiteral(&buf, src[literal:])
+	return buf.Bytes()
+}
+
+func snappyLiteral(buf *bytes.Buffer, lit []byte) {
+	if len(lit) == 0 {
+		return
+	}
+	n := uint32(len(lit) - 1)
+	switch {
+	case n < 60:
+		buf.WriteByte(byte(n << 2))
+	case n < 1<<8:
+		buf.WriteByte(60 << 2)
+		buf.WriteByte(byte(n))
+	case n < 1<<16:
+		buf.WriteByte(61 << 2)
+		buf.WriteByte(byte(n))
+		buf.WriteByte(byte(n >> 8))
+	case n < 1<<24:
+		buf.WriteByte(62 << 2)
+		buf.WriteByte(byte(n))
+		buf.WriteByte(byte(n >> 8))
+		buf.WriteByte(byte(n >> 16))
+	default:
+		buf.WriteByte(63 << 2)
+		buf.WriteByte(byte(n))
+		buf.WriteByte(byte(n >> 8))
+		buf.WriteByte(byte(n >> 16))
+		buf.WriteByte(byte(n >> 24))
+	}
+	buf.Write(lit)
+}
+
+// snappyCopy writes copies with a two byte offset, which hold at most 64
+// bytes each.
+func snappyCopy(buf *bytes.Buffer, offset, length int) {
+	for length > 0 {
+		n := length
+		if n > 64 {
+			n = 64
+		}
+		buf.WriteByte(byte(n-1)<<2 | 2)
+		buf.WriteByte(byte(offset))
+		buf.WriteByte(byte(offset >> 8))
+		length -= n
+	}
+}
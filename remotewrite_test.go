@@ -0,0 +1,158 @@
+// Copyright 2016 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"math/rand"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/golang/protobuf/proto"
+)
+
+// snappyDecode decodes the snappy block format for testing the encoder.
+func snappyDecode(src []byte) ([]byte, error) {
+	n, i := binary.Uvarint(src)
+	if i <= 0 {
+		return nil, errors.New("invalid length")
+	}
+	dst := make([]byte, 0, n)
+	for i < len(src) {
+		tag := src[i]
+		switch tag & 3 {
+		case 0:
+			length := int(tag >> 2)
+			i++
+			if length >= 60 {
+				extra := length - 59
+				length = 0
+				for j := 0; j < extra; j++ {
+					length |= int(src[i+j]) << (8 * uint(j))
+				}
+				i += extra
+			}
+			length++
+			dst = append(dst, src[i:i+length]...)
+			i += length
+		case 2:
+			length := int(tag>>2) + 1
+			offset := int(src[i+1]) | int(src[i+2])<<8
+			i += 3
+			if offset == 0 || offset > len(dst) {
+				return nil, errors.New("invalid offset")
+			}
+			for j := 0; j < length; j++ {
+				dst = append(dst, dst[len(dst)-offset])
+			}
+		default:
+			return nil, errors.New("unexpected tag")
+		}
+	}
+	if uint64(len(dst)) != n {
+		return nil, errors.New("length mismatch")
+	}
+	return dst, nil
+}
+
+func TestSnappyEncode(t *testing.T) {
+	random := make([]byte, 100000)
+	rand.New(rand.NewSource(1)).Read(random)
+	for _, in := range [][]byte{
+		nil,
+		[]byte("abc"),
+		bytes.Repeat([]byte("node_cpu_seconds_total"), 1000),
+		random,
+		append(bytes.Repeat([]byte{0}, 70000), random[:300]...),
+	} {
+		enc := snappyEncode(in)
+		out, err := snappyDecode(enc)
+		if err != nil {
+			t.Fatalf("decoding %d bytes: %s", len(in), err)
+		}
+		if !bytes.Equal(in, out) {
+			t.Errorf("round trip of %d bytes failed", len(in))
+		}
+	}
+	in := bytes.Repeat([]byte("node_cpu_seconds_total"), 1000)
+	if enc := snappyEncode(in); len(enc) > len(in)/10 {
+		t.Errorf("want repetitive input of %d bytes to compress, got %d bytes", len(in), len(enc))
+	}
+}
+
+func TestEncodeWriteRequest(t *testing.T) {
+	req := &prompbWriteRequest{Timeseries: []*prompbTimeSeries{{
+		Labels:  []*prompbLabel{{Name: "__name__", Value: "node_load1"}},
+		Samples: []*prompbSample{{Value: 0.5, Timestamp: 1500000000000}},
+	}}}
+	body, err := encodeWriteRequest(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := snappyDecode(body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := &prompbWriteRequest{}
+	if err := proto.Unmarshal(data, got); err != nil {
+		t.Fatal(err)
+	}
+	if !proto.Equal(req, got) {
+		t.Errorf("want %v, got %v", req, got)
+	}
+}
+
+func TestSendRemoteWrite(t *testing.T) {
+	for _, tt := range []struct {
+		status      int
+		err         bool
+		recoverable bool
+	}{
+		{http.StatusNoContent, false, false},
+		{http.StatusBadRequest, true, false},
+		{http.StatusTooManyRequests, true, true},
+		{http.StatusServiceUnavailable, true, true},
+	} {
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if want, got := "snappy", r.Header.Get("Content-Encoding"); want != got {
+				t.Errorf("want content encoding %q, got %q", want, got)
+			}
+			w.WriteHeader(tt.status)
+		}))
+		err := sendRemoteWrite(http.DefaultClient, srv.URL, []byte{0})
+		srv.Close()
+		if (err != nil) != tt.err {
+			t.Errorf("status %d: want error %v, got %v", tt.status, tt.err, err)
+			continue
+		}
+		if rwErr, ok := err.(remoteWriteError); ok && rwErr.recoverable != tt.recoverable {
+			t.Errorf("status %d: want recoverable %v", tt.status, tt.recoverable)
+		}
+	}
+}
+
+func TestRetryBackoff(t *testing.T) {
+	for failures, want := range []time.Duration{time.Second, time.Second, 2 * time.Second, 4 * time.Second} {
+		if got := retryBackoff(failures, time.Second, time.Minute); want != got {
+			t.Errorf("%d failures: want %s, got %s", failures, want, got)
+		}
+	}
+	if want, got := time.Minute, retryBackoff(20, time.Second, time.Minute); want != got {
+		t.Errorf("want backoff capped at %s, got %s", want, got)
+	}
+}
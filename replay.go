@@ -0,0 +1,247 @@
+// Copyright 2016 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/prometheus/common/log"
+	"github.com/prometheus/node_exporter/collector"
+)
+
+// replayer serves recorded snapshots of procfs and sysfs to the collectors
+// instead of the live system, advancing to the next snapshot on every
+// scrape. A snapshot is either a directory or a gzipped tarball holding proc
+// and sys directories.
+type replayer struct {
+	// mtx serializes scrapes, so that no collector reads a snapshot while
+	// the next one replaces it.
+	mtx       sync.Mutex
+	snapshots []string
+	next      int
+	loop      bool
+	// tmpDir holds the extracted current snapshot if it is a tarball.
+	tmpDir string
+}
+
+// newReplayer returns a replayer of the snapshots in dir, in the order of
+// their names. With loop, the first snapshot follows the last one, else the
+// last one is served from then on.
+func newReplayer(dir string, loop bool) (*replayer, error) {
+	entries, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	r := &replayer{loop: loop}
+	for _, e := range entries {
+		if e.IsDir() || isSnapshotArchive(e.Name()) {
+			r.snapshots = append(r.snapshots, filepath.Join(dir, e.Name()))
+		}
+	}
+	if len(r.snapshots) == 0 {
+		return nil, fmt.Errorf("no snapshots in %s", dir)
+	}
+	sort.Strings(r.snapshots)
+	return r, nil
+}
+
+func isSnapshotArchive(name string) bool {
+	return strings.HasSuffix(name, ".tar.gz") || strings.HasSuffix(name, ".tgz")
+}
+
+// begin switches the collectors to the next snapshot and holds it until end
+// is called.
+func (r *replayer) begin() {
+	r.mtx.Lock()
+	if err := r.advance(); err != nil {
+		log.Errorf("Couldn't replay snapshot: %s", err)
+	}
+}
+
+func (r *replayer) end() {
+	r.mtx.Unlock()
+}
+
+func (r *replayer) advance() error {
+	if r.next == len(r.snapshots) {
+		if !r.loop {
+			return nil
+		}
+		r.next = 0
+	}
+	snapshot := r.snapshots[r.next]
+	r.next++
+	log.Debugf("Replaying snapshot %s", snapshot)
+
+	root := snapshot
+	tmpDir := ""
+	if isSnapshotArchive(snapshot) {
+		var err error
+		if tmpDir, err = ioutil.TempDir("", "node_exporter_replay"); err != nil {
+			return err
+		}
+		if err := extractSnapshot(snapshot, tmpDir); err != nil {
+			os.RemoveAll(tmpDir)
+			return fmt.Errorf("%s: %s", snapshot, err)
+		}
+		root = tmpDir
+	}
+	collector.SetReplayRoot(root)
+	if r.tmpDir != "" {
+		os.RemoveAll(r.tmpDir)
+	}
+	r.tmpDir = tmpDir
+	return nil
+}
+
+// extractSnapshot unpacks the directories, regular files and symbolic links
+// within the snapshot of a gzipped tarball into dir.
+func extractSnapshot(archive, dir string) error {
+	root, err := filepath.EvalSymlinks(dir)
+	if err != nil {
+		return err
+	}
+	f, err := os.Open(archive)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	gz, err := gzip.NewReader(f)
+	if err != nil {
+		return err
+	}
+	defer gz.Close()
+
+	tr := tar.NewReader(gz)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		name := path.Clean("/" + hdr.Name)
+		if name == "/" {
+			continue
+		}
+		// Links extracted earlier may lead the entry's parent anywhere, so
+		// it is checked after resolving them rather than by its name.
+		parent, err := resolveLinks(root, path.Dir(name))
+		if err != nil {
+			return err
+		}
+		if !withinDir(root, parent) {
+			return fmt.Errorf("%s leaves the snapshot", hdr.Name)
+		}
+		target := filepath.Join(parent, path.Base(name))
+		switch hdr.Typeflag {
+		case tar.TypeDir:
+			err = os.MkdirAll(target, 0755)
+		case tar.TypeReg, tar.TypeRegA:
+			err = extractFile(tr, target)
+		case tar.TypeSymlink:
+			// Links leaving the snapshot would point into the live system.
+			// Cleaning the link leaves ".." only in front, so links
+			// extracted later can't take it elsewhere.
+			link := path.Clean(hdr.Linkname)
+			if path.IsAbs(link) {
+				continue
+			}
+			var dest string
+			if dest, err = resolveLinks(parent, link); err != nil {
+				return err
+			}
+			if !withinDir(root, dest) {
+				continue
+			}
+			if err = os.MkdirAll(parent, 0755); err == nil {
+				err = os.Symlink(filepath.FromSlash(link), target)
+			}
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// resolveLinks returns the path that the slash separated name refers to
+// relative to the directory dir, following symbolic links the way the kernel
+// does, even if they dangle.
+func resolveLinks(dir, name string) (string, error) {
+	p := dir
+	parts := strings.Split(name, "/")
+	for links := 0; len(parts) > 0; {
+		part := parts[0]
+		parts = parts[1:]
+		switch part {
+		case "", ".":
+			continue
+		case "..":
+			p = filepath.Dir(p)
+			continue
+		}
+		next := filepath.Join(p, part)
+		link, err := os.Readlink(next)
+		if err != nil {
+			// Not a link, or it doesn't exist yet.
+			p = next
+			continue
+		}
+		if filepath.IsAbs(link) {
+			return "", fmt.Errorf("absolute link %s", next)
+		}
+		if links++; links > 255 {
+			return "", fmt.Errorf("too many links in %s", name)
+		}
+		parts = append(strings.Split(filepath.ToSlash(link), "/"), parts...)
+	}
+	return p, nil
+}
+
+func withinDir(dir, p string) bool {
+	return p == dir || strings.HasPrefix(p, dir+string(filepath.Separator))
+}
+
+func extractFile(r io.Reader, target string) error {
+	if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+		return err
+	}
+	// A link extracted earlier under the same name must not be written
+	// through.
+	if fi, err := os.Lstat(target); err == nil && fi.Mode()&os.ModeSymlink != 0 {
+		if err := os.Remove(target); err != nil {
+			return err
+		}
+	}
+	f, err := os.Create(target)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(f, r); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
@@ -0,0 +1,170 @@
+// Copyright 2016 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/prometheus/node_exporter/collector"
+)
+
+func writeSnapshotArchive(t *testing.T, name string, files map[string]string, links map[string]string) {
+	f, err := os.Create(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	gz := gzip.NewWriter(f)
+	tw := tar.NewWriter(gz)
+	for name, target := range links {
+		if err := tw.WriteHeader(&tar.Header{Name: name, Typeflag: tar.TypeSymlink, Linkname: target, Mode: 0777}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for name, content := range files {
+		if err := tw.WriteHeader(&tar.Header{Name: name, Typeflag: tar.TypeReg, Size: int64(len(content)), Mode: 0644}); err != nil {
+			t.Fatal(err)
+		}
+		tw.Write([]byte(content))
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := gz.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestReplayer(t *testing.T) {
+	dir, err := ioutil.TempDir("", "node_exporter_replay_test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	writeSnapshotArchive(t, filepath.Join(dir, "01.tar.gz"),
+		map[string]string{"proc/loadavg": "1.00 0.50 0.25 1/100 1000\n"},
+		map[string]string{"sys/outside": "../../..", "sys/devices": "../proc"},
+	)
+	os.MkdirAll(filepath.Join(dir, "02", "proc"), 0755)
+	ioutil.WriteFile(filepath.Join(dir, "02", "proc", "loadavg"), []byte("4.00 2.00 1.00 1/100 1000\n"), 0644)
+	ioutil.WriteFile(filepath.Join(dir, "README"), []byte("not a snapshot"), 0644)
+
+	r, err := newReplayer(dir, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer collector.SetReplayRoot("")
+	if want, got := 2, len(r.snapshots); want != got {
+		t.Fatalf("want %d snapshots, got %v", want, got)
+	}
+
+	loadavg, err := collector.Factories["loadavg"]()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []float64{1, 4, 1} {
+		r.begin()
+		if r.tmpDir != "" {
+			if fi, err := os.Lstat(filepath.Join(r.tmpDir, "sys", "outside")); err == nil {
+				t.Errorf("want link leaving the snapshot to be skipped, got %s", fi.Mode())
+			}
+			if _, err := os.Stat(filepath.Join(r.tmpDir, "sys", "devices", "loadavg")); err != nil {
+				t.Errorf("want link within the snapshot to be extracted: %s", err)
+			}
+		}
+		ch := make(chan prometheus.Metric, 10)
+		if err := loadavg.Update(ch); err != nil {
+			r.end()
+			t.Fatal(err)
+		}
+		r.end()
+		close(ch)
+
+		load1 := -1.0
+		for m := range ch {
+			if match := descNameRE.FindStringSubmatch(m.Desc().String()); match != nil && match[1] == "node_load1" {
+				pb := &dto.Metric{}
+				m.Write(pb)
+				load1 = pb.GetGauge().GetValue()
+			}
+		}
+		if want != load1 {
+			t.Errorf("want node_load1 %f, got %f", want, load1)
+		}
+	}
+	os.RemoveAll(r.tmpDir)
+
+	if _, err := newReplayer(filepath.Join(dir, "02", "proc"), false); err == nil {
+		t.Error("want error for directory without snapshots")
+	}
+}
+
+func TestExtractSnapshotLinkChain(t *testing.T) {
+	dir, err := ioutil.TempDir("", "node_exporter_replay_test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	target := filepath.Join(dir, "snapshot", "target")
+	if err := os.MkdirAll(target, 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	archive := filepath.Join(dir, "chain.tar.gz")
+	f, err := os.Create(archive)
+	if err != nil {
+		t.Fatal(err)
+	}
+	gz := gzip.NewWriter(f)
+	tw := tar.NewWriter(gz)
+	for _, hdr := range []*tar.Header{
+		{Name: "a/x/", Typeflag: tar.TypeDir, Mode: 0755},
+		{Name: "a/x/b", Typeflag: tar.TypeSymlink, Linkname: "..", Mode: 0777},
+		{Name: "a/x/b/c", Typeflag: tar.TypeSymlink, Linkname: "../..", Mode: 0777},
+		{Name: "a/x/b/c/escaped", Typeflag: tar.TypeReg, Mode: 0644},
+	} {
+		if err := tw.WriteHeader(hdr); err != nil {
+			t.Fatal(err)
+		}
+	}
+	tw.Close()
+	gz.Close()
+	f.Close()
+
+	if err := extractSnapshot(archive, target); err != nil {
+		t.Fatal(err)
+	}
+	for _, p := range []string{
+		filepath.Join(dir, "snapshot", "escaped"),
+		filepath.Join(dir, "escaped"),
+	} {
+		if _, err := os.Lstat(p); err == nil {
+			t.Errorf("want nothing written outside the snapshot, got %s", p)
+		}
+	}
+	if fi, err := os.Lstat(filepath.Join(target, "a", "c")); err == nil && fi.Mode()&os.ModeSymlink != 0 {
+		t.Error("want link leaving the snapshot through another link to be skipped")
+	}
+	if _, err := os.Stat(filepath.Join(target, "a", "c", "escaped")); err != nil {
+		t.Errorf("want file extracted within the snapshot: %s", err)
+	}
+}
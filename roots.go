@@ -0,0 +1,129 @@
+// Copyright 2016 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/node_exporter/collector"
+)
+
+// sysfsRoot is a further sysfs tree, e.g. of a device under test mounted
+// over sshfs, exposed with its name as the value of the root label.
+type sysfsRoot struct {
+	name, path string
+}
+
+// parseSysfsRoots parses a comma-separated list of name=path roots.
+func parseSysfsRoots(s string) ([]sysfsRoot, error) {
+	if s == "" {
+		return nil, nil
+	}
+	var roots []sysfsRoot
+	seen := map[string]bool{}
+	for _, r := range strings.Split(s, ",") {
+		parts := strings.SplitN(r, "=", 2)
+		if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+			return nil, fmt.Errorf("invalid sysfs root %q, must be name=path", r)
+		}
+		if seen[parts[0]] {
+			return nil, fmt.Errorf("duplicate sysfs root %q", parts[0])
+		}
+		seen[parts[0]] = true
+		roots = append(roots, sysfsRoot{name: parts[0], path: parts[1]})
+	}
+	return roots, nil
+}
+
+// rootsCollector runs a collector for -collector.sysfs and for every further
+// sysfs root, adding the root's name as label. The metrics of -collector.sysfs
+// have an empty root label, so all series of a metric have the same labels.
+type rootsCollector struct {
+	label      string
+	local      collector.Collector
+	names      []string
+	collectors []collector.Collector
+}
+
+// newRootsCollector creates the collector of the given name for each root.
+func newRootsCollector(name string, local collector.Collector, roots []sysfsRoot, label string) (*rootsCollector, error) {
+	c := &rootsCollector{label: label, local: local}
+	for _, r := range roots {
+		rc, err := collector.SysfsFactories[name](r.path)
+		if err != nil {
+			return nil, fmt.Errorf("sysfs root %s: %s", r.name, err)
+		}
+		c.names = append(c.names, r.name)
+		c.collectors = append(c.collectors, rc)
+	}
+	return c, nil
+}
+
+// Update updates the collectors of all roots concurrently, so that a slow
+// network mount doesn't delay the others.
+func (c *rootsCollector) Update(ch chan<- prometheus.Metric) error {
+	errs := make([]error, len(c.collectors))
+	var wg sync.WaitGroup
+	for i, rc := range c.collectors {
+		wg.Add(1)
+		i, rc := i, rc
+		collector.Go(func() {
+			defer wg.Done()
+			if err := updateLabeled(rc, ch, c.label, c.names[i]); err != nil {
+				errs[i] = fmt.Errorf("sysfs root %s: %s", c.names[i], err)
+			}
+		})
+	}
+	err := updateLabeled(c.local, ch, c.label, "")
+	wg.Wait()
+
+	var msgs []string
+	if err != nil {
+		msgs = append(msgs, err.Error())
+	}
+	for _, e := range errs {
+		if e != nil {
+			msgs = append(msgs, e.Error())
+		}
+	}
+	switch {
+	case len(msgs) == 0:
+		return nil
+	case len(msgs) == 1 && err != nil:
+		// Keep the local error as is, e.g. for permission denied
+		// accounting.
+		return err
+	default:
+		return fmt.Errorf("%s", strings.Join(msgs, "; "))
+	}
+}
+
+// updateLabeled updates c, adding the label to its metrics.
+func updateLabeled(c collector.Collector, ch chan<- prometheus.Metric, name, value string) error {
+	metrics := make(chan prometheus.Metric)
+	done := make(chan struct{})
+	collector.Go(func() {
+		for m := range metrics {
+			ch <- relabel(m, setLabel(name, value))
+		}
+		close(done)
+	})
+	err := c.Update(metrics)
+	close(metrics)
+	<-done
+	return err
+}
@@ -0,0 +1,77 @@
+// Copyright 2016 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/prometheus/node_exporter/collector"
+)
+
+func TestParseSysfsRoots(t *testing.T) {
+	roots, err := parseSysfsRoots("dut1=/mnt/dut1/sys,dut2=/mnt/dut2/sys")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []sysfsRoot{{"dut1", "/mnt/dut1/sys"}, {"dut2", "/mnt/dut2/sys"}}; !reflect.DeepEqual(want, roots) {
+		t.Errorf("want %v, got %v", want, roots)
+	}
+	for _, s := range []string{"dut1", "=/mnt/sys", "dut1=", "dut1=/a,dut1=/b"} {
+		if _, err := parseSysfsRoots(s); err == nil {
+			t.Errorf("%q: want error", s)
+		}
+	}
+}
+
+func TestRootsCollector(t *testing.T) {
+	c := &rootsCollector{
+		label:      "sysfs_root",
+		local:      checkTestCollector{series: 1},
+		names:      []string{"dut1", "dut2"},
+		collectors: []collector.Collector{checkTestCollector{series: 1}, checkTestCollector{series: 1, err: errors.New("mount gone")}},
+	}
+	ch := make(chan prometheus.Metric)
+	errc := make(chan error, 1)
+	go func() {
+		errc <- c.Update(ch)
+		close(ch)
+	}()
+	var got []string
+	for m := range ch {
+		pb := &dto.Metric{}
+		if err := m.Write(pb); err != nil {
+			t.Fatal(err)
+		}
+		var labels []string
+		for _, lp := range pb.Label {
+			labels = append(labels, lp.GetName()+"="+lp.GetValue())
+		}
+		got = append(got, strings.Join(labels, ","))
+	}
+	sort.Strings(got)
+	want := []string{"n=0,sysfs_root=", "n=0,sysfs_root=dut1", "n=0,sysfs_root=dut2"}
+	if !reflect.DeepEqual(want, got) {
+		t.Errorf("want %v, got %v", want, got)
+	}
+	if want, got := "sysfs root dut2: mount gone", fmt.Sprint(<-errc); want != got {
+		t.Errorf("want error %q, got %q", want, got)
+	}
+}
@@ -0,0 +1,98 @@
+// Copyright 2016 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"unicode"
+	"unicode/utf8"
+)
+
+// sanitizeLabels returns a transform cleaning the label values of a
+// collector's metric. Strings read from the kernel, like model names and
+// serial numbers in sysfs, often carry padding, control characters or
+// invalid UTF-8 that break the exposition. Values longer than maxLength bytes
+// are truncated, 0 disables truncation.
+func sanitizeLabels(maxLength int) labelTransform {
+	return mapLabelValues(func(_, value string) string {
+		return truncateLabelValue(sanitizeLabelValue(value), maxLength)
+	})
+}
+
+// sanitizeLabelValue replaces invalid UTF-8 with U+FFFD, collapses runs of
+// whitespace and control characters into a single space and trims the
+// result.
+func sanitizeLabelValue(s string) string {
+	if isCleanLabelValue(s) {
+		return s
+	}
+	var buf bytes.Buffer
+	space := false
+	for i := 0; i < len(s); {
+		r, size := utf8.DecodeRuneInString(s[i:])
+		i += size
+		if unicode.IsSpace(r) || unicode.IsControl(r) {
+			space = true
+			continue
+		}
+		if space && buf.Len() > 0 {
+			buf.WriteByte(' ')
+		}
+		space = false
+		// Invalid bytes decode to utf8.RuneError, which is written as
+		// U+FFFD.
+		buf.WriteRune(r)
+	}
+	return buf.String()
+}
+
+// minLabelLength is the smallest maximum label value length, which leaves
+// room for the hash suffix of truncated values.
+const minLabelLength = 16
+
+// truncateLabelValue shortens s to at most max bytes if it is longer. The
+// end is replaced by a hash of the whole value, so that series which only
+// differ after the cut stay distinct. A max of 0 disables truncation.
+func truncateLabelValue(s string, max int) string {
+	if max <= 0 || len(s) <= max {
+		return s
+	}
+	sum := sha256.Sum256([]byte(s))
+	suffix := "~" + hex.EncodeToString(sum[:4])
+	cut := max - len(suffix)
+	for cut > 0 && !utf8.RuneStart(s[cut]) {
+		cut--
+	}
+	return s[:cut] + suffix
+}
+
+// isCleanLabelValue reports whether s is left unchanged by
+// sanitizeLabelValue, which is the common case.
+func isCleanLabelValue(s string) bool {
+	prevSpace := true
+	for i := 0; i < len(s); {
+		r, size := utf8.DecodeRuneInString(s[i:])
+		i += size
+		if r == utf8.RuneError && size == 1 {
+			return false
+		}
+		if unicode.IsControl(r) || (unicode.IsSpace(r) && (r != ' ' || prevSpace)) {
+			return false
+		}
+		prevSpace = r == ' '
+	}
+	return !prevSpace || len(s) == 0
+}
@@ -0,0 +1,90 @@
+// Copyright 2016 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+)
+
+func TestSanitizeLabelValue(t *testing.T) {
+	for _, tt := range []struct {
+		in, want string
+	}{
+		{"", ""},
+		{"BAT0", "BAT0"},
+		{"Intel(R) Core(TM) i7", "Intel(R) Core(TM) i7"},
+		{"SAMSUNG MZ7LN256   ", "SAMSUNG MZ7LN256"},
+		{"  leading", "leading"},
+		{"tab\tseparated\n", "tab separated"},
+		{"multiple   inner  spaces", "multiple inner spaces"},
+		{"nul\x00padded\x00\x00", "nul padded"},
+		{"bad\xffbyte", "bad�byte"},
+		{"   ", ""},
+		{"Grüße", "Grüße"},
+	} {
+		if got := sanitizeLabelValue(tt.in); tt.want != got {
+			t.Errorf("sanitizeLabelValue(%q): want %q, got %q", tt.in, tt.want, got)
+		}
+	}
+}
+
+func TestSanitizeLabels(t *testing.T) {
+	desc := prometheus.NewDesc("test_info", "Test.", []string{"model"}, nil)
+	orig := prometheus.MustNewConstMetric(desc, prometheus.GaugeValue, 1, "DELL  \x00")
+	m := relabel(orig, sanitizeLabels(0))
+
+	pb := &dto.Metric{}
+	if err := m.Write(pb); err != nil {
+		t.Fatal(err)
+	}
+	if want, got := "DELL", pb.Label[0].GetValue(); want != got {
+		t.Errorf("want label value %q, got %q", want, got)
+	}
+
+	pb = &dto.Metric{}
+	if err := orig.Write(pb); err != nil {
+		t.Fatal(err)
+	}
+	if want, got := "DELL  \x00", pb.Label[0].GetValue(); want != got {
+		t.Errorf("want original label value %q unchanged, got %q", want, got)
+	}
+}
+
+func TestTruncateLabelValue(t *testing.T) {
+	long := strings.Repeat("garbage ", 512)
+	for _, tt := range []struct {
+		in   string
+		max  int
+		want string
+	}{
+		{"DELL GPM0365", 0, "DELL GPM0365"},
+		{"DELL GPM0365", 16, "DELL GPM0365"},
+		{long, 0, long},
+		{long, 32, "garbage garbage garbage~3dbd6623"},
+		{long[:len(long)-1] + "!", 32, "garbage garbage garbage~6d181f2b"},
+		{"Grüße Grüße Grüße", 16, "Grüße~92c856c3"},
+	} {
+		got := truncateLabelValue(tt.in, tt.max)
+		if tt.want != got {
+			t.Errorf("truncateLabelValue(%.20q, %d): want %q, got %q", tt.in, tt.max, tt.want, got)
+		}
+		if tt.max > 0 && len(got) > tt.max {
+			t.Errorf("truncateLabelValue(%.20q, %d): %d bytes exceed limit", tt.in, tt.max, len(got))
+		}
+	}
+}
@@ -0,0 +1,172 @@
+// Copyright 2016 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/node_exporter/collector"
+)
+
+// Links are followed at most this often per path, like the kernel does.
+const snapshotMaxLinks = 40
+
+// captureSnapshot runs one collection of n and writes the procfs and sysfs
+// files read by the collectors into a gzipped tarball at out, in the layout
+// read by -replay.dir. The contents of files, and the values of uevent keys,
+// named in redactor are replaced by their hash. It returns the number of
+// entries written.
+func captureSnapshot(out string, n NodeCollector, redactor *labelRedactor) (int, error) {
+	collector.StartRecording()
+	ch := make(chan prometheus.Metric)
+	collector.Go(func() {
+		n.Collect(ch)
+		close(ch)
+	})
+	for range ch {
+	}
+	paths := collector.StopRecording()
+
+	f, err := os.Create(out)
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+	gz := gzip.NewWriter(f)
+	w := &snapshotWriter{
+		tw:       tar.NewWriter(gz),
+		redactor: redactor,
+		seen:     map[string]bool{},
+		modTime:  time.Now(),
+	}
+	for _, p := range paths {
+		if err := w.add(p.Tree, p.Root, p.Name, 0); err != nil {
+			return 0, err
+		}
+	}
+	if err := w.tw.Close(); err != nil {
+		return 0, err
+	}
+	if err := gz.Close(); err != nil {
+		return 0, err
+	}
+	return len(w.seen), f.Close()
+}
+
+// snapshotWriter adds files to a snapshot tarball, each once.
+type snapshotWriter struct {
+	tw       *tar.Writer
+	redactor *labelRedactor
+	seen     map[string]bool
+	modTime  time.Time
+}
+
+// add writes the named path below root, which is mounted as tree, to the
+// snapshot. Symbolic links on the way are added as links and followed
+// within the tree, so that the snapshot has the same layout. Paths that
+// vanished or can't be read are left out.
+func (w *snapshotWriter) add(tree, root, name string, links int) error {
+	if links > snapshotMaxLinks {
+		return nil
+	}
+	parts := strings.Split(name, "/")
+	for i := range parts {
+		cur := strings.Join(parts[:i+1], "/")
+		full := filepath.Join(root, filepath.FromSlash(cur))
+		fi, err := os.Lstat(full)
+		if err != nil {
+			return nil
+		}
+		if fi.Mode()&os.ModeSymlink != 0 {
+			target, err := os.Readlink(full)
+			if err != nil {
+				return nil
+			}
+			if err := w.write(&tar.Header{Name: tree + "/" + cur, Typeflag: tar.TypeSymlink, Linkname: target, Mode: 0777}, nil); err != nil {
+				return err
+			}
+			resolved := path.Join(path.Dir(cur), target)
+			if path.IsAbs(target) || resolved == ".." || strings.HasPrefix(resolved, "../") {
+				return nil
+			}
+			return w.add(tree, root, path.Join(append([]string{resolved}, parts[i+1:]...)...), links+1)
+		}
+		if i < len(parts)-1 {
+			continue
+		}
+		switch {
+		case fi.IsDir():
+			return w.write(&tar.Header{Name: tree + "/" + cur + "/", Typeflag: tar.TypeDir, Mode: 0755}, nil)
+		case fi.Mode().IsRegular():
+			data, err := ioutil.ReadFile(full)
+			if err != nil {
+				return nil
+			}
+			data = w.redact(path.Base(cur), data)
+			return w.write(&tar.Header{Name: tree + "/" + cur, Typeflag: tar.TypeReg, Mode: 0644, Size: int64(len(data))}, data)
+		}
+	}
+	return nil
+}
+
+func (w *snapshotWriter) write(hdr *tar.Header, data []byte) error {
+	if w.seen[hdr.Name] {
+		return nil
+	}
+	w.seen[hdr.Name] = true
+	hdr.ModTime = w.modTime
+	if err := w.tw.WriteHeader(hdr); err != nil {
+		return err
+	}
+	_, err := w.tw.Write(data)
+	return err
+}
+
+// redact returns the contents of the named file with sensitive values
+// hashed. Keys of uevent files match a name if they end in it, e.g.
+// POWER_SUPPLY_SERIAL_NUMBER matches serial_number.
+func (w *snapshotWriter) redact(name string, data []byte) []byte {
+	if w.redactor == nil {
+		return data
+	}
+	if w.redactor.labels[name] {
+		return []byte(w.redactor.redact(name, strings.TrimSpace(string(data))) + "\n")
+	}
+	if name != "uevent" {
+		return data
+	}
+	lines := strings.Split(string(data), "\n")
+	for i, line := range lines {
+		kv := strings.SplitN(line, "=", 2)
+		if len(kv) != 2 {
+			continue
+		}
+		key := strings.ToLower(kv[0])
+		for label := range w.redactor.labels {
+			if key == label || strings.HasSuffix(key, "_"+label) {
+				lines[i] = kv[0] + "=" + w.redactor.redact(label, kv[1])
+				break
+			}
+		}
+	}
+	return []byte(strings.Join(lines, "\n"))
+}
@@ -0,0 +1,144 @@
+// Copyright 2016 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"flag"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/prometheus/node_exporter/collector"
+)
+
+func collectStrings(t *testing.T, n NodeCollector) []string {
+	ch := make(chan prometheus.Metric)
+	go func() {
+		n.Collect(ch)
+		close(ch)
+	}()
+	var got []string
+	for m := range ch {
+		name := m.Desc().String()
+		if match := descNameRE.FindStringSubmatch(name); match != nil {
+			name = match[1]
+		}
+		if strings.HasPrefix(name, "node_exporter_") || strings.HasPrefix(name, "node_scrape_") {
+			continue
+		}
+		pb := &dto.Metric{}
+		m.Write(pb)
+		got = append(got, name+" "+pb.String())
+	}
+	sort.Strings(got)
+	return got
+}
+
+func TestCaptureSnapshot(t *testing.T) {
+	initExporterMetrics()
+	dir, err := ioutil.TempDir("", "node_exporter_snapshot_test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	flag.Set("collector.procfs", "collector/fixtures/proc")
+	flag.Set("collector.sysfs", "collector/fixtures/sys")
+	defer flag.Set("collector.procfs", "/proc")
+	defer flag.Set("collector.sysfs", "/sys")
+
+	n := NodeCollector{collectors: map[string]collector.Collector{}}
+	for _, name := range []string{"loadavg", "hwmon", "power_supply"} {
+		c, err := collector.Factories[name]()
+		if err != nil {
+			t.Fatal(err)
+		}
+		n.collectors[name] = c
+	}
+	want := collectStrings(t, n)
+
+	redactor, err := newLabelRedactor(redactHash, "serial_number")
+	if err != nil {
+		t.Fatal(err)
+	}
+	out := filepath.Join(dir, "redacted.tar.gz")
+	if _, err := captureSnapshot(out, n, redactor); err != nil {
+		t.Fatal(err)
+	}
+
+	f, err := os.Open(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	gz, err := gzip.NewReader(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	entries := map[string]*tar.Header{}
+	tr := tar.NewReader(gz)
+	for {
+		hdr, err := tr.Next()
+		if err != nil {
+			break
+		}
+		entries[hdr.Name] = hdr
+		if hdr.Name == "sys/class/power_supply/BAT0/uevent" {
+			data, _ := ioutil.ReadAll(tr)
+			if !strings.Contains(string(data), "POWER_SUPPLY_SERIAL_NUMBER="+redactor.redact("serial_number", "1234")) {
+				t.Errorf("want serial number to be redacted, got %q", data)
+			}
+		}
+	}
+	if hdr, ok := entries["sys/class/hwmon/hwmon0"]; !ok || hdr.Typeflag != tar.TypeSymlink {
+		t.Errorf("want sys/class/hwmon/hwmon0 as link, got %v", hdr)
+	}
+	for _, name := range []string{"proc/loadavg", "sys/devices/platform/coretemp.0/hwmon/hwmon0/temp1_input"} {
+		if _, ok := entries[name]; !ok {
+			t.Errorf("want %s in snapshot", name)
+		}
+	}
+	if _, ok := entries["proc/meminfo"]; ok {
+		t.Error("want files of disabled collectors to be left out")
+	}
+
+	// Redacted values would change the info labels, so replay an
+	// unredacted snapshot.
+	if _, err := captureSnapshot(filepath.Join(dir, "snapshot.tar.gz"), n, nil); err != nil {
+		t.Fatal(err)
+	}
+	os.Remove(out)
+	f.Close()
+
+	flag.Set("collector.procfs", "/nonexistent/proc")
+	flag.Set("collector.sysfs", "/nonexistent/sys")
+	r, err := newReplayer(dir, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer collector.SetReplayRoot("")
+	n.replay = r
+	if got := collectStrings(t, n); !reflect.DeepEqual(want, got) {
+		t.Errorf("want replayed snapshot to match fixtures:\nwant %v\ngot  %v", want, got)
+	}
+	os.RemoveAll(r.tmpDir)
+}
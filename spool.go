@@ -0,0 +1,137 @@
+// Copyright 2016 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/common/log"
+	"github.com/prometheus/node_exporter/collector"
+)
+
+const spoolSuffix = ".spool"
+
+// spool persists encoded remote write requests while the receiver is
+// unreachable. Every request is stored in its own file named after the
+// scrape's timestamp in milliseconds, so they sort oldest first.
+type spool struct {
+	dir string
+	// maxSize and maxAge bound the spool, the oldest requests are
+	// dropped first. Zero disables a limit.
+	maxSize int64
+	maxAge  time.Duration
+	dropped *prometheus.CounterVec
+}
+
+func newSpool(dir string, maxSize int64, maxAge time.Duration) (*spool, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+	return &spool{
+		dir:     dir,
+		maxSize: maxSize,
+		maxAge:  maxAge,
+		dropped: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Namespace: collector.Namespace,
+				Subsystem: "exporter",
+				Name:      "agent_dropped_scrapes_total",
+				Help:      "node_exporter: Number of scrapes the agent dropped instead of pushing, by reason.",
+			},
+			[]string{"reason"},
+		),
+	}, nil
+}
+
+// files returns the names of the spooled requests, oldest first.
+func (s *spool) files() ([]string, error) {
+	names, err := filepath.Glob(filepath.Join(s.dir, "*"+spoolSuffix))
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// empty reports whether no requests are spooled.
+func (s *spool) empty() bool {
+	names, err := s.files()
+	return err == nil && len(names) == 0
+}
+
+// write spools the request of the scrape at ts and enforces the limits.
+func (s *spool) write(ts int64, body []byte, now time.Time) error {
+	name := filepath.Join(s.dir, fmt.Sprintf("%020d%s", ts, spoolSuffix))
+	if err := ioutil.WriteFile(name+".tmp", body, 0644); err != nil {
+		return err
+	}
+	if err := os.Rename(name+".tmp", name); err != nil {
+		return err
+	}
+	return s.prune(now)
+}
+
+// prune removes requests older than maxAge, then the oldest ones until the
+// spool fits into maxSize.
+func (s *spool) prune(now time.Time) error {
+	names, err := s.files()
+	if err != nil {
+		return err
+	}
+	sizes := make([]int64, len(names))
+	var total int64
+	for i, name := range names {
+		fi, err := os.Stat(name)
+		if err != nil {
+			return err
+		}
+		sizes[i] = fi.Size()
+		total += fi.Size()
+	}
+	for i, name := range names {
+		reason := ""
+		switch {
+		case s.maxAge > 0 && now.Sub(spoolTime(name)) > s.maxAge:
+			reason = "age"
+		case s.maxSize > 0 && total > s.maxSize:
+			reason = "size"
+		default:
+			continue
+		}
+		if err := os.Remove(name); err != nil {
+			return err
+		}
+		log.Warnf("Dropped spooled scrape %s exceeding the spool's max %s", filepath.Base(name), reason)
+		s.dropped.WithLabelValues(reason).Inc()
+		total -= sizes[i]
+	}
+	return nil
+}
+
+// spoolTime returns the scrape time encoded in a spool file name.
+func spoolTime(name string) time.Time {
+	ms, err := strconv.ParseInt(strings.TrimSuffix(filepath.Base(name), spoolSuffix), 10, 64)
+	if err != nil {
+		return time.Time{}
+	}
+	return time.Unix(0, ms*int64(time.Millisecond))
+}
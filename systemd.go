@@ -0,0 +1,129 @@
+// Copyright 2016 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"net"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/prometheus/common/log"
+)
+
+// systemdNotifier sends service state notifications to systemd, see
+// sd_notify(3).
+type systemdNotifier struct {
+	addr *net.UnixAddr
+}
+
+// newSystemdNotifier returns a notifier for the socket systemd passes in
+// NOTIFY_SOCKET to services of Type=notify, or nil if there is none.
+// Abstract socket names starting with @ are handled by the net package.
+func newSystemdNotifier() *systemdNotifier {
+	name := os.Getenv("NOTIFY_SOCKET")
+	if name == "" {
+		return nil
+	}
+	return &systemdNotifier{addr: &net.UnixAddr{Name: name, Net: "unixgram"}}
+}
+
+// notify sends state, e.g. READY=1, to systemd.
+func (n *systemdNotifier) notify(state string) error {
+	if n == nil {
+		return nil
+	}
+	conn, err := net.DialUnix("unixgram", nil, n.addr)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	_, err = conn.Write([]byte(state))
+	return err
+}
+
+// watchdog pings the systemd watchdog at half its timeout as long as no
+// scrape has been running for longer than the timeout, so that systemd
+// restarts an exporter that hangs in a collector.
+func (n *systemdNotifier) watchdog(timeout time.Duration, monitor *scrapeMonitor) {
+	for range time.Tick(timeout / 2) {
+		if since := monitor.longest(time.Now()); since > timeout {
+			log.Warnf("Scrape running for %s, not pinging the systemd watchdog", since)
+			continue
+		}
+		if err := n.notify("WATCHDOG=1"); err != nil {
+			log.Errorf("Couldn't ping systemd watchdog: %s", err)
+		}
+	}
+}
+
+// systemdWatchdogTimeout returns the timeout of the systemd watchdog given
+// by WatchdogSec= in the unit, or 0 if it isn't enabled for this process.
+func systemdWatchdogTimeout() time.Duration {
+	usec, err := strconv.ParseInt(os.Getenv("WATCHDOG_USEC"), 10, 64)
+	if err != nil || usec <= 0 {
+		return 0
+	}
+	if pid := os.Getenv("WATCHDOG_PID"); pid != "" && pid != strconv.Itoa(os.Getpid()) {
+		return 0
+	}
+	return time.Duration(usec) * time.Microsecond
+}
+
+// scrapeMonitor keeps track of the running scrapes, to find hung ones.
+type scrapeMonitor struct {
+	mtx     sync.Mutex
+	running map[int]time.Time
+	next    int
+}
+
+func newScrapeMonitor() *scrapeMonitor {
+	return &scrapeMonitor{running: map[int]time.Time{}}
+}
+
+// begin records the start of a scrape and returns its id for end.
+func (m *scrapeMonitor) begin() int {
+	if m == nil {
+		return 0
+	}
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
+	m.next++
+	m.running[m.next] = time.Now()
+	return m.next
+}
+
+func (m *scrapeMonitor) end(id int) {
+	if m == nil {
+		return
+	}
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
+	delete(m.running, id)
+}
+
+// longest returns for how long the oldest running scrape has been running
+// at now, 0 if there is none.
+func (m *scrapeMonitor) longest(now time.Time) time.Duration {
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
+	var longest time.Duration
+	for _, begin := range m.running {
+		if d := now.Sub(begin); d > longest {
+			longest = d
+		}
+	}
+	return longest
+}
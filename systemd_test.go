@@ -0,0 +1,104 @@
+// Copyright 2016 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestSystemdNotifier(t *testing.T) {
+	defer os.Unsetenv("NOTIFY_SOCKET")
+	os.Unsetenv("NOTIFY_SOCKET")
+	if n := newSystemdNotifier(); n != nil {
+		t.Errorf("want no notifier without NOTIFY_SOCKET, got %v", n)
+	}
+	if err := newSystemdNotifier().notify("READY=1"); err != nil {
+		t.Errorf("want notifying without systemd to be a no-op, got %s", err)
+	}
+
+	dir, err := ioutil.TempDir("", "node_exporter_systemd_test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	name := filepath.Join(dir, "notify")
+	conn, err := net.ListenUnixgram("unixgram", &net.UnixAddr{Name: name, Net: "unixgram"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	os.Setenv("NOTIFY_SOCKET", name)
+	if err := newSystemdNotifier().notify("READY=1"); err != nil {
+		t.Fatal(err)
+	}
+	buf := make([]byte, 64)
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	n, err := conn.Read(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want, got := "READY=1", string(buf[:n]); want != got {
+		t.Errorf("want %q, got %q", want, got)
+	}
+}
+
+func TestSystemdWatchdogTimeout(t *testing.T) {
+	defer os.Unsetenv("WATCHDOG_USEC")
+	defer os.Unsetenv("WATCHDOG_PID")
+	for _, tt := range []struct {
+		usec, pid string
+		want      time.Duration
+	}{
+		{"", "", 0},
+		{"30000000", "", 30 * time.Second},
+		{"30000000", strconv.Itoa(os.Getpid()), 30 * time.Second},
+		{"30000000", "1", 0},
+		{"0", "", 0},
+		{"soon", "", 0},
+	} {
+		os.Setenv("WATCHDOG_USEC", tt.usec)
+		os.Setenv("WATCHDOG_PID", tt.pid)
+		if got := systemdWatchdogTimeout(); tt.want != got {
+			t.Errorf("WATCHDOG_USEC=%q WATCHDOG_PID=%q: want %s, got %s", tt.usec, tt.pid, tt.want, got)
+		}
+	}
+}
+
+func TestScrapeMonitor(t *testing.T) {
+	m := newScrapeMonitor()
+	if want, got := time.Duration(0), m.longest(time.Now()); want != got {
+		t.Errorf("want %s without scrapes, got %s", want, got)
+	}
+	first := m.begin()
+	second := m.begin()
+	now := time.Now().Add(time.Minute)
+	if got := m.longest(now); got < time.Minute {
+		t.Errorf("want at least a minute, got %s", got)
+	}
+	m.end(first)
+	m.end(second)
+	if want, got := time.Duration(0), m.longest(now); want != got {
+		t.Errorf("want %s after scrapes ended, got %s", want, got)
+	}
+
+	var disabled *scrapeMonitor
+	disabled.end(disabled.begin())
+}
@@ -0,0 +1,239 @@
+// Copyright 2016 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/prometheus/common/log"
+	"github.com/prometheus/common/version"
+	"github.com/prometheus/node_exporter/collector"
+)
+
+// OTLP span kinds and status codes, see
+// https://github.com/open-telemetry/opentelemetry-proto.
+const (
+	otlpSpanKindInternal = 1
+	otlpSpanKindServer   = 2
+	otlpStatusError      = 2
+)
+
+// tracer exports a trace per scrape, with a span per collector, to an
+// OpenTelemetry collector using OTLP over HTTP with JSON encoding.
+type tracer struct {
+	endpoint string
+	client   *http.Client
+	resource otlpResource
+}
+
+func newTracer(endpoint string, timeout time.Duration) *tracer {
+	hostname, _ := os.Hostname()
+	return &tracer{
+		endpoint: endpoint,
+		client:   &http.Client{Timeout: timeout},
+		resource: otlpResource{Attributes: []otlpAttribute{
+			stringAttribute("service.name", "node_exporter"),
+			stringAttribute("service.version", version.Version),
+			stringAttribute("host.name", hostname),
+		}},
+	}
+}
+
+// scrapeTrace collects the spans of a single scrape. A nil *scrapeTrace
+// ignores all calls, so callers don't need to check whether tracing is
+// enabled.
+type scrapeTrace struct {
+	tracer  *tracer
+	traceID string
+	root    *otlpSpan
+
+	mtx   sync.Mutex
+	spans []*otlpSpan
+}
+
+// startScrape begins the trace of a scrape. It returns nil if t is nil.
+func (t *tracer) startScrape() *scrapeTrace {
+	if t == nil {
+		return nil
+	}
+	traceID := randomID(16)
+	root := &otlpSpan{
+		TraceID:           traceID,
+		SpanID:            randomID(8),
+		Name:              "scrape",
+		Kind:              otlpSpanKindServer,
+		StartTimeUnixNano: unixNano(time.Now()),
+	}
+	return &scrapeTrace{tracer: t, traceID: traceID, root: root, spans: []*otlpSpan{root}}
+}
+
+// TraceID returns the hex encoded ID of the trace, or "" if s is nil.
+func (s *scrapeTrace) TraceID() string {
+	if s == nil {
+		return ""
+	}
+	return s.traceID
+}
+
+// startSpan begins a span below the scrape's root span.
+func (s *scrapeTrace) startSpan(name string) *otlpSpan {
+	if s == nil {
+		return nil
+	}
+	sp := &otlpSpan{
+		TraceID:           s.traceID,
+		SpanID:            randomID(8),
+		ParentSpanID:      s.root.SpanID,
+		Name:              name,
+		Kind:              otlpSpanKindInternal,
+		StartTimeUnixNano: unixNano(time.Now()),
+	}
+	s.mtx.Lock()
+	s.spans = append(s.spans, sp)
+	s.mtx.Unlock()
+	return sp
+}
+
+// endSpan ends sp, recording the collector's name, its number of series
+// and its error if any.
+func (s *scrapeTrace) endSpan(sp *otlpSpan, collectorName string, series int, err error) {
+	if s == nil {
+		return
+	}
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	sp.EndTimeUnixNano = unixNano(time.Now())
+	sp.Attributes = []otlpAttribute{
+		stringAttribute("collector", collectorName),
+		intAttribute("series", series),
+	}
+	if err != nil {
+		sp.Status = &otlpStatus{Code: otlpStatusError, Message: err.Error()}
+	}
+}
+
+// finish ends the root span and exports the trace in the background.
+func (s *scrapeTrace) finish() {
+	if s == nil {
+		return
+	}
+	s.mtx.Lock()
+	s.root.EndTimeUnixNano = unixNano(time.Now())
+	spans := s.spans
+	s.mtx.Unlock()
+	collector.Go(func() {
+		if err := s.tracer.export(spans); err != nil {
+			log.Debugf("Couldn't export scrape trace: %s", err)
+		}
+	})
+}
+
+func (t *tracer) export(spans []*otlpSpan) error {
+	req := otlpTraceRequest{ResourceSpans: []otlpResourceSpans{{
+		Resource: t.resource,
+		ScopeSpans: []otlpScopeSpans{{
+			Scope: otlpScope{Name: "github.com/prometheus/node_exporter"},
+			Spans: spans,
+		}},
+	}}}
+	body, err := json.Marshal(req)
+	if err != nil {
+		return err
+	}
+	resp, err := t.client.Post(t.endpoint, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		return fmt.Errorf("unexpected status %s", resp.Status)
+	}
+	return nil
+}
+
+func randomID(n int) string {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		log.Errorf("Couldn't generate trace ID: %s", err)
+	}
+	return hex.EncodeToString(b)
+}
+
+// unixNano returns the time in the string form OTLP/JSON uses for 64 bit
+// integers.
+func unixNano(t time.Time) string {
+	return strconv.FormatInt(t.UnixNano(), 10)
+}
+
+func stringAttribute(key, value string) otlpAttribute {
+	return otlpAttribute{Key: key, Value: otlpValue{StringValue: &value}}
+}
+
+func intAttribute(key string, value int) otlpAttribute {
+	v := strconv.Itoa(value)
+	return otlpAttribute{Key: key, Value: otlpValue{IntValue: &v}}
+}
+
+// The subset of the OTLP/JSON trace export request used here.
+type (
+	otlpTraceRequest struct {
+		ResourceSpans []otlpResourceSpans `json:"resourceSpans"`
+	}
+	otlpResourceSpans struct {
+		Resource   otlpResource     `json:"resource"`
+		ScopeSpans []otlpScopeSpans `json:"scopeSpans"`
+	}
+	otlpResource struct {
+		Attributes []otlpAttribute `json:"attributes"`
+	}
+	otlpScopeSpans struct {
+		Scope otlpScope   `json:"scope"`
+		Spans []*otlpSpan `json:"spans"`
+	}
+	otlpScope struct {
+		Name string `json:"name"`
+	}
+	otlpSpan struct {
+		TraceID           string          `json:"traceId"`
+		SpanID            string          `json:"spanId"`
+		ParentSpanID      string          `json:"parentSpanId,omitempty"`
+		Name              string          `json:"name"`
+		Kind              int             `json:"kind"`
+		StartTimeUnixNano string          `json:"startTimeUnixNano"`
+		EndTimeUnixNano   string          `json:"endTimeUnixNano"`
+		Attributes        []otlpAttribute `json:"attributes,omitempty"`
+		Status            *otlpStatus     `json:"status,omitempty"`
+	}
+	otlpAttribute struct {
+		Key   string    `json:"key"`
+		Value otlpValue `json:"value"`
+	}
+	otlpValue struct {
+		StringValue *string `json:"stringValue,omitempty"`
+		IntValue    *string `json:"intValue,omitempty"`
+	}
+	otlpStatus struct {
+		Code    int    `json:"code"`
+		Message string `json:"message,omitempty"`
+	}
+)
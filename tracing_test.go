@@ -0,0 +1,82 @@
+// Copyright 2016 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestScrapeTrace(t *testing.T) {
+	requests := make(chan otlpTraceRequest, 1)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req otlpTraceRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Error(err)
+		}
+		requests <- req
+	}))
+	defer srv.Close()
+
+	trace := newTracer(srv.URL, time.Second).startScrape()
+	trace.endSpan(trace.startSpan("collect cpu"), "cpu", 12, nil)
+	trace.endSpan(trace.startSpan("collect hwmon"), "hwmon", 0, errors.New("no sensors"))
+	trace.finish()
+
+	var req otlpTraceRequest
+	select {
+	case req = <-requests:
+	case <-time.After(5 * time.Second):
+		t.Fatal("no trace exported")
+	}
+	spans := req.ResourceSpans[0].ScopeSpans[0].Spans
+	if want, got := 3, len(spans); want != got {
+		t.Fatalf("want %d spans, got %d", want, got)
+	}
+	root := spans[0]
+	if len(root.TraceID) != 32 || len(root.SpanID) != 16 || root.ParentSpanID != "" {
+		t.Errorf("invalid root span %+v", root)
+	}
+	for _, sp := range spans[1:] {
+		if sp.TraceID != root.TraceID || sp.ParentSpanID != root.SpanID {
+			t.Errorf("span %q isn't a child of the root span", sp.Name)
+		}
+		if sp.EndTimeUnixNano == "" {
+			t.Errorf("span %q wasn't ended", sp.Name)
+		}
+	}
+	if spans[1].Status != nil {
+		t.Errorf("unexpected status of span %q: %+v", spans[1].Name, spans[1].Status)
+	}
+	if status := spans[2].Status; status == nil || status.Code != otlpStatusError || status.Message != "no sensors" {
+		t.Errorf("want error status of span %q, got %+v", spans[2].Name, status)
+	}
+	if want, got := root.TraceID, trace.TraceID(); want != got {
+		t.Errorf("want trace ID %s, got %s", want, got)
+	}
+}
+
+func TestNilScrapeTrace(t *testing.T) {
+	var tr *tracer
+	trace := tr.startScrape()
+	trace.endSpan(trace.startSpan("collect cpu"), "cpu", 1, nil)
+	trace.finish()
+	if trace.TraceID() != "" {
+		t.Error("want empty trace ID without tracer")
+	}
+}
@@ -0,0 +1,49 @@
+// Copyright 2016 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"strings"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/prometheus/node_exporter/collector"
+)
+
+// udevLabelName returns the label name of a udev property, e.g. id_serial
+// for ID_SERIAL.
+func udevLabelName(property string) string {
+	return strings.ToLower(property)
+}
+
+// addUdevLabels returns m with a label for each of the properties read by u
+// if m has a device label. Unknown properties get empty labels, so that all
+// metrics of a name keep the same label names.
+func addUdevLabels(m prometheus.Metric, u *collector.UdevReader, properties []string) prometheus.Metric {
+	pb := &dto.Metric{}
+	if err := m.Write(pb); err != nil {
+		// Let the registry report the error.
+		return m
+	}
+	for _, lp := range pb.Label {
+		if lp.GetName() != "device" {
+			continue
+		}
+		for i, v := range u.Properties(lp.GetValue()) {
+			m = relabel(m, setLabel(udevLabelName(properties[i]), v))
+		}
+		break
+	}
+	return m
+}
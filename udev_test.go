@@ -0,0 +1,105 @@
+// Copyright 2016 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"flag"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/prometheus/node_exporter/collector"
+)
+
+func TestAddUdevLabels(t *testing.T) {
+	flag.Set("path.rootfs", "collector/fixtures")
+	defer flag.Set("path.rootfs", "/")
+	props := []string{"ID_SERIAL", "ID_PATH"}
+	u := collector.NewUdevReader(props)
+
+	disk := prometheus.NewDesc("node_test_bytes", "Test metric.", []string{"device"}, nil)
+	load := prometheus.NewDesc("node_test_load", "Test metric.", nil, nil)
+	for _, tc := range []struct {
+		m    prometheus.Metric
+		want map[string]string
+	}{
+		{
+			m:    prometheus.MustNewConstMetric(disk, prometheus.GaugeValue, 1, "sda"),
+			want: map[string]string{"device": "sda", "id_serial": "WDC_WD40EFRX-68N32N0_WD-WCC7K1234567", "id_path": "pci-0000:00:17.0-ata-1"},
+		},
+		{
+			m:    prometheus.MustNewConstMetric(disk, prometheus.GaugeValue, 1, "loop0"),
+			want: map[string]string{"device": "loop0", "id_serial": "", "id_path": ""},
+		},
+		{
+			m:    prometheus.MustNewConstMetric(load, prometheus.GaugeValue, 1),
+			want: map[string]string{},
+		},
+	} {
+		pb := &dto.Metric{}
+		if err := addUdevLabels(tc.m, u, props).Write(pb); err != nil {
+			t.Fatal(err)
+		}
+		if want, got := len(tc.want), len(pb.Label); want != got {
+			t.Errorf("want %d labels, got %v", want, pb.Label)
+		}
+		for _, lp := range pb.Label {
+			if want, got := tc.want[lp.GetName()], lp.GetValue(); want != got {
+				t.Errorf("want label %s=%q, got %q", lp.GetName(), want, got)
+			}
+		}
+	}
+}
+
+type udevTestCollector struct{}
+
+func (udevTestCollector) Update(ch chan<- prometheus.Metric) error {
+	desc := prometheus.NewDesc("node_test_bytes", "Test metric.", []string{"device"}, nil)
+	ch <- prometheus.MustNewConstMetric(desc, prometheus.GaugeValue, 1, "sda")
+	return nil
+}
+
+func TestDropUdevLabels(t *testing.T) {
+	flag.Set("path.rootfs", "collector/fixtures")
+	defer flag.Set("path.rootfs", "/")
+	initExporterMetrics()
+	n := NodeCollector{
+		collectors:     map[string]collector.Collector{"test": udevTestCollector{}},
+		udevProperties: map[string][]string{"test": {"ID_SERIAL", "ID_PATH"}},
+		dropLabels:     map[string]map[string]bool{"test": {"id_serial": true}},
+	}
+	ch := make(chan prometheus.Metric)
+	go func() {
+		n.Collect(ch)
+		close(ch)
+	}()
+	var labels []*dto.LabelPair
+	for m := range ch {
+		if match := descNameRE.FindStringSubmatch(m.Desc().String()); match != nil && match[1] == "node_test_bytes" {
+			pb := &dto.Metric{}
+			if err := m.Write(pb); err != nil {
+				t.Fatal(err)
+			}
+			labels = pb.Label
+		}
+	}
+	if want, got := 2, len(labels); want != got {
+		t.Fatalf("want %d labels, got %v", want, labels)
+	}
+	for _, lp := range labels {
+		if lp.GetName() == "id_serial" {
+			t.Errorf("want udev label id_serial dropped, got %v", labels)
+		}
+	}
+}
@@ -0,0 +1,88 @@
+// Copyright 2016 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+)
+
+const (
+	violationDuplicate          = "duplicate_series"
+	violationInconsistentLabels = "inconsistent_labels"
+	violationInvalid            = "invalid_metric"
+)
+
+// Desc doesn't expose the metric name, so it is taken from its string
+// representation.
+var descNameRE = regexp.MustCompile(`^Desc\{fqName: "([^"]*)"`)
+
+// metricValidator checks the series of a single scrape for duplicates and
+// for metric names exposed with differing sets of label names. Both are
+// collector bugs that make the registry drop or mangle series.
+type metricValidator struct {
+	mtx        sync.Mutex
+	labelNames map[string]string
+	series     map[string]struct{}
+}
+
+func newMetricValidator() *metricValidator {
+	return &metricValidator{
+		labelNames: map[string]string{},
+		series:     map[string]struct{}{},
+	}
+}
+
+// check records m and returns the kind of violation it causes together with
+// a description, or an empty kind if m is fine.
+func (v *metricValidator) check(m prometheus.Metric) (kind, detail string) {
+	match := descNameRE.FindStringSubmatch(m.Desc().String())
+	if match == nil {
+		return violationInvalid, fmt.Sprintf("can't determine name of %s", m.Desc())
+	}
+	name := match[1]
+
+	pb := &dto.Metric{}
+	if err := m.Write(pb); err != nil {
+		return violationInvalid, fmt.Sprintf("%s: %s", name, err)
+	}
+	labels := make([]string, 0, len(pb.Label))
+	pairs := make([]string, 0, len(pb.Label))
+	for _, lp := range pb.Label {
+		labels = append(labels, lp.GetName())
+		pairs = append(pairs, fmt.Sprintf("%s=%q", lp.GetName(), lp.GetValue()))
+	}
+	sort.Strings(labels)
+	sort.Strings(pairs)
+	labelNames := strings.Join(labels, ",")
+	series := name + "{" + strings.Join(pairs, ",") + "}"
+
+	v.mtx.Lock()
+	defer v.mtx.Unlock()
+	if prev, ok := v.labelNames[name]; ok && prev != labelNames {
+		return violationInconsistentLabels, fmt.Sprintf("%s has labels [%s], previously [%s]", name, labelNames, prev)
+	}
+	v.labelNames[name] = labelNames
+	if _, ok := v.series[series]; ok {
+		return violationDuplicate, fmt.Sprintf("%s was exposed more than once", series)
+	}
+	v.series[series] = struct{}{}
+	return "", ""
+}
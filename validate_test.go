@@ -0,0 +1,41 @@
+// Copyright 2016 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+func TestMetricValidator(t *testing.T) {
+	var (
+		v     = newMetricValidator()
+		byDev = prometheus.NewDesc("node_test", "Test metric.", []string{"device"}, nil)
+		byCPU = prometheus.NewDesc("node_test", "Test metric.", []string{"cpu"}, nil)
+	)
+	for _, tc := range []struct {
+		metric prometheus.Metric
+		want   string
+	}{
+		{prometheus.MustNewConstMetric(byDev, prometheus.GaugeValue, 1, "sda"), ""},
+		{prometheus.MustNewConstMetric(byDev, prometheus.GaugeValue, 2, "sdb"), ""},
+		{prometheus.MustNewConstMetric(byDev, prometheus.GaugeValue, 3, "sda"), violationDuplicate},
+		{prometheus.MustNewConstMetric(byCPU, prometheus.GaugeValue, 1, "0"), violationInconsistentLabels},
+	} {
+		if got, detail := v.check(tc.metric); got != tc.want {
+			t.Errorf("want violation %q, got %q (%s)", tc.want, got, detail)
+		}
+	}
+}